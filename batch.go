@@ -0,0 +1,149 @@
+package llmrouter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchResult is the outcome of one item of a batch
+type BatchResult struct {
+	Response *Response
+	Err      error
+	Attempts int
+}
+
+// BatchOption configures CompleteBatch
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	concurrency int
+	clock       Clock
+}
+
+// Batch defaults
+const (
+	defaultBatchConcurrency = 16
+	maxBatchDelay           = 10 * time.Second
+)
+
+// Clock waits out retry delays, for CompleteBatch and the retry middleware.
+// Tests can inject one that fires immediately.
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock waits in real time
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithItemRetry retries each item independently, up to maxAttempts in total,
+// when it fails with a retryable error. Retries back off exponentially from
+// 500ms, so one item's transient failure doesn't hold up or fail the others.
+func WithItemRetry(maxAttempts int) BatchOption {
+	return func(c *batchConfig) {
+		c.maxAttempts = maxAttempts
+	}
+}
+
+// WithBatchConcurrency caps how many items are in flight at once. The
+// default is 16; n < 1 keeps the default.
+func WithBatchConcurrency(n int) BatchOption {
+	return func(c *batchConfig) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithBatchClock replaces the real-time clock used to wait between item
+// retries
+func WithBatchClock(clock Clock) BatchOption {
+	return func(c *batchConfig) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// CompleteBatch completes the requests in parallel, up to the batch
+// concurrency at a time, and returns one result per request, in order. A
+// failing item does not affect the others; check each result's Err.
+func (r *Router) CompleteBatch(ctx context.Context, reqs []*Request, opts ...BatchOption) []BatchResult {
+	cfg := batchConfig{
+		maxAttempts: 1,
+		baseDelay:   500 * time.Millisecond,
+		concurrency: defaultBatchConcurrency,
+		clock:       RealClock,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.maxAttempts < 1 {
+		cfg.maxAttempts = 1
+	}
+
+	results := make([]BatchResult, len(reqs))
+
+	sem := make(chan struct{}, cfg.concurrency)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, req *Request) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = r.completeItem(ctx, req, cfg)
+		}(i, req)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// completeItem completes a single batch item, retrying per cfg
+func (r *Router) completeItem(ctx context.Context, req *Request, cfg batchConfig) BatchResult {
+	var lastErr error
+	for attempt := 0; attempt < cfg.maxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return BatchResult{Err: ctx.Err(), Attempts: attempt}
+			case <-cfg.clock.After(batchDelay(cfg.baseDelay, attempt)):
+			}
+		}
+
+		resp, err := r.Complete(ctx, req)
+		if err == nil {
+			return BatchResult{Response: resp, Attempts: attempt + 1}
+		}
+
+		lastErr = err
+		if !IsRetryable(err) {
+			return BatchResult{Err: err, Attempts: attempt + 1}
+		}
+	}
+
+	if cfg.maxAttempts > 1 {
+		lastErr = fmt.Errorf("%w: %w", ErrMaxRetriesExceed, lastErr)
+	}
+	return BatchResult{Err: lastErr, Attempts: cfg.maxAttempts}
+}
+
+// batchDelay doubles base for every retry after the first, capped at
+// maxBatchDelay
+func batchDelay(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxBatchDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBatchDelay)
+}
@@ -0,0 +1,119 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock fires immediately, recording the delays it was asked to wait
+type fakeClock struct {
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.delays = append(c.delays, d)
+	c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+func TestCompleteBatchItemRetry(t *testing.T) {
+	var mu sync.Mutex
+	failed := false
+	stub := &stubProvider{models: []string{"m"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		content := req.Messages[0].Content
+		mu.Lock()
+		defer mu.Unlock()
+		if content == "flaky" && !failed {
+			failed = true
+			return nil, ErrRateLimited
+		}
+		return textResponse("stub", content), nil
+	}}
+	r := New(WithProvider("stub", stub))
+
+	var reqs []*Request
+	for _, content := range []string{"a", "flaky", "b"} {
+		req := userRequest(content)
+		req.Model = "m"
+		reqs = append(reqs, req)
+	}
+	clock := &fakeClock{}
+	results := r.CompleteBatch(context.Background(), reqs, WithItemRetry(3), WithBatchClock(clock))
+
+	for i, want := range []struct {
+		content  string
+		attempts int
+	}{{"a", 1}, {"flaky", 2}, {"b", 1}} {
+		res := results[i]
+		if res.Err != nil {
+			t.Errorf("item %d: %v", i, res.Err)
+			continue
+		}
+		if res.Response.Text() != want.content || res.Attempts != want.attempts {
+			t.Errorf("item %d = %q after %d attempts, want %q after %d", i, res.Response.Text(), res.Attempts, want.content, want.attempts)
+		}
+	}
+	if stub.callCount() != 4 {
+		t.Errorf("provider called %d times, want 4", stub.callCount())
+	}
+	if !slices.Equal(clock.delays, []time.Duration{500 * time.Millisecond}) {
+		t.Errorf("delays = %v, want one 500ms backoff", clock.delays)
+	}
+}
+
+func TestCompleteBatchRetriesExhausted(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		if req.Messages[0].Content == "denied" {
+			return nil, ErrAuthFailed
+		}
+		return nil, ErrRateLimited
+	}}
+	r := New(WithProvider("stub", stub))
+
+	limited, denied := userRequest("limited"), userRequest("denied")
+	limited.Model, denied.Model = "m", "m"
+	clock := &fakeClock{}
+	results := r.CompleteBatch(context.Background(), []*Request{limited, denied}, WithItemRetry(3), WithBatchClock(clock))
+
+	if res := results[0]; !errors.Is(res.Err, ErrMaxRetriesExceed) || !errors.Is(res.Err, ErrRateLimited) || res.Attempts != 3 {
+		t.Errorf("retryable item = %v after %d attempts, want ErrMaxRetriesExceed after 3", res.Err, res.Attempts)
+	}
+	if res := results[1]; !errors.Is(res.Err, ErrAuthFailed) || res.Attempts != 1 {
+		t.Errorf("non-retryable item = %v after %d attempts, want ErrAuthFailed after 1", res.Err, res.Attempts)
+	}
+	if !slices.Equal(clock.delays, []time.Duration{500 * time.Millisecond, time.Second}) {
+		t.Errorf("delays = %v, want 500ms then 1s", clock.delays)
+	}
+}
+
+func TestCompleteBatchWithoutRetry(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, ErrRateLimited
+	}}
+	r := New(WithProvider("stub", stub))
+
+	req := userRequest("hi")
+	req.Model = "m"
+	results := r.CompleteBatch(context.Background(), []*Request{req})
+	if res := results[0]; res.Err != ErrRateLimited || res.Attempts != 1 {
+		t.Errorf("result = %v after %d attempts, want the error after one attempt", res.Err, res.Attempts)
+	}
+}
+
+func TestBatchDelay(t *testing.T) {
+	base := 500 * time.Millisecond
+	want := []time.Duration{base, base, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, maxBatchDelay, maxBatchDelay}
+	for attempt, w := range want {
+		if got := batchDelay(base, attempt); got != w {
+			t.Errorf("batchDelay(attempt %d) = %v, want %v", attempt, got, w)
+		}
+	}
+}
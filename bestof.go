@@ -0,0 +1,105 @@
+package llmrouter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// CompleteBestOf generates n candidate completions for req in parallel, then
+// asks judgeModel to pick the best one and returns it. Candidates that fail
+// are skipped; an error is returned only if every candidate fails. If the
+// judge's answer can't be parsed, the first successful candidate is returned.
+func (r *Router) CompleteBestOf(ctx context.Context, req *Request, n int, judgeModel string) (*Response, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("%w: n must be at least 1", ErrInvalidRequest)
+	}
+
+	results := make([]*Response, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = r.Complete(ctx, req)
+		}(i)
+	}
+	wg.Wait()
+
+	var candidates []*Response
+	var lastErr error
+	for i, resp := range results {
+		if errs[i] != nil {
+			lastErr = errs[i]
+			continue
+		}
+		candidates = append(candidates, resp)
+	}
+	if len(candidates) == 0 {
+		return nil, lastErr
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	judgement, err := r.Complete(ctx, &Request{
+		Model:    judgeModel,
+		Messages: buildJudgeMessages(req, candidates),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if idx, ok := parseJudgeChoice(judgement, len(candidates)); ok {
+		return candidates[idx], nil
+	}
+	return candidates[0], nil
+}
+
+// buildJudgeMessages builds the prompt asking a judge model to pick a candidate
+func buildJudgeMessages(req *Request, candidates []*Response) []Message {
+	var b strings.Builder
+	b.WriteString("Conversation:\n")
+	for _, msg := range req.Messages {
+		fmt.Fprintf(&b, "[%s] %s\n", msg.Role, msg.Content)
+	}
+	b.WriteString("\nCandidate responses:\n")
+	for i, c := range candidates {
+		content := ""
+		if len(c.Choices) > 0 && c.Choices[0].Message != nil {
+			content = c.Choices[0].Message.Content
+		}
+		fmt.Fprintf(&b, "\n<candidate %d>\n%s\n</candidate %d>\n", i+1, content, i+1)
+	}
+
+	return []Message{
+		{Role: RoleSystem, Content: "You are an impartial judge. Pick the candidate that best answers the conversation. Reply with only the candidate number."},
+		{Role: RoleUser, Content: b.String()},
+	}
+}
+
+// parseJudgeChoice extracts the first number from the judge's reply as a
+// zero-based candidate index
+func parseJudgeChoice(resp *Response, count int) (int, bool) {
+	if len(resp.Choices) == 0 || resp.Choices[0].Message == nil {
+		return 0, false
+	}
+
+	fields := strings.FieldsFunc(resp.Choices[0].Message.Content, func(r rune) bool {
+		return !unicode.IsDigit(r)
+	})
+	if len(fields) == 0 {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n < 1 || n > count {
+		return 0, false
+	}
+	return n - 1, true
+}
@@ -0,0 +1,80 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync/atomic"
+	"testing"
+)
+
+// candidateNumber finds the number of the candidate with the given content in
+// a judge prompt
+var candidateNumber = regexp.MustCompile(`<candidate (\d+)>\nbest answer\n`)
+
+func TestCompleteBestOf(t *testing.T) {
+	var n atomic.Int32
+	cheap := &stubProvider{name: "cheap", models: []string{"cheap-model"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		if n.Add(1) == 2 {
+			return textResponse("cheap", "best answer"), nil
+		}
+		return textResponse("cheap", "weak answer"), nil
+	}}
+	// The judge deterministically picks the candidate reading "best answer"
+	judge := &stubProvider{name: "judge", models: []string{"judge-model"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		m := candidateNumber.FindStringSubmatch(req.Messages[len(req.Messages)-1].Content)
+		if m == nil {
+			return nil, fmt.Errorf("no best candidate in prompt")
+		}
+		return textResponse("judge", "Candidate "+m[1]), nil
+	}}
+	r := New(WithProvider("cheap", cheap), WithProvider("judge", judge))
+
+	req := userRequest("question")
+	req.Model = "cheap-model"
+	resp, err := r.CompleteBestOf(context.Background(), req, 3, "judge-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Text(); got != "best answer" {
+		t.Errorf("picked %q, want the best answer", got)
+	}
+	if cheap.callCount() != 3 || judge.callCount() != 1 {
+		t.Errorf("calls: cheap %d, judge %d; want 3 and 1", cheap.callCount(), judge.callCount())
+	}
+}
+
+func TestCompleteBestOfUnparsableJudge(t *testing.T) {
+	cheap := &stubProvider{name: "cheap", models: []string{"cheap-model"}}
+	judge := &stubProvider{name: "judge", models: []string{"judge-model"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		return textResponse("judge", "they are all fine"), nil
+	}}
+	r := New(WithProvider("cheap", cheap), WithProvider("judge", judge))
+
+	req := userRequest("question")
+	req.Model = "cheap-model"
+	resp, err := r.CompleteBestOf(context.Background(), req, 2, "judge-model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != "cheap" {
+		t.Errorf("got a response from %q, want a candidate", resp.Provider)
+	}
+}
+
+func TestCompleteBestOfAllFail(t *testing.T) {
+	cheap := &stubProvider{name: "cheap", models: []string{"cheap-model"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, ErrProviderError
+	}}
+	r := New(WithProvider("cheap", cheap))
+
+	req := userRequest("question")
+	req.Model = "cheap-model"
+	if _, err := r.CompleteBestOf(context.Background(), req, 2, "judge-model"); !errors.Is(err, ErrProviderError) {
+		t.Errorf("error = %v, want ErrProviderError", err)
+	}
+	if _, err := r.CompleteBestOf(context.Background(), req, 0, "judge-model"); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("n = 0: error = %v, want ErrInvalidRequest", err)
+	}
+}
@@ -0,0 +1,178 @@
+package llmrouter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// CostFunc prices a completion's usage, e.g. in dollars
+type CostFunc func(model string, usage Usage) float64
+
+// budget is a spending cap shared by all requests through a router and its
+// clones. Usage is charged once a request completes, so requests already in
+// flight when the cap is reached can overshoot it.
+type budget struct {
+	mu    sync.Mutex
+	limit float64
+	used  float64
+	cost  CostFunc
+	unit  string // for error messages
+}
+
+func newTokenBudget(limit int) *budget {
+	return &budget{
+		limit: float64(limit),
+		cost: func(model string, usage Usage) float64 {
+			return float64(usage.TotalTokens)
+		},
+		unit: "tokens",
+	}
+}
+
+func newCostBudget(limit float64, cost CostFunc) *budget {
+	return &budget{limit: limit, cost: cost, unit: "cost"}
+}
+
+// fresh returns an unspent budget with the same limit
+func (b *budget) fresh() *budget {
+	if b == nil {
+		return nil
+	}
+	return &budget{limit: b.limit, cost: b.cost, unit: b.unit}
+}
+
+// check returns ErrBudgetExceeded once the budget is spent
+func (b *budget) check() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used >= b.limit {
+		return fmt.Errorf("%w: %s used %g of %g", ErrBudgetExceeded, b.unit, b.used, b.limit)
+	}
+	return nil
+}
+
+// charge spends a completion's usage. Cache hits cost nothing.
+func (b *budget) charge(resp *Response) {
+	if resp == nil {
+		return
+	}
+	if hit, _ := resp.Metadata[MetadataCacheHit].(bool); hit {
+		return
+	}
+	b.chargeUsage(resp.Model, resp.Usage)
+}
+
+func (b *budget) chargeUsage(model string, usage *Usage) {
+	if b == nil || usage == nil {
+		return
+	}
+	spent := b.cost(model, *usage)
+	b.mu.Lock()
+	b.used += spent
+	b.mu.Unlock()
+}
+
+// remaining returns what is left of the budget, or -1 if there is none
+func (b *budget) remaining() float64 {
+	if b == nil {
+		return -1
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return max(b.limit-b.used, 0)
+}
+
+// chargeEmbedding charges an embedding's usage to the router's budgets
+func (r *Router) chargeEmbedding(resp *EmbeddingResponse) {
+	r.tokenBudget.chargeUsage(resp.Model, resp.Usage)
+	r.costBudget.chargeUsage(resp.Model, resp.Usage)
+}
+
+// checkBudgets rejects requests once any budget is spent
+func (r *Router) checkBudgets() error {
+	if err := r.tokenBudget.check(); err != nil {
+		return err
+	}
+	return r.costBudget.check()
+}
+
+// RemainingBudget returns the tokens left in the budget set by
+// WithTokenBudget, or -1 if there is no token budget
+func (r *Router) RemainingBudget() int {
+	return int(r.tokenBudget.remaining())
+}
+
+// RemainingCostBudget returns what is left of the budget set by
+// WithCostBudget, or -1 if there is no cost budget
+func (r *Router) RemainingCostBudget() float64 {
+	return r.costBudget.remaining()
+}
+
+// budgetProvider charges the usage of every completion to the router's budgets.
+// Streams that end without reported usage are charged an estimate.
+type budgetProvider struct {
+	Provider
+	budgets []*budget
+}
+
+func (p *budgetProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	p.charge(resp)
+	return resp, nil
+}
+
+func (p *budgetProvider) Stream(ctx context.Context, req *Request) (<-chan Event, error) {
+	ch, err := p.Provider.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	tally := newStreamTally(req)
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		for event := range ch {
+			tally.add(event)
+			if event.Type == EventDone {
+				p.charge(streamResponse(req, event.Response, tally))
+			}
+			select {
+			case outCh <- event:
+			case <-ctx.Done():
+				go func() {
+					for range ch {
+					}
+				}()
+				return
+			}
+		}
+	}()
+	return outCh, nil
+}
+
+// streamResponse returns a stream's final response with its usage
+// estimated when the provider reported none, so streams are never free
+func streamResponse(req *Request, resp *Response, tally *streamTally) *Response {
+	if resp != nil && resp.Usage != nil {
+		return resp
+	}
+	estimated := Response{Model: req.Model}
+	if resp != nil {
+		estimated = *resp
+	}
+	estimated.Usage = tally.usage(resp)
+	return &estimated
+}
+
+func (p *budgetProvider) charge(resp *Response) {
+	for _, b := range p.budgets {
+		b.charge(resp)
+	}
+}
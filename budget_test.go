@@ -0,0 +1,249 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// usageProvider is a stub for model "m" whose completions and streams each
+// report using tokens tokens
+func usageProvider(tokens int) *stubProvider {
+	resp := func() *Response {
+		r := textResponse("stub", "ok")
+		r.Model = "m"
+		r.Usage = &Usage{PromptTokens: tokens / 2, CompletionTokens: tokens - tokens/2, TotalTokens: tokens}
+		return r
+	}
+	return &stubProvider{
+		models: []string{"m"},
+		complete: func(ctx context.Context, req *Request) (*Response, error) {
+			return resp(), nil
+		},
+		stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+			return eventStream(
+				Event{Type: EventContentDelta, Content: "ok"},
+				Event{Type: EventDone, Response: resp()},
+			), nil
+		},
+	}
+}
+
+func TestTokenBudget(t *testing.T) {
+	stub := usageProvider(40)
+	r := New(WithProvider("stub", stub), WithTokenBudget(100))
+
+	if got := r.RemainingBudget(); got != 100 {
+		t.Errorf("RemainingBudget = %d, want 100 before any request", got)
+	}
+	for i, want := range []int{60, 20, 0} {
+		if _, err := r.Complete(context.Background(), modelRequest()); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+		if got := r.RemainingBudget(); got != want {
+			t.Errorf("after request %d RemainingBudget = %d, want %d", i+1, got, want)
+		}
+	}
+
+	// The third request overshot the cap; nothing more gets through
+	if _, err := r.Complete(context.Background(), modelRequest()); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Complete err = %v, want ErrBudgetExceeded", err)
+	}
+	if _, err := r.Route(context.Background(), modelRequest()); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Route err = %v, want ErrBudgetExceeded", err)
+	}
+	if stub.callCount() != 3 {
+		t.Errorf("%d calls reached the provider, want 3", stub.callCount())
+	}
+}
+
+func TestTokenBudgetChargesStreams(t *testing.T) {
+	r := New(WithProvider("stub", usageProvider(40)), WithTokenBudget(100))
+
+	ch, err := r.Route(context.Background(), modelRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	if got := r.RemainingBudget(); got != 60 {
+		t.Errorf("RemainingBudget = %d, want 60 once the stream is done", got)
+	}
+}
+
+func TestTokenBudgetEstimatesUnreportedStreamUsage(t *testing.T) {
+	stub := &stubProvider{
+		models: []string{"m"},
+		stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+			return eventStream(
+				Event{Type: EventContentDelta, Content: strings.Repeat("x", 200)},
+				Event{Type: EventDone, Response: textResponse("stub", "")},
+			), nil
+		},
+	}
+	r := New(WithProvider("stub", stub), WithTokenBudget(60))
+
+	req := modelRequest()
+	req.Messages = []Message{{Role: RoleUser, Content: "hello"}}
+	ch, err := r.Route(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	if got := r.RemainingBudget(); got != 8 {
+		t.Errorf("RemainingBudget = %d, want 8 after an estimated 2 prompt and 50 completion tokens", got)
+	}
+
+	ch, err = r.Route(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	if _, err := r.Route(context.Background(), req); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("err = %v, want ErrBudgetExceeded once streams used up the budget", err)
+	}
+}
+
+func TestCostBudget(t *testing.T) {
+	var priced []string
+	cost := func(model string, usage Usage) float64 {
+		priced = append(priced, model)
+		return float64(usage.TotalTokens) * 0.01
+	}
+	r := New(WithProvider("stub", usageProvider(100)), WithCostBudget(1.5, cost))
+
+	if got := r.RemainingBudget(); got != -1 {
+		t.Errorf("RemainingBudget = %d, want -1 without a token budget", got)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := r.Complete(context.Background(), modelRequest()); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if got := r.RemainingCostBudget(); got != 0 {
+		t.Errorf("RemainingCostBudget = %g, want 0", got)
+	}
+	if _, err := r.Complete(context.Background(), modelRequest()); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("err = %v, want ErrBudgetExceeded", err)
+	}
+	if len(priced) != 2 || priced[0] != "m" {
+		t.Errorf("priced models = %v, want the response's model per request", priced)
+	}
+}
+
+func TestNoBudget(t *testing.T) {
+	r := New(WithProvider("stub", usageProvider(1000)))
+	if r.RemainingBudget() != -1 || r.RemainingCostBudget() != -1 {
+		t.Errorf("remaining = %d, %g; want -1 without budgets", r.RemainingBudget(), r.RemainingCostBudget())
+	}
+}
+
+func TestBudgetConcurrentRequests(t *testing.T) {
+	r := New(WithProvider("stub", usageProvider(1)), WithTokenBudget(1000))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Complete(context.Background(), modelRequest()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := r.RemainingBudget(); got != 950 {
+		t.Errorf("RemainingBudget = %d, want 950", got)
+	}
+}
+
+func TestBudgetChecksEmbeddings(t *testing.T) {
+	e := &embedProvider{
+		stubProvider: stubProvider{models: []string{"embed"}},
+		embed: func(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+			return &EmbeddingResponse{Model: req.Model, Embeddings: [][]float64{{1}}, Usage: &Usage{PromptTokens: 60, TotalTokens: 60}}, nil
+		},
+	}
+	r := New(WithProvider("e", e), WithTokenBudget(100))
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Embed(context.Background(), &EmbeddingRequest{Model: "embed", Input: []string{"a"}}); err != nil {
+			t.Fatalf("embedding %d: %v", i+1, err)
+		}
+	}
+	if _, err := r.Embed(context.Background(), &EmbeddingRequest{Model: "embed", Input: []string{"a"}}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("err = %v, want ErrBudgetExceeded once embeddings spent the budget", err)
+	}
+	if _, err := r.GenerateImage(context.Background(), &ImageRequest{Model: "embed"}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("GenerateImage err = %v, want ErrBudgetExceeded", err)
+	}
+	if len(e.inputs) != 2 {
+		t.Errorf("%d embedding calls reached the provider, want 2", len(e.inputs))
+	}
+}
+
+func TestBudgetSharedWithClones(t *testing.T) {
+	r := New(WithProvider("stub", usageProvider(60)), WithTokenBudget(100))
+	clone := r.Clone()
+	fresh := r.Clone().Apply(WithFreshBudgets())
+
+	if _, err := clone.Complete(context.Background(), modelRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.RemainingBudget(); got != 40 {
+		t.Errorf("original RemainingBudget = %d, want 40 after the clone's request", got)
+	}
+	if got := fresh.RemainingBudget(); got != 100 {
+		t.Errorf("fresh clone RemainingBudget = %d, want its own 100", got)
+	}
+
+	if _, err := r.Complete(context.Background(), modelRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clone.Complete(context.Background(), modelRequest()); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("clone err = %v, want ErrBudgetExceeded from the shared budget", err)
+	}
+	if _, err := fresh.Complete(context.Background(), modelRequest()); err != nil {
+		t.Errorf("fresh clone err = %v, want its budget unspent", err)
+	}
+}
+
+func TestBudgetSkipsCacheHits(t *testing.T) {
+	stub := usageProvider(60)
+	complete := stub.complete
+	stub.complete = func(ctx context.Context, req *Request) (*Response, error) {
+		resp, _ := complete(ctx, req)
+		resp.Metadata = map[string]any{MetadataCacheHit: true}
+		return resp, nil
+	}
+	r := New(WithProvider("stub", stub), WithTokenBudget(100))
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.Complete(context.Background(), modelRequest()); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if got := r.RemainingBudget(); got != 100 {
+		t.Errorf("RemainingBudget = %d, want cache hits free", got)
+	}
+}
+
+func TestBudgetStreamCanceled(t *testing.T) {
+	p := &budgetProvider{Provider: usageProvider(10), budgets: []*budget{newTokenBudget(100)}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.Stream(ctx, modelRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+	cancel()
+
+	// Left unread, the channel closes instead of blocking on the next event
+	time.Sleep(20 * time.Millisecond)
+	if e, ok := <-ch; ok {
+		t.Errorf("received %+v after cancel, want the channel closed", e)
+	}
+}
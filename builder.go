@@ -0,0 +1,119 @@
+package llmrouter
+
+import "encoding/json"
+
+// RequestBuilder builds a Request fluently, handling pointer fields
+type RequestBuilder struct {
+	req Request
+}
+
+// NewRequest starts building a request for model
+func NewRequest(model string) *RequestBuilder {
+	return &RequestBuilder{req: Request{Model: model}}
+}
+
+// System appends a system message
+func (b *RequestBuilder) System(content string) *RequestBuilder {
+	return b.Message(Message{Role: RoleSystem, Content: content})
+}
+
+// User appends a user message
+func (b *RequestBuilder) User(content string) *RequestBuilder {
+	return b.Message(Message{Role: RoleUser, Content: content})
+}
+
+// Assistant appends an assistant message
+func (b *RequestBuilder) Assistant(content string) *RequestBuilder {
+	return b.Message(Message{Role: RoleAssistant, Content: content})
+}
+
+// Message appends an arbitrary message, e.g. a tool result
+func (b *RequestBuilder) Message(msg Message) *RequestBuilder {
+	b.req.Messages = append(b.req.Messages, msg)
+	return b
+}
+
+// Temperature sets the sampling temperature
+func (b *RequestBuilder) Temperature(t float64) *RequestBuilder {
+	b.req.Temperature = &t
+	return b
+}
+
+// MaxTokens sets the completion token limit
+func (b *RequestBuilder) MaxTokens(n int) *RequestBuilder {
+	b.req.MaxTokens = &n
+	return b
+}
+
+// TopP sets nucleus sampling
+func (b *RequestBuilder) TopP(p float64) *RequestBuilder {
+	b.req.TopP = &p
+	return b
+}
+
+// Stop sets the stop sequences
+func (b *RequestBuilder) Stop(stop ...string) *RequestBuilder {
+	b.req.Stop = stop
+	return b
+}
+
+// Tool adds a tool definition
+func (b *RequestBuilder) Tool(t Tool) *RequestBuilder {
+	b.req.Tools = append(b.req.Tools, t)
+	return b
+}
+
+// Function adds a function tool with a JSON schema for its parameters
+func (b *RequestBuilder) Function(name, description string, parameters json.RawMessage) *RequestBuilder {
+	return b.Tool(Tool{
+		Type: "function",
+		Function: Function{
+			Name:        name,
+			Description: description,
+			Parameters:  parameters,
+		},
+	})
+}
+
+// ToolChoice sets the tool choice
+func (b *RequestBuilder) ToolChoice(tc *ToolChoice) *RequestBuilder {
+	b.req.ToolChoice = tc
+	return b
+}
+
+// Metadata sets a metadata key
+func (b *RequestBuilder) Metadata(key string, value any) *RequestBuilder {
+	if b.req.Metadata == nil {
+		b.req.Metadata = make(map[string]any)
+	}
+	b.req.Metadata[key] = value
+	return b
+}
+
+// Build returns the request. The builder may be reused; later changes don't
+// affect requests already built.
+func (b *RequestBuilder) Build() *Request {
+	req := b.req
+	req.Messages = append([]Message(nil), b.req.Messages...)
+	req.Tools = append([]Tool(nil), b.req.Tools...)
+	req.Stop = append([]string(nil), b.req.Stop...)
+	if b.req.Metadata != nil {
+		req.Metadata = make(map[string]any, len(b.req.Metadata))
+		for k, v := range b.req.Metadata {
+			req.Metadata[k] = v
+		}
+	}
+	if b.req.Temperature != nil {
+		t := *b.req.Temperature
+		req.Temperature = &t
+	}
+	if b.req.MaxTokens != nil {
+		n := *b.req.MaxTokens
+		req.MaxTokens = &n
+	}
+	if b.req.TopP != nil {
+		p := *b.req.TopP
+		req.TopP = &p
+	}
+	return &req
+}
@@ -0,0 +1,79 @@
+package llmrouter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	params := json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`)
+	lookup := Tool{Type: "function", Function: Function{Name: "lookup"}}
+	choice := &ToolChoice{Type: "function", Function: &FuncRef{Name: "weather"}}
+
+	got := NewRequest("gpt-4o").
+		System("be brief").
+		User("weather in Paris?").
+		Assistant("checking").
+		Message(Message{Role: RoleTool, ToolCallID: "call_1", Content: "20°C"}).
+		Temperature(0.2).
+		MaxTokens(256).
+		TopP(0.9).
+		Stop("END").
+		Function("weather", "current weather", params).
+		Tool(lookup).
+		ToolChoice(choice).
+		Metadata(MetadataUserID, "user-1").
+		Build()
+
+	want := &Request{
+		Model: "gpt-4o",
+		Messages: []Message{
+			{Role: RoleSystem, Content: "be brief"},
+			{Role: RoleUser, Content: "weather in Paris?"},
+			{Role: RoleAssistant, Content: "checking"},
+			{Role: RoleTool, ToolCallID: "call_1", Content: "20°C"},
+		},
+		Temperature: floatPtr(0.2),
+		MaxTokens:   intPtr(256),
+		TopP:        floatPtr(0.9),
+		Stop:        []string{"END"},
+		Tools: []Tool{
+			{Type: "function", Function: Function{Name: "weather", Description: "current weather", Parameters: params}},
+			lookup,
+		},
+		ToolChoice: choice,
+		Metadata:   map[string]any{MetadataUserID: "user-1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRequestBuilderMinimal(t *testing.T) {
+	got := NewRequest("claude-3").User("hi").Build()
+	want := &Request{Model: "claude-3", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRequestBuilderReuse(t *testing.T) {
+	b := NewRequest("gpt-4o").User("first").Temperature(0.5).MaxTokens(10).Metadata("k", "v")
+	first := b.Build()
+
+	b.User("second").Temperature(1).MaxTokens(20).Metadata("k", "changed")
+	second := b.Build()
+	*second.Temperature = 2
+	second.Messages[0].Content = "edited"
+
+	if len(first.Messages) != 1 || first.Messages[0].Content != "first" {
+		t.Errorf("first messages = %+v, want unaffected by later changes", first.Messages)
+	}
+	if *first.Temperature != 0.5 || *first.MaxTokens != 10 || first.Metadata["k"] != "v" {
+		t.Errorf("first = temperature %v, max tokens %v, metadata %v; want unaffected", *first.Temperature, *first.MaxTokens, first.Metadata)
+	}
+	if third := b.Build(); *third.Temperature != 1 || third.Messages[0].Content != "first" {
+		t.Error("built request shares state with the builder")
+	}
+}
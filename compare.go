@@ -0,0 +1,38 @@
+package llmrouter
+
+import (
+	"context"
+	"sync"
+)
+
+// CompareAcross sends req to every model concurrently and returns the
+// responses and errors keyed by model. Each model appears in exactly one of
+// the two maps. Unlike a race, every call runs to completion.
+func (r *Router) CompareAcross(ctx context.Context, req *Request, models []string) (map[string]*Response, map[string]error) {
+	responses := make(map[string]*Response, len(models))
+	errs := make(map[string]error)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, model := range models {
+		wg.Add(1)
+		go func(model string) {
+			defer wg.Done()
+
+			modelReq := *req
+			modelReq.Model = model
+			resp, err := r.Complete(ctx, &modelReq)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[model] = err
+				return
+			}
+			responses[model] = resp
+		}(model)
+	}
+	wg.Wait()
+
+	return responses, errs
+}
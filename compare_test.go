@@ -0,0 +1,70 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompareAcross(t *testing.T) {
+	// Every call waits for the others, so the test only passes if they run
+	// concurrently
+	var arrived sync.WaitGroup
+	arrived.Add(3)
+	all := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(all)
+	}()
+	wait := func() error {
+		arrived.Done()
+		select {
+		case <-all:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("calls did not run concurrently")
+		}
+	}
+	answer := func(name string) func(ctx context.Context, req *Request) (*Response, error) {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			if err := wait(); err != nil {
+				return nil, err
+			}
+			return textResponse(name, name+" says hi to "+req.Model), nil
+		}
+	}
+
+	a := &stubProvider{name: "a", models: []string{"model-a"}, complete: answer("a")}
+	b := &stubProvider{name: "b", models: []string{"model-b"}, complete: answer("b")}
+	c := &stubProvider{name: "c", models: []string{"model-c"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		if err := wait(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRateLimited
+	}}
+	r := New(WithProvider("a", a), WithProvider("b", b), WithProvider("c", c))
+
+	req := userRequest("hi")
+	req.Model = "original"
+	responses, errs := r.CompareAcross(context.Background(), req, []string{"model-a", "model-b", "model-c", "unknown"})
+
+	if len(responses) != 2 || responses["model-a"].Text() != "a says hi to model-a" || responses["model-b"].Text() != "b says hi to model-b" {
+		t.Errorf("responses = %v, want model-a and model-b answered by their providers", responses)
+	}
+	if len(errs) != 2 || !errors.Is(errs["model-c"], ErrRateLimited) || !errors.Is(errs["unknown"], ErrUnknownModel) {
+		t.Errorf("errors = %v, want model-c rate limited and unknown unroutable", errs)
+	}
+	if req.Model != "original" {
+		t.Errorf("caller's request model changed to %q", req.Model)
+	}
+}
+
+func TestCompareAcrossNoModels(t *testing.T) {
+	r := New(WithProvider("a", &stubProvider{}))
+	responses, errs := r.CompareAcross(context.Background(), userRequest("hi"), nil)
+	if len(responses) != 0 || len(errs) != 0 {
+		t.Errorf("got %v, %v; want empty maps", responses, errs)
+	}
+}
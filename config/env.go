@@ -0,0 +1,83 @@
+// Package config builds routers from the environment or a config file. It
+// lives outside the root package because it depends on the provider packages.
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/bluefunda/llm-router/providers/anthropic"
+	"github.com/bluefunda/llm-router/providers/gemini"
+	"github.com/bluefunda/llm-router/providers/openai"
+)
+
+// compatibleProviders lists the OpenAI-compatible presets configured from the
+// environment, in registration order, with the variable holding each API key
+var compatibleProviders = []struct {
+	name   string
+	envKey string
+}{
+	{"deepseek", "DEEPSEEK_API_KEY"},
+	{"groq", "GROQ_API_KEY"},
+	{"together", "TOGETHER_API_KEY"},
+	{"perplexity", "PERPLEXITY_API_KEY"},
+}
+
+// FromEnv returns a router with a provider registered for every known API key
+// present in the environment: OPENAI_API_KEY, ANTHROPIC_API_KEY,
+// GEMINI_API_KEY, DEEPSEEK_API_KEY, GROQ_API_KEY, TOGETHER_API_KEY and
+// PERPLEXITY_API_KEY. OLLAMA_BASE_URL registers a local Ollama provider.
+// Each provider's models are mapped to it; a model offered by several
+// providers goes to the first registered, in the order above. opts are
+// applied after the providers, e.g. to add middleware.
+//
+// It returns ErrNoProviders if no keys are set.
+func FromEnv(ctx context.Context, opts ...llmrouter.Option) (*llmrouter.Router, error) {
+	var providers []llmrouter.Provider
+
+	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
+		providers = append(providers, openai.NewOpenAI(key))
+	}
+	if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
+		providers = append(providers, anthropic.New(llmrouter.ProviderConfig{APIKey: key}))
+	}
+	if key := os.Getenv("GEMINI_API_KEY"); key != "" {
+		p, err := gemini.New(ctx, llmrouter.ProviderConfig{APIKey: key})
+		if err != nil {
+			return nil, fmt.Errorf("gemini: %w", err)
+		}
+		providers = append(providers, p)
+	}
+	for _, ep := range compatibleProviders {
+		if key := os.Getenv(ep.envKey); key != "" {
+			providers = append(providers, openai.New(llmrouter.ProviderConfig{Name: ep.name, APIKey: key}))
+		}
+	}
+	if baseURL := os.Getenv("OLLAMA_BASE_URL"); baseURL != "" {
+		providers = append(providers, openai.NewOllama(baseURL))
+	}
+
+	if len(providers) == 0 {
+		return nil, llmrouter.ErrNoProviders
+	}
+	return llmrouter.New(append(registerAll(providers), opts...)...), nil
+}
+
+// registerAll returns options registering providers under their names and
+// mapping each of their models, first provider wins
+func registerAll(providers []llmrouter.Provider) []llmrouter.Option {
+	var opts []llmrouter.Option
+	mapped := make(map[string]bool)
+	for _, p := range providers {
+		opts = append(opts, llmrouter.WithProvider(p.Name(), p))
+		for _, model := range p.Models() {
+			if !mapped[model] {
+				mapped[model] = true
+				opts = append(opts, llmrouter.WithModelMapping(model, p.Name()))
+			}
+		}
+	}
+	return opts
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// envKeys lists every variable FromEnv reads
+var envKeys = []string{
+	"OPENAI_API_KEY", "ANTHROPIC_API_KEY", "GEMINI_API_KEY", "DEEPSEEK_API_KEY",
+	"GROQ_API_KEY", "TOGETHER_API_KEY", "PERPLEXITY_API_KEY", "OLLAMA_BASE_URL",
+}
+
+// setEnv clears every known variable, then sets vars for the test
+func setEnv(t *testing.T, vars map[string]string) {
+	t.Helper()
+	for _, key := range envKeys {
+		t.Setenv(key, vars[key])
+	}
+}
+
+func sortedProviders(r *llmrouter.Router) []string {
+	names := r.Providers()
+	sort.Strings(names)
+	return names
+}
+
+func TestFromEnv(t *testing.T) {
+	setEnv(t, map[string]string{
+		"OPENAI_API_KEY":    "sk-openai",
+		"ANTHROPIC_API_KEY": "sk-ant",
+		"GEMINI_API_KEY":    "gemini-key",
+		"GROQ_API_KEY":      "gsk",
+		"OLLAMA_BASE_URL":   "http://localhost:11434/v1",
+	})
+
+	r, err := FromEnv(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"anthropic", "gemini", "groq", "ollama", "openai"}; !slices.Equal(sortedProviders(r), want) {
+		t.Errorf("providers = %v, want %v", sortedProviders(r), want)
+	}
+	for model, provider := range map[string]string{
+		"gpt-4o":                  "openai",
+		"llama-3.3-70b-versatile": "groq",
+	} {
+		if exp := r.Explain(model); exp.Provider != provider || exp.Rule != llmrouter.RuleModelMapping {
+			t.Errorf("%s routed to %q by %s, want %q by model mapping", model, exp.Provider, exp.Rule, provider)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestFromEnvSkipsMissingKeys(t *testing.T) {
+	setEnv(t, map[string]string{"DEEPSEEK_API_KEY": "sk-ds"})
+
+	r, err := FromEnv(context.Background(), llmrouter.WithFallback("deepseek"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"deepseek"}; !slices.Equal(sortedProviders(r), want) {
+		t.Errorf("providers = %v, want %v", sortedProviders(r), want)
+	}
+	if exp := r.Explain("gpt-4o"); exp.Provider != "" {
+		t.Errorf("gpt-4o routed to %q without an OpenAI key", exp.Provider)
+	}
+}
+
+func TestFromEnvNoKeys(t *testing.T) {
+	setEnv(t, nil)
+	if _, err := FromEnv(context.Background()); !errors.Is(err, llmrouter.ErrNoProviders) {
+		t.Errorf("error = %v, want ErrNoProviders", err)
+	}
+}
+
+// namedProvider is a provider known only by its name and models
+type namedProvider struct {
+	llmrouter.Provider
+	name   string
+	models []string
+}
+
+func (p *namedProvider) Name() string     { return p.name }
+func (p *namedProvider) Models() []string { return p.models }
+
+func TestRegisterAllFirstProviderWins(t *testing.T) {
+	r := llmrouter.New(registerAll([]llmrouter.Provider{
+		&namedProvider{name: "first", models: []string{"shared", "only-first"}},
+		&namedProvider{name: "second", models: []string{"shared", "only-second"}},
+	})...)
+
+	for model, provider := range map[string]string{"shared": "first", "only-first": "first", "only-second": "second"} {
+		if exp := r.Explain(model); exp.Provider != provider {
+			t.Errorf("%s routed to %q, want %q", model, exp.Provider, provider)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/bluefunda/llm-router/middleware"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the schema of a router config file. YAML and JSON are both
+// accepted. Example:
+//
+//	providers:
+//	  - name: openai
+//	    api_key_env: OPENAI_API_KEY
+//	  - name: claude
+//	    type: anthropic
+//	    api_key_env: ANTHROPIC_API_KEY
+//	    timeout: 60s
+//	  - name: vllm
+//	    type: openai
+//	    base_url: https://vllm.internal/v1
+//	    api_key: ${VLLM_TOKEN}
+//	model_mappings:
+//	  gpt-4o: openai
+//	model_patterns:
+//	  - pattern: claude-*
+//	    provider: claude
+//	fallbacks: [openai, claude]
+//	model_defaults:
+//	  codestral-latest:
+//	    temperature: 0
+//	middleware:
+//	  - type: retry
+//	    max_attempts: 3
+//	    delay: 1s
+//	  - type: timeout
+//	    timeout: 60s
+type File struct {
+	Providers     []ProviderEntry          `yaml:"providers"`
+	ModelMappings map[string]string        `yaml:"model_mappings"`
+	ModelPatterns []PatternEntry           `yaml:"model_patterns"`
+	Fallbacks     []string                 `yaml:"fallbacks"`
+	AllowedModels []string                 `yaml:"allowed_models"`
+	Middleware    []MiddlewareEntry        `yaml:"middleware"`
+	ModelDefaults map[string]DefaultsEntry `yaml:"model_defaults"`
+}
+
+// ProviderEntry configures one provider. Type names a registered provider
+// factory: "openai", an OpenAI-compatible preset such as "groq",
+// "anthropic", "gemini", or one added with llmrouter.RegisterProviderFactory.
+// It defaults to the name. The API key is read from the variable named by
+// APIKeyEnv, or from APIKey after expanding ${VAR} references.
+type ProviderEntry struct {
+	Name      string   `yaml:"name"`
+	Type      string   `yaml:"type"`
+	APIKey    string   `yaml:"api_key"`
+	APIKeyEnv string   `yaml:"api_key_env"`
+	BaseURL   string   `yaml:"base_url"`
+	Model     string   `yaml:"model"`
+	Models    []string `yaml:"models"`
+	Timeout   string   `yaml:"timeout"` // e.g. "30s"
+	UserAgent string   `yaml:"user_agent"`
+}
+
+// PatternEntry routes models matching a glob to a provider
+type PatternEntry struct {
+	Pattern  string `yaml:"pattern"`
+	Provider string `yaml:"provider"`
+}
+
+// DefaultsEntry sets sampling defaults for one model, see
+// llmrouter.WithModelDefaults
+type DefaultsEntry struct {
+	Temperature *float64 `yaml:"temperature"`
+	TopP        *float64 `yaml:"top_p"`
+	MaxTokens   *int     `yaml:"max_tokens"`
+	N           *int     `yaml:"n"`
+	Stop        []string `yaml:"stop"`
+	ServiceTier string   `yaml:"service_tier"`
+}
+
+// MiddlewareEntry configures one middleware, applied in file order (first is
+// outermost). Supported types and their fields:
+//
+//   - retry: max_attempts, delay
+//   - timeout: timeout
+//   - circuit_breaker: max_failures, timeout
+type MiddlewareEntry struct {
+	Type        string `yaml:"type"`
+	MaxAttempts int    `yaml:"max_attempts"`
+	Delay       string `yaml:"delay"`
+	Timeout     string `yaml:"timeout"`
+	MaxFailures uint32 `yaml:"max_failures"`
+}
+
+// LoadConfig reads a config file and builds a router from it. The resulting
+// router is validated, so dangling provider references are reported here.
+func LoadConfig(ctx context.Context, path string) (*llmrouter.Router, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(ctx, data)
+}
+
+// Parse builds a router from config file contents
+func Parse(ctx context.Context, data []byte) (*llmrouter.Router, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	return f.Build(ctx)
+}
+
+// Build builds and validates a router from the config
+func (f *File) Build(ctx context.Context) (*llmrouter.Router, error) {
+	var opts []llmrouter.Option
+
+	for _, entry := range f.Providers {
+		p, err := entry.build(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("provider %q: %w", entry.Name, err)
+		}
+		opts = append(opts, llmrouter.WithProvider(entry.Name, p))
+		// Enforced by the router too, so shared TimeoutMiddleware defers to it
+		if timeout, _ := parseDuration(entry.Timeout); timeout > 0 {
+			opts = append(opts, llmrouter.WithProviderTimeout(p.Name(), timeout))
+		}
+	}
+	for model, provider := range f.ModelMappings {
+		opts = append(opts, llmrouter.WithModelMapping(model, provider))
+	}
+	for _, p := range f.ModelPatterns {
+		opts = append(opts, llmrouter.WithModelPattern(p.Pattern, p.Provider))
+	}
+	if len(f.Fallbacks) > 0 {
+		opts = append(opts, llmrouter.WithFallback(f.Fallbacks...))
+	}
+	if len(f.AllowedModels) > 0 {
+		opts = append(opts, llmrouter.WithAllowedModels(f.AllowedModels...))
+	}
+	if len(f.ModelDefaults) > 0 {
+		defaults := make(map[string]llmrouter.Request, len(f.ModelDefaults))
+		for model, d := range f.ModelDefaults {
+			defaults[model] = llmrouter.Request{
+				Temperature: d.Temperature,
+				TopP:        d.TopP,
+				MaxTokens:   d.MaxTokens,
+				N:           d.N,
+				Stop:        d.Stop,
+				ServiceTier: d.ServiceTier,
+			}
+		}
+		opts = append(opts, llmrouter.WithModelDefaults(defaults))
+	}
+	for i, entry := range f.Middleware {
+		m, err := entry.build()
+		if err != nil {
+			return nil, fmt.Errorf("middleware %d (%s): %w", i, entry.Type, err)
+		}
+		opts = append(opts, llmrouter.WithMiddleware(m))
+	}
+
+	r := llmrouter.New(opts...)
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (e ProviderEntry) build(ctx context.Context) (llmrouter.Provider, error) {
+	if e.Name == "" {
+		return nil, fmt.Errorf("%w: provider name is required", llmrouter.ErrInvalidRequest)
+	}
+
+	cfg := llmrouter.ProviderConfig{
+		Name:      e.Name,
+		APIKey:    os.ExpandEnv(e.APIKey),
+		BaseURL:   e.BaseURL,
+		Model:     e.Model,
+		Models:    e.Models,
+		UserAgent: e.UserAgent,
+	}
+	if e.APIKeyEnv != "" {
+		cfg.APIKey = os.Getenv(e.APIKeyEnv)
+	}
+	timeout, err := parseDuration(e.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Timeout = timeout
+
+	kind := e.Type
+	if kind == "" {
+		kind = e.Name
+	}
+	return llmrouter.NewProviderByName(kind, cfg)
+}
+
+func (e MiddlewareEntry) build() (llmrouter.Middleware, error) {
+	switch e.Type {
+	case "retry":
+		delay, err := parseDuration(e.Delay)
+		if err != nil {
+			return nil, err
+		}
+		if delay == 0 {
+			delay = time.Second
+		}
+		attempts := e.MaxAttempts
+		if attempts == 0 {
+			attempts = 3
+		}
+		return middleware.NewRetryMiddleware(attempts, delay), nil
+	case "timeout":
+		timeout, err := parseDuration(e.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		if timeout == 0 {
+			return nil, fmt.Errorf("%w: timeout is required", llmrouter.ErrInvalidRequest)
+		}
+		return middleware.NewTimeoutMiddleware(timeout), nil
+	case "circuit_breaker":
+		timeout, err := parseDuration(e.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		if timeout == 0 {
+			timeout = 30 * time.Second
+		}
+		failures := e.MaxFailures
+		if failures == 0 {
+			failures = 5
+		}
+		return middleware.NewCircuitBreakerMiddleware("llm-router", failures, timeout), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown middleware type %q", llmrouter.ErrInvalidRequest, e.Type)
+	}
+}
+
+// parseDuration parses an optional duration string such as "30s"
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", llmrouter.ErrInvalidRequest, err)
+	}
+	return d, nil
+}
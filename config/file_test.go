@@ -0,0 +1,249 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+const sampleConfig = `
+providers:
+  - name: openai
+    api_key_env: TEST_OPENAI_KEY
+  - name: claude
+    type: anthropic
+    api_key: ${TEST_ANTHROPIC_KEY}
+    timeout: 60s
+  - name: vllm
+    type: openai
+    base_url: https://vllm.internal/v1
+    api_key: static-token
+    models: [llama-local]
+model_mappings:
+  gpt-4o: openai
+  house-model: vllm
+model_patterns:
+  - pattern: claude-*
+    provider: claude
+fallbacks: [openai, claude]
+model_defaults:
+  llama-local:
+    temperature: 0
+middleware:
+  - type: retry
+    max_attempts: 3
+    delay: 1s
+  - type: timeout
+    timeout: 60s
+  - type: circuit_breaker
+`
+
+// writeConfig writes contents to a file in a temporary directory
+func writeConfig(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "sk-openai")
+	t.Setenv("TEST_ANTHROPIC_KEY", "sk-ant")
+
+	r, err := LoadConfig(context.Background(), writeConfig(t, "router.yaml", sampleConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"claude", "openai", "vllm"}; !slices.Equal(sortedProviders(r), want) {
+		t.Errorf("providers = %v, want %v", sortedProviders(r), want)
+	}
+	tests := []struct {
+		model    string
+		provider string
+		rule     llmrouter.RoutingRule
+	}{
+		{"gpt-4o", "openai", llmrouter.RuleModelMapping},
+		{"house-model", "vllm", llmrouter.RuleModelMapping},
+		{"claude-3-5-sonnet", "claude", llmrouter.RulePattern},
+		{"llama-local", "vllm", llmrouter.RuleModelList},
+	}
+	for _, tt := range tests {
+		if exp := r.Explain(tt.model); exp.Provider != tt.provider || exp.Rule != tt.rule {
+			t.Errorf("%s routed to %q by %s, want %q by %s", tt.model, exp.Provider, exp.Rule, tt.provider, tt.rule)
+		}
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	contents := `{
+		"providers": [{"name": "groq", "api_key": "gsk"}],
+		"model_mappings": {"fast": "groq"},
+		"allowed_models": ["fast"]
+	}`
+	r, err := LoadConfig(context.Background(), writeConfig(t, "router.json", contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := r.Explain("fast"); exp.Provider != "groq" {
+		t.Errorf("fast routed to %q, want groq", exp.Provider)
+	}
+	if exp := r.Explain("llama-3.3-70b-versatile"); !errors.Is(exp.Err, llmrouter.ErrModelNotAllowed) {
+		t.Errorf("unlisted model error = %v, want ErrModelNotAllowed", exp.Err)
+	}
+}
+
+func TestConfigAPIKeyFromEnv(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id": "1", "object": "chat.completion", "model": "local",
+			"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": "hi"}, "finish_reason": "stop"}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("TEST_VLLM_TOKEN", "secret")
+
+	r, err := Parse(context.Background(), []byte(`
+providers:
+  - name: vllm
+    type: openai
+    base_url: `+srv.URL+`/
+    api_key: tok-${TEST_VLLM_TOKEN}
+    models: [local]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &llmrouter.Request{Model: "local", Messages: []llmrouter.Message{{Role: llmrouter.RoleUser, Content: "hi"}}}
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer tok-secret" {
+		t.Errorf("Authorization = %q, want the expanded key", auth)
+	}
+}
+
+func TestConfigModelDefaults(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id": "1", "object": "chat.completion", "model": "local",
+			"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": "hi"}, "finish_reason": "stop"}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	r, err := Parse(context.Background(), []byte(`
+providers:
+  - name: vllm
+    type: openai
+    base_url: `+srv.URL+`/
+    api_key: k
+    models: [coder]
+model_defaults:
+  coder:
+    temperature: 0
+    max_tokens: 512
+    stop: ["###"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &llmrouter.Request{Model: "coder", Messages: []llmrouter.Message{{Role: llmrouter.RoleUser, Content: "hi"}}}
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if body["temperature"] != 0.0 || body["max_tokens"] != 512.0 || !slices.Equal(toStrings(body["stop"]), []string{"###"}) {
+		t.Errorf("request = temperature %v, max_tokens %v, stop %v; want the configured defaults", body["temperature"], body["max_tokens"], body["stop"])
+	}
+}
+
+// toStrings converts a decoded JSON array of strings
+func toStrings(v any) []string {
+	list, _ := v.([]any)
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		str, _ := s.(string)
+		out = append(out, str)
+	}
+	return out
+}
+
+func TestConfigCustomProviderType(t *testing.T) {
+	llmrouter.RegisterProviderFactory("config-test", func(cfg llmrouter.ProviderConfig) (llmrouter.Provider, error) {
+		return &namedProvider{name: cfg.Name, models: cfg.Models}, nil
+	})
+
+	r, err := Parse(context.Background(), []byte(`
+providers:
+  - name: inhouse
+    type: config-test
+    models: [inhouse-model]
+  - name: fast
+    type: groq
+    api_key: gsk
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		model    string
+		provider string
+	}{
+		{"inhouse-model", "inhouse"},
+		{"llama-3.3-70b-versatile", "fast"},
+	}
+	for _, tt := range tests {
+		if exp := r.Explain(tt.model); exp.Provider != tt.provider {
+			t.Errorf("%s routed to %q (%v), want %q", tt.model, exp.Provider, exp.Err, tt.provider)
+		}
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     error
+		contains string
+	}{
+		{"dangling mapping", "providers: [{name: openai, api_key: k}]\nmodel_mappings: {gpt-4o: missing}", llmrouter.ErrUnknownProvider, "missing"},
+		{"dangling fallback", "providers: [{name: openai, api_key: k}]\nfallbacks: [openai, gone]", llmrouter.ErrUnknownProvider, "gone"},
+		{"unknown provider type", "providers: [{name: x, type: nope, api_key: k}]", llmrouter.ErrUnknownProvider, `provider "x"`},
+		{"missing name", "providers: [{type: openai, api_key: k}]", llmrouter.ErrInvalidRequest, "name is required"},
+		{"bad timeout", "providers: [{name: openai, api_key: k, timeout: soon}]", llmrouter.ErrInvalidRequest, "soon"},
+		{"unknown middleware", "providers: [{name: openai, api_key: k}]\nmiddleware: [{type: magic}]", llmrouter.ErrInvalidRequest, "magic"},
+		{"timeout middleware without timeout", "providers: [{name: openai, api_key: k}]\nmiddleware: [{type: timeout}]", llmrouter.ErrInvalidRequest, "timeout is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(context.Background(), []byte(tt.contents))
+			if !errors.Is(err, tt.want) || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("error = %v, want %v mentioning %q", err, tt.want, tt.contains)
+			}
+		})
+	}
+
+	if _, err := Parse(context.Background(), []byte("providers: [")); err == nil || !strings.Contains(err.Error(), "parsing config") {
+		t.Errorf("malformed file: error = %v, want a parse error", err)
+	}
+	if _, err := LoadConfig(context.Background(), filepath.Join(t.TempDir(), "missing.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: error = %v, want os.ErrNotExist", err)
+	}
+}
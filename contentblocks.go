@@ -0,0 +1,60 @@
+package llmrouter
+
+import "strings"
+
+// SetContent replaces the message content and keeps ContentBlocks in step.
+// Content that is a prefix of the old, e.g. with a stop sequence trimmed, is
+// trimmed from the end of the text blocks. Any other content replaces the
+// text blocks with a single one, where the first text block was.
+func (m *Message) SetContent(content string) {
+	old := m.Content
+	m.Content = content
+	if len(m.ContentBlocks) == 0 || content == old {
+		return
+	}
+
+	if strings.HasPrefix(old, content) {
+		m.ContentBlocks = trimTextBlocks(m.ContentBlocks, len(old)-len(content))
+		return
+	}
+
+	blocks := make([]ContentPart, 0, len(m.ContentBlocks)+1)
+	placed := false
+	for _, b := range m.ContentBlocks {
+		if b.Type != "text" {
+			blocks = append(blocks, b)
+			continue
+		}
+		if !placed && content != "" {
+			blocks = append(blocks, ContentPart{Type: "text", Text: content})
+		}
+		placed = true
+	}
+	if !placed && content != "" {
+		blocks = append([]ContentPart{{Type: "text", Text: content}}, blocks...)
+	}
+	m.ContentBlocks = blocks
+}
+
+// trimTextBlocks returns a copy of blocks with n bytes of text removed from
+// the end, dropping text blocks left empty
+func trimTextBlocks(blocks []ContentPart, n int) []ContentPart {
+	trimmed := make([]ContentPart, len(blocks))
+	copy(trimmed, blocks)
+	for i := len(trimmed) - 1; i >= 0 && n > 0; i-- {
+		if trimmed[i].Type != "text" {
+			continue
+		}
+		cut := min(n, len(trimmed[i].Text))
+		trimmed[i].Text = trimmed[i].Text[:len(trimmed[i].Text)-cut]
+		n -= cut
+	}
+
+	kept := trimmed[:0]
+	for _, b := range trimmed {
+		if b.Type != "text" || b.Text != "" {
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,45 @@
+package llmrouter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetContent(t *testing.T) {
+	call := &ToolCall{ID: "call_1", Type: "function", Function: FuncCall{Name: "weather"}}
+	text := func(s string) ContentPart { return ContentPart{Type: "text", Text: s} }
+	tool := ContentPart{Type: "tool_call", ToolCall: call}
+
+	tests := []struct {
+		name    string
+		content string
+		blocks  []ContentPart
+		set     string
+		want    []ContentPart
+	}{
+		{"no blocks", "hello", nil, "bye", nil},
+		{"unchanged", "ab", []ContentPart{text("a"), tool, text("b")}, "ab", []ContentPart{text("a"), tool, text("b")}},
+		{"suffix trimmed", "Checking.Done. END", []ContentPart{text("Checking."), tool, text("Done. END")}, "Checking.Done.", []ContentPart{text("Checking."), tool, text("Done.")}},
+		{"trim spans blocks", "ab", []ContentPart{text("a"), tool, text("b")}, "", []ContentPart{tool}},
+		{"trim empties the last block", "a b", []ContentPart{text("a"), tool, text(" b")}, "a", []ContentPart{text("a"), tool}},
+		{"replaced at the first text block", "a b", []ContentPart{tool, text("a"), text(" b")}, "{}", []ContentPart{tool, text("{}")}},
+		{"replaced without text blocks", "", []ContentPart{tool}, "{}", []ContentPart{text("{}"), tool}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]ContentPart(nil), tt.blocks...)
+			m := &Message{Content: tt.content, ContentBlocks: tt.blocks}
+			m.SetContent(tt.set)
+
+			if m.Content != tt.set {
+				t.Errorf("Content = %q, want %q", m.Content, tt.set)
+			}
+			if !reflect.DeepEqual(m.ContentBlocks, tt.want) {
+				t.Errorf("ContentBlocks = %+v, want %+v", m.ContentBlocks, tt.want)
+			}
+			if !reflect.DeepEqual(tt.blocks, original) {
+				t.Errorf("original blocks modified: %+v", tt.blocks)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package llmrouter
+
+import (
+	"context"
+	"fmt"
+)
+
+// continuePrompt asks the model to resume a truncated answer
+const continuePrompt = "Continue exactly where you left off. Do not repeat any of the previous text."
+
+// Continue resumes a response that was cut off by the token limit. It sends
+// req's conversation followed by the truncated assistant content and a
+// request to continue, then returns prev merged with the continuation: the
+// contents are concatenated, usage is summed and the finish reason and
+// details are taken from the continuation. If prev was not truncated it is
+// returned unchanged.
+func (r *Router) Continue(ctx context.Context, req *Request, prev *Response) (*Response, error) {
+	if prev == nil || len(prev.Choices) == 0 || prev.Choices[0].Message == nil {
+		return nil, fmt.Errorf("%w: no previous response to continue", ErrInvalidRequest)
+	}
+	if prev.Choices[0].FinishReason != "length" {
+		return prev, nil
+	}
+
+	partial := prev.Choices[0].Message.Content
+
+	// The partial content already begins with any prefill
+	next := *req
+	next.Prefill = ""
+	next.N = nil
+	next.Messages = make([]Message, 0, len(req.Messages)+2)
+	next.Messages = append(next.Messages, req.Messages...)
+	next.Messages = append(next.Messages,
+		Message{Role: RoleAssistant, Content: partial},
+		Message{Role: RoleUser, Content: continuePrompt},
+	)
+
+	cont, err := r.Complete(ctx, &next)
+	if err != nil {
+		return nil, err
+	}
+	return mergeContinuation(prev, cont), nil
+}
+
+// mergeContinuation joins a truncated response with its continuation
+func mergeContinuation(prev, cont *Response) *Response {
+	merged := *cont
+	merged.ID = prev.ID
+
+	msg := *prev.Choices[0].Message
+	// Copy before appending so prev keeps its own slices
+	msg.ToolCalls = append([]ToolCall(nil), msg.ToolCalls...)
+	msg.ContentBlocks = append([]ContentPart(nil), msg.ContentBlocks...)
+	finishReason := "stop"
+	var details *FinishDetails
+	if len(cont.Choices) > 0 {
+		finishReason = cont.Choices[0].FinishReason
+		details = cont.Choices[0].FinishDetails
+		if m := cont.Choices[0].Message; m != nil {
+			msg.Content += m.Content
+			msg.ToolCalls = append(msg.ToolCalls, m.ToolCalls...)
+			msg.ContentBlocks = append(msg.ContentBlocks, m.ContentBlocks...)
+		}
+	}
+	if len(msg.ToolCalls) == 0 {
+		msg.ToolCalls = nil
+	}
+	if len(msg.ContentBlocks) == 0 {
+		msg.ContentBlocks = nil
+	}
+	merged.Choices = []Choice{{Index: 0, Message: &msg, FinishReason: finishReason, FinishDetails: details}}
+
+	if prev.Usage != nil || cont.Usage != nil {
+		usage := &Usage{}
+		for _, u := range []*Usage{prev.Usage, cont.Usage} {
+			if u == nil {
+				continue
+			}
+			usage.PromptTokens += u.PromptTokens
+			usage.CompletionTokens += u.CompletionTokens
+			usage.TotalTokens += u.TotalTokens
+		}
+		merged.Usage = usage
+	}
+	return &merged
+}
@@ -0,0 +1,69 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestContinue(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		resp := textResponse("stub", " and the end.")
+		resp.Usage = &Usage{PromptTokens: 20, CompletionTokens: 4, TotalTokens: 24}
+		return resp, nil
+	}}
+	r := New(WithProvider("stub", stub))
+
+	req := userRequest("tell a story")
+	req.Model = "m"
+	req.Prefill = "Once"
+	prev := textResponse("stub", "Once upon a time")
+	prev.ID = "first"
+	prev.Choices[0].FinishReason = "length"
+	prev.Usage = &Usage{PromptTokens: 10, CompletionTokens: 100, TotalTokens: 110}
+
+	resp, err := r.Continue(context.Background(), req, prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Text() != "Once upon a time and the end." || resp.ID != "first" {
+		t.Errorf("merged = %q (id %q)", resp.Text(), resp.ID)
+	}
+	if resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("finish reason = %q, want the continuation's", resp.Choices[0].FinishReason)
+	}
+	if *resp.Usage != (Usage{PromptTokens: 30, CompletionTokens: 104, TotalTokens: 134}) {
+		t.Errorf("usage = %+v, want the sum", resp.Usage)
+	}
+	if prev.Text() != "Once upon a time" {
+		t.Error("previous response was modified")
+	}
+
+	sent := stub.lastCall()
+	n := len(sent.Messages)
+	if n != 3 || sent.Messages[1].Role != RoleAssistant || sent.Messages[1].Content != "Once upon a time" || sent.Messages[2].Content != continuePrompt {
+		t.Errorf("continuation messages = %+v", sent.Messages)
+	}
+	if sent.Prefill != "" {
+		t.Error("prefill was sent again with the continuation")
+	}
+}
+
+func TestContinueNotTruncated(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}}
+	r := New(WithProvider("stub", stub))
+
+	prev := textResponse("stub", "complete answer")
+	resp, err := r.Continue(context.Background(), userRequest("hi"), prev)
+	if err != nil || resp != prev {
+		t.Errorf("got %v, %v; want prev unchanged", resp, err)
+	}
+	if stub.callCount() != 0 {
+		t.Error("a complete response was continued")
+	}
+
+	if _, err := r.Continue(context.Background(), userRequest("hi"), &Response{}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("empty response: error = %v, want ErrInvalidRequest", err)
+	}
+}
@@ -0,0 +1,28 @@
+package llmrouter
+
+import "context"
+
+// Result is the outcome of Do: Response is set for non-streaming requests and
+// Events for streaming ones.
+type Result struct {
+	Response *Response
+	Events   <-chan Event
+}
+
+// Do completes or streams req depending on req.Stream, for callers such as
+// proxies that forward the client's stream flag.
+func (r *Router) Do(ctx context.Context, req *Request) (*Result, error) {
+	if req.Stream {
+		ch, err := r.Stream(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Events: ch}, nil
+	}
+
+	resp, err := r.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Response: resp}, nil
+}
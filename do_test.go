@@ -0,0 +1,83 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// modelRequest is a user request for the stub's model
+func modelRequest() *Request {
+	req := userRequest("hi")
+	req.Model = "m"
+	return req
+}
+
+func TestDoComplete(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}}
+	r := New(WithProvider("stub", stub))
+
+	result, err := r.Do(context.Background(), modelRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Events != nil {
+		t.Error("Events set for a non-streaming request")
+	}
+	if result.Response == nil || result.Response.Choices[0].Message.Content != "ok" {
+		t.Errorf("Response = %+v, want the completion", result.Response)
+	}
+	if stub.callCount() != 1 {
+		t.Errorf("calls = %d, want 1", stub.callCount())
+	}
+}
+
+func TestDoStream(t *testing.T) {
+	stub := &stubProvider{
+		models: []string{"m"},
+		complete: func(ctx context.Context, req *Request) (*Response, error) {
+			t.Error("Complete called for a streaming request")
+			return nil, ErrProviderError
+		},
+	}
+	r := New(WithProvider("stub", stub))
+
+	req := modelRequest()
+	req.Stream = true
+	result, err := r.Do(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Response != nil {
+		t.Error("Response set for a streaming request")
+	}
+	if result.Events == nil {
+		t.Fatal("Events not set for a streaming request")
+	}
+	events := collect(result.Events)
+	if len(events) != 2 || events[0].Content != "ok" || events[1].Type != EventDone {
+		t.Errorf("events = %+v, want the stream", events)
+	}
+}
+
+func TestDoError(t *testing.T) {
+	stub := &stubProvider{
+		models: []string{"m"},
+		complete: func(ctx context.Context, req *Request) (*Response, error) {
+			return nil, ErrAuthFailed
+		},
+		stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+			return nil, ErrAuthFailed
+		},
+	}
+	r := New(WithProvider("stub", stub))
+
+	for _, stream := range []bool{false, true} {
+		req := modelRequest()
+		req.Stream = stream
+		result, err := r.Do(context.Background(), req)
+		if !errors.Is(err, ErrAuthFailed) || result != nil {
+			t.Errorf("stream=%v: Do = %+v, %v; want nil and ErrAuthFailed", stream, result, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package llmrouter
+
+import (
+	"context"
+	"fmt"
+)
+
+// EmbedBatch embeds inputs with model in chunks of at most batchSize, calling
+// progress (if non-nil) after each chunk with the number of inputs embedded
+// so far. Chunks are sent in order; if one fails, the vectors embedded before
+// it are returned together with the error, so callers can resume from
+// len(vectors).
+func (r *Router) EmbedBatch(ctx context.Context, model string, inputs []string, batchSize int, progress func(done, total int)) ([][]float64, error) {
+	if batchSize < 1 {
+		return nil, fmt.Errorf("%w: batch size must be at least 1", ErrInvalidRequest)
+	}
+
+	vectors := make([][]float64, 0, len(inputs))
+	for start := 0; start < len(inputs); start += batchSize {
+		end := min(start+batchSize, len(inputs))
+
+		resp, err := r.Embed(ctx, &EmbeddingRequest{
+			Model: model,
+			Input: inputs[start:end],
+		})
+		if err != nil {
+			return vectors, fmt.Errorf("embedding inputs %d-%d: %w", start, end-1, err)
+		}
+		if len(resp.Embeddings) != end-start {
+			return vectors, fmt.Errorf("%w: got %d embeddings for %d inputs", ErrProviderError, len(resp.Embeddings), end-start)
+		}
+
+		vectors = append(vectors, resp.Embeddings...)
+		if progress != nil {
+			progress(len(vectors), len(inputs))
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,104 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// embedProvider is a stub embedder. Each vector holds the input's position
+// among all inputs seen, so tests can check order across chunks.
+type embedProvider struct {
+	stubProvider
+	embed  func(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error)
+	inputs [][]string
+}
+
+func (p *embedProvider) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	p.inputs = append(p.inputs, req.Input)
+	if p.embed != nil {
+		return p.embed(ctx, req)
+	}
+	seen := 0
+	for _, in := range p.inputs[:len(p.inputs)-1] {
+		seen += len(in)
+	}
+	resp := &EmbeddingResponse{Model: req.Model}
+	for i := range req.Input {
+		resp.Embeddings = append(resp.Embeddings, []float64{float64(seen + i)})
+	}
+	return resp, nil
+}
+
+func TestEmbedBatch(t *testing.T) {
+	e := &embedProvider{stubProvider: stubProvider{models: []string{"embed"}}}
+	r := New(WithProvider("e", e))
+
+	type step struct{ done, total int }
+	var steps []step
+	vectors, err := r.EmbedBatch(context.Background(), "embed", []string{"a", "b", "c", "d", "e"}, 2, func(done, total int) {
+		steps = append(steps, step{done, total})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(e.inputs) != 3 || len(e.inputs[0]) != 2 || len(e.inputs[2]) != 1 || e.inputs[2][0] != "e" {
+		t.Errorf("chunks = %v, want [a b] [c d] [e]", e.inputs)
+	}
+	if len(vectors) != 5 {
+		t.Fatalf("got %d vectors, want 5", len(vectors))
+	}
+	for i, v := range vectors {
+		if v[0] != float64(i) {
+			t.Errorf("vector %d = %v, want input order kept", i, v)
+		}
+	}
+	if want := []step{{2, 5}, {4, 5}, {5, 5}}; len(steps) != len(want) || steps[0] != want[0] || steps[1] != want[1] || steps[2] != want[2] {
+		t.Errorf("progress = %v, want %v", steps, want)
+	}
+}
+
+func TestEmbedBatchPartialFailure(t *testing.T) {
+	e := &embedProvider{stubProvider: stubProvider{models: []string{"embed"}}}
+	e.embed = func(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+		if len(e.inputs) == 2 {
+			return nil, ErrRateLimited
+		}
+		return &EmbeddingResponse{Embeddings: [][]float64{{1}, {2}}}, nil
+	}
+	r := New(WithProvider("e", e))
+
+	vectors, err := r.EmbedBatch(context.Background(), "embed", []string{"a", "b", "c", "d", "e"}, 2, nil)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("error = %v, want ErrRateLimited", err)
+	}
+	if err.Error() != "embedding inputs 2-3: rate limited" {
+		t.Errorf("error = %q, want the failed inputs named", err)
+	}
+	if len(vectors) != 2 {
+		t.Errorf("got %d vectors, want the first chunk's 2", len(vectors))
+	}
+	if len(e.inputs) != 2 {
+		t.Errorf("embedded %d chunks, want to stop after the failure", len(e.inputs))
+	}
+}
+
+func TestEmbedBatchCountMismatch(t *testing.T) {
+	e := &embedProvider{stubProvider: stubProvider{models: []string{"embed"}}}
+	e.embed = func(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+		return &EmbeddingResponse{Embeddings: [][]float64{{1}}}, nil
+	}
+	r := New(WithProvider("e", e))
+
+	if _, err := r.EmbedBatch(context.Background(), "embed", []string{"a", "b"}, 2, nil); !errors.Is(err, ErrProviderError) {
+		t.Errorf("error = %v, want ErrProviderError", err)
+	}
+}
+
+func TestEmbedBatchSize(t *testing.T) {
+	r := New(WithProvider("e", &embedProvider{}))
+	if _, err := r.EmbedBatch(context.Background(), "embed", []string{"a"}, 0, nil); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("error = %v, want ErrInvalidRequest", err)
+	}
+}
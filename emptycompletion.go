@@ -0,0 +1,29 @@
+package llmrouter
+
+import (
+	"context"
+	"fmt"
+)
+
+// emptyCompletionProvider fails completions whose first choice has neither
+// content nor tool calls
+type emptyCompletionProvider struct {
+	Provider
+}
+
+func (p *emptyCompletionProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Text() == "" && len(resp.ToolCalls()) == 0 {
+		finishReason := ""
+		if len(resp.Choices) > 0 {
+			finishReason = resp.Choices[0].FinishReason
+		}
+		return nil, fmt.Errorf("%w: %s returned no content or tool calls (model %s, finish_reason %q)",
+			ErrEmptyCompletion, p.Name(), resp.Model, finishReason)
+	}
+	return resp, nil
+}
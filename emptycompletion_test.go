@@ -0,0 +1,73 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorOnEmptyCompletion(t *testing.T) {
+	tests := []struct {
+		name  string
+		resp  *Response
+		empty bool
+	}{
+		{"empty content", &Response{Model: "m", Choices: []Choice{{Message: &Message{Role: RoleAssistant}, FinishReason: "stop"}}}, true},
+		{"no message", &Response{Model: "m", Choices: []Choice{{FinishReason: "stop"}}}, true},
+		{"no choices", &Response{Model: "m"}, true},
+		{"content", textResponse("stub", "hi"), false},
+		{"tool calls only", &Response{Choices: []Choice{{Message: &Message{
+			Role:      RoleAssistant,
+			ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: FuncCall{Name: "weather"}}},
+		}, FinishReason: "tool_calls"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProvider{models: []string{"m"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+				return tt.resp, nil
+			}}
+			req := userRequest("hi")
+			req.Model = "m"
+
+			// Without the option every reply is returned as-is
+			if resp, err := New(WithProvider("stub", stub)).Complete(context.Background(), req); err != nil || resp != tt.resp {
+				t.Errorf("without the option: %+v, %v; want the reply", resp, err)
+			}
+
+			resp, err := New(WithProvider("stub", stub), WithErrorOnEmptyCompletion()).Complete(context.Background(), req)
+			if !tt.empty {
+				if err != nil || resp != tt.resp {
+					t.Errorf("with the option: %+v, %v; want the reply", resp, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrEmptyCompletion) || !strings.Contains(err.Error(), "stub") {
+				t.Errorf("with the option: err = %v, want ErrEmptyCompletion naming the provider", err)
+			}
+		})
+	}
+}
+
+func TestErrorOnEmptyCompletionFallback(t *testing.T) {
+	empty := &stubProvider{name: "empty", models: []string{"m"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		return &Response{Choices: []Choice{{Message: &Message{Role: RoleAssistant}, FinishReason: "stop"}}}, nil
+	}}
+	backup := &stubProvider{name: "backup"}
+	r := New(
+		WithProvider("empty", empty),
+		WithProvider("backup", backup),
+		WithFallback("backup"),
+		WithErrorOnEmptyCompletion(),
+	)
+
+	req := userRequest("hi")
+	req.Model = "m"
+	resp, err := r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != "backup" || resp.Text() != "ok" {
+		t.Errorf("response = %+v, want the fallback's reply", resp)
+	}
+}
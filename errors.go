@@ -3,21 +3,33 @@ package llmrouter
 import (
 	"errors"
 	"net/http"
+	"strings"
 )
 
 // Sentinel errors
 var (
-	ErrUnknownModel     = errors.New("unknown model")
-	ErrUnknownProvider  = errors.New("unknown provider")
-	ErrNoProviders      = errors.New("no providers registered")
-	ErrRateLimited      = errors.New("rate limited")
-	ErrContextCanceled  = errors.New("context canceled")
-	ErrStreamClosed     = errors.New("stream closed")
-	ErrInvalidRequest   = errors.New("invalid request")
-	ErrAuthFailed       = errors.New("authentication failed")
-	ErrProviderError    = errors.New("provider error")
-	ErrCircuitOpen      = errors.New("circuit breaker is open")
-	ErrMaxRetriesExceed = errors.New("max retries exceeded")
+	ErrUnknownModel           = errors.New("unknown model")
+	ErrModelNotAllowed        = errors.New("model not allowed")
+	ErrUnknownProvider        = errors.New("unknown provider")
+	ErrNoProviders            = errors.New("no providers registered")
+	ErrRateLimited            = errors.New("rate limited")
+	ErrContextCanceled        = errors.New("context canceled")
+	ErrStreamClosed           = errors.New("stream closed")
+	ErrInvalidRequest         = errors.New("invalid request")
+	ErrAuthFailed             = errors.New("authentication failed")
+	ErrProviderError          = errors.New("provider error")
+	ErrCircuitOpen            = errors.New("circuit breaker is open")
+	ErrMaxRetriesExceed       = errors.New("max retries exceeded")
+	ErrNotSupported           = errors.New("operation not supported by provider")
+	ErrInvalidJSON            = errors.New("invalid JSON output")
+	ErrPayloadTooLarge        = errors.New("payload too large")
+	ErrContentFiltered        = errors.New("content filtered")
+	ErrContextLengthExceeded  = errors.New("context length exceeded")
+	ErrEmptyCompletion        = errors.New("empty completion")
+	ErrConnectTimeout         = errors.New("stream connect timeout")
+	ErrIdleTimeout            = errors.New("stream idle timeout")
+	ErrStreamDurationExceeded = errors.New("stream duration exceeded")
+	ErrBudgetExceeded         = errors.New("budget exceeded")
 )
 
 // APIError represents an error from an LLM provider API
@@ -40,6 +52,51 @@ func (e *APIError) Unwrap() error {
 	return e.Err
 }
 
+// ProviderError pairs an error with the provider that produced it
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+// MultiError collects the errors from every provider tried by a fallback.
+// errors.Is and errors.As match against any contained error.
+type MultiError struct {
+	Errors []ProviderError
+}
+
+func (e *MultiError) Error() string {
+	var b strings.Builder
+	b.WriteString("all providers failed:")
+	for _, pe := range e.Errors {
+		b.WriteString("\n  ")
+		b.WriteString(pe.Provider)
+		b.WriteString(": ")
+		b.WriteString(pe.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, pe := range e.Errors {
+		errs[i] = pe.Err
+	}
+	return errs
+}
+
+// orSingle returns the sole error when only one provider was tried
+func (e *MultiError) orSingle() error {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Err
+	}
+	return e
+}
+
+// Add records an error from a provider
+func (e *MultiError) Add(provider string, err error) {
+	e.Errors = append(e.Errors, ProviderError{Provider: provider, Err: err})
+}
+
 // IsRetryable returns true if the error is retryable
 func IsRetryable(err error) bool {
 	if err == nil {
@@ -61,6 +118,26 @@ func IsRetryable(err error) bool {
 		return false
 	}
 
+	// Oversized payloads won't shrink on retry
+	if errors.Is(err, ErrPayloadTooLarge) {
+		return false
+	}
+
+	// The prompt won't fit on retry either
+	if errors.Is(err, ErrContextLengthExceeded) {
+		return false
+	}
+
+	// Blocked content is blocked again on retry
+	if errors.Is(err, ErrContentFiltered) {
+		return false
+	}
+
+	// The budget stays spent
+	if errors.Is(err, ErrBudgetExceeded) {
+		return false
+	}
+
 	// Check API errors
 	var apiErr *APIError
 	if errors.As(err, &apiErr) {
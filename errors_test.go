@@ -0,0 +1,80 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMultiError(t *testing.T) {
+	apiErr := &APIError{Provider: "b", StatusCode: 500, Message: "boom"}
+	multi := &MultiError{}
+	multi.Add("a", fmt.Errorf("%w: bad key", ErrAuthFailed))
+	multi.Add("b", apiErr)
+
+	if !errors.Is(multi, ErrAuthFailed) {
+		t.Error("errors.Is doesn't find the auth error")
+	}
+	if errors.Is(multi, ErrRateLimited) {
+		t.Error("errors.Is matched an error that isn't contained")
+	}
+	var target *APIError
+	if !errors.As(multi, &target) || target != apiErr {
+		t.Error("errors.As doesn't find the API error")
+	}
+
+	msg := multi.Error()
+	if !strings.HasPrefix(msg, "all providers failed:") || !strings.Contains(msg, "\n  a: authentication failed: bad key") || !strings.Contains(msg, "\n  b: ") {
+		t.Errorf("message = %q", msg)
+	}
+}
+
+func TestFallbackExhaustedReturnsMultiError(t *testing.T) {
+	primary := &stubProvider{name: "primary", models: []string{"m"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, fmt.Errorf("%w: bad key", ErrAuthFailed)
+	}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return nil, fmt.Errorf("%w: bad key", ErrAuthFailed)
+	}}
+	backup := &stubProvider{name: "backup", complete: func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, ErrRateLimited
+	}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return nil, ErrRateLimited
+	}}
+	r := New(WithProvider("primary", primary), WithProvider("backup", backup), WithFallback("backup"))
+
+	req := userRequest("hi")
+	req.Model = "m"
+	_, err := r.Complete(context.Background(), req)
+
+	var multi *MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 {
+		t.Fatalf("error = %v, want a MultiError from both providers", err)
+	}
+	if multi.Errors[0].Provider != "primary" || multi.Errors[1].Provider != "backup" {
+		t.Errorf("providers = %q, %q", multi.Errors[0].Provider, multi.Errors[1].Provider)
+	}
+	if !errors.Is(err, ErrAuthFailed) || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("error %v doesn't match both sub-errors", err)
+	}
+
+	_, err = r.Stream(context.Background(), req)
+	if !errors.As(err, &multi) || !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("stream error = %v, want a MultiError with ErrAuthFailed", err)
+	}
+}
+
+func TestSingleProviderErrorNotWrapped(t *testing.T) {
+	primary := &stubProvider{models: []string{"m"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, ErrRateLimited
+	}}
+	r := New(WithProvider("primary", primary))
+
+	req := userRequest("hi")
+	req.Model = "m"
+	_, err := r.Complete(context.Background(), req)
+	if err != ErrRateLimited {
+		t.Errorf("error = %v, want the provider's error unwrapped", err)
+	}
+}
@@ -77,13 +77,12 @@ func main() {
 	for _, tc := range testCases {
 		fmt.Printf("--- %s (%s) ---\n", tc.name, tc.model)
 
-		resp, err := router.Complete(ctx, &llmrouter.Request{
-			Model: tc.model,
-			Messages: []llmrouter.Message{
-				{Role: llmrouter.RoleUser, Content: "Say 'Hello from ' followed by your model name in 10 words or less."},
-			},
-			MaxTokens: intPtr(50),
-		})
+		req := llmrouter.NewRequest(tc.model).
+			User("Say 'Hello from ' followed by your model name in 10 words or less.").
+			MaxTokens(50).
+			Build()
+
+		resp, err := router.Complete(ctx, req)
 
 		if err != nil {
 			fmt.Printf("Error: %v\n\n", err)
@@ -91,7 +90,7 @@ func main() {
 		}
 
 		fmt.Printf("Provider: %s\n", resp.Provider)
-		fmt.Printf("Response: %s\n", resp.Choices[0].Message.Content)
+		fmt.Printf("Response: %s\n", resp.Text())
 		if resp.Usage != nil {
 			fmt.Printf("Tokens: %d total\n", resp.Usage.TotalTokens)
 		}
@@ -103,7 +102,3 @@ func main() {
 		geminiProvider.Close()
 	}
 }
-
-func intPtr(i int) *int {
-	return &i
-}
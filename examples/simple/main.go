@@ -41,7 +41,7 @@ func main() {
 	}
 
 	fmt.Printf("Response from %s:\n", resp.Provider)
-	fmt.Println(resp.Choices[0].Message.Content)
+	fmt.Println(resp.Text())
 	if resp.Usage != nil {
 		fmt.Printf("Tokens: %d prompt, %d completion\n", resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
 	}
@@ -60,7 +60,7 @@ func main() {
 	}
 
 	fmt.Printf("Response from %s:\n", resp.Provider)
-	fmt.Println(resp.Choices[0].Message.Content)
+	fmt.Println(resp.Text())
 	if resp.Usage != nil {
 		fmt.Printf("Tokens: %d prompt, %d completion\n", resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
 	}
@@ -66,8 +66,8 @@ func main() {
 	fmt.Printf("Response from %s:\n", resp.Provider)
 	fmt.Printf("Finish reason: %s\n", resp.Choices[0].FinishReason)
 
-	if len(resp.Choices[0].Message.ToolCalls) > 0 {
-		tc := resp.Choices[0].Message.ToolCalls[0]
+	if toolCalls := resp.ToolCalls(); len(toolCalls) > 0 {
+		tc := toolCalls[0]
 		fmt.Printf("\nTool call requested:\n")
 		fmt.Printf("  Function: %s\n", tc.Function.Name)
 		fmt.Printf("  Arguments: %s\n", tc.Function.Arguments)
@@ -83,7 +83,7 @@ func main() {
 				{Role: llmrouter.RoleUser, Content: "What's the weather like in San Francisco?"},
 				{
 					Role:      llmrouter.RoleAssistant,
-					ToolCalls: resp.Choices[0].Message.ToolCalls,
+					ToolCalls: toolCalls,
 				},
 				{
 					Role:       llmrouter.RoleTool,
@@ -98,8 +98,8 @@ func main() {
 			os.Exit(1)
 		}
 
-		fmt.Printf("\nFinal response:\n%s\n", resp.Choices[0].Message.Content)
+		fmt.Printf("\nFinal response:\n%s\n", resp.Text())
 	} else {
-		fmt.Printf("Content: %s\n", resp.Choices[0].Message.Content)
+		fmt.Printf("Content: %s\n", resp.Text())
 	}
 }
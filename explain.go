@@ -0,0 +1,29 @@
+package llmrouter
+
+// RoutingRule names the rule that picked a provider for a model
+type RoutingRule string
+
+const (
+	RuleNone         RoutingRule = "none"          // no provider serves the model
+	RuleModelMapping RoutingRule = "model_mapping" // WithModelMapping or MapModel
+	RuleProviderName RoutingRule = "provider_name" // the model is a provider's name
+	RulePattern      RoutingRule = "pattern"       // WithModelPattern
+	RuleModelList    RoutingRule = "model_list"    // listed in the provider's Models
+)
+
+// RoutingExplanation describes how a model was resolved to a provider
+type RoutingExplanation struct {
+	Model    string
+	Provider string // registered name of the chosen provider, "" if none
+	Rule     RoutingRule
+	Detail   string // the matching glob for RulePattern
+	Err      error  // why resolution failed, e.g. ErrModelNotAllowed
+}
+
+// Explain reports which provider a model resolves to and which rule chose
+// it, using the same decision path as routing. Sticky routing and fallbacks,
+// which depend on the request and its outcome, are not reflected.
+func (r *Router) Explain(model string) RoutingExplanation {
+	_, exp := r.resolve(model)
+	return exp
+}
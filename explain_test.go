@@ -0,0 +1,80 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	r := New(
+		WithProvider("openai", &stubProvider{name: "openai", models: []string{"gpt-4o", "shared"}}),
+		WithProvider("anthropic", &stubProvider{name: "anthropic", models: []string{"claude-3", "shared"}}),
+		WithModelMapping("gpt-4o", "anthropic"),
+		WithModelMapping("ghost", "missing"),
+		WithModelPattern("claude-*", "anthropic"),
+		WithModelPattern("claude-3*", "openai"),
+	)
+
+	tests := []struct {
+		model    string
+		provider string
+		rule     RoutingRule
+		detail   string
+	}{
+		{"gpt-4o", "anthropic", RuleModelMapping, ""},
+		{"openai", "openai", RuleProviderName, ""},
+		{"claude-3", "anthropic", RulePattern, "claude-*"}, // the first pattern added wins
+		{"shared", "anthropic", RuleModelList, ""},         // model lists are scanned by provider name
+		{"ghost", "", RuleNone, ""},                        // mapped to an unregistered provider
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			exp := r.Explain(tt.model)
+			if exp.Model != tt.model || exp.Provider != tt.provider || exp.Rule != tt.rule || exp.Detail != tt.detail {
+				t.Errorf("Explain = %+v, want %s by %s (%q)", exp, tt.provider, tt.rule, tt.detail)
+			}
+			if tt.rule == RuleNone {
+				if !errors.Is(exp.Err, ErrUnknownModel) {
+					t.Errorf("Err = %v, want ErrUnknownModel", exp.Err)
+				}
+			} else if exp.Err != nil {
+				t.Errorf("Err = %v, want nil", exp.Err)
+			}
+		})
+	}
+}
+
+func TestExplainMatchesRouting(t *testing.T) {
+	openai := &stubProvider{name: "openai", models: []string{"gpt-4o"}}
+	anthropic := &stubProvider{name: "anthropic", models: []string{"claude-3"}}
+	r := New(WithProvider("openai", openai), WithProvider("anthropic", anthropic))
+	r.MapModel("gpt-4o", "anthropic")
+
+	if exp := r.Explain("gpt-4o"); exp.Provider != "anthropic" || exp.Rule != RuleModelMapping {
+		t.Errorf("Explain = %+v, want the runtime mapping", exp)
+	}
+	req := userRequest("hi")
+	req.Model = "gpt-4o"
+	resp, err := r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != "anthropic" {
+		t.Errorf("routed to %q, want the explained provider", resp.Provider)
+	}
+}
+
+func TestExplainErrors(t *testing.T) {
+	if exp := New().Explain("gpt-4o"); !errors.Is(exp.Err, ErrNoProviders) || exp.Rule != RuleNone {
+		t.Errorf("no providers: Explain = %+v, want ErrNoProviders", exp)
+	}
+
+	r := New(
+		WithProvider("openai", &stubProvider{name: "openai", models: []string{"gpt-4o", "gpt-4"}}),
+		WithAllowedModels("gpt-4o"),
+	)
+	if exp := r.Explain("gpt-4"); !errors.Is(exp.Err, ErrModelNotAllowed) || exp.Provider != "" {
+		t.Errorf("disallowed model: Explain = %+v, want ErrModelNotAllowed", exp)
+	}
+}
@@ -0,0 +1,57 @@
+package llmrouter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ProviderFactory builds a provider from its configuration
+type ProviderFactory func(cfg ProviderConfig) (Provider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]ProviderFactory)
+)
+
+// RegisterProviderFactory makes a provider type constructible by name, e.g.
+// from config files. The core providers register themselves when their
+// package is imported. It panics if name is already registered or factory
+// is nil, so it is meant to be called from init.
+func RegisterProviderFactory(name string, factory ProviderFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("llmrouter: RegisterProviderFactory factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("llmrouter: RegisterProviderFactory called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// NewProviderByName builds a provider with the factory registered under name
+func NewProviderByName(name string, cfg ProviderConfig) (Provider, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: no factory registered for %s", ErrUnknownProvider, name)
+	}
+	return factory(cfg)
+}
+
+// ProviderFactories returns the registered factory names, sorted
+func ProviderFactories() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
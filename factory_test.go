@@ -0,0 +1,67 @@
+package llmrouter
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRegisterProviderFactory(t *testing.T) {
+	var got ProviderConfig
+	RegisterProviderFactory("test-custom", func(cfg ProviderConfig) (Provider, error) {
+		got = cfg
+		return &stubProvider{name: cfg.Name, models: cfg.Models}, nil
+	})
+
+	p, err := NewProviderByName("test-custom", ProviderConfig{Name: "mine", APIKey: "key", Models: []string{"custom-model"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "mine" || !slices.Equal(p.Models(), []string{"custom-model"}) {
+		t.Errorf("provider = %q serving %v, want mine serving custom-model", p.Name(), p.Models())
+	}
+	if got.APIKey != "key" {
+		t.Errorf("factory got config %+v, want the one passed by name", got)
+	}
+	if !slices.Contains(ProviderFactories(), "test-custom") {
+		t.Errorf("factories = %v, want test-custom listed", ProviderFactories())
+	}
+	if names := ProviderFactories(); !slices.IsSorted(names) {
+		t.Errorf("factories = %v, want sorted", names)
+	}
+}
+
+func TestRegisterProviderFactoryPanics(t *testing.T) {
+	RegisterProviderFactory("test-duplicate", func(cfg ProviderConfig) (Provider, error) { return &stubProvider{}, nil })
+
+	tests := []struct {
+		name    string
+		factory ProviderFactory
+	}{
+		{"test-duplicate", func(cfg ProviderConfig) (Provider, error) { return &stubProvider{}, nil }},
+		{"test-nil", nil},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registering %s did not panic", tt.name)
+				}
+			}()
+			RegisterProviderFactory(tt.name, tt.factory)
+		}()
+	}
+}
+
+func TestNewProviderByNameErrors(t *testing.T) {
+	if _, err := NewProviderByName("test-missing", ProviderConfig{}); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("unregistered name: err = %v, want ErrUnknownProvider", err)
+	}
+
+	RegisterProviderFactory("test-failing", func(cfg ProviderConfig) (Provider, error) {
+		return nil, ErrAuthFailed
+	})
+	if _, err := NewProviderByName("test-failing", ProviderConfig{}); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("failing factory: err = %v, want its error", err)
+	}
+}
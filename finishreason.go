@@ -0,0 +1,20 @@
+package llmrouter
+
+import (
+	"log"
+	"sync"
+)
+
+// warnedReasons records the unmapped finish reasons already logged
+var warnedReasons sync.Map
+
+// UnknownFinishReason is the catch-all for provider finish reasons without a
+// normalized equivalent. The reason is returned verbatim rather than
+// defaulting to "stop", so new states such as Anthropic's "pause_turn" stay
+// visible, and a warning is logged the first time each one is seen.
+func UnknownFinishReason(provider, raw string) string {
+	if _, seen := warnedReasons.LoadOrStore(provider+"\x00"+raw, true); !seen {
+		log.Printf("llmrouter: unmapped finish reason %q from %s; passing it through", raw, provider)
+	}
+	return raw
+}
@@ -0,0 +1,32 @@
+package llmrouter
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUnknownFinishReason(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for i := 0; i < 3; i++ {
+		if got := UnknownFinishReason("test-provider", "pause_turn"); got != "pause_turn" {
+			t.Errorf("UnknownFinishReason = %q, want the raw reason", got)
+		}
+	}
+	if got := UnknownFinishReason("test-other", "pause_turn"); got != "pause_turn" {
+		t.Errorf("UnknownFinishReason = %q, want the raw reason", got)
+	}
+
+	logged := buf.String()
+	if n := strings.Count(logged, `"pause_turn" from test-provider`); n != 1 {
+		t.Errorf("warned %d times for test-provider, want once:\n%s", n, logged)
+	}
+	if n := strings.Count(logged, `"pause_turn" from test-other`); n != 1 {
+		t.Errorf("warned %d times for test-other, want once:\n%s", n, logged)
+	}
+}
@@ -8,6 +8,7 @@ require (
 	github.com/openai/openai-go v0.1.0-alpha.40
 	github.com/sony/gobreaker v0.5.0
 	google.golang.org/api v0.189.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
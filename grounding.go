@@ -0,0 +1,19 @@
+package llmrouter
+
+import "slices"
+
+// ApplyGrounding returns req with Grounding translated to the
+// BuiltinWebSearch tool, for providers that ground answers through web
+// search. Requests without Grounding, or already using the tool, are
+// returned unchanged.
+func ApplyGrounding(req *Request) *Request {
+	if !req.Grounding {
+		return req
+	}
+	grounded := *req
+	grounded.Grounding = false
+	if !slices.Contains(req.BuiltinTools, BuiltinWebSearch) {
+		grounded.BuiltinTools = append(slices.Clip(req.BuiltinTools), BuiltinWebSearch)
+	}
+	return &grounded
+}
@@ -0,0 +1,28 @@
+package llmrouter
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestApplyGrounding(t *testing.T) {
+	req := userRequest("news?")
+	if ApplyGrounding(req) != req {
+		t.Error("request without grounding was copied")
+	}
+
+	req.Grounding = true
+	req.BuiltinTools = []string{"code_interpreter"}
+	got := ApplyGrounding(req)
+	if got.Grounding || !slices.Equal(got.BuiltinTools, []string{"code_interpreter", BuiltinWebSearch}) {
+		t.Errorf("grounded request = %+v, want web search added", got)
+	}
+	if !req.Grounding || len(req.BuiltinTools) != 1 {
+		t.Error("input request was modified")
+	}
+
+	req.BuiltinTools = []string{BuiltinWebSearch}
+	if got := ApplyGrounding(req); len(got.BuiltinTools) != 1 {
+		t.Errorf("builtin tools = %v, want web search once", got.BuiltinTools)
+	}
+}
@@ -0,0 +1,147 @@
+package llmrouter
+
+import (
+	"context"
+	"strconv"
+)
+
+// Handlers are callbacks for StreamWithHandlers. Any of them may be nil.
+// With N > 1 they fire for every choice, and tool calls are assembled per
+// choice.
+type Handlers struct {
+	// OnContent receives each text chunk
+	OnContent func(content string)
+	// OnToolCallStart fires once a tool call's ID is known. Providers that
+	// send arguments before the ID have them delivered to OnToolCallArgDelta
+	// right after the start, so both callbacks always get a non-empty ID.
+	OnToolCallStart func(id, name string)
+	// OnToolCallArgDelta receives each fragment of a tool call's arguments
+	OnToolCallArgDelta func(id, delta string)
+	// OnToolCallComplete receives each tool call with its assembled arguments,
+	// once the stream has finished
+	OnToolCallComplete func(call ToolCall)
+	// OnDone receives the final response
+	OnDone func(resp *Response)
+	// OnError receives a stream error
+	OnError func(err error)
+}
+
+// StreamWithHandlers streams req and dispatches events to h, blocking until
+// the stream ends. The returned error is the stream error, if any.
+func (r *Router) StreamWithHandlers(ctx context.Context, req *Request, h Handlers) error {
+	ch, err := r.Stream(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	acc := newToolCallAccumulator()
+	started := make(map[string]bool) // choice index and call ID
+	var streamErr error
+
+	for event := range ch {
+		switch event.Type {
+		case EventContentDelta:
+			if h.OnContent != nil {
+				h.OnContent(event.Content)
+			}
+
+		case EventToolCallDelta:
+			if event.Delta == nil {
+				continue
+			}
+			for _, tc := range event.Delta.ToolCalls {
+				call := acc.add(event.Index, tc)
+				if call.ID == "" {
+					continue
+				}
+				delta := tc.Function.Arguments
+				if key := strconv.Itoa(event.Index) + "/" + call.ID; !started[key] {
+					started[key] = true
+					if h.OnToolCallStart != nil {
+						h.OnToolCallStart(call.ID, call.Function.Name)
+					}
+					// Includes any arguments that arrived before the ID
+					delta = call.Function.Arguments
+				}
+				if delta != "" && h.OnToolCallArgDelta != nil {
+					h.OnToolCallArgDelta(call.ID, delta)
+				}
+			}
+
+		case EventDone:
+			if h.OnToolCallComplete != nil {
+				for _, call := range acc.calls() {
+					h.OnToolCallComplete(call)
+				}
+			}
+			if h.OnDone != nil {
+				h.OnDone(event.Response)
+			}
+
+		case EventError:
+			streamErr = event.Error
+			if h.OnError != nil {
+				h.OnError(event.Error)
+			}
+		}
+	}
+
+	return streamErr
+}
+
+// toolCallAccumulator assembles streamed tool call fragments per choice.
+// Within a choice, fragments are matched by Index when present, otherwise by
+// ID.
+type toolCallAccumulator struct {
+	order []string
+	byKey map[string]*ToolCall
+	last  map[int]string // choice index -> key of its last call
+}
+
+func newToolCallAccumulator() *toolCallAccumulator {
+	return &toolCallAccumulator{
+		byKey: make(map[string]*ToolCall),
+		last:  make(map[int]string),
+	}
+}
+
+// add merges a fragment of the given choice, returning the call so far
+func (a *toolCallAccumulator) add(choice int, tc ToolCall) ToolCall {
+	var key string
+	switch {
+	case tc.Index != nil:
+		key = strconv.Itoa(choice) + "#" + strconv.Itoa(*tc.Index)
+	case tc.ID != "":
+		key = strconv.Itoa(choice) + "/" + tc.ID
+	default:
+		// Continuation fragment without ID or index belongs to the last call
+		key = a.last[choice]
+	}
+
+	call, ok := a.byKey[key]
+	if !ok {
+		call = &ToolCall{ID: tc.ID, Type: tc.Type, Index: tc.Index}
+		a.byKey[key] = call
+		a.order = append(a.order, key)
+	}
+	a.last[choice] = key
+
+	if call.ID == "" {
+		call.ID = tc.ID
+	}
+	if call.Function.Name == "" {
+		call.Function.Name = tc.Function.Name
+	}
+	call.Function.Arguments += tc.Function.Arguments
+
+	return *call
+}
+
+// calls returns the assembled tool calls in first-seen order
+func (a *toolCallAccumulator) calls() []ToolCall {
+	result := make([]ToolCall, len(a.order))
+	for i, key := range a.order {
+		result[i] = *a.byKey[key]
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestToolCallAccumulatorSeparatesChoices(t *testing.T) {
+	acc := newToolCallAccumulator()
+	fragment := func(id, name, args string) ToolCall {
+		return ToolCall{ID: id, Index: intPtr(0), Function: FuncCall{Name: name, Arguments: args}}
+	}
+
+	// Both choices call a tool at index 0
+	acc.add(0, fragment("call_a", "weather", `{"city":`))
+	acc.add(1, fragment("call_b", "time", `{"tz":`))
+	acc.add(0, fragment("", "", `"Oslo"}`))
+	acc.add(1, fragment("", "", `"UTC"}`))
+
+	calls := acc.calls()
+	if len(calls) != 2 {
+		t.Fatalf("got %d calls, want 2: %+v", len(calls), calls)
+	}
+	if calls[0].ID != "call_a" || calls[0].Function.Name != "weather" || calls[0].Function.Arguments != `{"city":"Oslo"}` {
+		t.Errorf("choice 0 call = %+v", calls[0])
+	}
+	if calls[1].ID != "call_b" || calls[1].Function.Name != "time" || calls[1].Function.Arguments != `{"tz":"UTC"}` {
+		t.Errorf("choice 1 call = %+v", calls[1])
+	}
+}
+
+func TestStreamWithHandlers(t *testing.T) {
+	final := textResponse("stub", "Let me check.")
+	stub := &stubProvider{models: []string{"m"}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return eventStream(
+			Event{Type: EventContentDelta, Content: "Let me "},
+			Event{Type: EventContentDelta, Content: "check."},
+			Event{Type: EventToolCallDelta, Delta: &Delta{ToolCalls: []ToolCall{{ID: "call_1", Index: intPtr(0), Function: FuncCall{Name: "weather", Arguments: `{"city":`}}}}},
+			Event{Type: EventToolCallDelta, Delta: &Delta{ToolCalls: []ToolCall{{Index: intPtr(0), Function: FuncCall{Arguments: `"Oslo"}`}}}}},
+			Event{Type: EventDone, Response: final},
+		), nil
+	}}
+	r := New(WithProvider("stub", stub))
+
+	var content string
+	var calls []string
+	var done *Response
+	h := Handlers{
+		OnContent:          func(c string) { content += c },
+		OnToolCallStart:    func(id, name string) { calls = append(calls, "start "+id+" "+name) },
+		OnToolCallArgDelta: func(id, delta string) { calls = append(calls, "delta "+id+" "+delta) },
+		OnToolCallComplete: func(call ToolCall) { calls = append(calls, "complete "+call.ID+" "+call.Function.Arguments) },
+		OnDone:             func(resp *Response) { done = resp },
+		OnError:            func(err error) { t.Errorf("OnError(%v)", err) },
+	}
+
+	req := userRequest("weather?")
+	req.Model = "m"
+	if err := r.StreamWithHandlers(context.Background(), req, h); err != nil {
+		t.Fatal(err)
+	}
+
+	if content != "Let me check." {
+		t.Errorf("content = %q", content)
+	}
+	want := []string{
+		"start call_1 weather",
+		`delta call_1 {"city":`,
+		`delta call_1 "Oslo"}`,
+		`complete call_1 {"city":"Oslo"}`,
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("tool call callbacks = %q, want %q", calls, want)
+	}
+	if done != final {
+		t.Error("OnDone did not receive the final response")
+	}
+}
+
+func TestStreamWithHandlersLateToolCallID(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return eventStream(
+			Event{Type: EventToolCallDelta, Delta: &Delta{ToolCalls: []ToolCall{{Index: intPtr(0), Function: FuncCall{Name: "weather", Arguments: `{"city":`}}}}},
+			Event{Type: EventToolCallDelta, Delta: &Delta{ToolCalls: []ToolCall{{ID: "call_1", Index: intPtr(0), Function: FuncCall{Arguments: `"Oslo"`}}}}},
+			Event{Type: EventToolCallDelta, Delta: &Delta{ToolCalls: []ToolCall{{Index: intPtr(0), Function: FuncCall{Arguments: `}`}}}}},
+			Event{Type: EventDone, Response: textResponse("stub", "")},
+		), nil
+	}}
+	r := New(WithProvider("stub", stub))
+
+	var calls []string
+	h := Handlers{
+		OnToolCallStart:    func(id, name string) { calls = append(calls, "start "+id+" "+name) },
+		OnToolCallArgDelta: func(id, delta string) { calls = append(calls, "delta "+id+" "+delta) },
+	}
+	req := userRequest("weather?")
+	req.Model = "m"
+	if err := r.StreamWithHandlers(context.Background(), req, h); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"start call_1 weather",
+		`delta call_1 {"city":"Oslo"`,
+		`delta call_1 }`,
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("tool call callbacks = %q, want the start held until the ID arrives: %q", calls, want)
+	}
+}
+
+func TestStreamWithHandlersError(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return eventStream(Event{Type: EventError, Error: ErrRateLimited}), nil
+	}}
+	r := New(WithProvider("stub", stub))
+
+	var got error
+	req := userRequest("hi")
+	req.Model = "m"
+	// Nil handlers are skipped
+	err := r.StreamWithHandlers(context.Background(), req, Handlers{OnError: func(err error) { got = err }})
+	if !errors.Is(err, ErrRateLimited) || !errors.Is(got, ErrRateLimited) {
+		t.Errorf("returned %v, OnError got %v; want ErrRateLimited", err, got)
+	}
+}
@@ -0,0 +1,53 @@
+package llmrouter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// hashedRequest is the canonical subset of a Request used for hashing.
+// Metadata is deliberately excluded since it carries per-call values
+// (trace IDs, tenant tags) that don't affect the completion.
+type hashedRequest struct {
+	Model       string      `json:"model"`
+	Messages    []Message   `json:"messages"`
+	Tools       []Tool      `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice `json:"tool_choice,omitempty"`
+	Temperature *float64    `json:"temperature,omitempty"`
+	MaxTokens   *int        `json:"max_tokens,omitempty"`
+	TopP        *float64    `json:"top_p,omitempty"`
+	N           *int        `json:"n,omitempty"`
+	Stop        []string    `json:"stop,omitempty"`
+	ServiceTier string      `json:"service_tier,omitempty"`
+	Prefill     string      `json:"prefill,omitempty"`
+	Grounding   bool        `json:"grounding,omitempty"`
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	BuiltinTools   []string        `json:"builtin_tools,omitempty"`
+}
+
+// Hash returns a stable SHA-256 hex fingerprint of the request's messages,
+// model, tools, sampling parameters and service tier. Equal requests always hash identically,
+// making it suitable as a cache or deduplication key.
+func (r *Request) Hash() string {
+	b, _ := json.Marshal(hashedRequest{
+		Model:       r.Model,
+		Messages:    r.Messages,
+		Tools:       r.Tools,
+		ToolChoice:  r.ToolChoice,
+		Temperature: r.Temperature,
+		MaxTokens:   r.MaxTokens,
+		TopP:        r.TopP,
+		N:           r.N,
+		Stop:        r.Stop,
+		ServiceTier: r.ServiceTier,
+		Prefill:     r.Prefill,
+		Grounding:   r.Grounding,
+
+		ResponseFormat: r.ResponseFormat,
+		BuiltinTools:   r.BuiltinTools,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,43 @@
+package llmrouter
+
+import "testing"
+
+func TestHash(t *testing.T) {
+	base := func() *Request {
+		req := userRequest("hello")
+		req.Model = "gpt-4o"
+		req.Temperature = floatPtr(0.2)
+		return req
+	}
+
+	if base().Hash() != base().Hash() {
+		t.Error("equal requests hash differently")
+	}
+	if len(base().Hash()) != 64 {
+		t.Errorf("hash %q is not SHA-256 hex", base().Hash())
+	}
+
+	changed := base()
+	changed.Temperature = floatPtr(0.3)
+	if changed.Hash() == base().Hash() {
+		t.Error("changing the temperature didn't change the hash")
+	}
+
+	other := base()
+	other.Messages[0].Content = "goodbye"
+	if other.Hash() == base().Hash() {
+		t.Error("changing a message didn't change the hash")
+	}
+
+	tiered := base()
+	tiered.ServiceTier = "flex"
+	if tiered.Hash() == base().Hash() {
+		t.Error("changing the service tier didn't change the hash")
+	}
+
+	tagged := base()
+	tagged.Metadata = map[string]any{MetadataTraceID: "abc"}
+	if tagged.Hash() != base().Hash() {
+		t.Error("metadata changed the hash")
+	}
+}
@@ -0,0 +1,118 @@
+package llmrouter
+
+import (
+	"context"
+	"sync"
+)
+
+// stubProvider is a Provider whose behavior is set per test. Calls are
+// recorded so tests can inspect the requests that reached it.
+type stubProvider struct {
+	name     string
+	model    string
+	models   []string
+	complete func(ctx context.Context, req *Request) (*Response, error)
+	stream   func(ctx context.Context, req *Request) (<-chan Event, error)
+
+	mu    sync.Mutex
+	calls []*Request
+	ctxs  []context.Context
+}
+
+func (p *stubProvider) Name() string {
+	if p.name == "" {
+		return "stub"
+	}
+	return p.name
+}
+
+func (p *stubProvider) Models() []string    { return p.models }
+func (p *stubProvider) SupportsTools() bool { return true }
+func (p *stubProvider) DefaultModel() string {
+	return p.model
+}
+
+func (p *stubProvider) record(ctx context.Context, req *Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, req)
+	p.ctxs = append(p.ctxs, ctx)
+}
+
+func (p *stubProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	p.record(ctx, req)
+	if p.complete == nil {
+		return textResponse(p.Name(), "ok"), nil
+	}
+	return p.complete(ctx, req)
+}
+
+func (p *stubProvider) Stream(ctx context.Context, req *Request) (<-chan Event, error) {
+	p.record(ctx, req)
+	if p.stream == nil {
+		resp := textResponse(p.Name(), "ok")
+		return eventStream(
+			Event{Type: EventContentDelta, Content: "ok"},
+			Event{Type: EventDone, Response: resp},
+		), nil
+	}
+	return p.stream(ctx, req)
+}
+
+// callCount returns how many calls reached the provider
+func (p *stubProvider) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.calls)
+}
+
+// lastCall returns the last request that reached the provider
+func (p *stubProvider) lastCall() *Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.calls) == 0 {
+		return nil
+	}
+	return p.calls[len(p.calls)-1]
+}
+
+// textResponse builds a single-choice response with the given content
+func textResponse(provider, content string) *Response {
+	return &Response{
+		Provider: provider,
+		Choices: []Choice{{
+			Message:      &Message{Role: RoleAssistant, Content: content},
+			FinishReason: "stop",
+		}},
+	}
+}
+
+// eventStream returns a closed channel holding events
+func eventStream(events ...Event) <-chan Event {
+	ch := make(chan Event, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	return ch
+}
+
+// collect reads ch until it closes
+func collect(ch <-chan Event) []Event {
+	var events []Event
+	for e := range ch {
+		events = append(events, e)
+	}
+	return events
+}
+
+// userRequest builds a request with a single user message
+func userRequest(content string) *Request {
+	return &Request{
+		Messages: []Message{{Role: RoleUser, Content: content}},
+	}
+}
+
+func intPtr(n int) *int { return &n }
+
+func floatPtr(f float64) *float64 { return &f }
@@ -0,0 +1,69 @@
+package llmrouter
+
+import "time"
+
+// Request.Metadata keys that providers forward to their APIs. Other keys stay
+// local to the router and its middleware.
+const (
+	// MetadataUserID identifies the end user: Anthropic metadata.user_id and
+	// OpenAI's user field
+	MetadataUserID = "user_id"
+	// MetadataTraceID is sent by OpenAI-compatible providers as X-Trace-Id
+	MetadataTraceID = "trace_id"
+	// MetadataConversationID is sent by OpenAI-compatible providers as
+	// X-Conversation-Id
+	MetadataConversationID = "conversation_id"
+)
+
+// Request.Metadata keys read by middleware to override their defaults for a
+// single request
+const (
+	// MetadataTimeout overrides TimeoutMiddleware and per-provider timeouts.
+	// The value is a time.Duration or a duration string such as "30s".
+	MetadataTimeout = "timeout"
+	// MetadataMaxRetries overrides RetryMiddleware: the number of retries
+	// after the first attempt, as an int
+	MetadataMaxRetries = "max_retries"
+)
+
+// Response.Metadata keys set by middleware
+const (
+	// MetadataCacheHit is true on responses served by CacheMiddleware. They
+	// are not charged to the router's budgets.
+	MetadataCacheHit = "cache_hit"
+)
+
+// MetadataString returns the string value of a request metadata key
+func (r *Request) MetadataString(key string) string {
+	s, _ := r.Metadata[key].(string)
+	return s
+}
+
+// MetadataDuration returns a duration-valued metadata key, accepting a
+// time.Duration or a duration string
+func (r *Request) MetadataDuration(key string) (time.Duration, bool) {
+	switch v := r.Metadata[key].(type) {
+	case time.Duration:
+		return v, true
+	case string:
+		d, err := time.ParseDuration(v)
+		return d, err == nil
+	}
+	return 0, false
+}
+
+// MetadataInt returns an integer metadata key, accepting any Go integer or a
+// float64 as produced by JSON decoding
+func (r *Request) MetadataInt(key string) (int, bool) {
+	switch v := r.Metadata[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case float64:
+		return int(v), v == float64(int(v))
+	}
+	return 0, false
+}
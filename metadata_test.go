@@ -0,0 +1,60 @@
+package llmrouter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetadataDuration(t *testing.T) {
+	tests := []struct {
+		value  any
+		want   time.Duration
+		wantOK bool
+	}{
+		{5 * time.Second, 5 * time.Second, true},
+		{"1m30s", 90 * time.Second, true},
+		{"soon", 0, false},
+		{30, 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		req := &Request{Metadata: map[string]any{MetadataTimeout: tt.value}}
+		if got, ok := req.MetadataDuration(MetadataTimeout); got != tt.want || ok != tt.wantOK {
+			t.Errorf("MetadataDuration(%v) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+	if _, ok := (&Request{}).MetadataDuration(MetadataTimeout); ok {
+		t.Error("MetadataDuration on nil metadata reported a value")
+	}
+}
+
+func TestMetadataInt(t *testing.T) {
+	tests := []struct {
+		value  any
+		want   int
+		wantOK bool
+	}{
+		{3, 3, true},
+		{int64(4), 4, true},
+		{int32(5), 5, true},
+		{float64(2), 2, true},
+		{2.5, 2, false},
+		{"3", 0, false},
+	}
+	for _, tt := range tests {
+		req := &Request{Metadata: map[string]any{MetadataMaxRetries: tt.value}}
+		if got, ok := req.MetadataInt(MetadataMaxRetries); got != tt.want || ok != tt.wantOK {
+			t.Errorf("MetadataInt(%v) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestMetadataString(t *testing.T) {
+	req := &Request{Metadata: map[string]any{MetadataUserID: "user-1", "count": 3}}
+	if got := req.MetadataString(MetadataUserID); got != "user-1" {
+		t.Errorf("MetadataString = %q, want user-1", got)
+	}
+	if got := req.MetadataString("count"); got != "" {
+		t.Errorf("MetadataString of a non-string = %q, want empty", got)
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffStrategy decides how long to wait before a retry. attempt is the
+// 1-based retry number and err the error that caused it.
+type BackoffStrategy interface {
+	NextDelay(attempt int, err error) time.Duration
+}
+
+// ExponentialBackoff doubles the delay on every retry, starting at Base and
+// capped at Max (if positive)
+type ExponentialBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// NextDelay implements BackoffStrategy
+func (b ExponentialBackoff) NextDelay(attempt int, err error) time.Duration {
+	// Compare before converting, as large attempts overflow a Duration
+	delay := float64(b.Base) * math.Pow(2, float64(attempt-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		return b.Max
+	}
+	if delay >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// ConstantBackoff waits the same delay before every retry
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// NextDelay implements BackoffStrategy
+func (b ConstantBackoff) NextDelay(attempt int, err error) time.Duration {
+	return b.Delay
+}
+
+// DecorrelatedJitter picks a random delay between Base and an upper bound
+// that triples with every retry, capped at Max. It is a stateless variant of
+// the decorrelated jitter algorithm, which spreads out retries from many
+// clients failing at once.
+type DecorrelatedJitter struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// NextDelay implements BackoffStrategy
+func (b DecorrelatedJitter) NextDelay(attempt int, err error) time.Duration {
+	bound := float64(b.Base) * math.Pow(3, float64(attempt))
+	if b.Max > 0 && bound > float64(b.Max) {
+		bound = float64(b.Max)
+	}
+	upper := time.Duration(math.MaxInt64)
+	if bound < math.MaxInt64 {
+		upper = time.Duration(bound)
+	}
+	if upper <= b.Base {
+		return upper
+	}
+	return b.Base + time.Duration(rand.Int63n(int64(upper-b.Base)))
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// delays returns the strategy's delays for retries 1 through n
+func delays(b BackoffStrategy, n int) []time.Duration {
+	out := make([]time.Duration, n)
+	for i := range out {
+		out[i] = b.NextDelay(i+1, llmrouter.ErrRateLimited)
+	}
+	return out
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Base: 100 * time.Millisecond, Max: time.Second}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	if got := delays(b, 6); !slices.Equal(got, want) {
+		t.Errorf("delays = %v, want %v", got, want)
+	}
+}
+
+func TestExponentialBackoffOverflow(t *testing.T) {
+	uncapped := ExponentialBackoff{Base: time.Second}
+	for _, attempt := range []int{40, 64, 100, 10000} {
+		if got := uncapped.NextDelay(attempt, nil); got != time.Duration(math.MaxInt64) {
+			t.Errorf("uncapped attempt %d = %v, want clamped to the largest duration", attempt, got)
+		}
+	}
+	if got := (ExponentialBackoff{Base: 1}).NextDelay(64, nil); got != time.Duration(math.MaxInt64) {
+		t.Errorf("2^63ns = %v, want clamped to the largest duration", got)
+	}
+
+	capped := ExponentialBackoff{Base: time.Second, Max: time.Minute}
+	if got := capped.NextDelay(10000, nil); got != time.Minute {
+		t.Errorf("capped attempt 10000 = %v, want the 1m cap", got)
+	}
+}
+
+func TestConstantBackoff(t *testing.T) {
+	want := []time.Duration{time.Second, time.Second, time.Second, time.Second}
+	if got := delays(ConstantBackoff{Delay: time.Second}, 4); !slices.Equal(got, want) {
+		t.Errorf("delays = %v, want %v", got, want)
+	}
+}
+
+func TestDecorrelatedJitter(t *testing.T) {
+	b := DecorrelatedJitter{Base: 100 * time.Millisecond, Max: 2 * time.Second}
+	for i := 0; i < 100; i++ {
+		for attempt := 1; attempt <= 6; attempt++ {
+			upper := min(b.Base*time.Duration(math.Pow(3, float64(attempt))), b.Max)
+			if got := b.NextDelay(attempt, nil); got < b.Base || got >= upper {
+				t.Fatalf("attempt %d = %v, want in [%v, %v)", attempt, got, b.Base, upper)
+			}
+		}
+	}
+
+	// Without a cap the bound keeps growing, clamped at the largest duration
+	uncapped := DecorrelatedJitter{Base: time.Second}
+	if got := uncapped.NextDelay(100, nil); got < time.Second {
+		t.Errorf("uncapped attempt 100 = %v, want at least the base", got)
+	}
+	if got := (DecorrelatedJitter{Base: time.Second, Max: time.Second}).NextDelay(3, nil); got != time.Second {
+		t.Errorf("cap at the base = %v, want the base", got)
+	}
+}
+
+func TestRetryUsesBackoffStrategy(t *testing.T) {
+	stub := &stubProvider{complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		return nil, llmrouter.ErrRateLimited
+	}}
+	clock := &fakeClock{}
+	p := NewRetryMiddleware(4, time.Hour).
+		WithBackoffStrategy(ConstantBackoff{Delay: 7 * time.Millisecond}).
+		WithClock(clock).
+		Wrap(stub)
+
+	_, err := p.Complete(context.Background(), userRequest("hi"))
+	if !errors.Is(err, llmrouter.ErrMaxRetriesExceed) {
+		t.Fatalf("error = %v, want ErrMaxRetriesExceed", err)
+	}
+	if want := []time.Duration{7 * time.Millisecond, 7 * time.Millisecond, 7 * time.Millisecond}; !slices.Equal(clock.delays, want) {
+		t.Errorf("waited %v, want %v", clock.delays, want)
+	}
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// CacheMiddleware caches non-streaming completions in memory, keyed by the
+// provider name and Request.Hash
+type CacheMiddleware struct {
+	ttl        time.Duration
+	maxEntries int
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List // front is most recently used
+}
+
+type cacheEntry struct {
+	key       string
+	resp      *llmrouter.Response
+	expiresAt time.Time
+}
+
+// NewCacheMiddleware creates a cache middleware whose entries expire after ttl.
+// The cache is unbounded unless WithMaxEntries is set.
+func NewCacheMiddleware(ttl time.Duration) *CacheMiddleware {
+	return &CacheMiddleware{
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// WithMaxEntries caps the cache size, evicting least-recently-used entries
+func (m *CacheMiddleware) WithMaxEntries(n int) *CacheMiddleware {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxEntries = n
+	m.evict()
+	return m
+}
+
+// Len returns the number of cached entries
+func (m *CacheMiddleware) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// Wrap wraps a provider with response caching
+func (m *CacheMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &cacheProvider{
+		Provider: next,
+		cache:    m,
+	}
+}
+
+func (m *CacheMiddleware) get(key string) (*llmrouter.Response, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		m.lru.Remove(el)
+		delete(m.entries, key)
+		return nil, false
+	}
+	m.lru.MoveToFront(el)
+	return entry.resp, true
+}
+
+func (m *CacheMiddleware) put(key string, resp *llmrouter.Response) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &cacheEntry{key: key, resp: resp, expiresAt: time.Now().Add(m.ttl)}
+	if el, ok := m.entries[key]; ok {
+		el.Value = entry
+		m.lru.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.lru.PushFront(entry)
+	m.evict()
+}
+
+// evict drops least-recently-used entries beyond the cap. Callers hold mu.
+func (m *CacheMiddleware) evict() {
+	if m.maxEntries <= 0 {
+		return
+	}
+	for m.lru.Len() > m.maxEntries {
+		el := m.lru.Back()
+		m.lru.Remove(el)
+		delete(m.entries, el.Value.(*cacheEntry).key)
+	}
+}
+
+type cacheProvider struct {
+	llmrouter.Provider
+	cache *CacheMiddleware
+}
+
+// Complete serves req from the cache when possible. Callers get their own
+// copy of a cached response, marked with MetadataCacheHit, so modifying it
+// doesn't corrupt the cache.
+func (p *cacheProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	// Requests that leave the model to the provider look alike across
+	// providers, e.g. fallbacks, so the key includes the provider
+	key := p.Provider.Name() + "/" + req.Hash()
+	if resp, ok := p.cache.get(key); ok {
+		hit := cloneResponse(resp)
+		if hit.Metadata == nil {
+			hit.Metadata = make(map[string]any, 1)
+		}
+		hit.Metadata[llmrouter.MetadataCacheHit] = true
+		return hit, nil
+	}
+
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	p.cache.put(key, cloneResponse(resp))
+	return resp, nil
+}
+
+// cloneResponse deep-copies resp's slices, maps and pointers
+func cloneResponse(resp *llmrouter.Response) *llmrouter.Response {
+	c := *resp
+	if resp.Usage != nil {
+		usage := *resp.Usage
+		c.Usage = &usage
+	}
+	c.Citations = slices.Clone(resp.Citations)
+	c.BuiltinToolCalls = slices.Clone(resp.BuiltinToolCalls)
+	c.Metadata = maps.Clone(resp.Metadata)
+	if resp.Choices != nil {
+		c.Choices = make([]llmrouter.Choice, len(resp.Choices))
+		for i, choice := range resp.Choices {
+			if choice.Message != nil {
+				msg := *choice.Message
+				msg.ToolCalls = slices.Clone(msg.ToolCalls)
+				msg.ContentParts = slices.Clone(msg.ContentParts)
+				msg.ContentBlocks = slices.Clone(msg.ContentBlocks)
+				for j, block := range msg.ContentBlocks {
+					if block.ToolCall != nil {
+						tc := *block.ToolCall
+						msg.ContentBlocks[j].ToolCall = &tc
+					}
+				}
+				choice.Message = &msg
+			}
+			if choice.Delta != nil {
+				delta := *choice.Delta
+				delta.ToolCalls = slices.Clone(delta.ToolCalls)
+				choice.Delta = &delta
+			}
+			if choice.FinishDetails != nil {
+				details := *choice.FinishDetails
+				choice.FinishDetails = &details
+			}
+			c.Choices[i] = choice
+		}
+	}
+	return &c
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// countingProvider answers each request with its content and how many calls
+// the provider has seen
+func countingProvider(name string) *stubProvider {
+	stub := &stubProvider{name: name}
+	stub.complete = func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		resp := textResponse(req.Messages[0].Content)
+		resp.Provider = name
+		return resp, nil
+	}
+	return stub
+}
+
+func TestCacheServesRepeatedRequests(t *testing.T) {
+	stub := countingProvider("stub")
+	p := NewCacheMiddleware(time.Hour).Wrap(stub)
+
+	first, err := p.Complete(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := p.Complete(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stub.callCount() != 1 {
+		t.Errorf("provider called %d times, want 1", stub.callCount())
+	}
+	if first.Metadata[llmrouter.MetadataCacheHit] != nil {
+		t.Error("fresh response marked as a cache hit")
+	}
+	if second.Metadata[llmrouter.MetadataCacheHit] != true || second.Text() != "hi" {
+		t.Errorf("cached response = %+v, want a marked hit", second)
+	}
+}
+
+func TestCacheReturnsCopies(t *testing.T) {
+	stub := countingProvider("stub")
+	p := NewCacheMiddleware(time.Hour).Wrap(stub)
+
+	first, _ := p.Complete(context.Background(), userRequest("hi"))
+	first.Choices[0].Message.Content = "modified by caller"
+
+	second, _ := p.Complete(context.Background(), userRequest("hi"))
+	second.Choices[0].Message.Content = "modified again"
+	second.Metadata["extra"] = true
+
+	third, _ := p.Complete(context.Background(), userRequest("hi"))
+	if third.Text() != "hi" || third.Metadata["extra"] != nil {
+		t.Errorf("cached response was corrupted: %+v", third)
+	}
+}
+
+func TestCacheKeyIncludesProvider(t *testing.T) {
+	m := NewCacheMiddleware(time.Hour)
+	a := countingProvider("a")
+	b := countingProvider("b")
+
+	if _, err := m.Wrap(a).Complete(context.Background(), userRequest("hi")); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := m.Wrap(b).Complete(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != "b" || b.callCount() != 1 {
+		t.Error("a request to another provider was served from the first provider's cache")
+	}
+}
+
+func TestCacheLRUEviction(t *testing.T) {
+	stub := countingProvider("stub")
+	m := NewCacheMiddleware(time.Hour).WithMaxEntries(2)
+	p := m.Wrap(stub)
+	complete := func(content string) {
+		if _, err := p.Complete(context.Background(), userRequest(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	complete("a")
+	complete("b")
+	complete("a") // a is now the most recently used
+	complete("c") // evicts b
+	if m.Len() != 2 {
+		t.Errorf("Len = %d, want 2", m.Len())
+	}
+
+	calls := stub.callCount()
+	complete("a")
+	if stub.callCount() != calls {
+		t.Error("recently used entry was evicted")
+	}
+	complete("b")
+	if stub.callCount() != calls+1 {
+		t.Error("least recently used entry was kept")
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	stub := countingProvider("stub")
+	p := NewCacheMiddleware(time.Nanosecond).Wrap(stub)
+
+	_, _ = p.Complete(context.Background(), userRequest("hi"))
+	time.Sleep(time.Millisecond)
+	_, _ = p.Complete(context.Background(), userRequest("hi"))
+	if stub.callCount() != 2 {
+		t.Errorf("provider called %d times, want expired entries refetched", stub.callCount())
+	}
+}
+
+func TestCacheHitsFreeUnderBudget(t *testing.T) {
+	stub := &stubProvider{complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		resp := textResponse("ok")
+		resp.Usage = &llmrouter.Usage{TotalTokens: 60}
+		return resp, nil
+	}}
+	r := llmrouter.New(
+		llmrouter.WithProvider("stub", stub),
+		llmrouter.WithTokenBudget(100),
+		llmrouter.WithMiddleware(NewCacheMiddleware(time.Hour)),
+	)
+
+	for i := 0; i < 3; i++ {
+		req := userRequest("hi")
+		req.Model = "stub"
+		if _, err := r.Complete(context.Background(), req); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if stub.callCount() != 1 || r.RemainingBudget() != 40 {
+		t.Errorf("calls = %d, remaining = %d; want one charged call and the hits free", stub.callCount(), r.RemainingBudget())
+	}
+}
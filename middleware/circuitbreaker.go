@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	llmrouter "github.com/bluefunda/llm-router"
@@ -10,12 +11,12 @@ import (
 
 // CircuitBreakerMiddleware provides circuit breaker protection
 type CircuitBreakerMiddleware struct {
-	cb *gobreaker.CircuitBreaker
+	cb *gobreaker.TwoStepCircuitBreaker
 }
 
 // NewCircuitBreakerMiddleware creates a new circuit breaker middleware
 func NewCircuitBreakerMiddleware(name string, maxFailures uint32, timeout time.Duration) *CircuitBreakerMiddleware {
-	cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+	cb := gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
 		Name:        name,
 		MaxRequests: maxFailures,
 		Interval:    60 * time.Second,
@@ -46,35 +47,82 @@ func (m *CircuitBreakerMiddleware) State() gobreaker.State {
 
 type circuitBreakerProvider struct {
 	llmrouter.Provider
-	cb *gobreaker.CircuitBreaker
+	cb *gobreaker.TwoStepCircuitBreaker
 }
 
 func (p *circuitBreakerProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
-	result, err := p.cb.Execute(func() (interface{}, error) {
-		return p.Provider.Complete(ctx, req)
-	})
+	done, err := p.cb.Allow()
+	if err != nil {
+		return nil, llmrouter.ErrCircuitOpen
+	}
 
+	resp, err := p.Provider.Complete(ctx, req)
+	done(!isBreakerFailure(err))
 	if err != nil {
-		if err == gobreaker.ErrOpenState || err == gobreaker.ErrTooManyRequests {
-			return nil, llmrouter.ErrCircuitOpen
-		}
 		return nil, err
 	}
 
-	return result.(*llmrouter.Response), nil
+	return resp, nil
 }
 
+// Stream reports the outcome to the breaker once the stream ends, so errors
+// that arrive mid-stream count as failures, not just failures to connect. A
+// stream abandoned through ctx is reported as soon as ctx is done.
 func (p *circuitBreakerProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
-	result, err := p.cb.Execute(func() (interface{}, error) {
-		return p.Provider.Stream(ctx, req)
-	})
+	done, err := p.cb.Allow()
+	if err != nil {
+		return nil, llmrouter.ErrCircuitOpen
+	}
 
+	ch, err := p.Provider.Stream(ctx, req)
 	if err != nil {
-		if err == gobreaker.ErrOpenState || err == gobreaker.ErrTooManyRequests {
-			return nil, llmrouter.ErrCircuitOpen
-		}
+		done(!isBreakerFailure(err))
 		return nil, err
 	}
 
-	return result.(<-chan llmrouter.Event), nil
+	outCh := make(chan llmrouter.Event)
+	go func() {
+		defer close(outCh)
+
+		success := true
+		abandon := func() {
+			done(success)
+			go func() {
+				for range ch {
+				}
+			}()
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				abandon()
+				return
+			case event, ok := <-ch:
+				if !ok {
+					done(success)
+					return
+				}
+				if event.Type == llmrouter.EventError && isBreakerFailure(event.Error) {
+					success = false
+				}
+				select {
+				case outCh <- event:
+				case <-ctx.Done():
+					abandon()
+					return
+				}
+			}
+		}
+	}()
+
+	return outCh, nil
+}
+
+// isBreakerFailure reports whether err should count against the breaker.
+// Cancellation by the caller says nothing about the provider's health.
+func isBreakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, llmrouter.ErrContextCanceled)
 }
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/sony/gobreaker"
+)
+
+// failingStream returns a stream that ends with err after one delta
+func failingStream(err error) func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	return func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+		return eventStream(
+			llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "partial"},
+			llmrouter.Event{Type: llmrouter.EventError, Error: err},
+		), nil
+	}
+}
+
+func TestCircuitBreakerOpensOnStreamErrors(t *testing.T) {
+	m := NewCircuitBreakerMiddleware("test", 2, time.Hour)
+	stub := &stubProvider{stream: failingStream(llmrouter.ErrProviderError)}
+	p := m.Wrap(stub)
+
+	for i := 0; i < 3; i++ {
+		ch, err := p.Stream(context.Background(), userRequest("hi"))
+		if err != nil {
+			t.Fatalf("stream %d: %v", i, err)
+		}
+		collect(ch)
+	}
+
+	if m.State() != gobreaker.StateOpen {
+		t.Fatalf("state = %v after repeated stream errors, want open", m.State())
+	}
+	if _, err := p.Stream(context.Background(), userRequest("hi")); !errors.Is(err, llmrouter.ErrCircuitOpen) {
+		t.Errorf("error = %v, want ErrCircuitOpen", err)
+	}
+	if stub.callCount() != 3 {
+		t.Errorf("provider called %d times, want none once open", stub.callCount())
+	}
+}
+
+func TestCircuitBreakerStaysClosedOnSuccessfulStreams(t *testing.T) {
+	m := NewCircuitBreakerMiddleware("test", 2, time.Hour)
+	p := m.Wrap(&stubProvider{})
+
+	for i := 0; i < 5; i++ {
+		ch, err := p.Stream(context.Background(), userRequest("hi"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		collect(ch)
+	}
+	if m.State() != gobreaker.StateClosed {
+		t.Errorf("state = %v, want closed", m.State())
+	}
+}
+
+func TestCircuitBreakerIgnoresCancellation(t *testing.T) {
+	m := NewCircuitBreakerMiddleware("test", 2, time.Hour)
+	canceled := fmt.Errorf("%w: %w", llmrouter.ErrContextCanceled, context.Canceled)
+	p := m.Wrap(&stubProvider{
+		stream: failingStream(canceled),
+		complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+			return nil, context.Canceled
+		},
+	})
+
+	for i := 0; i < 5; i++ {
+		ch, err := p.Stream(context.Background(), userRequest("hi"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		collect(ch)
+		_, _ = p.Complete(context.Background(), userRequest("hi"))
+	}
+
+	// A stream abandoned through its context isn't a failure either
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		blocked := make(chan llmrouter.Event)
+		wrapped := m.Wrap(&stubProvider{stream: func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+			return blocked, nil
+		}})
+		ch, err := wrapped.Stream(ctx, userRequest("hi"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		collect(ch)
+		close(blocked)
+	}
+
+	if m.State() != gobreaker.StateClosed {
+		t.Errorf("state = %v after canceled requests, want closed", m.State())
+	}
+}
+
+func TestCircuitBreakerOpensOnCompleteErrors(t *testing.T) {
+	m := NewCircuitBreakerMiddleware("test", 1, time.Hour)
+	p := m.Wrap(&stubProvider{complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		return nil, llmrouter.ErrProviderError
+	}})
+
+	for i := 0; i < 2; i++ {
+		_, _ = p.Complete(context.Background(), userRequest("hi"))
+	}
+	if _, err := p.Complete(context.Background(), userRequest("hi")); !errors.Is(err, llmrouter.ErrCircuitOpen) {
+		t.Errorf("error = %v, want ErrCircuitOpen", err)
+	}
+}
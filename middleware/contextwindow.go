@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// ContextWindowMiddleware rejects requests that can't fit the model's context
+// window before they are sent
+type ContextWindowMiddleware struct {
+	windows map[string]int
+	counter llmrouter.TokenCounter
+}
+
+// NewContextWindowMiddleware creates a middleware that estimates the prompt
+// tokens of each request and fails with ErrContextLengthExceeded if the
+// prompt plus MaxTokens exceeds windows[req.Model]. Models without an entry
+// are not checked. The estimate is approximate, so leave some headroom.
+func NewContextWindowMiddleware(windows map[string]int) *ContextWindowMiddleware {
+	return &ContextWindowMiddleware{
+		windows: windows,
+		counter: llmrouter.EstimateTokens,
+	}
+}
+
+// WithCounter sets the token counter used for estimates
+func (m *ContextWindowMiddleware) WithCounter(c llmrouter.TokenCounter) *ContextWindowMiddleware {
+	m.counter = c
+	return m
+}
+
+// Wrap wraps a provider with the context window check
+func (m *ContextWindowMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &contextWindowProvider{
+		Provider: next,
+		windows:  m.windows,
+		counter:  m.counter,
+	}
+}
+
+type contextWindowProvider struct {
+	llmrouter.Provider
+	windows map[string]int
+	counter llmrouter.TokenCounter
+}
+
+func (p *contextWindowProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	if err := p.check(req); err != nil {
+		return nil, err
+	}
+	return p.Provider.Complete(ctx, req)
+}
+
+func (p *contextWindowProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	if err := p.check(req); err != nil {
+		return nil, err
+	}
+	return p.Provider.Stream(ctx, req)
+}
+
+func (p *contextWindowProvider) check(req *llmrouter.Request) error {
+	window, ok := p.windows[req.Model]
+	if !ok {
+		return nil
+	}
+
+	needed := llmrouter.EstimateRequestTokens(req, p.counter)
+	if req.MaxTokens != nil {
+		needed += *req.MaxTokens
+	}
+	if needed > window {
+		return fmt.Errorf("%w: %s needs about %d tokens, window is %d", llmrouter.ErrContextLengthExceeded, req.Model, needed, window)
+	}
+	return nil
+}
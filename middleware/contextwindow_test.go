@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestContextWindow(t *testing.T) {
+	windows := map[string]int{"small": 100, "large": 100000}
+	long := strings.Repeat("word ", 200) // about 250 estimated tokens
+
+	tests := []struct {
+		name      string
+		model     string
+		prompt    string
+		maxTokens *int
+		rejected  bool
+	}{
+		{"long prompt, small window", "small", long, nil, true},
+		{"long prompt, large window", "large", long, nil, false},
+		{"short prompt fits", "small", "hello", nil, false},
+		{"max tokens pushes over", "small", "hello", intPtr(99), true},
+		{"max tokens fits", "small", "hello", intPtr(50), false},
+		{"unknown model unchecked", "other", long, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProvider{}
+			p := NewContextWindowMiddleware(windows).Wrap(stub)
+
+			req := userRequest(tt.prompt)
+			req.Model = tt.model
+			req.MaxTokens = tt.maxTokens
+
+			_, err := p.Complete(context.Background(), req)
+			_, streamErr := p.Stream(context.Background(), req)
+			if tt.rejected {
+				if !errors.Is(err, llmrouter.ErrContextLengthExceeded) || !errors.Is(streamErr, llmrouter.ErrContextLengthExceeded) {
+					t.Errorf("errors = %v, %v; want ErrContextLengthExceeded", err, streamErr)
+				}
+				if stub.callCount() != 0 {
+					t.Errorf("%d calls reached the provider, want none", stub.callCount())
+				}
+				return
+			}
+			if err != nil || streamErr != nil {
+				t.Errorf("errors = %v, %v; want none", err, streamErr)
+			}
+			if stub.callCount() != 2 {
+				t.Errorf("%d calls reached the provider, want 2", stub.callCount())
+			}
+		})
+	}
+}
+
+func TestContextWindowCounter(t *testing.T) {
+	stub := &stubProvider{}
+	// One token per character
+	p := NewContextWindowMiddleware(map[string]int{"small": 10}).
+		WithCounter(func(s string) int { return len(s) }).
+		Wrap(stub)
+
+	req := userRequest("twelve chars")
+	req.Model = "small"
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrContextLengthExceeded) {
+		t.Errorf("err = %v, want ErrContextLengthExceeded from the custom counter", err)
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// ForceToolMiddleware makes the model call a tool whenever the request offers
+// tools but leaves the choice open
+type ForceToolMiddleware struct {
+	function string
+}
+
+// NewForceToolMiddleware creates a middleware that sets ToolChoice to
+// "required" on requests that have tools and no ToolChoice. Requests with an
+// explicit ToolChoice, including "auto" and "none", are left alone.
+func NewForceToolMiddleware() *ForceToolMiddleware {
+	return &ForceToolMiddleware{}
+}
+
+// WithFunction forces the named function instead of any tool. Requests that
+// don't offer a tool with that name still get "required".
+func (m *ForceToolMiddleware) WithFunction(name string) *ForceToolMiddleware {
+	m.function = name
+	return m
+}
+
+// Wrap wraps a provider with forced tool choice
+func (m *ForceToolMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &forceToolProvider{
+		Provider: next,
+		function: m.function,
+	}
+}
+
+type forceToolProvider struct {
+	llmrouter.Provider
+	function string
+}
+
+func (p *forceToolProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	return p.Provider.Complete(ctx, p.force(req))
+}
+
+func (p *forceToolProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	return p.Provider.Stream(ctx, p.force(req))
+}
+
+// force returns a copy of req with the tool choice set, or req itself if it
+// has no tools or already chooses
+func (p *forceToolProvider) force(req *llmrouter.Request) *llmrouter.Request {
+	if len(req.Tools) == 0 || req.ToolChoice != nil {
+		return req
+	}
+
+	choice := &llmrouter.ToolChoice{Type: "required"}
+	for _, t := range req.Tools {
+		if p.function != "" && t.Function.Name == p.function {
+			choice = &llmrouter.ToolChoice{
+				Type:     "function",
+				Function: &llmrouter.FuncRef{Name: p.function},
+			}
+			break
+		}
+	}
+
+	forced := *req
+	forced.ToolChoice = choice
+	return &forced
+}
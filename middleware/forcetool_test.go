@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestForceTool(t *testing.T) {
+	weather := llmrouter.Tool{Type: "function", Function: llmrouter.Function{Name: "weather"}}
+	search := llmrouter.Tool{Type: "function", Function: llmrouter.Function{Name: "search"}}
+	required := &llmrouter.ToolChoice{Type: "required"}
+	auto := &llmrouter.ToolChoice{Type: "auto"}
+
+	tests := []struct {
+		name     string
+		function string
+		tools    []llmrouter.Tool
+		choice   *llmrouter.ToolChoice
+		want     *llmrouter.ToolChoice
+	}{
+		{"tools without choice", "", []llmrouter.Tool{weather}, nil, required},
+		{"no tools", "", nil, nil, nil},
+		{"explicit choice kept", "", []llmrouter.Tool{weather}, auto, auto},
+		{"function forced", "search", []llmrouter.Tool{weather, search}, nil, &llmrouter.ToolChoice{Type: "function", Function: &llmrouter.FuncRef{Name: "search"}}},
+		{"function not offered", "search", []llmrouter.Tool{weather}, nil, required},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProvider{}
+			m := NewForceToolMiddleware()
+			if tt.function != "" {
+				m.WithFunction(tt.function)
+			}
+			p := m.Wrap(stub)
+
+			req := userRequest("weather in Paris?")
+			req.Tools = tt.tools
+			req.ToolChoice = tt.choice
+			if _, err := p.Complete(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+			ch, err := p.Stream(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			collect(ch)
+
+			for i, call := range stub.calls {
+				if !reflect.DeepEqual(call.ToolChoice, tt.want) {
+					t.Errorf("call %d tool choice = %+v, want %+v", i, call.ToolChoice, tt.want)
+				}
+			}
+			if req.ToolChoice != tt.choice {
+				t.Errorf("caller's request was modified: %+v", req.ToolChoice)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// HeartbeatMiddleware emits an EventHeartbeat whenever a stream has been
+// quiet for the configured interval, so connections relaying the stream
+// (e.g. SSE through proxies) stay alive. Heartbeats are only sent between
+// events, never reorder them, and stop once the stream ends.
+type HeartbeatMiddleware struct {
+	interval time.Duration
+}
+
+// NewHeartbeatMiddleware creates a new heartbeat middleware
+func NewHeartbeatMiddleware(interval time.Duration) *HeartbeatMiddleware {
+	return &HeartbeatMiddleware{interval: interval}
+}
+
+// Wrap wraps a provider with stream heartbeats
+func (m *HeartbeatMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &heartbeatProvider{
+		Provider: next,
+		interval: m.interval,
+	}
+}
+
+type heartbeatProvider struct {
+	llmrouter.Provider
+	interval time.Duration
+}
+
+func (p *heartbeatProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	ch, err := p.Provider.Stream(ctx, req)
+	if err != nil || p.interval <= 0 {
+		return ch, err
+	}
+
+	outCh := make(chan llmrouter.Event)
+	go func() {
+		defer close(outCh)
+
+		timer := time.NewTimer(p.interval)
+		defer timer.Stop()
+
+		for {
+			select {
+			case event, ok := <-ch:
+				if !ok {
+					return
+				}
+				select {
+				case outCh <- event:
+				case <-ctx.Done():
+					return
+				}
+				if event.Type == llmrouter.EventDone || event.Type == llmrouter.EventError {
+					// Drain without heartbeats in case the provider sends more
+					for event := range ch {
+						select {
+						case outCh <- event:
+						case <-ctx.Done():
+							return
+						}
+					}
+					return
+				}
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(p.interval)
+			case <-timer.C:
+				select {
+				case outCh <- llmrouter.Event{Type: llmrouter.EventHeartbeat}:
+				case <-ctx.Done():
+					return
+				}
+				timer.Reset(p.interval)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return outCh, nil
+}
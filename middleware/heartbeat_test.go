@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// quietStream sends "a", stays quiet for pause, then sends "b" and done.
+// After done it waits for pause again before closing.
+func quietStream(pause time.Duration) func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	return func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+		ch := make(chan llmrouter.Event)
+		go func() {
+			defer close(ch)
+			ch <- llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "a"}
+			time.Sleep(pause)
+			ch <- llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "b"}
+			ch <- llmrouter.Event{Type: llmrouter.EventDone, Response: textResponse("ab")}
+			time.Sleep(pause)
+		}()
+		return ch, nil
+	}
+}
+
+func TestHeartbeatDuringQuietPeriod(t *testing.T) {
+	p := NewHeartbeatMiddleware(5 * time.Millisecond).Wrap(&stubProvider{stream: quietStream(60 * time.Millisecond)})
+
+	ch, err := p.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+
+	var kinds []llmrouter.EventType
+	heartbeats := 0
+	for _, e := range events {
+		if e.Type == llmrouter.EventHeartbeat {
+			heartbeats++
+			if len(kinds) == 0 || kinds[len(kinds)-1] != llmrouter.EventHeartbeat {
+				kinds = append(kinds, e.Type)
+			}
+			continue
+		}
+		kinds = append(kinds, e.Type)
+	}
+	want := []llmrouter.EventType{llmrouter.EventContentDelta, llmrouter.EventHeartbeat, llmrouter.EventContentDelta, llmrouter.EventDone}
+	if len(kinds) != len(want) {
+		t.Fatalf("events = %v, want content, heartbeats, content, done", kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("events = %v, want content, heartbeats, content, done", kinds)
+		}
+	}
+	if heartbeats < 2 {
+		t.Errorf("got %d heartbeats in the quiet period, want several", heartbeats)
+	}
+	if events[0].Content != "a" || events[len(events)-2].Content != "b" {
+		t.Error("content reordered")
+	}
+}
+
+func TestHeartbeatNotSentWhenBusy(t *testing.T) {
+	p := NewHeartbeatMiddleware(time.Hour).Wrap(&stubProvider{stream: quietStream(time.Millisecond)})
+
+	ch, err := p.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range collect(ch) {
+		if e.Type == llmrouter.EventHeartbeat {
+			t.Error("heartbeat sent before the interval elapsed")
+		}
+	}
+}
+
+func TestHeartbeatStopsOnCancel(t *testing.T) {
+	stream := func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+		return make(chan llmrouter.Event), nil
+	}
+	p := NewHeartbeatMiddleware(time.Millisecond).Wrap(&stubProvider{stream: stream})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.Stream(ctx, userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := <-ch; e.Type != llmrouter.EventHeartbeat {
+		t.Fatalf("first event = %v, want a heartbeat", e.Type)
+	}
+	cancel()
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("stream not closed after cancel")
+		}
+	}
+}
+
+func TestHeartbeatDisabled(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewHeartbeatMiddleware(0).Wrap(stub)
+
+	ch, err := p.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events := collect(ch); len(events) != 2 {
+		t.Errorf("got %d events, want the stream passed through", len(events))
+	}
+}
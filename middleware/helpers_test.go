@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// stubProvider is a Provider whose behavior is set per test. Calls are
+// recorded so tests can inspect the requests that reached it.
+type stubProvider struct {
+	name     string
+	complete func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error)
+	stream   func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error)
+
+	mu    sync.Mutex
+	calls []*llmrouter.Request
+	ctxs  []context.Context
+}
+
+func (p *stubProvider) Name() string {
+	if p.name == "" {
+		return "stub"
+	}
+	return p.name
+}
+
+func (p *stubProvider) Models() []string    { return nil }
+func (p *stubProvider) SupportsTools() bool { return true }
+
+func (p *stubProvider) record(ctx context.Context, req *llmrouter.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, req)
+	p.ctxs = append(p.ctxs, ctx)
+}
+
+func (p *stubProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	p.record(ctx, req)
+	if p.complete == nil {
+		return textResponse("ok"), nil
+	}
+	return p.complete(ctx, req)
+}
+
+func (p *stubProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	p.record(ctx, req)
+	if p.stream == nil {
+		return eventStream(
+			llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "ok"},
+			llmrouter.Event{Type: llmrouter.EventDone, Response: textResponse("ok")},
+		), nil
+	}
+	return p.stream(ctx, req)
+}
+
+// callCount returns how many calls reached the provider
+func (p *stubProvider) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.calls)
+}
+
+// lastCall returns the last request that reached the provider
+func (p *stubProvider) lastCall() *llmrouter.Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.calls) == 0 {
+		return nil
+	}
+	return p.calls[len(p.calls)-1]
+}
+
+// fakeClock fires immediately, recording the delays it was asked to wait
+type fakeClock struct {
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.delays = append(c.delays, d)
+	c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+// textResponse builds a single-choice response with the given content
+func textResponse(content string) *llmrouter.Response {
+	return &llmrouter.Response{
+		Provider: "stub",
+		Choices: []llmrouter.Choice{{
+			Message:      &llmrouter.Message{Role: llmrouter.RoleAssistant, Content: content},
+			FinishReason: "stop",
+		}},
+	}
+}
+
+// eventStream returns a closed channel holding events
+func eventStream(events ...llmrouter.Event) <-chan llmrouter.Event {
+	ch := make(chan llmrouter.Event, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	return ch
+}
+
+// collect reads ch until it closes
+func collect(ch <-chan llmrouter.Event) []llmrouter.Event {
+	var events []llmrouter.Event
+	for e := range ch {
+		events = append(events, e)
+	}
+	return events
+}
+
+// userRequest builds a request with a single user message
+func userRequest(content string) *llmrouter.Request {
+	return &llmrouter.Request{
+		Messages: []llmrouter.Message{{Role: llmrouter.RoleUser, Content: content}},
+	}
+}
+
+func intPtr(n int) *int { return &n }
+
+func floatPtr(f float64) *float64 { return &f }
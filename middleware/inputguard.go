@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// InputGuardMiddleware rejects requests with too many messages or too much content
+type InputGuardMiddleware struct {
+	maxMessages int
+	maxBytes    int
+}
+
+// NewInputGuardMiddleware creates a new input guard middleware.
+// A limit of zero or less disables that check.
+func NewInputGuardMiddleware(maxMessages int, maxBytes int) *InputGuardMiddleware {
+	return &InputGuardMiddleware{
+		maxMessages: maxMessages,
+		maxBytes:    maxBytes,
+	}
+}
+
+// Wrap wraps a provider with input limits
+func (m *InputGuardMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &inputGuardProvider{
+		Provider:    next,
+		maxMessages: m.maxMessages,
+		maxBytes:    m.maxBytes,
+	}
+}
+
+type inputGuardProvider struct {
+	llmrouter.Provider
+	maxMessages int
+	maxBytes    int
+}
+
+func (p *inputGuardProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	if err := p.check(req); err != nil {
+		return nil, err
+	}
+	return p.Provider.Complete(ctx, req)
+}
+
+func (p *inputGuardProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	if err := p.check(req); err != nil {
+		return nil, err
+	}
+	return p.Provider.Stream(ctx, req)
+}
+
+func (p *inputGuardProvider) check(req *llmrouter.Request) error {
+	if p.maxMessages > 0 && len(req.Messages) > p.maxMessages {
+		return fmt.Errorf("%w: %d messages exceeds limit of %d", llmrouter.ErrInvalidRequest, len(req.Messages), p.maxMessages)
+	}
+
+	if p.maxBytes > 0 {
+		size := 0
+		for _, msg := range req.Messages {
+			size += len(msg.Content)
+			for _, part := range msg.ContentParts {
+				size += len(part.Text)
+				if part.ImageURL != nil {
+					size += len(part.ImageURL.URL) + len(part.ImageURL.Base64)
+				}
+				if part.Document != nil {
+					size += len(part.Document.Base64)
+				}
+			}
+		}
+		if size > p.maxBytes {
+			return fmt.Errorf("%w: %d content bytes exceeds limit of %d", llmrouter.ErrInvalidRequest, size, p.maxBytes)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestInputGuard(t *testing.T) {
+	many := &llmrouter.Request{}
+	for i := 0; i < 4; i++ {
+		many.Messages = append(many.Messages, llmrouter.Message{Role: llmrouter.RoleUser, Content: "hi"})
+	}
+	largeImage := &llmrouter.Request{Messages: []llmrouter.Message{{
+		Role: llmrouter.RoleUser,
+		ContentParts: []llmrouter.ContentPart{
+			{Type: "image_url", ImageURL: &llmrouter.ImageURL{Base64: strings.Repeat("A", 200)}},
+		},
+	}}}
+
+	tests := []struct {
+		name    string
+		req     *llmrouter.Request
+		wantErr bool
+	}{
+		{"within limits", userRequest("hello"), false},
+		{"too many messages", many, true},
+		{"content too large", userRequest(strings.Repeat("x", 101)), true},
+		{"content parts count toward size", largeImage, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProvider{}
+			p := NewInputGuardMiddleware(3, 100).Wrap(stub)
+
+			_, err := p.Complete(context.Background(), tt.req)
+			_, streamErr := p.Stream(context.Background(), tt.req)
+
+			if tt.wantErr {
+				if !errors.Is(err, llmrouter.ErrInvalidRequest) || !errors.Is(streamErr, llmrouter.ErrInvalidRequest) {
+					t.Errorf("errors = %v, %v; want ErrInvalidRequest", err, streamErr)
+				}
+				if stub.callCount() != 0 {
+					t.Error("rejected request reached the provider")
+				}
+				return
+			}
+			if err != nil || streamErr != nil {
+				t.Errorf("errors = %v, %v", err, streamErr)
+			}
+			if stub.callCount() != 2 {
+				t.Errorf("provider got %d calls, want 2", stub.callCount())
+			}
+		})
+	}
+}
+
+func TestInputGuardDisabledLimits(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewInputGuardMiddleware(0, 0).Wrap(stub)
+	if _, err := p.Complete(context.Background(), userRequest(strings.Repeat("x", 1<<16))); err != nil {
+		t.Errorf("error = %v", err)
+	}
+}
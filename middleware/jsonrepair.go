@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// MetadataJSONRepaired is the Response.Metadata key set to true when the
+// content was repaired or re-prompted into valid JSON
+const MetadataJSONRepaired = "json_repaired"
+
+const repairPrompt = "Your previous reply was not valid JSON. Respond again with only the corrected JSON and no other text."
+
+// JSONRepairMiddleware ensures completions contain valid JSON
+type JSONRepairMiddleware struct {
+	reprompt bool
+}
+
+// NewJSONRepairMiddleware creates a middleware that validates completion
+// content as JSON and applies light repair (stripping code fences and
+// surrounding prose, balancing brackets) when it doesn't parse.
+func NewJSONRepairMiddleware() *JSONRepairMiddleware {
+	return &JSONRepairMiddleware{}
+}
+
+// WithReprompt asks the model once to fix its output when local repair fails
+func (m *JSONRepairMiddleware) WithReprompt() *JSONRepairMiddleware {
+	m.reprompt = true
+	return m
+}
+
+// Wrap wraps a provider with JSON validation and repair
+func (m *JSONRepairMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &jsonRepairProvider{
+		Provider: next,
+		reprompt: m.reprompt,
+	}
+}
+
+type jsonRepairProvider struct {
+	llmrouter.Provider
+	reprompt bool
+}
+
+func (p *jsonRepairProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message == nil || len(resp.Choices[0].Message.ToolCalls) > 0 {
+		return resp, nil
+	}
+
+	content := resp.Choices[0].Message.Content
+	if json.Valid([]byte(content)) {
+		return resp, nil
+	}
+
+	if repaired, ok := RepairJSON(content); ok {
+		resp.Choices[0].Message.SetContent(repaired)
+		markRepaired(resp)
+		return resp, nil
+	}
+
+	if !p.reprompt {
+		return nil, fmt.Errorf("%w: %s", llmrouter.ErrInvalidJSON, resp.Provider)
+	}
+
+	retry := *req
+	retry.Messages = append(append([]llmrouter.Message{}, req.Messages...),
+		llmrouter.Message{Role: llmrouter.RoleAssistant, Content: content},
+		llmrouter.Message{Role: llmrouter.RoleUser, Content: repairPrompt},
+	)
+
+	resp, err = p.Provider.Complete(ctx, &retry)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message == nil {
+		return nil, fmt.Errorf("%w: %s", llmrouter.ErrInvalidJSON, resp.Provider)
+	}
+
+	content = resp.Choices[0].Message.Content
+	if !json.Valid([]byte(content)) {
+		repaired, ok := RepairJSON(content)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", llmrouter.ErrInvalidJSON, resp.Provider)
+		}
+		resp.Choices[0].Message.SetContent(repaired)
+	}
+	markRepaired(resp)
+	return resp, nil
+}
+
+func markRepaired(resp *llmrouter.Response) {
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]any)
+	}
+	resp.Metadata[MetadataJSONRepaired] = true
+}
+
+// RepairJSON attempts light repair of almost-JSON model output: it strips
+// markdown code fences and surrounding prose, then closes any unbalanced
+// brackets. It returns the repaired string and whether it is now valid JSON.
+func RepairJSON(s string) (string, bool) {
+	s = strings.TrimSpace(TrimCodeFences(s))
+
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return s, false
+	}
+	s = s[start:]
+	if json.Valid([]byte(s)) {
+		return s, true
+	}
+
+	// Drop trailing prose after the last closing bracket
+	if end := strings.LastIndexAny(s, "}]"); end >= 0 && json.Valid([]byte(s[:end+1])) {
+		return s[:end+1], true
+	}
+
+	// Close any brackets and strings left open
+	var stack []byte
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if len(stack) > 0 && stack[len(stack)-1] == c {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(s, ", \n\t"))
+	if inString {
+		b.WriteByte('"')
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteByte(stack[i])
+	}
+
+	repaired := b.String()
+	return repaired, json.Valid([]byte(repaired))
+}
+
+// TrimCodeFences removes a surrounding markdown code fence (``` or ```json)
+func TrimCodeFences(s string) string {
+	t := strings.TrimSpace(s)
+	if !strings.HasPrefix(t, "```") {
+		return s
+	}
+
+	t = strings.TrimPrefix(t, "```")
+	if nl := strings.IndexByte(t, '\n'); nl >= 0 {
+		t = t[nl+1:]
+	} else {
+		t = strings.TrimPrefix(t, "json")
+	}
+	t = strings.TrimSpace(t)
+	t = strings.TrimSuffix(t, "```")
+	return strings.TrimSpace(t)
+}
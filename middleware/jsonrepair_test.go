@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+		ok   bool
+	}{
+		{"well formed", `{"a":1}`, `{"a":1}`, true},
+		{"fenced", "```json\n{\"a\":1}\n```", `{"a":1}`, true},
+		{"surrounding prose", "Here you go: {\"a\":1} Hope that helps.", `{"a":1}`, true},
+		{"unclosed", `{"a":[1,2`, `{"a":[1,2]}`, true},
+		{"unterminated string", `{"a":"b`, `{"a":"b"}`, true},
+		{"no json", "I can't help with that.", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := RepairJSON(tt.in)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v (got %q)", ok, tt.ok, got)
+			}
+			if ok && got != tt.want {
+				t.Errorf("RepairJSON(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONRepairMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		want     string
+		repaired bool
+	}{
+		{"valid passes through", `{"a":1}`, `{"a":1}`, false},
+		{"fenced is repaired", "```json\n{\"a\":1}\n```", `{"a":1}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProvider{complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+				return textResponse(tt.content), nil
+			}}
+			resp, err := NewJSONRepairMiddleware().Wrap(stub).Complete(context.Background(), userRequest("json please"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.Choices[0].Message.Content; got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+			if got := resp.Metadata[MetadataJSONRepaired] == true; got != tt.repaired {
+				t.Errorf("repaired marker = %v, want %v", got, tt.repaired)
+			}
+		})
+	}
+}
+
+func TestJSONRepairMiddlewareUnrepairable(t *testing.T) {
+	stub := &stubProvider{complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		return textResponse("no json here"), nil
+	}}
+	_, err := NewJSONRepairMiddleware().Wrap(stub).Complete(context.Background(), userRequest("json please"))
+	if !errors.Is(err, llmrouter.ErrInvalidJSON) {
+		t.Errorf("error = %v, want ErrInvalidJSON", err)
+	}
+	if stub.callCount() != 1 {
+		t.Errorf("calls = %d, want 1 without reprompt", stub.callCount())
+	}
+}
+
+func TestJSONRepairMiddlewareReprompt(t *testing.T) {
+	replies := []string{"no json here", `{"fixed":true}`}
+	stub := &stubProvider{}
+	stub.complete = func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		return textResponse(replies[stub.callCount()-1]), nil
+	}
+	resp, err := NewJSONRepairMiddleware().WithReprompt().Wrap(stub).Complete(context.Background(), userRequest("json please"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid([]byte(resp.Choices[0].Message.Content)) {
+		t.Errorf("content = %q, want JSON", resp.Choices[0].Message.Content)
+	}
+	retry := stub.lastCall()
+	if n := len(retry.Messages); n != 3 || retry.Messages[2].Content != repairPrompt {
+		t.Errorf("reprompt messages = %+v", retry.Messages)
+	}
+}
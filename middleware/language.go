@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"unicode"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// MetadataLanguage is the Request.Metadata key set to the detected language
+const MetadataLanguage = "language"
+
+// LanguageRoutingMiddleware picks the model by the language of the prompt
+type LanguageRoutingMiddleware struct {
+	detect func(string) string
+	routes map[string]string
+}
+
+// NewLanguageRoutingMiddleware creates a middleware that detects the language
+// of the last user message, tags the request with it under MetadataLanguage,
+// and rewrites req.Model to routes[language] when there is an entry.
+//
+// As middleware it runs after the router has picked a provider, so mapped
+// models must be served by the same provider. To route a language to another
+// provider, install Rewriter on the router instead:
+//
+//	lang := middleware.NewLanguageRoutingMiddleware(nil, map[string]string{"ja": "claude-sonnet-4-0"})
+//	router := llmrouter.New(llmrouter.WithRequestRewriter(lang.Rewriter()))
+//
+// A nil detect uses DetectLanguage.
+func NewLanguageRoutingMiddleware(detect func(string) string, routes map[string]string) *LanguageRoutingMiddleware {
+	if detect == nil {
+		detect = DetectLanguage
+	}
+	return &LanguageRoutingMiddleware{
+		detect: detect,
+		routes: routes,
+	}
+}
+
+// Rewriter returns the language routing as a router request rewriter, which
+// runs before the provider is chosen
+func (m *LanguageRoutingMiddleware) Rewriter() llmrouter.RequestRewriter {
+	p := &languageRoutingProvider{detect: m.detect, routes: m.routes}
+	return p.route
+}
+
+// Wrap wraps a provider with language routing
+func (m *LanguageRoutingMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &languageRoutingProvider{
+		Provider: next,
+		detect:   m.detect,
+		routes:   m.routes,
+	}
+}
+
+type languageRoutingProvider struct {
+	llmrouter.Provider
+	detect func(string) string
+	routes map[string]string
+}
+
+func (p *languageRoutingProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	return p.Provider.Complete(ctx, p.route(req))
+}
+
+func (p *languageRoutingProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	return p.Provider.Stream(ctx, p.route(req))
+}
+
+// route returns a copy of req tagged with the detected language and, if
+// routed, pointed at the mapped model
+func (p *languageRoutingProvider) route(req *llmrouter.Request) *llmrouter.Request {
+	var text string
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == llmrouter.RoleUser {
+			text = req.Messages[i].Text()
+			break
+		}
+	}
+
+	lang := p.detect(text)
+	if lang == "" {
+		return req
+	}
+
+	routed := *req
+	routed.Metadata = make(map[string]any, len(req.Metadata)+1)
+	for k, v := range req.Metadata {
+		routed.Metadata[k] = v
+	}
+	routed.Metadata[MetadataLanguage] = lang
+	if model, ok := p.routes[lang]; ok {
+		routed.Model = model
+	}
+	return &routed
+}
+
+// scriptLanguages maps writing systems to a representative language code
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Latin, "en"},
+}
+
+// DetectLanguage is a simple detector based on the dominant writing system,
+// returning an ISO 639-1 code or "" if s has no letters. It can't tell
+// languages that share a script apart: all Latin text is reported as "en".
+// Kana anywhere marks Japanese, since Japanese text also uses Han characters.
+func DetectLanguage(s string) string {
+	counts := make(map[string]int)
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		for _, sl := range scriptLanguages {
+			if unicode.Is(sl.table, r) {
+				counts[sl.lang]++
+				break
+			}
+		}
+	}
+	if counts["ja"] > 0 {
+		return "ja"
+	}
+
+	best := ""
+	for _, sl := range scriptLanguages {
+		if counts[sl.lang] > counts[best] {
+			best = sl.lang
+		}
+	}
+	return best
+}
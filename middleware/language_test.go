@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Hello there", "en"},
+		{"Bonjour à tous", "en"}, // Latin script can't be told apart
+		{"こんにちは世界", "ja"},
+		{"東京タワー", "ja"}, // kana marks Japanese despite the Han characters
+		{"你好世界", "zh"},
+		{"안녕하세요", "ko"},
+		{"Привет, как дела?", "ru"},
+		{"مرحبا", "ar"},
+		{"Переведи на английский: hello", "ru"}, // the dominant script wins
+		{"12345 !?", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageRouting(t *testing.T) {
+	routes := map[string]string{"ja": "japanese-model", "ru": "russian-model"}
+	tests := []struct {
+		name  string
+		text  string
+		model string
+		lang  string
+	}{
+		{"routed", "こんにちは", "japanese-model", "ja"},
+		{"other route", "Привет", "russian-model", "ru"},
+		{"no route keeps the model", "Hello", "default-model", "en"},
+		{"nothing detected", "123", "default-model", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProvider{}
+			p := NewLanguageRoutingMiddleware(nil, routes).Wrap(stub)
+
+			req := userRequest(tt.text)
+			req.Model = "default-model"
+			req.Metadata = map[string]any{"trace": "1"}
+			if _, err := p.Complete(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+
+			got := stub.lastCall()
+			if got.Model != tt.model {
+				t.Errorf("model = %q, want %q", got.Model, tt.model)
+			}
+			lang, _ := got.Metadata[MetadataLanguage].(string)
+			if lang != tt.lang || got.Metadata["trace"] != "1" {
+				t.Errorf("metadata = %v, want language %q and the caller's metadata kept", got.Metadata, tt.lang)
+			}
+			if req.Model != "default-model" || len(req.Metadata) != 1 {
+				t.Errorf("caller's request was modified: %+v", req)
+			}
+		})
+	}
+}
+
+func TestLanguageRoutingUsesLastUserMessage(t *testing.T) {
+	stub := &stubProvider{}
+	var detected string
+	detect := func(s string) string {
+		detected = s
+		return "ja"
+	}
+	p := NewLanguageRoutingMiddleware(detect, map[string]string{"ja": "japanese-model"}).Wrap(stub)
+
+	req := &llmrouter.Request{Messages: []llmrouter.Message{
+		{Role: llmrouter.RoleSystem, Content: "system"},
+		{Role: llmrouter.RoleUser, Content: "first"},
+		{Role: llmrouter.RoleUser, Content: "last"},
+		{Role: llmrouter.RoleAssistant, Content: "reply"},
+	}}
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	if detected != "last" {
+		t.Errorf("detected on %q, want the last user message", detected)
+	}
+	if stub.lastCall().Model != "japanese-model" {
+		t.Errorf("stream model = %q, want japanese-model", stub.lastCall().Model)
+	}
+}
+
+func TestLanguageRoutingRewriterAcrossProviders(t *testing.T) {
+	english := &stubProvider{name: "english"}
+	japanese := &stubProvider{name: "japanese"}
+	lang := NewLanguageRoutingMiddleware(nil, map[string]string{"ja": "japanese-model"})
+	r := llmrouter.New(
+		llmrouter.WithProvider("english", english),
+		llmrouter.WithProvider("japanese", japanese),
+		llmrouter.WithModelMapping("english-model", "english"),
+		llmrouter.WithModelMapping("japanese-model", "japanese"),
+		llmrouter.WithRequestRewriter(lang.Rewriter()),
+	)
+
+	req := userRequest("こんにちは")
+	req.Model = "english-model"
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if japanese.callCount() != 1 || english.callCount() != 0 {
+		t.Fatalf("calls = english %d, japanese %d; want the request routed to japanese", english.callCount(), japanese.callCount())
+	}
+	if got := japanese.lastCall(); got.Model != "japanese-model" || got.Metadata[MetadataLanguage] != "ja" {
+		t.Errorf("request = model %q, metadata %v; want japanese-model tagged ja", got.Model, got.Metadata)
+	}
+
+	req = userRequest("Hello")
+	req.Model = "english-model"
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if english.callCount() != 1 || english.lastCall().Model != "english-model" {
+		t.Errorf("English prompt was not kept on english-model")
+	}
+}
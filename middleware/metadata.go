@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// MetadataMiddleware merges conversation-level metadata, such as trace and
+// conversation IDs, into Request.Metadata. Values already set on the request
+// take precedence. Providers forward the keys they recognize (see
+// llmrouter.MetadataUserID and friends).
+type MetadataMiddleware struct {
+	static  map[string]string
+	dynamic func(ctx context.Context) map[string]string
+}
+
+// NewMetadataMiddleware creates a metadata middleware. dynamic, if non-nil, is
+// called per request and overrides static values with the same key.
+func NewMetadataMiddleware(static map[string]string, dynamic func(ctx context.Context) map[string]string) *MetadataMiddleware {
+	return &MetadataMiddleware{
+		static:  static,
+		dynamic: dynamic,
+	}
+}
+
+// Wrap wraps a provider with metadata injection
+func (m *MetadataMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &metadataProvider{
+		Provider: next,
+		static:   m.static,
+		dynamic:  m.dynamic,
+	}
+}
+
+type metadataProvider struct {
+	llmrouter.Provider
+	static  map[string]string
+	dynamic func(ctx context.Context) map[string]string
+}
+
+func (p *metadataProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	return p.Provider.Complete(ctx, p.apply(ctx, req))
+}
+
+func (p *metadataProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	return p.Provider.Stream(ctx, p.apply(ctx, req))
+}
+
+// apply returns a copy of req with the metadata merged in
+func (p *metadataProvider) apply(ctx context.Context, req *llmrouter.Request) *llmrouter.Request {
+	var dynamic map[string]string
+	if p.dynamic != nil {
+		dynamic = p.dynamic(ctx)
+	}
+	if len(p.static) == 0 && len(dynamic) == 0 {
+		return req
+	}
+
+	merged := make(map[string]any, len(p.static)+len(dynamic)+len(req.Metadata))
+	for k, v := range p.static {
+		merged[k] = v
+	}
+	for k, v := range dynamic {
+		merged[k] = v
+	}
+	for k, v := range req.Metadata {
+		merged[k] = v
+	}
+
+	out := *req
+	out.Metadata = merged
+	return &out
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+type traceKey struct{}
+
+func TestMetadataFromContext(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewMetadataMiddleware(
+		map[string]string{"env": "prod", llmrouter.MetadataTraceID: "static-trace"},
+		func(ctx context.Context) map[string]string {
+			id, _ := ctx.Value(traceKey{}).(string)
+			return map[string]string{llmrouter.MetadataTraceID: id}
+		},
+	).Wrap(stub)
+
+	req := userRequest("hi")
+	req.Metadata = map[string]any{llmrouter.MetadataUserID: "user-1", "env": "dev"}
+	ctx := context.WithValue(context.Background(), traceKey{}, "trace-42")
+	if _, err := p.Complete(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := stub.calls[0]
+	if id := got.MetadataString(llmrouter.MetadataTraceID); id != "trace-42" {
+		t.Errorf("trace_id = %q, want the dynamic value from ctx", id)
+	}
+	if env := got.MetadataString("env"); env != "dev" {
+		t.Errorf("env = %q, want the request's own value", env)
+	}
+	if user := got.MetadataString(llmrouter.MetadataUserID); user != "user-1" {
+		t.Errorf("user_id = %q, want it kept", user)
+	}
+	if len(req.Metadata) != 2 {
+		t.Errorf("caller's metadata modified: %v", req.Metadata)
+	}
+}
+
+func TestMetadataStream(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewMetadataMiddleware(map[string]string{llmrouter.MetadataConversationID: "conv-1"}, nil).Wrap(stub)
+
+	ch, err := p.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	if id := stub.calls[0].MetadataString(llmrouter.MetadataConversationID); id != "conv-1" {
+		t.Errorf("conversation_id = %q, want conv-1", id)
+	}
+}
+
+func TestMetadataNothingToAdd(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewMetadataMiddleware(nil, func(ctx context.Context) map[string]string { return nil }).Wrap(stub)
+
+	req := userRequest("hi")
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if stub.calls[0] != req {
+		t.Error("request copied with no metadata to add")
+	}
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// PayloadSizeBuckets are the upper bounds, in bytes, of the payload size histogram.
+// A final implicit bucket counts everything larger.
+var PayloadSizeBuckets = []int{1 << 10, 10 << 10, 100 << 10, 1 << 20, 10 << 20}
+
+// SizeHistogram counts payload sizes into PayloadSizeBuckets. It is safe for concurrent use.
+type SizeHistogram struct {
+	counts [6]atomic.Int64
+}
+
+// Observe records a payload size
+func (h *SizeHistogram) Observe(size int) {
+	for i, bound := range PayloadSizeBuckets {
+		if size <= bound {
+			h.counts[i].Add(1)
+			return
+		}
+	}
+	h.counts[len(PayloadSizeBuckets)].Add(1)
+}
+
+// Counts returns the count per bucket, with the overflow bucket last
+func (h *SizeHistogram) Counts() []int64 {
+	result := make([]int64, len(h.counts))
+	for i := range h.counts {
+		result[i] = h.counts[i].Load()
+	}
+	return result
+}
+
+// PayloadSizeMiddleware measures serialized request and response sizes and
+// guards against oversized payloads such as large base64 images
+type PayloadSizeMiddleware struct {
+	maxRequestBytes  int
+	maxResponseBytes int
+	onOversize       func(kind string, size, limit int)
+	requests         SizeHistogram
+	responses        SizeHistogram
+}
+
+// NewPayloadSizeMiddleware creates a payload size middleware. Requests or
+// responses larger than their limit are rejected with ErrPayloadTooLarge.
+// A limit of zero or less disables that check.
+func NewPayloadSizeMiddleware(maxRequestBytes, maxResponseBytes int) *PayloadSizeMiddleware {
+	return &PayloadSizeMiddleware{
+		maxRequestBytes:  maxRequestBytes,
+		maxResponseBytes: maxResponseBytes,
+	}
+}
+
+// WithWarnOnly calls fn for oversized payloads instead of rejecting them.
+// kind is "request" or "response".
+func (m *PayloadSizeMiddleware) WithWarnOnly(fn func(kind string, size, limit int)) *PayloadSizeMiddleware {
+	m.onOversize = fn
+	return m
+}
+
+// RequestSizes returns the histogram of serialized request sizes
+func (m *PayloadSizeMiddleware) RequestSizes() *SizeHistogram {
+	return &m.requests
+}
+
+// ResponseSizes returns the histogram of serialized response sizes
+func (m *PayloadSizeMiddleware) ResponseSizes() *SizeHistogram {
+	return &m.responses
+}
+
+// Wrap wraps a provider with payload size tracking
+func (m *PayloadSizeMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &payloadSizeProvider{
+		Provider: next,
+		m:        m,
+	}
+}
+
+type payloadSizeProvider struct {
+	llmrouter.Provider
+	m *PayloadSizeMiddleware
+}
+
+func (p *payloadSizeProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	if err := p.m.check("request", req, &p.m.requests, p.m.maxRequestBytes); err != nil {
+		return nil, err
+	}
+
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.m.check("response", resp, &p.m.responses, p.m.maxResponseBytes); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (p *payloadSizeProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	if err := p.m.check("request", req, &p.m.requests, p.m.maxRequestBytes); err != nil {
+		return nil, err
+	}
+
+	ch, err := p.Provider.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	outCh := make(chan llmrouter.Event)
+	go func() {
+		defer close(outCh)
+
+		for event := range ch {
+			if event.Type == llmrouter.EventDone && event.Response != nil {
+				if err := p.m.check("response", event.Response, &p.m.responses, p.m.maxResponseBytes); err != nil {
+					event = llmrouter.Event{
+						Type:  llmrouter.EventError,
+						Error: err,
+					}
+				}
+			}
+			outCh <- event
+		}
+	}()
+
+	return outCh, nil
+}
+
+// check records the serialized size of v and enforces the limit
+func (m *PayloadSizeMiddleware) check(kind string, v any, h *SizeHistogram, limit int) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	size := len(b)
+	h.Observe(size)
+
+	if limit <= 0 || size <= limit {
+		return nil
+	}
+	if m.onOversize != nil {
+		m.onOversize(kind, size, limit)
+		return nil
+	}
+	return fmt.Errorf("%w: %s is %d bytes, limit %d", llmrouter.ErrPayloadTooLarge, kind, size, limit)
+}
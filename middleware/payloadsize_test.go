@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// imageRequest builds a request carrying a base64 image of size bytes
+func imageRequest(size int) *llmrouter.Request {
+	return &llmrouter.Request{Messages: []llmrouter.Message{{
+		Role:    llmrouter.RoleUser,
+		Content: "what is this?",
+		ContentParts: []llmrouter.ContentPart{{
+			Type:     "image_url",
+			ImageURL: &llmrouter.ImageURL{Base64: strings.Repeat("A", size), MediaType: "image/png"},
+		}},
+	}}}
+}
+
+func TestPayloadSizeRejectsOversizedImage(t *testing.T) {
+	stub := &stubProvider{}
+	m := NewPayloadSizeMiddleware(64<<10, 0)
+	p := m.Wrap(stub)
+
+	_, err := p.Complete(context.Background(), imageRequest(100<<10))
+	if !errors.Is(err, llmrouter.ErrPayloadTooLarge) {
+		t.Errorf("error = %v, want ErrPayloadTooLarge", err)
+	}
+	if _, err := p.Stream(context.Background(), imageRequest(100<<10)); !errors.Is(err, llmrouter.ErrPayloadTooLarge) {
+		t.Errorf("stream error = %v, want ErrPayloadTooLarge", err)
+	}
+	if stub.callCount() != 0 {
+		t.Error("oversized request reached the provider")
+	}
+
+	if _, err := p.Complete(context.Background(), imageRequest(1<<10)); err != nil {
+		t.Errorf("small image: %v", err)
+	}
+}
+
+func TestPayloadSizeRejectsOversizedResponse(t *testing.T) {
+	stub := &stubProvider{complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		return textResponse(strings.Repeat("x", 2048)), nil
+	}}
+	p := NewPayloadSizeMiddleware(0, 1024).Wrap(stub)
+
+	if _, err := p.Complete(context.Background(), userRequest("hi")); !errors.Is(err, llmrouter.ErrPayloadTooLarge) {
+		t.Errorf("error = %v, want ErrPayloadTooLarge", err)
+	}
+
+	stub.stream = func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+		return eventStream(llmrouter.Event{Type: llmrouter.EventDone, Response: textResponse(strings.Repeat("x", 2048))}), nil
+	}
+	ch, err := p.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	if len(events) != 1 || !errors.Is(events[0].Error, llmrouter.ErrPayloadTooLarge) {
+		t.Errorf("events = %+v, want the done event replaced by ErrPayloadTooLarge", events)
+	}
+}
+
+func TestPayloadSizeWarnOnly(t *testing.T) {
+	var warned []string
+	m := NewPayloadSizeMiddleware(1024, 0).WithWarnOnly(func(kind string, size, limit int) {
+		warned = append(warned, kind)
+	})
+
+	if _, err := m.Wrap(&stubProvider{}).Complete(context.Background(), imageRequest(4096)); err != nil {
+		t.Fatalf("warn-only rejected the request: %v", err)
+	}
+	if len(warned) != 1 || warned[0] != "request" {
+		t.Errorf("warnings = %v, want one for the request", warned)
+	}
+}
+
+func TestPayloadSizeHistogram(t *testing.T) {
+	m := NewPayloadSizeMiddleware(0, 0)
+	p := m.Wrap(&stubProvider{})
+	for _, size := range []int{0, 50 << 10, 2 << 20, 20 << 20} {
+		if _, err := p.Complete(context.Background(), imageRequest(size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []int64{1, 0, 1, 0, 1, 1}
+	got := m.RequestSizes().Counts()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("request size counts = %v, want %v", got, want)
+		}
+	}
+	if counts := m.ResponseSizes().Counts(); counts[0] != 4 {
+		t.Errorf("response size counts = %v, want 4 small responses", counts)
+	}
+}
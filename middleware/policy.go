@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// Policy bounds what callers may request. Zero limits are disabled.
+type Policy struct {
+	MaxTokensCap   int     // upper bound on max_tokens; also applied when unset
+	MaxTemperature float64 // upper bound on temperature
+	AllowTools     bool    // requests with tools are rejected unless set
+	Clamp          bool    // clamp out-of-bound values instead of rejecting
+}
+
+// PolicyMiddleware enforces a Policy before requests reach the provider.
+// Violations are rejected with ErrInvalidRequest naming the field, or clamped
+// when the policy says so. Tool use can't be clamped and is always rejected.
+type PolicyMiddleware struct {
+	policy Policy
+}
+
+// NewPolicyMiddleware creates a new policy middleware
+func NewPolicyMiddleware(policy Policy) *PolicyMiddleware {
+	return &PolicyMiddleware{policy: policy}
+}
+
+// Wrap wraps a provider with policy enforcement
+func (m *PolicyMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &policyProvider{
+		Provider: next,
+		policy:   m.policy,
+	}
+}
+
+type policyProvider struct {
+	llmrouter.Provider
+	policy Policy
+}
+
+func (p *policyProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	req, err := p.enforce(req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Provider.Complete(ctx, req)
+}
+
+func (p *policyProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	req, err := p.enforce(req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Provider.Stream(ctx, req)
+}
+
+// enforce returns req, or a clamped copy of it, if it satisfies the policy
+func (p *policyProvider) enforce(req *llmrouter.Request) (*llmrouter.Request, error) {
+	if !p.policy.AllowTools && len(req.Tools) > 0 {
+		return nil, fmt.Errorf("%w: tools are not allowed by policy", llmrouter.ErrInvalidRequest)
+	}
+
+	out := *req
+	if limit := p.policy.MaxTokensCap; limit > 0 {
+		switch {
+		case req.MaxTokens == nil:
+			out.MaxTokens = &limit
+		case *req.MaxTokens > limit:
+			if !p.policy.Clamp {
+				return nil, fmt.Errorf("%w: max_tokens %d exceeds policy cap of %d", llmrouter.ErrInvalidRequest, *req.MaxTokens, limit)
+			}
+			out.MaxTokens = &limit
+		}
+	}
+	if limit := p.policy.MaxTemperature; limit > 0 && req.Temperature != nil && *req.Temperature > limit {
+		if !p.policy.Clamp {
+			return nil, fmt.Errorf("%w: temperature %g exceeds policy cap of %g", llmrouter.ErrInvalidRequest, *req.Temperature, limit)
+		}
+		out.Temperature = &limit
+	}
+	return &out, nil
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestPolicyClamps(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewPolicyMiddleware(Policy{MaxTokensCap: 1000, MaxTemperature: 1, Clamp: true}).Wrap(stub)
+
+	req := userRequest("hi")
+	req.MaxTokens = intPtr(4000)
+	req.Temperature = floatPtr(1.8)
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := stub.lastCall()
+	if *got.MaxTokens != 1000 || *got.Temperature != 1 {
+		t.Errorf("sent max_tokens %d, temperature %g; want clamped to 1000 and 1", *got.MaxTokens, *got.Temperature)
+	}
+	if *req.MaxTokens != 4000 || *req.Temperature != 1.8 {
+		t.Error("caller's request modified")
+	}
+}
+
+func TestPolicyWithinBounds(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewPolicyMiddleware(Policy{MaxTokensCap: 1000, MaxTemperature: 1}).Wrap(stub)
+
+	req := userRequest("hi")
+	req.MaxTokens = intPtr(500)
+	req.Temperature = floatPtr(0.7)
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := stub.lastCall(); *got.MaxTokens != 500 || *got.Temperature != 0.7 {
+		t.Errorf("sent max_tokens %d, temperature %g; want unchanged", *got.MaxTokens, *got.Temperature)
+	}
+
+	// An unset max_tokens gets the cap
+	if _, err := p.Complete(context.Background(), userRequest("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if got := stub.lastCall(); got.MaxTokens == nil || *got.MaxTokens != 1000 || got.Temperature != nil {
+		t.Errorf("sent max_tokens %v, temperature %v; want the cap and no temperature", got.MaxTokens, got.Temperature)
+	}
+}
+
+func TestPolicyRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		modify func(*llmrouter.Request)
+		field  string
+	}{
+		{"max tokens", Policy{MaxTokensCap: 1000}, func(r *llmrouter.Request) { r.MaxTokens = intPtr(4000) }, "max_tokens"},
+		{"temperature", Policy{MaxTemperature: 1}, func(r *llmrouter.Request) { r.Temperature = floatPtr(1.5) }, "temperature"},
+		{"tools", Policy{}, func(r *llmrouter.Request) {
+			r.Tools = []llmrouter.Tool{{Type: "function", Function: llmrouter.Function{Name: "weather"}}}
+		}, "tools"},
+		{"tools even when clamping", Policy{Clamp: true}, func(r *llmrouter.Request) {
+			r.Tools = []llmrouter.Tool{{Type: "function", Function: llmrouter.Function{Name: "weather"}}}
+		}, "tools"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProvider{}
+			p := NewPolicyMiddleware(tt.policy).Wrap(stub)
+			req := userRequest("hi")
+			tt.modify(req)
+
+			_, err := p.Complete(context.Background(), req)
+			if !errors.Is(err, llmrouter.ErrInvalidRequest) || !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("Complete error = %v, want ErrInvalidRequest naming %s", err, tt.field)
+			}
+			if _, err := p.Stream(context.Background(), req); !errors.Is(err, llmrouter.ErrInvalidRequest) {
+				t.Errorf("Stream error = %v, want ErrInvalidRequest", err)
+			}
+			if stub.callCount() != 0 {
+				t.Error("rejected request reached the provider")
+			}
+		})
+	}
+}
+
+func TestPolicyAllowsTools(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewPolicyMiddleware(Policy{AllowTools: true}).Wrap(stub)
+
+	req := userRequest("hi")
+	req.Tools = []llmrouter.Tool{{Type: "function", Function: llmrouter.Function{Name: "weather"}}}
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	if len(stub.lastCall().Tools) != 1 {
+		t.Error("tools not passed through")
+	}
+}
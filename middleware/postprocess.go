@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// PostProcessMiddleware rewrites completion content, e.g. to strip code
+// fences or normalize whitespace before downstream parsing
+type PostProcessMiddleware struct {
+	fn func(string) string
+}
+
+// NewPostProcessMiddleware creates a middleware that applies fn to the content
+// of every choice. For streams, deltas pass through unchanged and fn is
+// applied to the content assembled from them, which replaces the content of
+// the EventDone response (or to that content if no deltas were seen).
+func NewPostProcessMiddleware(fn func(string) string) *PostProcessMiddleware {
+	return &PostProcessMiddleware{fn: fn}
+}
+
+// Wrap wraps a provider with content post-processing
+func (m *PostProcessMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &postProcessProvider{
+		Provider: next,
+		fn:       m.fn,
+	}
+}
+
+type postProcessProvider struct {
+	llmrouter.Provider
+	fn func(string) string
+}
+
+func (p *postProcessProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range resp.Choices {
+		if msg := resp.Choices[i].Message; msg != nil {
+			msg.SetContent(p.fn(msg.Content))
+		}
+	}
+	return resp, nil
+}
+
+func (p *postProcessProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	ch, err := p.Provider.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	outCh := make(chan llmrouter.Event)
+	go func() {
+		defer close(outCh)
+
+		// Content per choice index
+		contents := make(map[int]string)
+
+		for event := range ch {
+			switch event.Type {
+			case llmrouter.EventContentDelta:
+				contents[event.Index] += event.Content
+			case llmrouter.EventDone:
+				if event.Response != nil {
+					for i := range event.Response.Choices {
+						choice := &event.Response.Choices[i]
+						content, ok := contents[choice.Index]
+						switch {
+						case choice.Message == nil && !ok:
+							continue
+						case choice.Message == nil:
+							choice.Message = &llmrouter.Message{Role: llmrouter.RoleAssistant}
+						case !ok:
+							content = choice.Message.Content
+						}
+						choice.Message.SetContent(p.fn(content))
+					}
+				}
+			}
+			select {
+			case outCh <- event:
+			case <-ctx.Done():
+				go func() {
+					for range ch {
+					}
+				}()
+				return
+			}
+		}
+	}()
+
+	return outCh, nil
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestTrimCodeFences(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"```json\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"  ```\nplain\n```  \n", "plain"},
+		{"```json{\"a\": 1}```", `{"a": 1}`},
+		{"no fences", "no fences"},
+		{"text then ```code```", "text then ```code```"},
+	}
+	for _, tt := range tests {
+		if got := TrimCodeFences(tt.in); got != tt.want {
+			t.Errorf("TrimCodeFences(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPostProcessComplete(t *testing.T) {
+	stub := &stubProvider{complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		return &llmrouter.Response{Choices: []llmrouter.Choice{
+			{Index: 0, Message: &llmrouter.Message{Role: llmrouter.RoleAssistant, Content: "```json\n{}\n```"}},
+			{Index: 1, Message: &llmrouter.Message{Role: llmrouter.RoleAssistant, Content: "second  \n"}},
+			{Index: 2},
+		}}, nil
+	}}
+	p := NewPostProcessMiddleware(func(s string) string { return strings.TrimSpace(TrimCodeFences(s)) }).Wrap(stub)
+
+	resp, err := p.Complete(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "{}" {
+		t.Errorf("choice 0 = %q, want fences trimmed", got)
+	}
+	if got := resp.Choices[1].Message.Content; got != "second" {
+		t.Errorf("choice 1 = %q, want whitespace trimmed", got)
+	}
+	if resp.Choices[2].Message != nil {
+		t.Errorf("choice 2 message = %+v, want nil kept", resp.Choices[2].Message)
+	}
+}
+
+func TestPostProcessStream(t *testing.T) {
+	stub := &stubProvider{stream: func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+		return eventStream(
+			llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "```json\n"},
+			llmrouter.Event{Type: llmrouter.EventContentDelta, Index: 1, Content: "other "},
+			llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "{}\n```"},
+			llmrouter.Event{Type: llmrouter.EventDone, Response: &llmrouter.Response{Choices: []llmrouter.Choice{
+				{Index: 0},
+				{Index: 1, Message: &llmrouter.Message{Role: llmrouter.RoleAssistant, Content: "stale"}},
+				{Index: 2, Message: &llmrouter.Message{Role: llmrouter.RoleAssistant, Content: " only done "}},
+			}}},
+		), nil
+	}}
+	p := NewPostProcessMiddleware(func(s string) string { return strings.TrimSpace(TrimCodeFences(s)) }).Wrap(stub)
+
+	ch, err := p.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	if len(events) != 4 {
+		t.Fatalf("events = %d, want 4", len(events))
+	}
+	if events[0].Content != "```json\n" || events[2].Content != "{}\n```" {
+		t.Errorf("deltas = %q, %q; want them passed through unchanged", events[0].Content, events[2].Content)
+	}
+
+	choices := events[3].Response.Choices
+	want := []string{"{}", "other", "only done"}
+	for i, w := range want {
+		if choices[i].Message == nil || choices[i].Message.Content != w {
+			t.Errorf("choice %d = %+v, want %q", i, choices[i].Message, w)
+		}
+	}
+}
+
+func TestPostProcessKeepsBlocksInSync(t *testing.T) {
+	call := &llmrouter.ToolCall{ID: "call_1", Type: "function", Function: llmrouter.FuncCall{Name: "weather"}}
+	stub := &stubProvider{complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		return &llmrouter.Response{Choices: []llmrouter.Choice{{Message: &llmrouter.Message{
+			Role:    llmrouter.RoleAssistant,
+			Content: "Checking.  ",
+			ContentBlocks: []llmrouter.ContentPart{
+				{Type: "text", Text: "Checking.  "},
+				{Type: "tool_call", ToolCall: call},
+			},
+		}}}}, nil
+	}}
+	p := NewPostProcessMiddleware(strings.TrimSpace).Wrap(stub)
+
+	resp, err := p.Complete(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := resp.Choices[0].Message
+	if msg.Content != "Checking." {
+		t.Errorf("content = %q, want trimmed", msg.Content)
+	}
+	if len(msg.ContentBlocks) != 2 || msg.ContentBlocks[0].Text != "Checking." || msg.ContentBlocks[1].ToolCall != call {
+		t.Errorf("blocks = %+v, want the text block trimmed and the tool call kept", msg.ContentBlocks)
+	}
+}
+
+func TestPostProcessStreamCanceled(t *testing.T) {
+	stub := &stubProvider{stream: func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+		return eventStream(
+			llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "a"},
+			llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "b"},
+			llmrouter.Event{Type: llmrouter.EventDone, Response: textResponse("ab")},
+		), nil
+	}}
+	p := NewPostProcessMiddleware(strings.TrimSpace).Wrap(stub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.Stream(ctx, userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+	cancel()
+
+	// Left unread, the channel closes instead of blocking on the next event
+	time.Sleep(20 * time.Millisecond)
+	if e, ok := <-ch; ok {
+		t.Errorf("received %+v after cancel, want the channel closed", e)
+	}
+}
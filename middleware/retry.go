@@ -2,8 +2,9 @@ package middleware
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"math"
 	"time"
 
 	llmrouter "github.com/bluefunda/llm-router"
@@ -15,8 +16,14 @@ type RetryMiddleware struct {
 	baseDelay   time.Duration
 	maxDelay    time.Duration
 	retryable   func(error) bool
+	backoff     BackoffStrategy
+	clock       Clock
+	idempotent  bool
 }
 
+// Clock waits out retry delays. Tests can inject one that fires immediately.
+type Clock = llmrouter.Clock
+
 // NewRetryMiddleware creates a new retry middleware
 func NewRetryMiddleware(maxAttempts int, baseDelay time.Duration) *RetryMiddleware {
 	return &RetryMiddleware{
@@ -24,6 +31,7 @@ func NewRetryMiddleware(maxAttempts int, baseDelay time.Duration) *RetryMiddlewa
 		baseDelay:   baseDelay,
 		maxDelay:    30 * time.Second,
 		retryable:   llmrouter.IsRetryable,
+		clock:       llmrouter.RealClock,
 	}
 }
 
@@ -33,6 +41,30 @@ func (m *RetryMiddleware) WithMaxDelay(d time.Duration) *RetryMiddleware {
 	return m
 }
 
+// WithBackoffStrategy replaces the default exponential backoff. The base and
+// max delays only apply to the default strategy.
+func (m *RetryMiddleware) WithBackoffStrategy(b BackoffStrategy) *RetryMiddleware {
+	m.backoff = b
+	return m
+}
+
+// WithClock replaces the real-time clock used to wait between attempts
+func (m *RetryMiddleware) WithClock(c Clock) *RetryMiddleware {
+	if c == nil {
+		c = llmrouter.RealClock
+	}
+	m.clock = c
+	return m
+}
+
+// WithIdempotencyKeys gives requests without an IdempotencyKey a random one,
+// so every attempt of a request carries the same key and a provider that
+// already processed it can return the original result instead of billing twice
+func (m *RetryMiddleware) WithIdempotencyKeys() *RetryMiddleware {
+	m.idempotent = true
+	return m
+}
+
 // WithRetryFunc sets a custom retry decision function
 func (m *RetryMiddleware) WithRetryFunc(f func(error) bool) *RetryMiddleware {
 	m.retryable = f
@@ -47,6 +79,9 @@ func (m *RetryMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
 		baseDelay:   m.baseDelay,
 		maxDelay:    m.maxDelay,
 		retryable:   m.retryable,
+		backoff:     m.backoff,
+		clock:       m.clock,
+		idempotent:  m.idempotent,
 	}
 }
 
@@ -56,66 +91,118 @@ type retryProvider struct {
 	baseDelay   time.Duration
 	maxDelay    time.Duration
 	retryable   func(error) bool
+	backoff     BackoffStrategy
+	clock       Clock
+	idempotent  bool
 }
 
 func (p *retryProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	req = p.withIdempotencyKey(req)
+
 	var lastErr error
 
-	for attempt := 0; attempt < p.maxAttempts; attempt++ {
+	maxAttempts := p.attemptsFor(req)
+	for attempt := 0; attempt < maxAttempts; attempt++ {
 		if attempt > 0 {
-			delay := p.calculateBackoff(attempt)
+			delay := p.calculateBackoff(attempt, lastErr)
 			select {
 			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(delay):
+				return nil, canceledError(ctx)
+			case <-p.clock.After(delay):
 			}
 		}
+		if ctx.Err() != nil {
+			return nil, canceledError(ctx)
+		}
 
 		resp, err := p.Provider.Complete(ctx, req)
 		if err == nil {
 			return resp, nil
 		}
 
+		// An attempt cut short by cancellation isn't worth retrying
+		if ctx.Err() != nil {
+			return nil, canceledError(ctx)
+		}
 		lastErr = err
 		if !p.retryable(err) {
 			return nil, err
 		}
 	}
 
-	return nil, fmt.Errorf("%w: %v", llmrouter.ErrMaxRetriesExceed, lastErr)
+	return nil, fmt.Errorf("%w: %w", llmrouter.ErrMaxRetriesExceed, lastErr)
 }
 
 func (p *retryProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	req = p.withIdempotencyKey(req)
+
 	var lastErr error
 
-	for attempt := 0; attempt < p.maxAttempts; attempt++ {
+	maxAttempts := p.attemptsFor(req)
+	for attempt := 0; attempt < maxAttempts; attempt++ {
 		if attempt > 0 {
-			delay := p.calculateBackoff(attempt)
+			delay := p.calculateBackoff(attempt, lastErr)
 			select {
 			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(delay):
+				return nil, canceledError(ctx)
+			case <-p.clock.After(delay):
 			}
 		}
+		if ctx.Err() != nil {
+			return nil, canceledError(ctx)
+		}
 
 		ch, err := p.Provider.Stream(ctx, req)
 		if err == nil {
 			return ch, nil
 		}
 
+		// An attempt cut short by cancellation isn't worth retrying
+		if ctx.Err() != nil {
+			return nil, canceledError(ctx)
+		}
 		lastErr = err
 		if !p.retryable(err) {
 			return nil, err
 		}
 	}
 
-	return nil, fmt.Errorf("%w: %v", llmrouter.ErrMaxRetriesExceed, lastErr)
+	return nil, fmt.Errorf("%w: %w", llmrouter.ErrMaxRetriesExceed, lastErr)
+}
+
+// withIdempotencyKey returns req with a generated idempotency key if keys
+// are enabled and it has none. The same request is reused for every attempt.
+func (p *retryProvider) withIdempotencyKey(req *llmrouter.Request) *llmrouter.Request {
+	if !p.idempotent || req.IdempotencyKey != "" {
+		return req
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return req
+	}
+	keyed := *req
+	keyed.IdempotencyKey = hex.EncodeToString(b[:])
+	return &keyed
+}
+
+// canceledError reports a done context as ErrContextCanceled, keeping the
+// context's own error in the chain
+func canceledError(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", llmrouter.ErrContextCanceled, ctx.Err())
+}
+
+// attemptsFor returns the attempt budget, honoring a per-request
+// max_retries override in metadata
+func (p *retryProvider) attemptsFor(req *llmrouter.Request) int {
+	if n, ok := req.MetadataInt(llmrouter.MetadataMaxRetries); ok && n >= 0 {
+		return n + 1
+	}
+	return p.maxAttempts
 }
 
-func (p *retryProvider) calculateBackoff(attempt int) time.Duration {
-	delay := time.Duration(float64(p.baseDelay) * math.Pow(2, float64(attempt-1)))
-	if delay > p.maxDelay {
-		delay = p.maxDelay
+func (p *retryProvider) calculateBackoff(attempt int, err error) time.Duration {
+	if p.backoff != nil {
+		return p.backoff.NextDelay(attempt, err)
 	}
-	return delay
+	return ExponentialBackoff{Base: p.baseDelay, Max: p.maxDelay}.NextDelay(attempt, err)
 }
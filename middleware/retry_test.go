@@ -0,0 +1,286 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// failingProvider returns a stub that always fails with err
+func failingProvider(err error) *stubProvider {
+	return &stubProvider{
+		complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+			return nil, err
+		},
+		stream: func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+			return nil, err
+		},
+	}
+}
+
+// flakyProvider returns a stub that fails with err for the first n calls
+// to each of Complete and Stream, then succeeds
+func flakyProvider(n int, err error) *stubProvider {
+	var completes, streams int
+	return &stubProvider{
+		complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+			if completes++; completes <= n {
+				return nil, err
+			}
+			return textResponse("ok"), nil
+		},
+		stream: func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+			if streams++; streams <= n {
+				return nil, err
+			}
+			return eventStream(llmrouter.Event{Type: llmrouter.EventDone, Response: textResponse("ok")}), nil
+		},
+	}
+}
+
+func TestRetryWithFakeClock(t *testing.T) {
+	// An hour-long base delay would hang the test if the real clock were used
+	want := []time.Duration{time.Hour, 2 * time.Hour, 4 * time.Hour}
+
+	t.Run("complete", func(t *testing.T) {
+		stub := flakyProvider(3, llmrouter.ErrRateLimited)
+		clock := &fakeClock{}
+		p := NewRetryMiddleware(4, time.Hour).WithMaxDelay(24 * time.Hour).WithClock(clock).Wrap(stub)
+
+		resp, err := p.Complete(context.Background(), userRequest("hi"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Choices[0].Message.Content != "ok" {
+			t.Errorf("content = %q, want ok", resp.Choices[0].Message.Content)
+		}
+		if stub.callCount() != 4 {
+			t.Errorf("attempts = %d, want 4", stub.callCount())
+		}
+		if !slices.Equal(clock.delays, want) {
+			t.Errorf("delays = %v, want %v", clock.delays, want)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		stub := flakyProvider(3, llmrouter.ErrRateLimited)
+		clock := &fakeClock{}
+		p := NewRetryMiddleware(4, time.Hour).WithMaxDelay(24 * time.Hour).WithClock(clock).Wrap(stub)
+
+		ch, err := p.Stream(context.Background(), userRequest("hi"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if events := collect(ch); len(events) != 1 || events[0].Type != llmrouter.EventDone {
+			t.Errorf("events = %+v, want the successful stream", events)
+		}
+		if stub.callCount() != 4 {
+			t.Errorf("attempts = %d, want 4", stub.callCount())
+		}
+		if !slices.Equal(clock.delays, want) {
+			t.Errorf("delays = %v, want %v", clock.delays, want)
+		}
+	})
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	stub := failingProvider(llmrouter.ErrInvalidRequest)
+	clock := &fakeClock{}
+	p := NewRetryMiddleware(4, time.Hour).WithClock(clock).Wrap(stub)
+
+	if _, err := p.Complete(context.Background(), userRequest("hi")); !errors.Is(err, llmrouter.ErrInvalidRequest) {
+		t.Fatalf("err = %v, want ErrInvalidRequest", err)
+	}
+	if stub.callCount() != 1 || len(clock.delays) != 0 {
+		t.Errorf("attempts = %d, waits = %d; want 1 attempt and no wait", stub.callCount(), len(clock.delays))
+	}
+}
+
+func TestRetryMaxRetriesOverride(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		attempts int
+	}{
+		{"no override", nil, 3},
+		{"no retries", 0, 1},
+		{"more retries", 4, 5},
+		{"from JSON", float64(1), 2},
+		{"negative keeps the default", -1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := failingProvider(llmrouter.ErrRateLimited)
+			p := NewRetryMiddleware(3, time.Millisecond).WithClock(&fakeClock{}).Wrap(stub)
+
+			req := userRequest("hi")
+			if tt.value != nil {
+				req.Metadata = map[string]any{llmrouter.MetadataMaxRetries: tt.value}
+			}
+			if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrMaxRetriesExceed) {
+				t.Fatalf("Complete error = %v, want ErrMaxRetriesExceed", err)
+			}
+			if stub.callCount() != tt.attempts {
+				t.Errorf("Complete made %d attempts, want %d", stub.callCount(), tt.attempts)
+			}
+
+			stub.calls = nil
+			if _, err := p.Stream(context.Background(), req); !errors.Is(err, llmrouter.ErrMaxRetriesExceed) {
+				t.Fatalf("Stream error = %v, want ErrMaxRetriesExceed", err)
+			}
+			if stub.callCount() != tt.attempts {
+				t.Errorf("Stream made %d attempts, want %d", stub.callCount(), tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryIdempotencyKey(t *testing.T) {
+	stub := flakyProvider(2, llmrouter.ErrRateLimited)
+	p := NewRetryMiddleware(3, time.Millisecond).WithIdempotencyKeys().WithClock(&fakeClock{}).Wrap(stub)
+
+	req := userRequest("hi")
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if req.IdempotencyKey != "" {
+		t.Errorf("caller's request was given key %q", req.IdempotencyKey)
+	}
+	if len(stub.calls) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(stub.calls))
+	}
+	key := stub.calls[0].IdempotencyKey
+	if key == "" {
+		t.Fatal("no idempotency key generated")
+	}
+	for i, call := range stub.calls {
+		if call.IdempotencyKey != key {
+			t.Errorf("attempt %d key = %q, want %q", i+1, call.IdempotencyKey, key)
+		}
+	}
+
+	// A second request gets a fresh key
+	if _, err := p.Complete(context.Background(), userRequest("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if got := stub.lastCall().IdempotencyKey; got == "" || got == key {
+		t.Errorf("second request key = %q, want a new key", got)
+	}
+}
+
+func TestRetryIdempotencyKeyKept(t *testing.T) {
+	stub := flakyProvider(1, llmrouter.ErrRateLimited)
+	p := NewRetryMiddleware(3, time.Millisecond).WithIdempotencyKeys().WithClock(&fakeClock{}).Wrap(stub)
+
+	req := userRequest("hi")
+	req.IdempotencyKey = "caller-key"
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	for i, call := range stub.calls {
+		if call.IdempotencyKey != "caller-key" {
+			t.Errorf("attempt %d key = %q, want the caller's key", i+1, call.IdempotencyKey)
+		}
+	}
+}
+
+func TestRetryWithoutIdempotencyKeys(t *testing.T) {
+	stub := flakyProvider(1, llmrouter.ErrRateLimited)
+	p := NewRetryMiddleware(3, time.Millisecond).WithClock(&fakeClock{}).Wrap(stub)
+
+	if _, err := p.Complete(context.Background(), userRequest("hi")); err != nil {
+		t.Fatal(err)
+	}
+	for i, call := range stub.calls {
+		if call.IdempotencyKey != "" {
+			t.Errorf("attempt %d key = %q, want none", i+1, call.IdempotencyKey)
+		}
+	}
+}
+
+// blockingClock never fires, so a retry waits until its context is done
+type blockingClock struct {
+	waiting chan time.Duration
+}
+
+func (c *blockingClock) After(d time.Duration) <-chan time.Time {
+	c.waiting <- d
+	return nil
+}
+
+func TestRetryCanceledDuringBackoff(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		stub := failingProvider(llmrouter.ErrRateLimited)
+		clock := &blockingClock{waiting: make(chan time.Duration, 1)}
+		p := NewRetryMiddleware(3, time.Hour).WithClock(clock).Wrap(stub)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-clock.waiting
+			cancel()
+		}()
+
+		done := make(chan error, 1)
+		go func() {
+			var err error
+			if stream {
+				_, err = p.Stream(ctx, userRequest("hi"))
+			} else {
+				_, err = p.Complete(ctx, userRequest("hi"))
+			}
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, llmrouter.ErrContextCanceled) || !errors.Is(err, context.Canceled) {
+				t.Errorf("stream=%v: err = %v, want ErrContextCanceled wrapping context.Canceled", stream, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("stream=%v: retry did not return after cancellation", stream)
+		}
+		if stub.callCount() != 1 {
+			t.Errorf("stream=%v: attempts = %d, want 1", stream, stub.callCount())
+		}
+		cancel()
+	}
+}
+
+func TestRetryCanceledBeforeAttempt(t *testing.T) {
+	stub := failingProvider(llmrouter.ErrRateLimited)
+	p := NewRetryMiddleware(3, time.Millisecond).WithClock(&fakeClock{}).Wrap(stub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Complete(ctx, userRequest("hi")); !errors.Is(err, llmrouter.ErrContextCanceled) {
+		t.Errorf("err = %v, want ErrContextCanceled", err)
+	}
+	if stub.callCount() != 0 {
+		t.Errorf("attempts = %d, want none", stub.callCount())
+	}
+}
+
+func TestRetryCanceledDuringAttempt(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	// The provider ignores the context and fails with a retryable error,
+	// but the attempt was cut short so it isn't retried
+	stub := &stubProvider{complete: func(context.Context, *llmrouter.Request) (*llmrouter.Response, error) {
+		cancel()
+		return nil, llmrouter.ErrRateLimited
+	}}
+	p := NewRetryMiddleware(3, time.Millisecond).WithClock(&fakeClock{}).Wrap(stub)
+
+	if _, err := p.Complete(ctx, userRequest("hi")); !errors.Is(err, llmrouter.ErrContextCanceled) {
+		t.Errorf("err = %v, want ErrContextCanceled", err)
+	}
+	if stub.callCount() != 1 {
+		t.Errorf("attempts = %d, want 1", stub.callCount())
+	}
+}
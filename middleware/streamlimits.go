@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// StreamLimits bounds the phases of a stream. A zero field disables that limit.
+type StreamLimits struct {
+	Connect time.Duration // until the first event
+	Idle    time.Duration // between events, once the first has arrived
+	Total   time.Duration // the whole stream
+}
+
+// StreamLimitsMiddleware ends streams that break any of its limits with an
+// EventError wrapping ErrConnectTimeout, ErrIdleTimeout or
+// ErrStreamDurationExceeded, according to the limit that fired. Heartbeats
+// don't count as events. Complete is not limited; use TimeoutMiddleware.
+type StreamLimitsMiddleware struct {
+	limits StreamLimits
+}
+
+// NewStreamLimitsMiddleware creates a new stream limits middleware
+func NewStreamLimitsMiddleware(limits StreamLimits) *StreamLimitsMiddleware {
+	return &StreamLimitsMiddleware{limits: limits}
+}
+
+// Wrap wraps a provider with stream limits
+func (m *StreamLimitsMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &streamLimitsProvider{
+		Provider: next,
+		limits:   m.limits,
+	}
+}
+
+type streamLimitsProvider struct {
+	llmrouter.Provider
+	limits StreamLimits
+}
+
+// limitTimer runs f after d, or never if d is not positive
+func limitTimer(d time.Duration, f func()) *time.Timer {
+	if d <= 0 {
+		return nil
+	}
+	return time.AfterFunc(d, f)
+}
+
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
+
+func (p *streamLimitsProvider) Stream(parent context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	ctx, cancel := context.WithCancel(parent)
+
+	// The first limit to fire records its error and cancels the stream
+	var mu sync.Mutex
+	var breach error
+	fire := func(err error) func() {
+		return func() {
+			mu.Lock()
+			if breach == nil {
+				breach = err
+			}
+			mu.Unlock()
+			cancel()
+		}
+	}
+	breached := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return breach
+	}
+
+	connect := limitTimer(p.limits.Connect, fire(fmt.Errorf("%w: no data after %s", llmrouter.ErrConnectTimeout, p.limits.Connect)))
+	total := limitTimer(p.limits.Total, fire(fmt.Errorf("%w: still streaming after %s", llmrouter.ErrStreamDurationExceeded, p.limits.Total)))
+	var idle *time.Timer
+
+	ch, err := p.Provider.Stream(ctx, req)
+	if err != nil {
+		stopTimer(connect)
+		stopTimer(total)
+		cancel()
+		if b := breached(); b != nil {
+			return nil, b
+		}
+		return nil, err
+	}
+
+	outCh := make(chan llmrouter.Event)
+	go func() {
+		defer close(outCh)
+		defer cancel()
+		defer func() {
+			stopTimer(connect)
+			stopTimer(total)
+			stopTimer(idle)
+		}()
+
+		// fail reports the limit that fired, if any; plain cancellation by
+		// the caller ends the stream silently
+		fail := func() {
+			if err := breached(); err != nil {
+				select {
+				case outCh <- llmrouter.Event{Type: llmrouter.EventError, Error: err}:
+				case <-parent.Done():
+				}
+			}
+			// Don't leave the provider blocked on a send
+			go func() {
+				for range ch {
+				}
+			}()
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				fail()
+				return
+			case event, ok := <-ch:
+				if !ok {
+					return
+				}
+				data := event.Type != llmrouter.EventHeartbeat
+				if data {
+					stopTimer(connect)
+					stopTimer(idle)
+				}
+				select {
+				case outCh <- event:
+				case <-ctx.Done():
+					fail()
+					return
+				}
+				if event.Type == llmrouter.EventDone || event.Type == llmrouter.EventError {
+					stopTimer(total)
+					for event := range ch {
+						select {
+						case outCh <- event:
+						case <-parent.Done():
+							return
+						}
+					}
+					return
+				}
+				// Restart the idle clock once the event is delivered, so a
+				// slow reader doesn't count against the provider
+				if data {
+					idle = limitTimer(p.limits.Idle, fire(fmt.Errorf("%w: no data for %s", llmrouter.ErrIdleTimeout, p.limits.Idle)))
+				}
+			}
+		}
+	}()
+
+	return outCh, nil
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// pacedStream waits for each delay in turn and sends a content delta after
+// it, then done. It stops early if the context is canceled.
+func pacedStream(delays ...time.Duration) func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	return func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+		ch := make(chan llmrouter.Event)
+		go func() {
+			defer close(ch)
+			send := func(e llmrouter.Event) bool {
+				select {
+				case ch <- e:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			}
+			for _, d := range delays {
+				select {
+				case <-time.After(d):
+				case <-ctx.Done():
+					return
+				}
+				if !send(llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "x"}) {
+					return
+				}
+			}
+			send(llmrouter.Event{Type: llmrouter.EventDone, Response: textResponse("done")})
+		}()
+		return ch, nil
+	}
+}
+
+func TestStreamLimits(t *testing.T) {
+	limitErrs := []error{llmrouter.ErrConnectTimeout, llmrouter.ErrIdleTimeout, llmrouter.ErrStreamDurationExceeded}
+	tests := []struct {
+		name   string
+		limits StreamLimits
+		delays []time.Duration
+		deltas int
+		want   error
+	}{
+		{"connect", StreamLimits{Connect: 20 * time.Millisecond}, []time.Duration{time.Second}, 0, llmrouter.ErrConnectTimeout},
+		{"idle", StreamLimits{Connect: time.Second, Idle: 20 * time.Millisecond}, []time.Duration{0, time.Second}, 1, llmrouter.ErrIdleTimeout},
+		{"total", StreamLimits{Idle: time.Second, Total: 100 * time.Millisecond}, []time.Duration{0, 70 * time.Millisecond, 70 * time.Millisecond}, 2, llmrouter.ErrStreamDurationExceeded},
+		{"within limits", StreamLimits{Connect: time.Second, Idle: time.Second, Total: time.Second}, []time.Duration{0, time.Millisecond}, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewStreamLimitsMiddleware(tt.limits).Wrap(&stubProvider{stream: pacedStream(tt.delays...)})
+
+			ch, err := p.Stream(context.Background(), userRequest("hi"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			events := collect(ch)
+			if len(events) != tt.deltas+1 {
+				t.Fatalf("events = %+v, want %d deltas then the end", events, tt.deltas)
+			}
+			last := events[len(events)-1]
+
+			if tt.want == nil {
+				if last.Type != llmrouter.EventDone {
+					t.Errorf("last event = %+v, want done", last)
+				}
+				return
+			}
+			if last.Type != llmrouter.EventError {
+				t.Fatalf("last event = %+v, want an error", last)
+			}
+			for _, e := range limitErrs {
+				if errors.Is(last.Error, e) != (e == tt.want) {
+					t.Errorf("error = %v, want only %v", last.Error, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamLimitsConnectBeforeStreamReturns(t *testing.T) {
+	stub := &stubProvider{stream: func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	p := NewStreamLimitsMiddleware(StreamLimits{Connect: 10 * time.Millisecond}).Wrap(stub)
+
+	if _, err := p.Stream(context.Background(), userRequest("hi")); !errors.Is(err, llmrouter.ErrConnectTimeout) {
+		t.Errorf("err = %v, want ErrConnectTimeout", err)
+	}
+}
+
+func TestStreamLimitsIgnoreHeartbeats(t *testing.T) {
+	stub := &stubProvider{stream: func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+		ch := make(chan llmrouter.Event)
+		go func() {
+			defer close(ch)
+			for {
+				select {
+				case ch <- llmrouter.Event{Type: llmrouter.EventHeartbeat}:
+					time.Sleep(5 * time.Millisecond)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch, nil
+	}}
+	p := NewStreamLimitsMiddleware(StreamLimits{Connect: 30 * time.Millisecond}).Wrap(stub)
+
+	ch, err := p.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	last := events[len(events)-1]
+	if last.Type != llmrouter.EventError || !errors.Is(last.Error, llmrouter.ErrConnectTimeout) {
+		t.Errorf("last event = %+v, want ErrConnectTimeout despite the heartbeats", last)
+	}
+}
+
+func TestStreamLimitsCallerCancel(t *testing.T) {
+	p := NewStreamLimitsMiddleware(StreamLimits{Connect: time.Second, Idle: time.Second, Total: time.Second}).
+		Wrap(&stubProvider{stream: pacedStream(0, time.Hour)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.Stream(ctx, userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := <-ch; e.Type != llmrouter.EventContentDelta {
+		t.Fatalf("first event = %+v, want a delta", e)
+	}
+	cancel()
+	for e := range ch {
+		if e.Type == llmrouter.EventError {
+			t.Errorf("error event %v, want the stream to end silently on cancellation", e.Error)
+		}
+	}
+}
+
+func TestStreamLimitsCompleteUnaffected(t *testing.T) {
+	stub := &stubProvider{complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		time.Sleep(20 * time.Millisecond)
+		return textResponse("ok"), nil
+	}}
+	p := NewStreamLimitsMiddleware(StreamLimits{Connect: time.Millisecond, Total: time.Millisecond}).Wrap(stub)
+
+	if _, err := p.Complete(context.Background(), userRequest("hi")); err != nil {
+		t.Errorf("Complete err = %v, want no stream limits applied", err)
+	}
+}
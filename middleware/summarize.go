@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+const summaryPrompt = "Summarize the following conversation concisely. Preserve facts, decisions, names and open questions needed to continue it."
+
+// SummarizationMiddleware compacts long conversations by replacing older
+// turns with a summary generated by a separate provider
+type SummarizationMiddleware struct {
+	summarizer    llmrouter.Provider
+	keepRecent    int
+	triggerTokens int
+	counter       llmrouter.TokenCounter
+}
+
+// NewSummarizationMiddleware creates a new summarization middleware. When a
+// request's estimated prompt tokens exceed triggerTokens, all but the most
+// recent keepRecent non-system messages are replaced by a single system
+// message holding a summary produced by summarizer. The kept messages are
+// extended back to the start of a user turn, so an exchange is never split.
+// System prompts are kept.
+func NewSummarizationMiddleware(summarizer llmrouter.Provider, keepRecent int, triggerTokens int) *SummarizationMiddleware {
+	return &SummarizationMiddleware{
+		summarizer:    summarizer,
+		keepRecent:    keepRecent,
+		triggerTokens: triggerTokens,
+		counter:       llmrouter.EstimateTokens,
+	}
+}
+
+// WithCounter sets the token counter used to decide when to summarize
+func (m *SummarizationMiddleware) WithCounter(c llmrouter.TokenCounter) *SummarizationMiddleware {
+	m.counter = c
+	return m
+}
+
+// Wrap wraps a provider with history summarization
+func (m *SummarizationMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &summarizationProvider{
+		Provider: next,
+		m:        m,
+	}
+}
+
+type summarizationProvider struct {
+	llmrouter.Provider
+	m *SummarizationMiddleware
+}
+
+func (p *summarizationProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	req, err := p.m.compact(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Provider.Complete(ctx, req)
+}
+
+func (p *summarizationProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	req, err := p.m.compact(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Provider.Stream(ctx, req)
+}
+
+// compact returns req unchanged if it's under the trigger, otherwise a copy
+// with older turns replaced by a summary
+func (m *SummarizationMiddleware) compact(ctx context.Context, req *llmrouter.Request) (*llmrouter.Request, error) {
+	if llmrouter.EstimateRequestTokens(req, m.counter) <= m.triggerTokens {
+		return req, nil
+	}
+
+	var system, turns []llmrouter.Message
+	for _, msg := range req.Messages {
+		if msg.Role == llmrouter.RoleSystem {
+			system = append(system, msg)
+		} else {
+			turns = append(turns, msg)
+		}
+	}
+
+	cut := len(turns) - m.keepRecent
+	if cut <= 0 {
+		return req, nil
+	}
+	// Keep whole exchanges: the kept tail starts with a user turn, so tool
+	// results stay with their calls and no assistant turn leads
+	for cut > 0 && turns[cut].Role != llmrouter.RoleUser {
+		cut--
+	}
+	if cut == 0 {
+		return req, nil
+	}
+
+	var transcript strings.Builder
+	for _, msg := range turns[:cut] {
+		if text := msg.Text(); text != "" {
+			fmt.Fprintf(&transcript, "%s: %s\n", msg.Role, text)
+		}
+		for _, tc := range msg.ToolCalls {
+			fmt.Fprintf(&transcript, "%s: called %s(%s)\n", msg.Role, tc.Function.Name, tc.Function.Arguments)
+		}
+	}
+
+	resp, err := m.summarizer.Complete(ctx, &llmrouter.Request{
+		Messages: []llmrouter.Message{
+			{Role: llmrouter.RoleSystem, Content: summaryPrompt},
+			{Role: llmrouter.RoleUser, Content: transcript.String()},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("summarize history: %w", err)
+	}
+
+	var summary string
+	if len(resp.Choices) > 0 && resp.Choices[0].Message != nil {
+		summary = resp.Choices[0].Message.Content
+	}
+
+	messages := make([]llmrouter.Message, 0, len(system)+1+len(turns)-cut)
+	messages = append(messages, system...)
+	messages = append(messages, llmrouter.Message{
+		Role:    llmrouter.RoleSystem,
+		Content: "Conversation summary: " + summary,
+	})
+	messages = append(messages, turns[cut:]...)
+
+	compacted := *req
+	compacted.Messages = messages
+	return &compacted, nil
+}
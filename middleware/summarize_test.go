@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// summaryStub is a summarizer that replies with a fixed summary
+func summaryStub() *stubProvider {
+	return &stubProvider{complete: func(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+		return textResponse("the user asked about A and B"), nil
+	}}
+}
+
+func TestSummarizationCompactsHistory(t *testing.T) {
+	summarizer := summaryStub()
+	next := &stubProvider{}
+	p := NewSummarizationMiddleware(summarizer, 2, 10).Wrap(next)
+
+	req := &llmrouter.Request{Messages: []llmrouter.Message{
+		{Role: llmrouter.RoleSystem, Content: "be brief"},
+		{Role: llmrouter.RoleUser, Content: "tell me about A"},
+		{Role: llmrouter.RoleAssistant, Content: "A is a long story"},
+		{Role: llmrouter.RoleUser, Content: "and B?"},
+		{Role: llmrouter.RoleAssistant, Content: "B is another long story"},
+		{Role: llmrouter.RoleUser, Content: "thanks, and C?"},
+	}}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	// The kept tail is extended back to the user turn starting its exchange
+	got := next.lastCall().Messages
+	want := []llmrouter.Message{
+		{Role: llmrouter.RoleSystem, Content: "be brief"},
+		{Role: llmrouter.RoleSystem, Content: "Conversation summary: the user asked about A and B"},
+		{Role: llmrouter.RoleUser, Content: "and B?"},
+		{Role: llmrouter.RoleAssistant, Content: "B is another long story"},
+		{Role: llmrouter.RoleUser, Content: "thanks, and C?"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d messages, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Role != want[i].Role || got[i].Content != want[i].Content {
+			t.Errorf("message %d = %s %q, want %s %q", i, got[i].Role, got[i].Content, want[i].Role, want[i].Content)
+		}
+	}
+
+	transcript := summarizer.lastCall().Messages[1].Content
+	if transcript != "user: tell me about A\nassistant: A is a long story\n" {
+		t.Errorf("transcript = %q", transcript)
+	}
+	if len(req.Messages) != 6 {
+		t.Error("the caller's request was modified")
+	}
+}
+
+func TestSummarizationTranscriptIncludesToolCalls(t *testing.T) {
+	summarizer := summaryStub()
+	p := NewSummarizationMiddleware(summarizer, 1, 1).Wrap(&stubProvider{})
+
+	req := &llmrouter.Request{Messages: []llmrouter.Message{
+		{Role: llmrouter.RoleUser, Content: "weather?"},
+		{Role: llmrouter.RoleAssistant, ToolCalls: []llmrouter.ToolCall{{ID: "1", Type: "function", Function: llmrouter.FuncCall{Name: "weather", Arguments: `{"city":"Oslo"}`}}}},
+		{Role: llmrouter.RoleTool, ToolCallID: "1", Content: "rain"},
+		{Role: llmrouter.RoleAssistant, Content: "It's raining."},
+		{Role: llmrouter.RoleUser, Content: "thanks"},
+	}}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	transcript := summarizer.lastCall().Messages[1].Content
+	for _, line := range []string{"assistant: called weather({\"city\":\"Oslo\"})", "tool: rain", "assistant: It's raining."} {
+		if !strings.Contains(transcript, line) {
+			t.Errorf("transcript %q is missing %q", transcript, line)
+		}
+	}
+}
+
+func TestSummarizationBelowTrigger(t *testing.T) {
+	summarizer := summaryStub()
+	next := &stubProvider{}
+	p := NewSummarizationMiddleware(summarizer, 1, 1000).Wrap(next)
+
+	req := &llmrouter.Request{Messages: []llmrouter.Message{
+		{Role: llmrouter.RoleUser, Content: "hi"},
+		{Role: llmrouter.RoleAssistant, Content: "hello"},
+		{Role: llmrouter.RoleUser, Content: "bye"},
+	}}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if summarizer.callCount() != 0 {
+		t.Error("summarized a conversation under the trigger")
+	}
+	if next.lastCall() != req {
+		t.Error("request under the trigger was not passed through unchanged")
+	}
+}
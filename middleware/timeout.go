@@ -9,7 +9,9 @@ import (
 
 // TimeoutMiddleware adds timeout to requests
 type TimeoutMiddleware struct {
-	timeout time.Duration
+	timeout  time.Duration
+	base     time.Duration
+	perToken time.Duration
 }
 
 // NewTimeoutMiddleware creates a new timeout middleware
@@ -19,65 +21,66 @@ func NewTimeoutMiddleware(timeout time.Duration) *TimeoutMiddleware {
 	}
 }
 
+// WithAdaptiveTimeout scales the timeout with the requested token budget.
+// Requests that set MaxTokens get base + MaxTokens*perToken; requests without
+// MaxTokens keep the static timeout.
+func (m *TimeoutMiddleware) WithAdaptiveTimeout(perToken time.Duration, base time.Duration) *TimeoutMiddleware {
+	m.perToken = perToken
+	m.base = base
+	return m
+}
+
 // Wrap wraps a provider with timeout
 func (m *TimeoutMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
 	return &timeoutProvider{
 		Provider: next,
 		timeout:  m.timeout,
+		base:     m.base,
+		perToken: m.perToken,
 	}
 }
 
 type timeoutProvider struct {
 	llmrouter.Provider
-	timeout time.Duration
+	timeout  time.Duration
+	base     time.Duration
+	perToken time.Duration
+}
+
+// timeoutFor returns the effective timeout for a request: a per-request
+// timeout in metadata, else the middleware's own
+func (p *timeoutProvider) timeoutFor(req *llmrouter.Request) time.Duration {
+	if d, ok := req.MetadataDuration(llmrouter.MetadataTimeout); ok && d > 0 {
+		return d
+	}
+	if p.perToken > 0 && req.MaxTokens != nil {
+		return p.base + time.Duration(*req.MaxTokens)*p.perToken
+	}
+	return p.timeout
 }
 
+// Complete and Stream leave the deadline to the router when the provider has
+// its own timeout, which it applies to each call beneath the middleware
 func (p *timeoutProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
-	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	if _, ok := llmrouter.ProviderTimeout(ctx); ok {
+		return p.Provider.Complete(ctx, req)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeoutFor(req))
 	defer cancel()
 
 	return p.Provider.Complete(ctx, req)
 }
 
 func (p *timeoutProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
-	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	if _, ok := llmrouter.ProviderTimeout(ctx); ok {
+		return p.Provider.Stream(ctx, req)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeoutFor(req))
 
 	ch, err := p.Provider.Stream(ctx, req)
 	if err != nil {
 		cancel()
 		return nil, err
 	}
-
-	// Wrap the channel to handle context cancellation
-	outCh := make(chan llmrouter.Event)
-	go func() {
-		defer close(outCh)
-		defer cancel()
-
-		for {
-			select {
-			case <-ctx.Done():
-				outCh <- llmrouter.Event{
-					Type:  llmrouter.EventError,
-					Error: ctx.Err(),
-				}
-				return
-			case event, ok := <-ch:
-				if !ok {
-					return
-				}
-				select {
-				case outCh <- event:
-				case <-ctx.Done():
-					outCh <- llmrouter.Event{
-						Type:  llmrouter.EventError,
-						Error: ctx.Err(),
-					}
-					return
-				}
-			}
-		}
-	}()
-
-	return outCh, nil
+	return llmrouter.WatchStream(ctx, cancel, ch), nil
 }
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestAdaptiveTimeoutScalesWithMaxTokens(t *testing.T) {
+	m := NewTimeoutMiddleware(time.Minute).WithAdaptiveTimeout(10*time.Millisecond, 5*time.Second)
+	p := m.Wrap(&stubProvider{}).(*timeoutProvider)
+
+	tests := []struct {
+		name      string
+		maxTokens *int
+		want      time.Duration
+	}{
+		{"no max tokens keeps the static timeout", nil, time.Minute},
+		{"small budget", intPtr(100), 6 * time.Second},
+		{"large budget", intPtr(1000), 15 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := userRequest("hi")
+			req.MaxTokens = tt.maxTokens
+			if got := p.timeoutFor(req); got != tt.want {
+				t.Errorf("timeoutFor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptiveTimeoutSetsDeadline(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewTimeoutMiddleware(time.Minute).WithAdaptiveTimeout(time.Second, 0).Wrap(stub)
+
+	req := userRequest("hi")
+	req.MaxTokens = intPtr(3)
+	start := time.Now()
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline, ok := stub.ctxs[0].Deadline()
+	if !ok {
+		t.Fatal("context has no deadline")
+	}
+	if d := deadline.Sub(start); d < 2*time.Second || d > 4*time.Second {
+		t.Errorf("deadline in %v, want about 3s", d)
+	}
+}
+
+func TestTimeoutDefersToProviderTimeout(t *testing.T) {
+	timed := &stubProvider{name: "timed"}
+	untimed := &stubProvider{name: "untimed"}
+	r := llmrouter.New(
+		llmrouter.WithProvider("timed", timed),
+		llmrouter.WithProvider("untimed", untimed),
+		llmrouter.WithModelMapping("timed-model", "timed"),
+		llmrouter.WithModelMapping("untimed-model", "untimed"),
+		llmrouter.WithMiddleware(NewTimeoutMiddleware(time.Hour)),
+		llmrouter.WithProviderTimeout("timed", time.Second),
+	)
+
+	start := time.Now()
+	for _, model := range []string{"timed-model", "untimed-model"} {
+		req := userRequest("hi")
+		req.Model = model
+		if _, err := r.Complete(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline, ok := timed.ctxs[0].Deadline()
+	if !ok || deadline.Sub(start) > 2*time.Second {
+		t.Errorf("timed provider deadline = %v, want the 1s provider timeout", deadline.Sub(start))
+	}
+	deadline, ok = untimed.ctxs[0].Deadline()
+	if !ok || deadline.Sub(start) < 59*time.Minute {
+		t.Errorf("untimed provider deadline = %v, want the 1h middleware timeout", deadline.Sub(start))
+	}
+}
+
+func TestTimeoutOverriddenByMetadata(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  time.Duration
+	}{
+		{"duration", 3 * time.Second, 3 * time.Second},
+		{"string", "90s", 90 * time.Second},
+		{"invalid keeps the default", "later", time.Minute},
+		{"zero keeps the default", time.Duration(0), time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubProvider{}
+			p := NewTimeoutMiddleware(time.Minute).Wrap(stub)
+
+			req := userRequest("hi")
+			req.Metadata = map[string]any{llmrouter.MetadataTimeout: tt.value}
+			start := time.Now()
+			if _, err := p.Complete(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+
+			deadline, ok := stub.ctxs[0].Deadline()
+			if d := deadline.Sub(start); !ok || d < tt.want || d > tt.want+time.Second {
+				t.Errorf("deadline in %v, want %v", d, tt.want)
+			}
+		})
+	}
+}
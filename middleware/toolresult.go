@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"unicode/utf8"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// ToolResultGuardMiddleware shrinks oversized tool results before they are
+// sent back to the model
+type ToolResultGuardMiddleware struct {
+	maxBytes   int
+	onOversize func(string) string
+}
+
+// NewToolResultGuardMiddleware creates a middleware that passes the text of
+// every tool message larger than maxBytes through onOversize, e.g. to
+// truncate or summarize it. The result replaces the message's text; image
+// parts are kept. A nil onOversize truncates to maxBytes. A maxBytes of zero
+// or less disables the guard.
+func NewToolResultGuardMiddleware(maxBytes int, onOversize func(string) string) *ToolResultGuardMiddleware {
+	if onOversize == nil {
+		onOversize = func(s string) string {
+			return TruncateText(s, maxBytes)
+		}
+	}
+	return &ToolResultGuardMiddleware{
+		maxBytes:   maxBytes,
+		onOversize: onOversize,
+	}
+}
+
+// Wrap wraps a provider with the tool result guard
+func (m *ToolResultGuardMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	if m.maxBytes <= 0 {
+		return next
+	}
+	return &toolResultGuardProvider{
+		Provider:   next,
+		maxBytes:   m.maxBytes,
+		onOversize: m.onOversize,
+	}
+}
+
+type toolResultGuardProvider struct {
+	llmrouter.Provider
+	maxBytes   int
+	onOversize func(string) string
+}
+
+func (p *toolResultGuardProvider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	return p.Provider.Complete(ctx, p.guard(req))
+}
+
+func (p *toolResultGuardProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	return p.Provider.Stream(ctx, p.guard(req))
+}
+
+// guard returns req with oversized tool results replaced, copying the
+// messages only if something changed
+func (p *toolResultGuardProvider) guard(req *llmrouter.Request) *llmrouter.Request {
+	var msgs []llmrouter.Message
+	for i, msg := range req.Messages {
+		if msg.Role != llmrouter.RoleTool {
+			continue
+		}
+		text := msg.Text()
+		if len(text) <= p.maxBytes {
+			continue
+		}
+
+		if msgs == nil {
+			msgs = append([]llmrouter.Message(nil), req.Messages...)
+		}
+		msg.Content = p.onOversize(text)
+		msg.ContentParts = nil
+		for _, part := range req.Messages[i].ContentParts {
+			if part.Type != "text" {
+				msg.ContentParts = append(msg.ContentParts, part)
+			}
+		}
+		msgs[i] = msg
+	}
+	if msgs == nil {
+		return req
+	}
+
+	guarded := *req
+	guarded.Messages = msgs
+	return &guarded
+}
+
+// truncatedNote marks text cut by TruncateText
+const truncatedNote = "\n[truncated]"
+
+// TruncateText cuts s to at most maxBytes, on a rune boundary, ending with a
+// note so the model knows the result is incomplete. If maxBytes leaves no
+// room for the note, s is cut without it.
+func TruncateText(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	if maxBytes <= 0 {
+		return ""
+	}
+
+	note := truncatedNote
+	if maxBytes <= len(note) {
+		note = ""
+	}
+	cut := maxBytes - len(note)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + note
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{"fits", "short", 10, "short"},
+		{"cut with note", strings.Repeat("a", 50), 20, strings.Repeat("a", 8) + "\n[truncated]"},
+		{"rune boundary", "ééééééééééé", 15, "é\n[truncated]"},
+		{"no room for the note", "abcdefghijklmnop", 5, "abcde"},
+		{"zero", "abc", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateText(tt.in, tt.max)
+			if got != tt.want {
+				t.Errorf("TruncateText = %q, want %q", got, tt.want)
+			}
+			if len(got) > max(tt.max, 0) && tt.max < len(tt.in) {
+				t.Errorf("result is %d bytes, want at most %d", len(got), tt.max)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("result %q is not valid UTF-8", got)
+			}
+		})
+	}
+}
+
+// toolConversation builds a conversation ending in a tool result
+func toolConversation(result llmrouter.Message) *llmrouter.Request {
+	result.Role = llmrouter.RoleTool
+	result.ToolCallID = "call_1"
+	return &llmrouter.Request{Messages: []llmrouter.Message{
+		{Role: llmrouter.RoleUser, Content: strings.Repeat("long question ", 20)},
+		{Role: llmrouter.RoleAssistant, ToolCalls: []llmrouter.ToolCall{{ID: "call_1", Type: "function", Function: llmrouter.FuncCall{Name: "search"}}}},
+		result,
+	}}
+}
+
+func TestToolResultGuardTruncates(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewToolResultGuardMiddleware(100, nil).Wrap(stub)
+
+	huge := strings.Repeat("row,", 1000)
+	req := toolConversation(llmrouter.Message{Content: huge})
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := stub.lastCall().Messages
+	if got := sent[2].Content; len(got) > 100 || !strings.HasSuffix(got, "[truncated]") || !strings.HasPrefix(huge, strings.TrimSuffix(got, truncatedNote)) {
+		t.Errorf("tool result = %q (%d bytes), want it truncated to 100 bytes", got, len(got))
+	}
+	if sent[2].ToolCallID != "call_1" {
+		t.Errorf("tool call ID = %q, want it kept", sent[2].ToolCallID)
+	}
+	if sent[0].Content != req.Messages[0].Content {
+		t.Error("user message was changed, want only tool results guarded")
+	}
+	if req.Messages[2].Content != huge {
+		t.Error("caller's tool message was modified")
+	}
+
+	// Streams are guarded too
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	if got := stub.lastCall().Messages[2].Content; len(got) > 100 {
+		t.Errorf("streamed tool result is %d bytes, want at most 100", len(got))
+	}
+}
+
+func TestToolResultGuardSmallResult(t *testing.T) {
+	stub := &stubProvider{}
+	p := NewToolResultGuardMiddleware(100, nil).Wrap(stub)
+
+	req := toolConversation(llmrouter.Message{Content: "sunny"})
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if stub.lastCall() != req {
+		t.Error("request was copied although no tool result was oversized")
+	}
+}
+
+func TestToolResultGuardHandler(t *testing.T) {
+	stub := &stubProvider{}
+	var got string
+	summarize := func(s string) string {
+		got = s
+		return "summary of " + s[:5]
+	}
+	p := NewToolResultGuardMiddleware(10, summarize).Wrap(stub)
+
+	image := llmrouter.ContentPart{Type: "image_url", ImageURL: &llmrouter.ImageURL{URL: "https://example.com/chart.png"}}
+	req := toolConversation(llmrouter.Message{ContentParts: []llmrouter.ContentPart{
+		{Type: "text", Text: "first part"},
+		image,
+		{Type: "text", Text: "second part"},
+	}})
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := req.Messages[2].Text(); got != want {
+		t.Errorf("handler got %q, want the result's full text", got)
+	}
+	sent := stub.lastCall().Messages[2]
+	if sent.Content != "summary of first" {
+		t.Errorf("content = %q, want the handler's result", sent.Content)
+	}
+	if len(sent.ContentParts) != 1 || sent.ContentParts[0].Type != "image_url" {
+		t.Errorf("parts = %+v, want only the image kept", sent.ContentParts)
+	}
+}
+
+func TestToolResultGuardDisabled(t *testing.T) {
+	stub := &stubProvider{}
+	for _, maxBytes := range []int{0, -1} {
+		if p := NewToolResultGuardMiddleware(maxBytes, nil).Wrap(stub); p != llmrouter.Provider(stub) {
+			t.Errorf("maxBytes %d: Wrap returned %T, want the provider unwrapped", maxBytes, p)
+		}
+	}
+}
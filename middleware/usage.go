@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// UsageEstimateMiddleware emits running completion-token estimates while streaming
+type UsageEstimateMiddleware struct {
+	interval time.Duration
+	counter  llmrouter.TokenCounter
+}
+
+// NewUsageEstimateMiddleware creates a middleware that emits EventUsageUpdate
+// events at most once per interval while content is streaming. The estimates
+// are approximate; the final usage still arrives on the EventDone response.
+func NewUsageEstimateMiddleware(interval time.Duration) *UsageEstimateMiddleware {
+	return &UsageEstimateMiddleware{
+		interval: interval,
+		counter:  llmrouter.EstimateTokens,
+	}
+}
+
+// WithCounter sets the token counter used for estimates
+func (m *UsageEstimateMiddleware) WithCounter(c llmrouter.TokenCounter) *UsageEstimateMiddleware {
+	m.counter = c
+	return m
+}
+
+// Wrap wraps a provider with usage estimation
+func (m *UsageEstimateMiddleware) Wrap(next llmrouter.Provider) llmrouter.Provider {
+	return &usageEstimateProvider{
+		Provider: next,
+		interval: m.interval,
+		counter:  m.counter,
+	}
+}
+
+type usageEstimateProvider struct {
+	llmrouter.Provider
+	interval time.Duration
+	counter  llmrouter.TokenCounter
+}
+
+func (p *usageEstimateProvider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	ch, err := p.Provider.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	promptTokens := llmrouter.EstimateRequestTokens(req, p.counter)
+
+	outCh := make(chan llmrouter.Event)
+	go func() {
+		defer close(outCh)
+
+		// Deltas are counted as they arrive, across all choices, rather
+		// than recounting the whole reply each time
+		completion := 0
+		lastCount := 0
+		var lastEmit time.Time
+
+		send := func(event llmrouter.Event) bool {
+			select {
+			case outCh <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for event := range ch {
+			if !send(event) {
+				return
+			}
+
+			if event.Type != llmrouter.EventContentDelta {
+				continue
+			}
+			completion += p.counter(event.Content)
+
+			if completion <= lastCount || time.Since(lastEmit) < p.interval {
+				continue
+			}
+			lastEmit = time.Now()
+			lastCount = completion
+
+			usage := llmrouter.Event{
+				Type: llmrouter.EventUsageUpdate,
+				Usage: &llmrouter.Usage{
+					PromptTokens:     promptTokens,
+					CompletionTokens: completion,
+					TotalTokens:      promptTokens + completion,
+				},
+			}
+			if !send(usage) {
+				return
+			}
+		}
+	}()
+
+	return outCh, nil
+}
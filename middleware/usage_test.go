@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestUsageEstimateEmitsIncreasingUpdates(t *testing.T) {
+	stub := &stubProvider{
+		stream: func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+			return eventStream(
+				llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "one "},
+				llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "two three "},
+				llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "four five six"},
+				llmrouter.Event{Type: llmrouter.EventDone, Response: textResponse("one two three four five six")},
+			), nil
+		},
+	}
+	counter := func(s string) int { return len(s) }
+	p := NewUsageEstimateMiddleware(0).WithCounter(counter).Wrap(stub)
+
+	ch, err := p.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var updates []*llmrouter.Usage
+	var done bool
+	for e := range ch {
+		switch e.Type {
+		case llmrouter.EventUsageUpdate:
+			updates = append(updates, e.Usage)
+		case llmrouter.EventDone:
+			done = true
+		}
+	}
+
+	if !done {
+		t.Error("done event was not forwarded")
+	}
+	if len(updates) != 3 {
+		t.Fatalf("got %d usage updates, want 3", len(updates))
+	}
+	want := []int{4, 14, 27}
+	for i, u := range updates {
+		if u.CompletionTokens != want[i] {
+			t.Errorf("update %d: completion tokens = %d, want %d", i, u.CompletionTokens, want[i])
+		}
+		if u.TotalTokens != u.PromptTokens+u.CompletionTokens {
+			t.Errorf("update %d: total %d != prompt %d + completion %d", i, u.TotalTokens, u.PromptTokens, u.CompletionTokens)
+		}
+		if i > 0 && u.TotalTokens <= updates[i-1].TotalTokens {
+			t.Errorf("update %d: total %d did not increase from %d", i, u.TotalTokens, updates[i-1].TotalTokens)
+		}
+	}
+}
+
+func TestUsageEstimateRespectsInterval(t *testing.T) {
+	stub := &stubProvider{
+		stream: func(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+			return eventStream(
+				llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "a"},
+				llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "b"},
+				llmrouter.Event{Type: llmrouter.EventContentDelta, Content: "c"},
+			), nil
+		},
+	}
+	p := NewUsageEstimateMiddleware(time.Hour).Wrap(stub)
+
+	ch, err := p.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates := 0
+	for e := range ch {
+		if e.Type == llmrouter.EventUsageUpdate {
+			updates++
+		}
+	}
+	if updates != 1 {
+		t.Errorf("got %d usage updates within one interval, want 1", updates)
+	}
+}
@@ -0,0 +1,45 @@
+package llmrouter
+
+// servedModel returns the model provider will serve for the requested
+// model: the model itself, or the provider's default for "" and the
+// provider's own name
+func servedModel(provider Provider, model string) string {
+	if model != "" && model != provider.Name() {
+		return model
+	}
+	if dm, ok := provider.(DefaultModeler); ok {
+		return dm.DefaultModel()
+	}
+	return model
+}
+
+// withModelDefaults returns req with any sampling parameters it leaves unset
+// filled in from the router's defaults for the model provider will serve.
+// Provider defaults only apply to what is still unset afterwards.
+func (r *Router) withModelDefaults(provider Provider, req *Request) *Request {
+	defaults, ok := r.modelDefaults[servedModel(provider, req.Model)]
+	if !ok {
+		return req
+	}
+
+	merged := *req
+	if merged.Temperature == nil {
+		merged.Temperature = defaults.Temperature
+	}
+	if merged.TopP == nil {
+		merged.TopP = defaults.TopP
+	}
+	if merged.MaxTokens == nil {
+		merged.MaxTokens = defaults.MaxTokens
+	}
+	if merged.N == nil {
+		merged.N = defaults.N
+	}
+	if merged.Stop == nil {
+		merged.Stop = defaults.Stop
+	}
+	if merged.ServiceTier == "" {
+		merged.ServiceTier = defaults.ServiceTier
+	}
+	return &merged
+}
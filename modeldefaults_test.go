@@ -0,0 +1,116 @@
+package llmrouter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// defaultsRouter routes "codestral" and "gpt-4o" to one stub, with
+// temperature 0 and a stop sequence by default for the coding model
+func defaultsRouter(stub *stubProvider, opts ...Option) *Router {
+	return New(append([]Option{
+		WithProvider("stub", stub),
+		WithModelDefaults(map[string]Request{
+			"codestral": {Temperature: floatPtr(0), Stop: []string{"```"}, Model: "ignored"},
+		}),
+	}, opts...)...)
+}
+
+func TestModelDefaults(t *testing.T) {
+	stub := &stubProvider{models: []string{"codestral", "gpt-4o"}}
+	r := defaultsRouter(stub)
+
+	req := userRequest("write a function")
+	req.Model = "codestral"
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	got := stub.lastCall()
+	if got.Temperature == nil || *got.Temperature != 0 || !reflect.DeepEqual(got.Stop, []string{"```"}) {
+		t.Errorf("request = temperature %v, stop %v; want the coding model's defaults", got.Temperature, got.Stop)
+	}
+	if got.Model != "codestral" {
+		t.Errorf("model = %q, want fields other than sampling ignored", got.Model)
+	}
+	if req.Temperature != nil || req.Stop != nil {
+		t.Error("caller's request was modified")
+	}
+
+	req = userRequest("write a poem")
+	req.Model = "gpt-4o"
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := stub.lastCall(); got.Temperature != nil || got.Stop != nil {
+		t.Errorf("gpt-4o request = temperature %v, stop %v; want no defaults", got.Temperature, got.Stop)
+	}
+}
+
+func TestModelDefaultsRequestWins(t *testing.T) {
+	stub := &stubProvider{models: []string{"codestral"}}
+	r := defaultsRouter(stub)
+
+	req := userRequest("write a function")
+	req.Model = "codestral"
+	req.Temperature = floatPtr(0.7)
+	req.Stream = true
+	ch, err := r.Route(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+
+	got := stub.lastCall()
+	if got.Temperature == nil || *got.Temperature != 0.7 {
+		t.Errorf("temperature = %v, want the request's 0.7", got.Temperature)
+	}
+	if !reflect.DeepEqual(got.Stop, []string{"```"}) {
+		t.Errorf("stop = %v, want the default for the field the request left unset", got.Stop)
+	}
+}
+
+func TestModelDefaultsByProviderName(t *testing.T) {
+	stub := &stubProvider{model: "codestral", models: []string{"codestral"}}
+	r := defaultsRouter(stub)
+
+	// Routed by provider name, the provider serves its default model
+	req := userRequest("write a function")
+	req.Model = "stub"
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := stub.lastCall().Temperature; got == nil || *got != 0 {
+		t.Errorf("temperature = %v, want the default model's 0", got)
+	}
+}
+
+func TestModelDefaultsFallback(t *testing.T) {
+	primary := &stubProvider{
+		name:   "primary",
+		models: []string{"gpt-4o"},
+		complete: func(ctx context.Context, req *Request) (*Response, error) {
+			return nil, ErrProviderError
+		},
+	}
+	coder := &stubProvider{name: "coder", model: "codestral", models: []string{"codestral"}}
+	r := New(
+		WithProvider("primary", primary),
+		WithProvider("coder", coder),
+		WithFallback("coder"),
+		WithModelDefaults(map[string]Request{"codestral": {Temperature: floatPtr(0)}}),
+	)
+
+	// The fallback request has no model, so the fallback's default applies
+	req := userRequest("write a function")
+	req.Model = "gpt-4o"
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := primary.lastCall().Temperature; got != nil {
+		t.Errorf("primary temperature = %v, want no defaults for gpt-4o", *got)
+	}
+	if got := coder.lastCall().Temperature; got == nil || *got != 0 {
+		t.Errorf("fallback temperature = %v, want the fallback model's 0", got)
+	}
+}
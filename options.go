@@ -1,5 +1,7 @@
 package llmrouter
 
+import "time"
+
 // Option configures the Router
 type Option func(*Router)
 
@@ -17,6 +19,17 @@ func WithModelMapping(model, provider string) Option {
 	}
 }
 
+// WithModelPattern routes models matching a glob pattern (path.Match syntax,
+// e.g. "gpt-*" or "claude-*"; "*" does not cross "/") to a provider.
+// Patterns are checked after exact model mappings and provider names, before
+// scanning provider model lists. When several patterns match, the one added
+// first wins. Malformed patterns never match; Validate reports them.
+func WithModelPattern(pattern, provider string) Option {
+	return func(r *Router) {
+		r.patterns = append(r.patterns, modelPattern{pattern: pattern, provider: provider})
+	}
+}
+
 // WithFallback sets fallback providers in priority order
 func WithFallback(providers ...string) Option {
 	return func(r *Router) {
@@ -24,6 +37,157 @@ func WithFallback(providers ...string) Option {
 	}
 }
 
+// WithAllowedModels restricts the router to the given models. Requests for any
+// other model fail with ErrModelNotAllowed, regardless of provider support.
+// Fallback providers are only used if their default model is allowed.
+func WithAllowedModels(models ...string) Option {
+	return func(r *Router) {
+		if r.allowed == nil {
+			r.allowed = make(map[string]bool, len(models))
+		}
+		for _, m := range models {
+			r.allowed[m] = true
+		}
+	}
+}
+
+// WithStreamFallbackToComplete makes streaming requests fall back to a
+// non-streaming Complete call, emulated as a single-chunk stream, when the
+// provider's stream fails to establish
+func WithStreamFallbackToComplete() Option {
+	return func(r *Router) {
+		r.streamToComplete = true
+	}
+}
+
+// WithErrorOnEmptyCompletion makes Complete fail with ErrEmptyCompletion when
+// the reply has neither content nor tool calls. Such replies are otherwise
+// returned as-is, since an empty answer can be valid. Fallback providers are
+// tried as for any other error.
+func WithErrorOnEmptyCompletion() Option {
+	return func(r *Router) {
+		r.errorOnEmpty = true
+	}
+}
+
+// WithStripStopSequences strips a stop sequence from the end of the reply
+// content. Providers differ on whether the matched sequence is included; with
+// this option it never is. Streams hold back the last few bytes of content
+// until they can tell whether it is a stop sequence.
+func WithStripStopSequences() Option {
+	return func(r *Router) {
+		r.stripStops = true
+	}
+}
+
+// WithStickyRouting pins sessions to a provider. When several providers can
+// serve a model, requests with the same non-empty session key, as returned
+// by sessionKey, always go to the same one, e.g. for prompt cache locality.
+// Requests with an empty key, and models with an explicit mapping (see
+// WithModelMapping), are routed as usual.
+//
+//	llmrouter.WithStickyRouting(func(req *llmrouter.Request) string {
+//	    return req.MetadataString(llmrouter.MetadataConversationID)
+//	})
+func WithStickyRouting(sessionKey func(*Request) string) Option {
+	return func(r *Router) {
+		r.sessionKey = sessionKey
+	}
+}
+
+// WithStats enables per-provider request, error and token counters,
+// readable via Router.Stats
+func WithStats() Option {
+	return func(r *Router) {
+		r.stats = newStatsRecorder()
+	}
+}
+
+// WithProviderTimeout bounds every call to the named provider (as returned
+// by Provider.Name) to d. The deadline applies to each attempt, beneath
+// RetryMiddleware, rather than to all attempts together. It takes precedence
+// over TimeoutMiddleware, which defers to it, so providers behind a shared
+// middleware chain can still have different deadlines. Config files set it
+// from the provider's timeout.
+func WithProviderTimeout(name string, d time.Duration) Option {
+	return func(r *Router) {
+		if r.timeouts == nil {
+			r.timeouts = make(map[string]time.Duration)
+		}
+		r.timeouts[name] = d
+	}
+}
+
+// WithDefaultTools makes tools available to every request. They are merged
+// into Request.Tools before dispatch; a request tool with the same function
+// name takes precedence.
+func WithDefaultTools(tools ...Tool) Option {
+	return func(r *Router) {
+		r.defaultTools = append(r.defaultTools, tools...)
+	}
+}
+
+// WithModelDefaults sets per-model sampling defaults, keyed by model name.
+// Temperature, TopP, MaxTokens, N, Stop and ServiceTier are taken from the
+// entry for the model that serves the request when the request leaves them
+// unset, ahead of the provider's own defaults. Requests without a model, or
+// routed by provider name, use the provider's default model (see
+// DefaultModeler), and so do fallbacks. Other fields are ignored.
+//
+//	zero := 0.0
+//	llmrouter.WithModelDefaults(map[string]llmrouter.Request{
+//	    "codestral-latest": {Temperature: &zero},
+//	})
+func WithModelDefaults(defaults map[string]Request) Option {
+	return func(r *Router) {
+		if r.modelDefaults == nil {
+			r.modelDefaults = make(map[string]Request, len(defaults))
+		}
+		for model, d := range defaults {
+			r.modelDefaults[model] = d
+		}
+	}
+}
+
+// WithTokenBudget caps the total tokens the router may use, as reported in
+// Usage.TotalTokens. Once the cap is reached, requests fail with
+// ErrBudgetExceeded; requests already in flight can overshoot it. Streams
+// are charged only if the provider reports their usage, embeddings are
+// charged too, and cache hits are free. Image, transcription and speech
+// requests report no usage, so they are checked against the budget but not
+// charged. Clones share the budget; see WithFreshBudgets.
+func WithTokenBudget(limit int) Option {
+	return func(r *Router) {
+		r.tokenBudget = newTokenBudget(limit)
+	}
+}
+
+// WithCostBudget is like WithTokenBudget but caps spend as priced by cost,
+// e.g. in dollars
+func WithCostBudget(limit float64, cost CostFunc) Option {
+	return func(r *Router) {
+		r.costBudget = newCostBudget(limit, cost)
+	}
+}
+
+// WithFreshBudgets gives the router unspent budgets with the same limits,
+// e.g. so a clone for a new tenant doesn't share the original's spend
+func WithFreshBudgets() Option {
+	return func(r *Router) {
+		r.tokenBudget = r.tokenBudget.fresh()
+		r.costBudget = r.costBudget.fresh()
+	}
+}
+
+// WithRequestRewriter adds functions that rewrite each request before its
+// provider is chosen, so they can route it elsewhere, e.g. by changing the
+// model. Rewriters run in the order added.
+func WithRequestRewriter(fns ...RequestRewriter) Option {
+	return func(r *Router) {
+		r.rewriters = append(r.rewriters, fns...)
+	}
+}
+
 // WithMiddleware adds middleware to the processing chain.
 // Use this with middleware from the middleware package:
 //
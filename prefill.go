@@ -0,0 +1,25 @@
+package llmrouter
+
+// PrefillInstruction returns msgs with an instruction to begin the reply with
+// prefill prepended to the system prompt. Providers without native assistant
+// prefill use this to approximate it. msgs is not modified.
+func PrefillInstruction(msgs []Message, prefill string) []Message {
+	if prefill == "" {
+		return msgs
+	}
+
+	instruction := "Begin your response with exactly the following text, then continue naturally: " + prefill
+
+	result := make([]Message, 0, len(msgs)+1)
+	for i, msg := range msgs {
+		if msg.Role == RoleSystem {
+			msg.Content = instruction + "\n\n" + msg.Content
+			result = append(result, msgs[:i]...)
+			result = append(result, msg)
+			return append(result, msgs[i+1:]...)
+		}
+	}
+
+	result = append(result, Message{Role: RoleSystem, Content: instruction})
+	return append(result, msgs...)
+}
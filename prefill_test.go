@@ -0,0 +1,29 @@
+package llmrouter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrefillInstruction(t *testing.T) {
+	msgs := []Message{
+		{Role: RoleSystem, Content: "be brief"},
+		{Role: RoleUser, Content: "hi"},
+	}
+	got := PrefillInstruction(msgs, "Dear")
+	if len(got) != 2 || !strings.HasPrefix(got[0].Content, "Begin your response with exactly the following text") || !strings.HasSuffix(got[0].Content, "Dear\n\nbe brief") {
+		t.Errorf("messages = %+v, want the instruction prepended to the system prompt", got)
+	}
+	if msgs[0].Content != "be brief" {
+		t.Error("input messages were modified")
+	}
+
+	got = PrefillInstruction(msgs[1:], "Dear")
+	if len(got) != 2 || got[0].Role != RoleSystem || got[1].Content != "hi" {
+		t.Errorf("messages = %+v, want a system prompt added", got)
+	}
+
+	if got := PrefillInstruction(msgs, ""); len(got) != 2 || got[0].Content != "be brief" {
+		t.Error("empty prefill changed the messages")
+	}
+}
@@ -22,7 +22,33 @@ type Provider interface {
 	SupportsTools() bool
 }
 
+// DefaultModeler is implemented by providers that report the model they
+// serve when a request names no model or the provider itself
+type DefaultModeler interface {
+	DefaultModel() string
+}
+
 // Middleware wraps a Provider with additional functionality
 type Middleware interface {
 	Wrap(next Provider) Provider
 }
+
+// ImageGenerator is implemented by providers that support image generation
+type ImageGenerator interface {
+	GenerateImage(ctx context.Context, req *ImageRequest) (*ImageResponse, error)
+}
+
+// Transcriber is implemented by providers that support speech-to-text
+type Transcriber interface {
+	Transcribe(ctx context.Context, req *TranscribeRequest) (*TranscribeResponse, error)
+}
+
+// Speaker is implemented by providers that support text-to-speech
+type Speaker interface {
+	Synthesize(ctx context.Context, req *SpeechRequest) (*SpeechResponse, error)
+}
+
+// Embedder is implemented by providers that support text embeddings
+type Embedder interface {
+	Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error)
+}
@@ -38,15 +38,8 @@ func convertMessages(msgs []llmrouter.Message) ([]anthropic.MessageParam, string
 							))
 						}
 					case "document":
-						if p.Document != nil && p.Document.Base64 != "" {
-							blocks = append(blocks, anthropic.DocumentBlockParam{
-								Type: anthropic.F(anthropic.DocumentBlockParamTypeDocument),
-								Source: anthropic.F(anthropic.Base64PDFSourceParam{
-									Type:      anthropic.F(anthropic.Base64PDFSourceTypeBase64),
-									MediaType: anthropic.F(anthropic.Base64PDFSourceMediaTypeApplicationPDF),
-									Data:      anthropic.F(p.Document.Base64),
-								}),
-							})
+						if block, ok := convertDocument(p.Document); ok {
+							blocks = append(blocks, block)
 						}
 					}
 				}
@@ -78,15 +71,132 @@ func convertMessages(msgs []llmrouter.Message) ([]anthropic.MessageParam, string
 
 		case llmrouter.RoleTool:
 			// Tool result message
-			messages = append(messages, anthropic.NewUserMessage(
-				anthropic.NewToolResultBlock(msg.ToolCallID, msg.Content, false),
-			))
+			messages = append(messages, anthropic.NewUserMessage(convertToolResult(msg)))
+		}
+
+		if msg.CacheHint && msg.Role != llmrouter.RoleSystem {
+			markCacheBreakpoint(messages)
 		}
 	}
 
 	return messages, systemPrompt
 }
 
+// convertDocument converts a document to a document block, from base64 data
+// (a PDF unless MediaType says otherwise) or a URL
+func convertDocument(doc *llmrouter.Document) (anthropic.DocumentBlockParam, bool) {
+	block := anthropic.DocumentBlockParam{
+		Type: anthropic.F(anthropic.DocumentBlockParamTypeDocument),
+	}
+	switch {
+	case doc == nil:
+		return block, false
+	case doc.Base64 != "":
+		mediaType := anthropic.Base64PDFSourceMediaTypeApplicationPDF
+		if doc.MediaType != "" {
+			mediaType = anthropic.Base64PDFSourceMediaType(doc.MediaType)
+		}
+		block.Source = anthropic.F(anthropic.Base64PDFSourceParam{
+			Type:      anthropic.F(anthropic.Base64PDFSourceTypeBase64),
+			MediaType: anthropic.F(mediaType),
+			Data:      anthropic.F(doc.Base64),
+		})
+	case doc.URI != "":
+		// The SDK only models base64 sources
+		block.Source = anthropic.Raw[anthropic.Base64PDFSourceParam](map[string]string{
+			"type": "url",
+			"url":  doc.URI,
+		})
+	default:
+		return block, false
+	}
+	return block, true
+}
+
+// convertToolResult converts a tool message, including any image parts
+func convertToolResult(msg llmrouter.Message) anthropic.ToolResultBlockParam {
+	block := anthropic.NewToolResultBlock(msg.ToolCallID, msg.Content, false)
+	if len(msg.ContentParts) == 0 {
+		return block
+	}
+
+	var content []anthropic.ToolResultBlockParamContentUnion
+	if msg.Content != "" {
+		content = append(content, anthropic.NewTextBlock(msg.Content))
+	}
+	for _, p := range msg.ContentParts {
+		switch p.Type {
+		case "text":
+			content = append(content, anthropic.NewTextBlock(p.Text))
+		case "image_url":
+			if p.ImageURL != nil && p.ImageURL.Base64 != "" {
+				content = append(content, anthropic.NewImageBlockBase64(
+					p.ImageURL.MediaType,
+					p.ImageURL.Base64,
+				))
+			}
+		}
+	}
+	block.Content = anthropic.F(content)
+	return block
+}
+
+// cacheControl is the ephemeral prompt-caching marker
+var cacheControl = anthropic.F(anthropic.CacheControlEphemeralParam{
+	Type: anthropic.F(anthropic.CacheControlEphemeralTypeEphemeral),
+})
+
+// markCacheBreakpoint sets cache_control on the last block of the last message,
+// caching the prompt prefix up to and including it
+func markCacheBreakpoint(messages []anthropic.MessageParam) {
+	if len(messages) == 0 {
+		return
+	}
+	blocks := messages[len(messages)-1].Content.Value
+	if len(blocks) == 0 {
+		return
+	}
+
+	last := len(blocks) - 1
+	switch b := blocks[last].(type) {
+	case anthropic.TextBlockParam:
+		b.CacheControl = cacheControl
+		blocks[last] = b
+	case anthropic.ImageBlockParam:
+		b.CacheControl = cacheControl
+		blocks[last] = b
+	case anthropic.DocumentBlockParam:
+		b.CacheControl = cacheControl
+		blocks[last] = b
+	case anthropic.ToolUseBlockParam:
+		b.CacheControl = cacheControl
+		blocks[last] = b
+	case anthropic.ToolResultBlockParam:
+		b.CacheControl = cacheControl
+		blocks[last] = b
+	}
+}
+
+// hasToolHistory reports whether the conversation contains tool calls or results
+func hasToolHistory(msgs []llmrouter.Message) bool {
+	for _, msg := range msgs {
+		if len(msg.ToolCalls) > 0 || msg.Role == llmrouter.RoleTool {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSystemCacheHint reports whether any system message asks to be cached
+func hasSystemCacheHint(msgs []llmrouter.Message) bool {
+	for _, msg := range msgs {
+		if msg.Role == llmrouter.RoleSystem && msg.CacheHint {
+			return true
+		}
+	}
+	return false
+}
+
 // convertTools converts llmrouter tools to Anthropic format
 func convertTools(tools []llmrouter.Tool) []anthropic.ToolParam {
 	result := make([]anthropic.ToolParam, len(tools))
@@ -117,7 +227,109 @@ func convertTools(tools []llmrouter.Tool) []anthropic.ToolParam {
 	return result
 }
 
-// convertToolChoice converts llmrouter tool choice to Anthropic format
+// structuredOutputTool returns the tool that emulates a JSON response format,
+// or nil if the request doesn't ask for JSON
+func structuredOutputTool(rf *llmrouter.ResponseFormat) *llmrouter.Tool {
+	if rf == nil || (rf.Type != "json_object" && rf.Type != "json_schema") {
+		return nil
+	}
+
+	tool := &llmrouter.Tool{
+		Type: "function",
+		Function: llmrouter.Function{
+			Name:        "json_response",
+			Description: "Respond with a JSON object.",
+		},
+	}
+	if rf.JSONSchema != nil {
+		if rf.JSONSchema.Name != "" {
+			tool.Function.Name = rf.JSONSchema.Name
+		}
+		if rf.JSONSchema.Description != "" {
+			tool.Function.Description = rf.JSONSchema.Description
+		}
+		tool.Function.Parameters = rf.JSONSchema.Schema
+	}
+	return tool
+}
+
+// extractStructuredOutput replaces the forced tool call with its input as the
+// reply content
+func extractStructuredOutput(resp *llmrouter.Response, toolName string) {
+	for i := range resp.Choices {
+		msg := resp.Choices[i].Message
+		if msg == nil {
+			continue
+		}
+		var rest []llmrouter.ToolCall
+		found := false
+		for _, tc := range msg.ToolCalls {
+			if tc.Function.Name == toolName {
+				msg.Content = tc.Function.Arguments
+				found = true
+				continue
+			}
+			rest = append(rest, tc)
+		}
+		if !found {
+			continue
+		}
+		msg.ToolCalls = rest
+		msg.ContentBlocks = structuredBlocks(msg.ContentBlocks, toolName)
+		if len(rest) == 0 && resp.Choices[i].FinishReason == "tool_calls" {
+			resp.Choices[i].FinishReason = "stop"
+			if d := resp.Choices[i].FinishDetails; d != nil {
+				d.Reason = "stop"
+				d.ToolCalled = false
+			}
+		}
+	}
+}
+
+// structuredBlocks rewrites a reply's blocks once the forced tool call has
+// become the content: the call turns into a text block holding its
+// arguments, any other calls keep their place, and the original text, which
+// the content no longer carries, is dropped
+func structuredBlocks(blocks []llmrouter.ContentPart, toolName string) []llmrouter.ContentPart {
+	var out []llmrouter.ContentPart
+	for _, b := range blocks {
+		switch {
+		case b.Type != "tool_call":
+			continue
+		case b.ToolCall != nil && b.ToolCall.Function.Name == toolName:
+			out = append(out, llmrouter.ContentPart{Type: "text", Text: b.ToolCall.Function.Arguments})
+		default:
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// appendTextBlock adds text to blocks, extending the last block if it is
+// text too (e.g. text split around a server tool call)
+func appendTextBlock(blocks []llmrouter.ContentPart, text string) []llmrouter.ContentPart {
+	if text == "" {
+		return blocks
+	}
+	if n := len(blocks); n > 0 && blocks[n-1].Type == "text" {
+		blocks[n-1].Text += text
+		return blocks
+	}
+	return append(blocks, llmrouter.ContentPart{Type: "text", Text: text})
+}
+
+// prependPrefill adds the prefill to the start of the reply
+func prependPrefill(msg *llmrouter.Message, prefill string) {
+	msg.Content = prefill + msg.Content
+	if len(msg.ContentBlocks) > 0 && msg.ContentBlocks[0].Type == "text" {
+		msg.ContentBlocks[0].Text = prefill + msg.ContentBlocks[0].Text
+		return
+	}
+	msg.ContentBlocks = append([]llmrouter.ContentPart{{Type: "text", Text: prefill}}, msg.ContentBlocks...)
+}
+
+// convertToolChoice converts llmrouter tool choice to Anthropic format. The
+// SDK has no "none" choice; buildParams and requestOptions handle it.
 func convertToolChoice(tc *llmrouter.ToolChoice) anthropic.ToolChoiceUnionParam {
 	if tc == nil {
 		return nil
@@ -128,11 +340,6 @@ func convertToolChoice(tc *llmrouter.ToolChoice) anthropic.ToolChoiceUnionParam
 		return anthropic.ToolChoiceAutoParam{
 			Type: anthropic.F(anthropic.ToolChoiceAutoTypeAuto),
 		}
-	case "none":
-		// Anthropic doesn't have "none" - use auto as fallback
-		return anthropic.ToolChoiceAutoParam{
-			Type: anthropic.F(anthropic.ToolChoiceAutoTypeAuto),
-		}
 	case "required", "any":
 		return anthropic.ToolChoiceAnyParam{
 			Type: anthropic.F(anthropic.ToolChoiceAnyTypeAny),
@@ -153,33 +360,36 @@ func convertToolChoice(tc *llmrouter.ToolChoice) anthropic.ToolChoiceUnionParam
 func convertToOpenAIResponse(msg *anthropic.Message, provider string) *llmrouter.Response {
 	var content string
 	var toolCalls []llmrouter.ToolCall
+	var citations []llmrouter.Citation
+	var blocks []llmrouter.ContentPart
 
 	for _, block := range msg.Content {
 		switch b := block.AsUnion().(type) {
 		case anthropic.TextBlock:
 			content += b.Text
+			citations = append(citations, convertCitations(b.JSON.ExtraFields["citations"].Raw())...)
+			blocks = appendTextBlock(blocks, b.Text)
 		case anthropic.ToolUseBlock:
+			// Server tool calls (e.g. web search) decode as tool use too, but
+			// have already been run by Anthropic
+			if block.Type != anthropic.ContentBlockTypeToolUse {
+				continue
+			}
 			args, _ := json.Marshal(b.Input)
-			toolCalls = append(toolCalls, llmrouter.ToolCall{
+			tc := llmrouter.ToolCall{
 				ID:   b.ID,
 				Type: "function",
 				Function: llmrouter.FuncCall{
 					Name:      b.Name,
 					Arguments: string(args),
 				},
-			})
+			}
+			toolCalls = append(toolCalls, tc)
+			blocks = append(blocks, llmrouter.ContentPart{Type: "tool_call", ToolCall: &tc})
 		}
 	}
 
-	finishReason := "stop"
-	switch msg.StopReason {
-	case anthropic.MessageStopReasonToolUse:
-		finishReason = "tool_calls"
-	case anthropic.MessageStopReasonMaxTokens:
-		finishReason = "length"
-	case anthropic.MessageStopReasonStopSequence:
-		finishReason = "stop"
-	}
+	finishReason := convertStopReason(string(msg.StopReason), len(toolCalls) > 0)
 
 	return &llmrouter.Response{
 		ID:       msg.ID,
@@ -190,11 +400,13 @@ func convertToOpenAIResponse(msg *anthropic.Message, provider string) *llmrouter
 			{
 				Index: 0,
 				Message: &llmrouter.Message{
-					Role:      llmrouter.RoleAssistant,
-					Content:   content,
-					ToolCalls: toolCalls,
+					Role:          llmrouter.RoleAssistant,
+					Content:       content,
+					ToolCalls:     toolCalls,
+					ContentBlocks: blocks,
 				},
-				FinishReason: finishReason,
+				FinishReason:  finishReason,
+				FinishDetails: llmrouter.NewFinishDetails(finishReason, string(msg.StopReason), len(toolCalls) > 0),
 			},
 		},
 		Usage: &llmrouter.Usage{
@@ -202,6 +414,53 @@ func convertToOpenAIResponse(msg *anthropic.Message, provider string) *llmrouter
 			CompletionTokens: int(msg.Usage.OutputTokens),
 			TotalTokens:      int(msg.Usage.InputTokens + msg.Usage.OutputTokens),
 		},
+		Citations: citations,
+	}
+}
+
+// webCitation is a web search citation attached to a text block
+type webCitation struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Title     string `json:"title"`
+	CitedText string `json:"cited_text"`
+}
+
+// convertCitations decodes a text block's citations, as returned when the
+// web search tool is enabled. Other citation kinds are skipped.
+func convertCitations(raw string) []llmrouter.Citation {
+	if raw == "" || raw == "null" {
+		return nil
+	}
+	var list []webCitation
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil
+	}
+
+	var citations []llmrouter.Citation
+	for _, c := range list {
+		if c.Type == "web_search_result_location" && c.URL != "" {
+			citations = append(citations, llmrouter.Citation{URL: c.URL, Title: c.Title, Snippet: c.CitedText})
+		}
+	}
+	return citations
+}
+
+// convertStopReason maps an Anthropic stop reason to the normalized finish
+// reason. Unknown reasons are passed through.
+func convertStopReason(reason string, toolCalled bool) string {
+	switch reason {
+	case "tool_use":
+		if toolCalled {
+			return "tool_calls"
+		}
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "end_turn", "stop_sequence", "":
+		return "stop"
+	default:
+		return llmrouter.UnknownFinishReason("anthropic", reason)
 	}
 }
 
@@ -0,0 +1,9 @@
+package anthropic
+
+import llmrouter "github.com/bluefunda/llm-router"
+
+func init() {
+	llmrouter.RegisterProviderFactory("anthropic", func(cfg llmrouter.ProviderConfig) (llmrouter.Provider, error) {
+		return New(cfg), nil
+	})
+}
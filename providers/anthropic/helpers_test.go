@@ -0,0 +1,150 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// recordedRequest is a request received by the fake API
+type recordedRequest struct {
+	Header http.Header
+	Body   []byte
+}
+
+// JSON decodes the request body
+func (r recordedRequest) JSON() map[string]any {
+	var body map[string]any
+	_ = json.Unmarshal(r.Body, &body)
+	return body
+}
+
+// fakeAPI records requests sent to an httptest server
+type fakeAPI struct {
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+func (a *fakeAPI) last() recordedRequest {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.requests) == 0 {
+		return recordedRequest{}
+	}
+	return a.requests[len(a.requests)-1]
+}
+
+// newTestProvider returns a provider built from cfg talking to a fake API
+// whose handler writes each response
+func newTestProvider(t *testing.T, cfg llmrouter.ProviderConfig, respond http.HandlerFunc) (*Provider, *fakeAPI) {
+	t.Helper()
+	api := &fakeAPI{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		api.mu.Lock()
+		api.requests = append(api.requests, recordedRequest{Header: r.Header.Clone(), Body: body})
+		api.mu.Unlock()
+		respond(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	if cfg.APIKey == "" {
+		cfg.APIKey = "test"
+	}
+	p := New(cfg)
+	// Keep the provider's options (middleware included), pointed at the fake
+	p.client = anthropic.NewClient(append(p.client.Options, option.WithBaseURL(srv.URL+"/"), option.WithMaxRetries(0))...)
+	return p, api
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeSSE writes each event as a server-sent event named by its type
+func writeSSE(w http.ResponseWriter, events ...map[string]any) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, e := range events {
+		data, _ := json.Marshal(e)
+		_, _ = w.Write([]byte("event: " + e["type"].(string) + "\ndata: " + string(data) + "\n\n"))
+	}
+}
+
+// message builds a Messages API response with the given content blocks
+func message(stopReason string, content ...map[string]any) map[string]any {
+	return map[string]any{
+		"id":            "msg_1",
+		"type":          "message",
+		"role":          "assistant",
+		"model":         "claude-test",
+		"content":       content,
+		"stop_reason":   stopReason,
+		"stop_sequence": nil,
+		"usage":         map[string]any{"input_tokens": 3, "output_tokens": 2},
+	}
+}
+
+// textBlock builds a text content block
+func textBlock(text string) map[string]any {
+	return map[string]any{"type": "text", "text": text}
+}
+
+// toolUseBlock builds a tool use content block
+func toolUseBlock(id, name string, input any) map[string]any {
+	return map[string]any{"type": "tool_use", "id": id, "name": name, "input": input}
+}
+
+// userRequest builds a request with a single user message
+func userRequest(content string) *llmrouter.Request {
+	return &llmrouter.Request{
+		Messages: []llmrouter.Message{{Role: llmrouter.RoleUser, Content: content}},
+	}
+}
+
+// collect reads ch until it closes
+func collect(ch <-chan llmrouter.Event) []llmrouter.Event {
+	var events []llmrouter.Event
+	for e := range ch {
+		events = append(events, e)
+	}
+	return events
+}
+
+// textStream builds the events of a streamed message with one text block
+// made of the given deltas
+func textStream(stopReason string, deltas ...string) []map[string]any {
+	events := []map[string]any{
+		{"type": "message_start", "message": message("")},
+		{"type": "content_block_start", "index": 0, "content_block": textBlock("")},
+	}
+	for _, d := range deltas {
+		events = append(events, map[string]any{"type": "content_block_delta", "index": 0, "delta": map[string]any{"type": "text_delta", "text": d}})
+	}
+	return append(events,
+		map[string]any{"type": "content_block_stop", "index": 0},
+		map[string]any{"type": "message_delta", "delta": map[string]any{"stop_reason": stopReason}, "usage": map[string]any{"output_tokens": 2}},
+		map[string]any{"type": "message_stop"},
+	)
+}
+
+// weatherTool is a function tool taking a city
+func weatherTool() llmrouter.Tool {
+	return llmrouter.Tool{
+		Type: "function",
+		Function: llmrouter.Function{
+			Name:        "weather",
+			Description: "Look up the weather",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+		},
+	}
+}
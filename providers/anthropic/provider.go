@@ -3,7 +3,11 @@ package anthropic
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	llmrouter "github.com/bluefunda/llm-router"
@@ -13,11 +17,20 @@ import (
 
 // Provider handles Anthropic Claude API
 type Provider struct {
-	client *anthropic.Client
-	model  string
-	models []string
+	client     *anthropic.Client
+	model      string
+	models     []string
+	betas      []string
+	rateLimits *llmrouter.RateLimitTracker
+	onClamp    llmrouter.ClampFunc
 }
 
+// BetaOutput128k enables extended output of up to 128k tokens on Claude 3.7 Sonnet
+const BetaOutput128k = "output-128k-2025-02-19"
+
+// BetaTokenEfficientTools reduces output tokens spent on tool calls
+const BetaTokenEfficientTools = "token-efficient-tools-2025-02-19"
+
 // DefaultModels is the list of available Claude models
 var DefaultModels = []string{
 	"claude-opus-4-20250514",
@@ -48,11 +61,21 @@ func New(cfg llmrouter.ProviderConfig) *Provider {
 	if cfg.Timeout > 0 {
 		opts = append(opts, option.WithRequestTimeout(cfg.Timeout))
 	}
+	if client := cfg.HTTPClient(); client != nil {
+		opts = append(opts, option.WithHTTPClient(client))
+	}
+
+	rateLimits := &llmrouter.RateLimitTracker{}
+	opts = append(opts, option.WithMiddleware(trackRateLimits(rateLimits)))
+	if cfg.UserAgent != "" {
+		opts = append(opts, option.WithMiddleware(appendUserAgent(cfg.UserAgent)))
+	}
 
 	return &Provider{
-		client: anthropic.NewClient(opts...),
-		model:  model,
-		models: models,
+		client:     anthropic.NewClient(opts...),
+		model:      model,
+		models:     models,
+		rateLimits: rateLimits,
 	}
 }
 
@@ -63,134 +86,152 @@ func NewFromEnv() *Provider {
 	})
 }
 
-func (p *Provider) Name() string {
-	return "anthropic"
+// Client returns the underlying Anthropic SDK client, an escape hatch for API
+// features the router doesn't model. Calls made through it bypass router
+// middleware.
+func (p *Provider) Client() *anthropic.Client {
+	return p.client
 }
 
-func (p *Provider) Models() []string {
-	return p.models
+// WithBetas enables Anthropic beta features, sent as the anthropic-beta header.
+// Enabling BetaOutput128k raises the default max_tokens to 128000 on models
+// that support it (Claude 3.7 Sonnet).
+func (p *Provider) WithBetas(betas ...string) *Provider {
+	p.betas = append(p.betas, betas...)
+	return p
 }
 
-func (p *Provider) SupportsTools() bool {
-	return true
+// RateLimitStatus returns the rate limit budget from the latest response headers
+func (p *Provider) RateLimitStatus() (llmrouter.RateLimitStatus, bool) {
+	return p.rateLimits.Status()
 }
 
-func (p *Provider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
-	messages, systemPrompt := convertMessages(req.Messages)
-
-	model := req.Model
-	if model == "" || model == "anthropic" {
-		// Use default model if not specified or if model matches provider name
-		model = p.model
-	}
-
-	maxTokens := int64(16384)
-	if req.MaxTokens != nil {
-		maxTokens = int64(*req.MaxTokens)
+// trackRateLimits records rate limit headers from every HTTP response
+func trackRateLimits(t *llmrouter.RateLimitTracker) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		resp, err := next(req)
+		if resp != nil {
+			t.Update(resp.Header)
+		}
+		return resp, err
 	}
+}
 
-	params := anthropic.MessageNewParams{
-		Model:     anthropic.F(model),
-		MaxTokens: anthropic.F(maxTokens),
-		Messages:  anthropic.F(messages),
+// appendUserAgent adds ua to the User-Agent header set by the SDK
+func appendUserAgent(ua string) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		value := ua
+		if existing := req.Header.Get("User-Agent"); existing != "" {
+			value = existing + " " + ua
+		}
+		req.Header.Set("User-Agent", value)
+		return next(req)
 	}
+}
 
-	if systemPrompt != "" {
-		params.System = anthropic.F([]anthropic.TextBlockParam{
-			{Type: anthropic.F(anthropic.TextBlockParamTypeText), Text: anthropic.F(systemPrompt)},
-		})
-	}
+// temperatureRange is the temperature range the API accepts
+var temperatureRange = llmrouter.TemperatureRange{Min: 0, Max: 1}
 
-	if req.Temperature != nil {
-		params.Temperature = anthropic.F(*req.Temperature)
+// WithTemperatureClamp clamps out-of-range temperatures instead of rejecting
+// them with ErrInvalidRequest, calling warn (if non-nil) for each clamp
+func (p *Provider) WithTemperatureClamp(warn llmrouter.ClampFunc) *Provider {
+	if warn == nil {
+		warn = func(requested, clamped float64) {}
 	}
+	p.onClamp = warn
+	return p
+}
 
-	if req.TopP != nil {
-		params.TopP = anthropic.F(*req.TopP)
-	}
+func (p *Provider) Name() string {
+	return "anthropic"
+}
 
-	if len(req.Stop) > 0 {
-		params.StopSequences = anthropic.F(req.Stop)
-	}
+// DefaultModel returns the model used when a request names none
+func (p *Provider) DefaultModel() string {
+	return p.model
+}
 
-	if len(req.Tools) > 0 {
-		params.Tools = anthropic.F(convertTools(req.Tools))
-	}
+func (p *Provider) Models() []string {
+	return p.models
+}
 
-	if req.ToolChoice != nil {
-		params.ToolChoice = anthropic.F(convertToolChoice(req.ToolChoice))
-	}
+func (p *Provider) SupportsTools() bool {
+	return true
+}
 
-	resp, err := p.client.Messages.New(ctx, params)
+func (p *Provider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	req, err := llmrouter.CheckTemperature(req, temperatureRange, p.onClamp)
 	if err != nil {
-		return nil, wrapError(err)
+		return nil, err
 	}
-
-	return convertToOpenAIResponse(resp, p.Name()), nil
-}
-
-func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
-	ch := make(chan llmrouter.Event)
-
-	messages, systemPrompt := convertMessages(req.Messages)
-
-	model := req.Model
-	if model == "" || model == "anthropic" {
-		// Use default model if not specified or if model matches provider name
-		model = p.model
+	req = llmrouter.ApplyGrounding(req)
+	if err := checkBuiltinTools(req.BuiltinTools); err != nil {
+		return nil, err
 	}
 
-	maxTokens := int64(16384)
-	if req.MaxTokens != nil {
-		maxTokens = int64(*req.MaxTokens)
-	}
+	params, _ := p.buildParams(req)
 
-	params := anthropic.MessageNewParams{
-		Model:     anthropic.F(model),
-		MaxTokens: anthropic.F(maxTokens),
-		Messages:  anthropic.F(messages),
+	resp, err := p.client.Messages.New(ctx, params, p.requestOptions(req)...)
+	if err != nil {
+		return nil, wrapError(err)
 	}
 
-	if systemPrompt != "" {
-		params.System = anthropic.F([]anthropic.TextBlockParam{
-			{Type: anthropic.F(anthropic.TextBlockParamTypeText), Text: anthropic.F(systemPrompt)},
-		})
+	result := convertToOpenAIResponse(resp, p.Name())
+	if tool := structuredOutputTool(req.ResponseFormat); tool != nil {
+		extractStructuredOutput(result, tool.Function.Name)
 	}
-
-	if req.Temperature != nil {
-		params.Temperature = anthropic.F(*req.Temperature)
+	if prefill := prefillText(req); prefill != "" {
+		prependPrefill(result.Choices[0].Message, prefill)
 	}
+	return result, nil
+}
 
-	if req.TopP != nil {
-		params.TopP = anthropic.F(*req.TopP)
+func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	req, err := llmrouter.CheckTemperature(req, temperatureRange, p.onClamp)
+	if err != nil {
+		return nil, err
 	}
-
-	if len(req.Stop) > 0 {
-		params.StopSequences = anthropic.F(req.Stop)
+	req = llmrouter.ApplyGrounding(req)
+	if err := checkBuiltinTools(req.BuiltinTools); err != nil {
+		return nil, err
 	}
 
-	if len(req.Tools) > 0 {
-		params.Tools = anthropic.F(convertTools(req.Tools))
-	}
+	ch := make(chan llmrouter.Event)
 
-	if req.ToolChoice != nil {
-		params.ToolChoice = anthropic.F(convertToolChoice(req.ToolChoice))
-	}
+	params, model := p.buildParams(req)
 
 	go func() {
 		defer close(ch)
 
-		stream := p.client.Messages.NewStreaming(ctx, params)
+		stream := p.client.Messages.NewStreaming(ctx, params, p.requestOptions(req)...)
 
 		// Accumulate the response manually
 		var fullContent string
+		var blocks []llmrouter.ContentPart
+
+		// Surface the prefill as the start of the reply
+		if prefill := prefillText(req); prefill != "" {
+			fullContent = prefill
+			blocks = appendTextBlock(blocks, prefill)
+			ch <- llmrouter.Event{
+				Type:    llmrouter.EventContentDelta,
+				Content: prefill,
+			}
+		}
+		// The forced JSON tool's input streams as content
+		var structuredTool string
+		if tool := structuredOutputTool(req.ResponseFormat); tool != nil {
+			structuredTool = tool.Function.Name
+		}
 		var toolCalls []llmrouter.ToolCall
+		var currentBlockType string
 		var currentToolID string
 		var currentToolName string
 		var toolArgsBuilder string
 		var inputTokens, outputTokens int64
 		var msgID string
 		var stopReason string
+		var citations []llmrouter.Citation
 
 		for stream.Next() {
 			event := stream.Current()
@@ -205,25 +246,50 @@ func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan l
 				}
 
 			case anthropic.ContentBlockStartEvent:
+				currentBlockType = string(e.ContentBlock.Type)
 				switch cb := e.ContentBlock.AsUnion().(type) {
 				case anthropic.TextBlock:
 					// Text block started
 				case anthropic.ToolUseBlock:
+					// Server tool blocks decode as tool use too
+					if currentBlockType != "tool_use" {
+						continue
+					}
 					currentToolID = cb.ID
 					currentToolName = cb.Name
 					toolArgsBuilder = ""
 				}
 
 			case anthropic.ContentBlockDeltaEvent:
+				// The SDK predates citation deltas and would decode them as
+				// empty text deltas
+				if e.Delta.Type == "citations_delta" {
+					raw := e.Delta.JSON.ExtraFields["citation"].Raw()
+					citations = append(citations, convertCitations("["+raw+"]")...)
+					continue
+				}
 				switch d := e.Delta.AsUnion().(type) {
 				case anthropic.TextDelta:
 					fullContent += d.Text
+					blocks = appendTextBlock(blocks, d.Text)
 					ch <- llmrouter.Event{
 						Type:    llmrouter.EventContentDelta,
 						Content: d.Text,
 					}
 				case anthropic.InputJSONDelta:
+					// Server tool input (e.g. web search queries) isn't ours to run
+					if currentBlockType != "tool_use" {
+						continue
+					}
 					toolArgsBuilder += d.PartialJSON
+					if currentToolName == structuredTool {
+						fullContent += d.PartialJSON
+						ch <- llmrouter.Event{
+							Type:    llmrouter.EventContentDelta,
+							Content: d.PartialJSON,
+						}
+						continue
+					}
 					ch <- llmrouter.Event{
 						Type: llmrouter.EventToolCallDelta,
 						Delta: &llmrouter.Delta{
@@ -243,15 +309,17 @@ func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan l
 
 			case anthropic.ContentBlockStopEvent:
 				// If we were building a tool call, finalize it
-				if currentToolID != "" && currentToolName != "" {
-					toolCalls = append(toolCalls, llmrouter.ToolCall{
+				if currentToolID != "" && currentToolName != "" && currentToolName != structuredTool {
+					tc := llmrouter.ToolCall{
 						ID:   currentToolID,
 						Type: "function",
 						Function: llmrouter.FuncCall{
 							Name:      currentToolName,
 							Arguments: toolArgsBuilder,
 						},
-					})
+					}
+					toolCalls = append(toolCalls, tc)
+					blocks = append(blocks, llmrouter.ContentPart{Type: "tool_call", ToolCall: &tc})
 					currentToolID = ""
 					currentToolName = ""
 					toolArgsBuilder = ""
@@ -276,11 +344,10 @@ func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan l
 		}
 
 		// Build final response
-		finishReason := "stop"
-		if stopReason == "tool_use" {
-			finishReason = "tool_calls"
-		} else if stopReason == "max_tokens" {
-			finishReason = "length"
+		finishReason := convertStopReason(stopReason, len(toolCalls) > 0)
+		if structuredTool != "" {
+			// As in Complete, the forced tool call is the reply
+			blocks = nil
 		}
 
 		ch <- llmrouter.Event{
@@ -295,11 +362,13 @@ func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan l
 					{
 						Index: 0,
 						Message: &llmrouter.Message{
-							Role:      llmrouter.RoleAssistant,
-							Content:   fullContent,
-							ToolCalls: toolCalls,
+							Role:          llmrouter.RoleAssistant,
+							Content:       fullContent,
+							ToolCalls:     toolCalls,
+							ContentBlocks: blocks,
 						},
-						FinishReason: finishReason,
+						FinishReason:  finishReason,
+						FinishDetails: llmrouter.NewFinishDetails(finishReason, stopReason, len(toolCalls) > 0),
 					},
 				},
 				Usage: &llmrouter.Usage{
@@ -307,6 +376,7 @@ func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan l
 					CompletionTokens: int(outputTokens),
 					TotalTokens:      int(inputTokens + outputTokens),
 				},
+				Citations: citations,
 			},
 		}
 	}()
@@ -314,6 +384,146 @@ func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan l
 	return ch, nil
 }
 
+// buildParams converts a request to message params, returning the resolved model
+func (p *Provider) buildParams(req *llmrouter.Request) (anthropic.MessageNewParams, string) {
+	messages, systemPrompt := convertMessages(req.Messages)
+
+	// Anthropic continues from a trailing assistant turn, which must not end in whitespace
+	if prefill := prefillText(req); prefill != "" {
+		messages = append(messages, anthropic.NewAssistantMessage(anthropic.NewTextBlock(prefill)))
+	}
+
+	model := req.Model
+	if model == "" || model == "anthropic" {
+		// Use default model if not specified or if model matches provider name
+		model = p.model
+	}
+
+	maxTokens := p.defaultMaxTokens(model)
+	if req.MaxTokens != nil {
+		maxTokens = int64(*req.MaxTokens)
+	}
+
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.F(model),
+		MaxTokens: anthropic.F(maxTokens),
+		Messages:  anthropic.F(messages),
+	}
+
+	if systemPrompt != "" {
+		system := anthropic.TextBlockParam{Type: anthropic.F(anthropic.TextBlockParamTypeText), Text: anthropic.F(systemPrompt)}
+		if hasSystemCacheHint(req.Messages) {
+			system.CacheControl = cacheControl
+		}
+		params.System = anthropic.F([]anthropic.TextBlockParam{system})
+	}
+
+	if req.Temperature != nil {
+		params.Temperature = anthropic.F(*req.Temperature)
+	}
+
+	if req.TopP != nil {
+		params.TopP = anthropic.F(*req.TopP)
+	}
+
+	if len(req.Stop) > 0 {
+		params.StopSequences = anthropic.F(req.Stop)
+	}
+
+	tools, toolChoice := req.Tools, req.ToolChoice
+	// JSON output is emulated by forcing a tool that takes the schema as input
+	if tool := structuredOutputTool(req.ResponseFormat); tool != nil {
+		tools = append(append([]llmrouter.Tool(nil), tools...), *tool)
+		toolChoice = &llmrouter.ToolChoice{Type: "function", Function: &llmrouter.FuncRef{Name: tool.Function.Name}}
+	}
+
+	// Tool use is forbidden by not offering tools. Tools must stay defined if
+	// the history references them, so requestOptions sends a "none" choice
+	// instead, which the SDK can't express.
+	choiceNone := toolChoice != nil && toolChoice.Type == "none"
+	toolsForbidden := choiceNone && !hasToolHistory(req.Messages)
+
+	if len(tools) > 0 && !toolsForbidden {
+		params.Tools = anthropic.F(convertTools(tools))
+	}
+
+	if toolChoice != nil && !choiceNone {
+		params.ToolChoice = anthropic.F(convertToolChoice(toolChoice))
+	}
+
+	if user := req.MetadataString(llmrouter.MetadataUserID); user != "" {
+		params.Metadata = anthropic.F(anthropic.MetadataParam{UserID: anthropic.F(user)})
+	}
+
+	return params, model
+}
+
+// prefillText returns the assistant prefill, trimmed of the trailing whitespace
+// Anthropic rejects. Prefill is skipped when JSON output is emulated by a tool.
+func prefillText(req *llmrouter.Request) string {
+	if structuredOutputTool(req.ResponseFormat) != nil {
+		return ""
+	}
+	return strings.TrimRight(req.Prefill, " \t\n")
+}
+
+// requestOptions returns per-request options such as the beta header
+func (p *Provider) requestOptions(req *llmrouter.Request) []option.RequestOption {
+	var opts []option.RequestOption
+	if len(p.betas) > 0 {
+		opts = append(opts, option.WithHeader("anthropic-beta", strings.Join(p.betas, ",")))
+	}
+	// The SDK has no server tool params, so built-in tools are appended to
+	// the serialized tools list
+	for _, name := range req.BuiltinTools {
+		if tool, ok := builtinTools[name]; ok {
+			opts = append(opts, option.WithJSONSet("tools.-1", tool))
+		}
+	}
+	if forbidsToolsWithHistory(req) {
+		opts = append(opts, option.WithJSONSet("tool_choice", map[string]string{"type": "none"}))
+	}
+	return opts
+}
+
+// forbidsToolsWithHistory reports whether tool use is forbidden while the
+// tools stay offered because the history references them
+func forbidsToolsWithHistory(req *llmrouter.Request) bool {
+	return req.ToolChoice != nil && req.ToolChoice.Type == "none" && len(req.Tools) > 0 &&
+		structuredOutputTool(req.ResponseFormat) == nil && hasToolHistory(req.Messages)
+}
+
+// builtinTools maps Request.BuiltinTools names to Anthropic server tools
+var builtinTools = map[string]map[string]string{
+	llmrouter.BuiltinWebSearch: {"type": "web_search_20250305", "name": "web_search"},
+}
+
+// checkBuiltinTools rejects built-in tools Anthropic doesn't offer
+func checkBuiltinTools(names []string) error {
+	for _, name := range names {
+		if _, ok := builtinTools[name]; !ok {
+			return fmt.Errorf("%w: anthropic built-in tool %q", llmrouter.ErrNotSupported, name)
+		}
+	}
+	return nil
+}
+
+// extendedOutputModels are the model prefixes BetaOutput128k applies to
+var extendedOutputModels = []string{"claude-3-7-sonnet"}
+
+// defaultMaxTokens returns the max_tokens used for model when the request
+// doesn't set one
+func (p *Provider) defaultMaxTokens(model string) int64 {
+	if slices.Contains(p.betas, BetaOutput128k) {
+		for _, prefix := range extendedOutputModels {
+			if strings.HasPrefix(model, prefix) {
+				return 128000
+			}
+		}
+	}
+	return 16384
+}
+
 // Helper to marshal tool args
 func marshalToolArgs(args interface{}) string {
 	if args == nil {
@@ -0,0 +1,865 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/bluefunda/llm-router/middleware"
+)
+
+func TestBetaHeader(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+	p.WithBetas(BetaOutput128k, BetaTokenEfficientTools)
+
+	if _, err := p.Complete(context.Background(), userRequest("hello")); err != nil {
+		t.Fatal(err)
+	}
+	want := BetaOutput128k + "," + BetaTokenEfficientTools
+	if got := api.last().Header.Get("anthropic-beta"); got != want {
+		t.Errorf("anthropic-beta = %q, want %q", got, want)
+	}
+}
+
+func TestNoBetaHeaderByDefault(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+
+	if _, err := p.Complete(context.Background(), userRequest("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.last().Header.Get("anthropic-beta"); got != "" {
+		t.Errorf("anthropic-beta = %q, want none", got)
+	}
+}
+
+func TestDefaultMaxTokens(t *testing.T) {
+	tests := []struct {
+		model string
+		betas []string
+		want  int64
+	}{
+		{"claude-sonnet-4-20250514", nil, 16384},
+		{"claude-3-7-sonnet-20250219", nil, 16384},
+		{"claude-3-7-sonnet-20250219", []string{BetaOutput128k}, 128000},
+		{"claude-sonnet-4-20250514", []string{BetaOutput128k}, 16384},
+		{"claude-3-5-sonnet-20241022", []string{BetaOutput128k}, 16384},
+	}
+	for _, tt := range tests {
+		p := New(llmrouter.ProviderConfig{APIKey: "test"}).WithBetas(tt.betas...)
+		if got := p.defaultMaxTokens(tt.model); got != tt.want {
+			t.Errorf("defaultMaxTokens(%q) with betas %v = %d, want %d", tt.model, tt.betas, got, tt.want)
+		}
+	}
+}
+
+func TestMaxTokensSent(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+
+	req := userRequest("hello")
+	req.Model = "claude-sonnet-4-20250514"
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.last().JSON()["max_tokens"]; got != float64(16384) {
+		t.Errorf("max_tokens = %v, want the default 16384", got)
+	}
+
+	n := 100
+	req.MaxTokens = &n
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.last().JSON()["max_tokens"]; got != float64(100) {
+		t.Errorf("max_tokens = %v, want the requested 100", got)
+	}
+}
+
+func TestCacheHintBreakpoints(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+
+	req := &llmrouter.Request{Messages: []llmrouter.Message{
+		{Role: llmrouter.RoleSystem, Content: "long instructions", CacheHint: true},
+		{Role: llmrouter.RoleUser, Content: "long document", CacheHint: true},
+		{Role: llmrouter.RoleAssistant, Content: "noted"},
+		{Role: llmrouter.RoleUser, Content: "question"},
+	}}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	body := api.last().JSON()
+	system := body["system"].([]any)[0].(map[string]any)
+	if system["cache_control"] == nil {
+		t.Error("hinted system prompt has no cache_control")
+	}
+
+	messages := body["messages"].([]any)
+	for i, want := range []bool{true, false, false} {
+		block := messages[i].(map[string]any)["content"].([]any)[0].(map[string]any)
+		cc, ok := block["cache_control"].(map[string]any)
+		if ok != want {
+			t.Errorf("message %d: cache_control = %v, want present %v", i, block["cache_control"], want)
+		}
+		if ok && cc["type"] != "ephemeral" {
+			t.Errorf("message %d: cache_control type = %v", i, cc["type"])
+		}
+	}
+}
+
+func TestRateLimitHeadersTracked(t *testing.T) {
+	p, _ := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("anthropic-ratelimit-requests-remaining", "49")
+		w.Header().Set("anthropic-ratelimit-tokens-remaining", "8000")
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+
+	if _, err := p.Complete(context.Background(), userRequest("hello")); err != nil {
+		t.Fatal(err)
+	}
+	status, ok := p.RateLimitStatus()
+	if !ok || status.RemainingRequests != 49 || status.RemainingTokens != 8000 {
+		t.Errorf("status = %+v, %v", status, ok)
+	}
+}
+
+func TestPrefill(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock(` "answer": 42}`)))
+	})
+
+	req := userRequest("reply in JSON")
+	req.Prefill = "{\n"
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := api.last().JSON()["messages"].([]any)
+	last := messages[len(messages)-1].(map[string]any)
+	text := last["content"].([]any)[0].(map[string]any)["text"]
+	// Anthropic rejects a final assistant turn ending in whitespace
+	if last["role"] != "assistant" || text != "{" {
+		t.Errorf("last message = %v, want the trimmed prefill as an assistant turn", last)
+	}
+	if got := resp.Text(); got != `{ "answer": 42}` {
+		t.Errorf("content = %q, want the prefill prepended", got)
+	}
+	if blocks := resp.Choices[0].Message.ContentBlocks; len(blocks) != 1 || blocks[0].Text != `{ "answer": 42}` {
+		t.Errorf("content blocks = %+v", blocks)
+	}
+}
+
+func TestPrefillStream(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w, textStream("end_turn", ` "answer": 42}`)...)
+	})
+
+	req := userRequest("reply in JSON")
+	req.Prefill = "{"
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+
+	if len(events) == 0 || events[0].Content != "{" {
+		t.Fatalf("events = %+v, want the prefill first", events)
+	}
+	done := events[len(events)-1]
+	if done.Type != llmrouter.EventDone || done.Response.Text() != `{ "answer": 42}` {
+		t.Errorf("final event = %+v", done)
+	}
+	messages := api.last().JSON()["messages"].([]any)
+	if messages[len(messages)-1].(map[string]any)["role"] != "assistant" {
+		t.Error("prefill was not sent as an assistant turn")
+	}
+}
+
+func TestPrefillSkippedForStructuredOutput(t *testing.T) {
+	req := userRequest("hi")
+	req.Prefill = "{"
+	req.ResponseFormat = &llmrouter.ResponseFormat{Type: "json_object"}
+	if got := prefillText(req); got != "" {
+		t.Errorf("prefillText = %q, want none when JSON output is emulated by a tool", got)
+	}
+}
+
+func TestToolChoiceNone(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+
+	req := userRequest("what's the weather?")
+	req.Tools = []llmrouter.Tool{weatherTool()}
+	req.ToolChoice = &llmrouter.ToolChoice{Type: "none"}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	body := api.last().JSON()
+	if _, ok := body["tools"]; ok {
+		t.Error("tools offered although tool use is forbidden")
+	}
+	if _, ok := body["tool_choice"]; ok {
+		t.Errorf("tool_choice = %v, want none sent", body["tool_choice"])
+	}
+}
+
+func TestForcedToolChoice(t *testing.T) {
+	tests := []struct {
+		name     string
+		function string
+		want     map[string]any
+	}{
+		{"any tool", "", map[string]any{"type": "any"}},
+		{"named function", "weather", map[string]any{"type": "tool", "name": "weather"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, message("tool_use", toolUseBlock("toolu_1", "weather", map[string]any{"city": "Paris"})))
+			})
+			forced := middleware.NewForceToolMiddleware().WithFunction(tt.function).Wrap(p)
+
+			req := userRequest("what's the weather?")
+			req.Tools = []llmrouter.Tool{weatherTool()}
+			if _, err := forced.Complete(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+			choice, _ := api.last().JSON()["tool_choice"].(map[string]any)
+			for k, v := range tt.want {
+				if choice[k] != v {
+					t.Errorf("tool_choice = %v, want %v", choice, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestToolChoiceNoneKeepsToolsReferencedByHistory(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("It's raining.")))
+	})
+
+	req := &llmrouter.Request{
+		Messages: []llmrouter.Message{
+			{Role: llmrouter.RoleUser, Content: "what's the weather?"},
+			{Role: llmrouter.RoleAssistant, ToolCalls: []llmrouter.ToolCall{{ID: "toolu_1", Type: "function", Function: llmrouter.FuncCall{Name: "weather", Arguments: "{}"}}}},
+			{Role: llmrouter.RoleTool, ToolCallID: "toolu_1", Content: "rain"},
+		},
+		Tools:      []llmrouter.Tool{weatherTool()},
+		ToolChoice: &llmrouter.ToolChoice{Type: "none"},
+	}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	// The API rejects tool_use blocks for tools that aren't defined
+	if tools, _ := api.last().JSON()["tools"].([]any); len(tools) != 1 {
+		t.Errorf("tools = %v, want the tool the history references", api.last().JSON()["tools"])
+	}
+	if choice, _ := api.last().JSON()["tool_choice"].(map[string]any); choice["type"] != "none" {
+		t.Errorf("tool_choice = %v, want none so the offered tools can't be used", api.last().JSON()["tool_choice"])
+	}
+}
+
+func TestGroundingAddsWebSearch(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		text := textBlock("It rained.")
+		text["citations"] = []any{
+			map[string]any{"type": "web_search_result_location", "url": "https://news.example", "title": "Weather", "cited_text": "rain all day"},
+			map[string]any{"type": "char_location", "document_index": 0},
+		}
+		writeJSON(w, message("end_turn", text))
+	})
+
+	req := userRequest("weather yesterday?")
+	req.Grounding = true
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tools, _ := api.last().JSON()["tools"].([]any)
+	if len(tools) != 1 || tools[0].(map[string]any)["type"] != "web_search_20250305" {
+		t.Errorf("tools = %v, want the web search server tool", api.last().JSON()["tools"])
+	}
+	want := llmrouter.Citation{URL: "https://news.example", Title: "Weather", Snippet: "rain all day"}
+	if len(resp.Citations) != 1 || resp.Citations[0] != want {
+		t.Errorf("citations = %+v, want %+v", resp.Citations, want)
+	}
+}
+
+func TestBuiltinToolAppendedToFunctionTools(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+
+	req := userRequest("weather in Paris?")
+	req.Tools = []llmrouter.Tool{weatherTool()}
+	req.BuiltinTools = []string{llmrouter.BuiltinWebSearch}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	tools, _ := api.last().JSON()["tools"].([]any)
+	if len(tools) != 2 {
+		t.Fatalf("tools = %v, want the function tool and web search", api.last().JSON()["tools"])
+	}
+	if got := tools[0].(map[string]any)["name"]; got != "weather" {
+		t.Errorf("tools[0] = %v, want the weather function", tools[0])
+	}
+	if got := tools[1].(map[string]any); got["type"] != "web_search_20250305" || got["name"] != "web_search" {
+		t.Errorf("tools[1] = %v, want the web search server tool", got)
+	}
+}
+
+func TestServerToolUseNotReturnedAsToolCall(t *testing.T) {
+	p, _ := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn",
+			map[string]any{"type": "server_tool_use", "id": "srvtoolu_1", "name": "web_search", "input": map[string]any{"query": "weather"}},
+			map[string]any{"type": "web_search_tool_result", "tool_use_id": "srvtoolu_1", "content": []any{}},
+			textBlock("It rained."),
+		))
+	})
+
+	req := userRequest("weather yesterday?")
+	req.BuiltinTools = []string{llmrouter.BuiltinWebSearch}
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := resp.Choices[0].Message
+	if len(msg.ToolCalls) != 0 {
+		t.Errorf("tool calls = %+v, want the server tool use skipped", msg.ToolCalls)
+	}
+	if msg.Content != "It rained." || resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("choice = %q, %q; want the text and stop", msg.Content, resp.Choices[0].FinishReason)
+	}
+}
+
+func TestStreamBuiltinToolCitations(t *testing.T) {
+	p, _ := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			map[string]any{"type": "message_start", "message": message("")},
+			map[string]any{"type": "content_block_start", "index": 0, "content_block": map[string]any{"type": "server_tool_use", "id": "srvtoolu_1", "name": "web_search", "input": map[string]any{}}},
+			map[string]any{"type": "content_block_delta", "index": 0, "delta": map[string]any{"type": "input_json_delta", "partial_json": `{"query":"weather"}`}},
+			map[string]any{"type": "content_block_stop", "index": 0},
+			map[string]any{"type": "content_block_start", "index": 1, "content_block": textBlock("")},
+			map[string]any{"type": "content_block_delta", "index": 1, "delta": map[string]any{"type": "citations_delta", "citation": map[string]any{
+				"type": "web_search_result_location", "url": "https://news.example", "title": "Weather", "cited_text": "rain all day",
+			}}},
+			map[string]any{"type": "content_block_delta", "index": 1, "delta": map[string]any{"type": "text_delta", "text": "It rained."}},
+			map[string]any{"type": "content_block_stop", "index": 1},
+			map[string]any{"type": "message_delta", "delta": map[string]any{"stop_reason": "end_turn"}, "usage": map[string]any{"output_tokens": 2}},
+			map[string]any{"type": "message_stop"},
+		)
+	})
+
+	req := userRequest("weather yesterday?")
+	req.BuiltinTools = []string{llmrouter.BuiltinWebSearch}
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+
+	if len(events) != 2 || events[0].Type != llmrouter.EventContentDelta || events[0].Content != "It rained." {
+		t.Fatalf("events = %+v, want the text delta then done", events)
+	}
+	done := events[1]
+	if done.Type != llmrouter.EventDone {
+		t.Fatalf("last event = %+v, want done", done)
+	}
+	msg := done.Response.Choices[0].Message
+	if msg.Content != "It rained." || len(msg.ToolCalls) != 0 {
+		t.Errorf("message = %+v, want the text and no tool calls", msg)
+	}
+	want := llmrouter.Citation{URL: "https://news.example", Title: "Weather", Snippet: "rain all day"}
+	if len(done.Response.Citations) != 1 || done.Response.Citations[0] != want {
+		t.Errorf("citations = %+v, want %+v", done.Response.Citations, want)
+	}
+}
+
+func TestUnsupportedBuiltinTool(t *testing.T) {
+	p := New(llmrouter.ProviderConfig{APIKey: "test"})
+	req := userRequest("hi")
+	req.BuiltinTools = []string{"code_interpreter"}
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestTemperatureRange(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+
+	req := userRequest("hello")
+	temp := 1.5
+	req.Temperature = &temp
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrInvalidRequest) {
+		t.Errorf("error = %v, want ErrInvalidRequest for temperature 1.5", err)
+	}
+
+	var clamped float64
+	p.WithTemperatureClamp(func(requested, c float64) { clamped = c })
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if clamped != 1 || api.last().JSON()["temperature"] != float64(1) {
+		t.Errorf("temperature sent = %v, clamped to %v; want 1", api.last().JSON()["temperature"], clamped)
+	}
+}
+
+func TestUserAgentAppended(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{UserAgent: "my-app/1.2"}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+
+	if _, err := p.Complete(context.Background(), userRequest("hello")); err != nil {
+		t.Fatal(err)
+	}
+	ua := api.last().Header.Get("User-Agent")
+	if !strings.HasSuffix(ua, " my-app/1.2") || strings.HasPrefix(ua, "my-app") {
+		t.Errorf("User-Agent = %q, want the SDK's followed by my-app/1.2", ua)
+	}
+}
+
+func TestFinishDetailsKeepRawReason(t *testing.T) {
+	tests := []struct {
+		stopReason string
+		content    map[string]any
+		reason     string
+		toolCalled bool
+	}{
+		{"end_turn", textBlock("hi"), "stop", false},
+		{"max_tokens", textBlock("hi"), "length", false},
+		{"stop_sequence", textBlock("hi"), "stop", false},
+		{"tool_use", toolUseBlock("call_1", "weather", map[string]any{"city": "Paris"}), "tool_calls", true},
+		{"pause_turn", textBlock("hi"), "pause_turn", false},
+		{"refusal", textBlock("hi"), "refusal", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stopReason, func(t *testing.T) {
+			p, _ := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, message(tt.stopReason, tt.content))
+			})
+
+			resp, err := p.Complete(context.Background(), userRequest("hello"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := resp.Choices[0]
+			want := llmrouter.FinishDetails{Reason: tt.reason, RawReason: tt.stopReason, ToolCalled: tt.toolCalled}
+			if c.FinishReason != tt.reason || c.FinishDetails == nil || *c.FinishDetails != want {
+				t.Errorf("finish = %q, %+v; want %q, %+v", c.FinishReason, c.FinishDetails, tt.reason, want)
+			}
+		})
+	}
+}
+
+func TestStreamFinishDetailsKeepRawReason(t *testing.T) {
+	p, _ := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w, textStream("max_tokens", "hel", "lo")...)
+	})
+
+	ch, err := p.Stream(context.Background(), userRequest("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	done := events[len(events)-1]
+	if done.Type != llmrouter.EventDone || done.Response == nil {
+		t.Fatalf("last event = %+v, want done with a response", done)
+	}
+	d := done.Response.Choices[0].FinishDetails
+	if d == nil || d.Reason != "length" || d.RawReason != "max_tokens" {
+		t.Errorf("finish details = %+v, want length from max_tokens", d)
+	}
+}
+
+func TestStreamUnknownStopReasonPassedThrough(t *testing.T) {
+	p, _ := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w, textStream("pause_turn", "hel", "lo")...)
+	})
+
+	ch, err := p.Stream(context.Background(), userRequest("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	done := events[len(events)-1]
+	if done.Type != llmrouter.EventDone || done.Response == nil || done.Response.Choices[0].FinishReason != "pause_turn" {
+		t.Errorf("last event = %+v, want done with finish reason pause_turn", done)
+	}
+}
+
+// toolResultRequest builds a conversation ending in a result for a weather call
+func toolResultRequest(result llmrouter.Message) *llmrouter.Request {
+	req := userRequest("what's the weather?")
+	req.Tools = []llmrouter.Tool{weatherTool()}
+	req.Messages = append(req.Messages,
+		llmrouter.Message{Role: llmrouter.RoleAssistant, ToolCalls: []llmrouter.ToolCall{{
+			ID: "call_1", Type: "function",
+			Function: llmrouter.FuncCall{Name: "weather", Arguments: `{"city":"Paris"}`},
+		}}},
+		result,
+	)
+	return req
+}
+
+// lastToolResult returns the tool_result block of the last message sent
+func lastToolResult(t *testing.T, api *fakeAPI) map[string]any {
+	t.Helper()
+	messages := api.last().JSON()["messages"].([]any)
+	last := messages[len(messages)-1].(map[string]any)
+	block := last["content"].([]any)[0].(map[string]any)
+	if last["role"] != "user" || block["type"] != "tool_result" || block["tool_use_id"] != "call_1" {
+		t.Fatalf("last message = %v, want a tool_result for call_1", last)
+	}
+	return block
+}
+
+func TestMultimodalToolResult(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("sunny")))
+	})
+
+	req := toolResultRequest(llmrouter.Message{
+		Role:       llmrouter.RoleTool,
+		ToolCallID: "call_1",
+		Content:    "chart attached",
+		ContentParts: []llmrouter.ContentPart{
+			{Type: "image_url", ImageURL: &llmrouter.ImageURL{Base64: "iVBORw0KGgo=", MediaType: "image/png"}},
+			{Type: "text", Text: "20°C"},
+			{Type: "image_url", ImageURL: &llmrouter.ImageURL{URL: "https://example.com/no-base64.png"}},
+		},
+	})
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	content := lastToolResult(t, api)["content"].([]any)
+	if len(content) != 3 {
+		t.Fatalf("tool result content = %v, want text, image, text", content)
+	}
+	if c := content[0].(map[string]any); c["type"] != "text" || c["text"] != "chart attached" {
+		t.Errorf("content[0] = %v, want the message content as text", c)
+	}
+	image := content[1].(map[string]any)
+	source, _ := image["source"].(map[string]any)
+	if image["type"] != "image" || source["type"] != "base64" || source["media_type"] != "image/png" || source["data"] != "iVBORw0KGgo=" {
+		t.Errorf("content[1] = %v, want the base64 image", image)
+	}
+	if c := content[2].(map[string]any); c["type"] != "text" || c["text"] != "20°C" {
+		t.Errorf("content[2] = %v, want the text part", c)
+	}
+}
+
+func TestDocumentBlocks(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("summary")))
+	})
+
+	req := &llmrouter.Request{Messages: []llmrouter.Message{{Role: llmrouter.RoleUser, ContentParts: []llmrouter.ContentPart{
+		{Type: "text", Text: "Summarize these."},
+		{Type: "document", Document: &llmrouter.Document{Base64: "JVBERi0="}},
+		{Type: "document", Document: &llmrouter.Document{Base64: "aGVsbG8=", MediaType: "text/plain"}},
+		{Type: "document", Document: &llmrouter.Document{URI: "https://example.com/report.pdf"}},
+		{Type: "document", Document: &llmrouter.Document{}},
+	}}}}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, _ := api.last().JSON()["messages"].([]any)
+	content, _ := messages[0].(map[string]any)["content"].([]any)
+	var sources []any
+	for _, c := range content {
+		if block := c.(map[string]any); block["type"] == "document" {
+			sources = append(sources, block["source"])
+		}
+	}
+	want := []any{
+		map[string]any{"type": "base64", "media_type": "application/pdf", "data": "JVBERi0="},
+		map[string]any{"type": "base64", "media_type": "text/plain", "data": "aGVsbG8="},
+		map[string]any{"type": "url", "url": "https://example.com/report.pdf"},
+	}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("document sources = %v, want %v", sources, want)
+	}
+}
+
+func TestTextToolResult(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("sunny")))
+	})
+
+	req := toolResultRequest(llmrouter.Message{Role: llmrouter.RoleTool, ToolCallID: "call_1", Content: "20°C"})
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	content := lastToolResult(t, api)["content"].([]any)
+	if len(content) != 1 || content[0].(map[string]any)["text"] != "20°C" {
+		t.Errorf("tool result content = %v, want the text result", content)
+	}
+}
+
+func TestMetadataForwarded(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+
+	req := userRequest("hello")
+	req.Metadata = map[string]any{llmrouter.MetadataUserID: "user-1", llmrouter.MetadataTraceID: "trace-42"}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, _ := api.last().JSON()["metadata"].(map[string]any)
+	if len(metadata) != 1 || metadata["user_id"] != "user-1" {
+		t.Errorf("metadata = %v, want only user_id", metadata)
+	}
+}
+
+// interleavedWant is the blocks of a reply that checks the weather in two
+// cities, with text around each call
+func interleavedWant() []llmrouter.ContentPart {
+	return []llmrouter.ContentPart{
+		{Type: "text", Text: "Checking Paris."},
+		{Type: "tool_call", ToolCall: &llmrouter.ToolCall{ID: "call_1", Type: "function", Function: llmrouter.FuncCall{Name: "weather", Arguments: `{"city":"Paris"}`}}},
+		{Type: "text", Text: "Now Rome."},
+		{Type: "tool_call", ToolCall: &llmrouter.ToolCall{ID: "call_2", Type: "function", Function: llmrouter.FuncCall{Name: "weather", Arguments: `{"city":"Rome"}`}}},
+	}
+}
+
+func TestInterleavedContentBlocks(t *testing.T) {
+	p, _ := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("tool_use",
+			textBlock("Checking Paris."),
+			toolUseBlock("call_1", "weather", map[string]any{"city": "Paris"}),
+			textBlock("Now Rome."),
+			toolUseBlock("call_2", "weather", map[string]any{"city": "Rome"}),
+		))
+	})
+
+	req := userRequest("weather in Paris and Rome?")
+	req.Tools = []llmrouter.Tool{weatherTool()}
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := resp.Choices[0].Message
+	if !reflect.DeepEqual(msg.ContentBlocks, interleavedWant()) {
+		t.Errorf("blocks = %+v, want text and calls in order", msg.ContentBlocks)
+	}
+	if msg.Content != "Checking Paris.Now Rome." || len(msg.ToolCalls) != 2 || msg.ToolCalls[1].ID != "call_2" {
+		t.Errorf("message = %+v, want Content and ToolCalls kept", msg)
+	}
+}
+
+func TestStreamInterleavedContentBlocks(t *testing.T) {
+	p, _ := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		events := []map[string]any{{"type": "message_start", "message": message("")}}
+		for i, b := range interleavedWant() {
+			var start, delta map[string]any
+			if b.ToolCall != nil {
+				start = toolUseBlock(b.ToolCall.ID, b.ToolCall.Function.Name, map[string]any{})
+				delta = map[string]any{"type": "input_json_delta", "partial_json": b.ToolCall.Function.Arguments}
+			} else {
+				start = textBlock("")
+				delta = map[string]any{"type": "text_delta", "text": b.Text}
+			}
+			events = append(events,
+				map[string]any{"type": "content_block_start", "index": i, "content_block": start},
+				map[string]any{"type": "content_block_delta", "index": i, "delta": delta},
+				map[string]any{"type": "content_block_stop", "index": i},
+			)
+		}
+		events = append(events,
+			map[string]any{"type": "message_delta", "delta": map[string]any{"stop_reason": "tool_use"}, "usage": map[string]any{"output_tokens": 9}},
+			map[string]any{"type": "message_stop"},
+		)
+		writeSSE(w, events...)
+	})
+
+	req := userRequest("weather in Paris and Rome?")
+	req.Tools = []llmrouter.Tool{weatherTool()}
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+
+	done := events[len(events)-1]
+	if done.Type != llmrouter.EventDone {
+		t.Fatalf("last event = %+v, want done", done)
+	}
+	msg := done.Response.Choices[0].Message
+	if !reflect.DeepEqual(msg.ContentBlocks, interleavedWant()) {
+		t.Errorf("blocks = %+v, want text and calls in order", msg.ContentBlocks)
+	}
+	if msg.Content != "Checking Paris.Now Rome." || len(msg.ToolCalls) != 2 {
+		t.Errorf("message = %+v, want Content and ToolCalls kept", msg)
+	}
+}
+
+// personFormat asks for JSON matching a person schema
+func personFormat() *llmrouter.ResponseFormat {
+	return &llmrouter.ResponseFormat{Type: "json_schema", JSONSchema: &llmrouter.JSONSchema{
+		Name:        "person",
+		Description: "A person.",
+		Schema:      json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"},"age":{"type":"integer"}},"required":["name","age"]}`),
+		Strict:      true,
+	}}
+}
+
+func TestStructuredOutputForcedTool(t *testing.T) {
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("tool_use",
+			textBlock("Here is the person."),
+			toolUseBlock("call_1", "person", map[string]any{"name": "Ada", "age": 36}),
+		))
+	})
+
+	req := userRequest("who wrote the first program?")
+	req.ResponseFormat = personFormat()
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := api.last().JSON()
+	tools := body["tools"].([]any)
+	tool := tools[len(tools)-1].(map[string]any)
+	schema, _ := tool["input_schema"].(map[string]any)
+	if tool["name"] != "person" || tool["description"] != "A person." || schema["type"] != "object" || schema["properties"] == nil {
+		t.Errorf("tool = %v, want the schema as the person tool's input", tool)
+	}
+	if choice := body["tool_choice"].(map[string]any); choice["type"] != "tool" || choice["name"] != "person" {
+		t.Errorf("tool_choice = %v, want the person tool forced", choice)
+	}
+
+	msg := resp.Choices[0].Message
+	var person struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	if err := json.Unmarshal([]byte(msg.Content), &person); err != nil || person.Name != "Ada" || person.Age != 36 {
+		t.Errorf("content = %q, want the tool input as a person object", msg.Content)
+	}
+	if len(msg.ToolCalls) != 0 || resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("tool calls %v, finish %q; want the forced call turned into the reply", msg.ToolCalls, resp.Choices[0].FinishReason)
+	}
+	if d := resp.Choices[0].FinishDetails; d.Reason != "stop" || d.ToolCalled {
+		t.Errorf("finish details = %+v, want stop without a tool call", d)
+	}
+}
+
+func TestStructuredOutputKeepsOtherToolCalls(t *testing.T) {
+	p, _ := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("tool_use",
+			toolUseBlock("call_1", "weather", map[string]any{"city": "Paris"}),
+			toolUseBlock("call_2", "json_response", map[string]any{"ok": true}),
+		))
+	})
+
+	req := userRequest("hi")
+	req.Tools = []llmrouter.Tool{weatherTool()}
+	req.ResponseFormat = &llmrouter.ResponseFormat{Type: "json_object"}
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := resp.Choices[0]
+	if c.Message.Content != `{"ok":true}` {
+		t.Errorf("content = %q, want the json_response input", c.Message.Content)
+	}
+	if len(c.Message.ToolCalls) != 1 || c.Message.ToolCalls[0].Function.Name != "weather" || c.FinishReason != "tool_calls" {
+		t.Errorf("tool calls %v, finish %q; want the weather call kept", c.Message.ToolCalls, c.FinishReason)
+	}
+	blocks := c.Message.ContentBlocks
+	if len(blocks) != 2 || blocks[0].ToolCall == nil || blocks[0].ToolCall.ID != "call_1" || blocks[1].Type != "text" || blocks[1].Text != `{"ok":true}` {
+		t.Errorf("blocks = %+v, want the weather call then the JSON as text", blocks)
+	}
+}
+
+func TestStructuredOutputStream(t *testing.T) {
+	p, _ := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			map[string]any{"type": "message_start", "message": message("")},
+			map[string]any{"type": "content_block_start", "index": 0, "content_block": toolUseBlock("call_1", "person", map[string]any{})},
+			map[string]any{"type": "content_block_delta", "index": 0, "delta": map[string]any{"type": "input_json_delta", "partial_json": `{"name":"Ada",`}},
+			map[string]any{"type": "content_block_delta", "index": 0, "delta": map[string]any{"type": "input_json_delta", "partial_json": `"age":36}`}},
+			map[string]any{"type": "content_block_stop", "index": 0},
+			map[string]any{"type": "message_delta", "delta": map[string]any{"stop_reason": "tool_use"}, "usage": map[string]any{"output_tokens": 9}},
+			map[string]any{"type": "message_stop"},
+		)
+	})
+
+	req := userRequest("who wrote the first program?")
+	req.ResponseFormat = personFormat()
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content string
+	var done *llmrouter.Response
+	for _, event := range collect(ch) {
+		switch event.Type {
+		case llmrouter.EventContentDelta:
+			content += event.Content
+		case llmrouter.EventToolCallDelta:
+			t.Errorf("tool call delta %+v, want the forced tool streamed as content", event.Delta)
+		case llmrouter.EventDone:
+			done = event.Response
+		}
+	}
+	if content != `{"name":"Ada","age":36}` {
+		t.Errorf("streamed content = %q", content)
+	}
+	if done == nil || done.Choices[0].Message.Content != content || len(done.Choices[0].Message.ToolCalls) != 0 || done.Choices[0].FinishReason != "stop" {
+		t.Errorf("final response = %+v, want the JSON as content and finish stop", done)
+	}
+}
+
+func TestClient(t *testing.T) {
+	if New(llmrouter.ProviderConfig{APIKey: "test"}).Client() == nil {
+		t.Fatal("Client() = nil")
+	}
+
+	p, api := newTestProvider(t, llmrouter.ProviderConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, message("end_turn", textBlock("hi")))
+	})
+	_, err := p.Client().Messages.New(context.Background(), anthropic.MessageNewParams{
+		Model:     anthropic.F(anthropic.ModelClaude3_5HaikuLatest),
+		MaxTokens: anthropic.F(int64(16)),
+		Messages:  anthropic.F([]anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock("hello"))}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.last().JSON()["model"] != "claude-3-5-haiku-latest" {
+		t.Error("direct SDK call didn't reach the provider's endpoint")
+	}
+}
@@ -0,0 +1,111 @@
+package gemini
+
+import (
+	"context"
+	"log"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/google/generative-ai-go/genai"
+)
+
+// maxCacheEntries bounds how many cached content names the provider remembers
+const maxCacheEntries = 256
+
+type cacheEntry struct {
+	name      string
+	expiresAt time.Time
+}
+
+// WithCacheTTL sets how long cached content created for CacheHint prefixes
+// lives. A ttl of zero or less keeps the default of one hour.
+func (p *Provider) WithCacheTTL(ttl time.Duration) *Provider {
+	if ttl > 0 {
+		p.cacheTTL = ttl
+	}
+	return p
+}
+
+// localExpiry returns when a cache created now should stop being reused. It
+// is a minute before the server expires it, or halfway for short TTLs, so we
+// never reference a dead cache.
+func localExpiry(now time.Time, ttl time.Duration) time.Time {
+	margin := time.Minute
+	if ttl <= 2*margin {
+		margin = ttl / 2
+	}
+	return now.Add(ttl - margin)
+}
+
+// cachedPrefix creates or reuses Gemini cached content covering every message
+// up to and including the last one with a CacheHint. It returns the cached
+// content name and the messages that still need to be sent, or an empty name
+// if the request carries no hint. Caching is best-effort: if the cached
+// content can't be created, the full history is sent uncached.
+func (p *Provider) cachedPrefix(ctx context.Context, req *llmrouter.Request, modelName string, model *genai.GenerativeModel) (string, []llmrouter.Message, error) {
+	last := -1
+	for i, msg := range req.Messages {
+		if msg.CacheHint {
+			last = i
+		}
+	}
+	// The final message is sent as the prompt and can't be part of the cache
+	if last < 0 || last == len(req.Messages)-1 {
+		return "", req.Messages, nil
+	}
+
+	prefix := req.Messages[:last+1]
+	key := (&llmrouter.Request{Model: modelName, Messages: prefix, Tools: req.Tools, ToolChoice: req.ToolChoice}).Hash()
+
+	p.cacheMu.Lock()
+	entry, ok := p.caches[key]
+	p.cacheMu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.name, req.Messages[last+1:], nil
+	}
+
+	contents, lastParts := convertHistory(prefix)
+	if len(lastParts) > 0 {
+		contents = append(contents, &genai.Content{Role: "user", Parts: lastParts})
+	}
+
+	cc, err := p.createCache(ctx, &genai.CachedContent{
+		Model:             modelName,
+		SystemInstruction: model.SystemInstruction,
+		Contents:          contents,
+		Tools:             model.Tools,
+		ToolConfig:        model.ToolConfig,
+		Expiration:        genai.ExpireTimeOrTTL{TTL: p.cacheTTL},
+	})
+	if err != nil {
+		log.Printf("llmrouter: gemini: creating cached content failed, sending uncached: %v", err)
+		return "", req.Messages, nil
+	}
+
+	now := time.Now()
+	p.cacheMu.Lock()
+	p.evictCaches(now)
+	p.caches[key] = cacheEntry{name: cc.Name, expiresAt: localExpiry(now, p.cacheTTL)}
+	p.cacheMu.Unlock()
+
+	return cc.Name, req.Messages[last+1:], nil
+}
+
+// evictCaches drops expired entries and, if the map is still full, the one
+// that expires soonest. The caller must hold cacheMu.
+func (p *Provider) evictCaches(now time.Time) {
+	for key, entry := range p.caches {
+		if !now.Before(entry.expiresAt) {
+			delete(p.caches, key)
+		}
+	}
+	for len(p.caches) >= maxCacheEntries {
+		var oldest string
+		for key, entry := range p.caches {
+			if oldest == "" || entry.expiresAt.Before(p.caches[oldest].expiresAt) {
+				oldest = key
+			}
+		}
+		delete(p.caches, oldest)
+	}
+}
@@ -0,0 +1,154 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/google/generative-ai-go/genai"
+)
+
+// cachedRequest has a hinted prefix followed by the prompt
+func cachedRequest() *llmrouter.Request {
+	return &llmrouter.Request{Messages: []llmrouter.Message{
+		{Role: llmrouter.RoleSystem, Content: "long instructions"},
+		{Role: llmrouter.RoleUser, Content: "long document", CacheHint: true},
+		{Role: llmrouter.RoleUser, Content: "question"},
+	}}
+}
+
+// fakeCaches replaces cached content creation, which the SDK only offers
+// over gRPC, recording what was cached
+type fakeCaches struct {
+	created []*genai.CachedContent
+	err     error
+}
+
+func (f *fakeCaches) create(ctx context.Context, cc *genai.CachedContent) (*genai.CachedContent, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.created = append(f.created, cc)
+	return &genai.CachedContent{Name: "cachedContents/abc", Model: cc.Model}, nil
+}
+
+func TestCacheHintCreatesAndReusesCachedContent(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+	caches := &fakeCaches{}
+	p.createCache = caches.create
+
+	for i := 0; i < 2; i++ {
+		model, history, lastParts, err := p.prepare(context.Background(), cachedRequest(), "gemini-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if model.CachedContentName != "cachedContents/abc" {
+			t.Errorf("call %d: CachedContentName = %q", i, model.CachedContentName)
+		}
+		if model.SystemInstruction != nil {
+			t.Errorf("call %d: system instruction sent alongside the cache", i)
+		}
+		if len(history) != 0 || len(lastParts) != 1 {
+			t.Errorf("call %d: sent %d history turns and %d parts, want only the prompt", i, len(history), len(lastParts))
+		}
+	}
+
+	if len(caches.created) != 1 {
+		t.Fatalf("created %d cached contents, want 1 reused", len(caches.created))
+	}
+	cc := caches.created[0]
+	if cc.SystemInstruction == nil || len(cc.Contents) != 1 || cc.Expiration.TTL != time.Hour {
+		t.Errorf("cached content = %+v, want the system instruction and hinted turn for an hour", cc)
+	}
+}
+
+func TestCacheCreationFailureSendsUncached(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+	p.createCache = (&fakeCaches{err: errors.New("too small to cache")}).create
+
+	model, history, lastParts, err := p.prepare(context.Background(), cachedRequest(), "gemini-test")
+	if err != nil {
+		t.Fatalf("cache failure surfaced as %v", err)
+	}
+	if model.CachedContentName != "" || model.SystemInstruction == nil {
+		t.Error("request was not sent uncached")
+	}
+	if len(history) != 1 || len(lastParts) != 1 {
+		t.Errorf("sent %d history turns and %d parts, want the full conversation", len(history), len(lastParts))
+	}
+	if len(p.caches) != 0 {
+		t.Error("failed cache was remembered")
+	}
+}
+
+func TestNoCacheWithoutHint(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+	caches := &fakeCaches{}
+	p.createCache = caches.create
+
+	req := cachedRequest()
+	req.Messages[1].CacheHint = false
+	// A hint on the prompt itself leaves nothing to cache
+	req.Messages[2].CacheHint = true
+	if _, _, _, err := p.prepare(context.Background(), req, "gemini-test"); err != nil {
+		t.Fatal(err)
+	}
+	if len(caches.created) != 0 {
+		t.Errorf("created %d cached contents, want none", len(caches.created))
+	}
+}
+
+func TestLocalExpiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []struct {
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{time.Hour, 59 * time.Minute},
+		{5 * time.Minute, 4 * time.Minute},
+		{2 * time.Minute, time.Minute},
+		{30 * time.Second, 15 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := localExpiry(now, tt.ttl).Sub(now); got != tt.want {
+			t.Errorf("localExpiry with ttl %v = +%v, want +%v", tt.ttl, got, tt.want)
+		}
+	}
+}
+
+func TestEvictCaches(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+	now := time.Unix(1000, 0)
+
+	p.caches["expired"] = cacheEntry{name: "e", expiresAt: now}
+	for i := 0; i < maxCacheEntries; i++ {
+		p.caches[string(rune('a'+i%26))+strings.Repeat("x", i/26)] = cacheEntry{expiresAt: now.Add(time.Duration(i+1) * time.Minute)}
+	}
+	p.evictCaches(now)
+
+	if _, ok := p.caches["expired"]; ok {
+		t.Error("expired entry kept")
+	}
+	if len(p.caches) != maxCacheEntries-1 {
+		t.Errorf("%d entries left, want room for one more", len(p.caches))
+	}
+	if _, ok := p.caches["a"]; ok {
+		t.Error("the entry expiring soonest was kept")
+	}
+}
+
+func TestWithCacheTTLIgnoresNonPositive(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+	p.WithCacheTTL(0).WithCacheTTL(-time.Minute)
+	if p.cacheTTL != time.Hour {
+		t.Errorf("cacheTTL = %v, want the default hour", p.cacheTTL)
+	}
+	p.WithCacheTTL(10 * time.Minute)
+	if p.cacheTTL != 10*time.Minute {
+		t.Errorf("cacheTTL = %v, want 10m", p.cacheTTL)
+	}
+}
@@ -3,6 +3,9 @@ package gemini
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	llmrouter "github.com/bluefunda/llm-router"
@@ -55,19 +58,27 @@ func convertHistory(msgs []llmrouter.Message) ([]*genai.Content, []genai.Part) {
 
 		case llmrouter.RoleTool:
 			// Tool results
+			text := msg.Text()
 			var result map[string]interface{}
-			_ = json.Unmarshal([]byte(msg.Content), &result)
+			_ = json.Unmarshal([]byte(text), &result)
 			if result == nil {
-				result = map[string]interface{}{"result": msg.Content}
+				result = map[string]interface{}{"result": text}
 			}
-			history = append(history, &genai.Content{
-				Role: "function",
-				Parts: []genai.Part{
-					genai.FunctionResponse{
-						Name:     msg.Name,
-						Response: result,
-					},
+			parts := []genai.Part{
+				genai.FunctionResponse{
+					Name:     msg.Name,
+					Response: result,
 				},
+			}
+			// Images returned by the tool travel alongside the function response
+			for _, p := range buildUserParts(llmrouter.Message{ContentParts: msg.ContentParts}) {
+				if _, ok := p.(genai.Blob); ok {
+					parts = append(parts, p)
+				}
+			}
+			history = append(history, &genai.Content{
+				Role:  "function",
+				Parts: parts,
 			})
 		}
 	}
@@ -90,6 +101,10 @@ func buildUserParts(msg llmrouter.Message) []genai.Part {
 						parts = append(parts, genai.ImageData(p.ImageURL.MediaType, imgBytes))
 					}
 				}
+			case "document":
+				if part := convertDocument(p.Document); part != nil {
+					parts = append(parts, part)
+				}
 			}
 		}
 		return parts
@@ -97,6 +112,46 @@ func buildUserParts(msg llmrouter.Message) []genai.Part {
 	return []genai.Part{genai.Text(msg.Content)}
 }
 
+// checkDocuments rejects document parts Gemini can't take: those without a
+// media type, and inline data that isn't valid base64
+func checkDocuments(msgs []llmrouter.Message) error {
+	for _, msg := range msgs {
+		for _, p := range msg.ContentParts {
+			if p.Type != "document" || p.Document == nil {
+				continue
+			}
+			if p.Document.MediaType == "" {
+				return fmt.Errorf("%w: document media type is required", llmrouter.ErrInvalidRequest)
+			}
+			if p.Document.Base64 != "" {
+				if _, err := base64.StdEncoding.DecodeString(p.Document.Base64); err != nil {
+					return fmt.Errorf("%w: document data: %v", llmrouter.ErrInvalidRequest, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// convertDocument converts a document to inline data or, for a URI, file
+// data. Documents are validated by checkDocuments first.
+func convertDocument(doc *llmrouter.Document) genai.Part {
+	switch {
+	case doc == nil:
+		return nil
+	case doc.Base64 != "":
+		data, err := base64.StdEncoding.DecodeString(doc.Base64)
+		if err != nil {
+			return nil
+		}
+		return genai.Blob{MIMEType: doc.MediaType, Data: data}
+	case doc.URI != "":
+		return genai.FileData{MIMEType: doc.MediaType, URI: doc.URI}
+	default:
+		return nil
+	}
+}
+
 // convertTools converts llmrouter tools to Gemini format
 func convertTools(tools []llmrouter.Tool) []*genai.Tool {
 	funcDecls := make([]*genai.FunctionDeclaration, len(tools))
@@ -121,6 +176,29 @@ func convertTools(tools []llmrouter.Tool) []*genai.Tool {
 	}
 }
 
+// convertToolChoice maps a tool choice to Gemini's function calling config:
+// "auto" to AUTO, "required" to ANY, "none" to NONE, and a named function to
+// ANY restricted to that function. Nil leaves the model default.
+func convertToolChoice(tc *llmrouter.ToolChoice) *genai.ToolConfig {
+	if tc == nil {
+		return nil
+	}
+
+	cfg := &genai.FunctionCallingConfig{}
+	switch {
+	case tc.Type == "function" && tc.Function != nil:
+		cfg.Mode = genai.FunctionCallingAny
+		cfg.AllowedFunctionNames = []string{tc.Function.Name}
+	case tc.Type == "required":
+		cfg.Mode = genai.FunctionCallingAny
+	case tc.Type == "none":
+		cfg.Mode = genai.FunctionCallingNone
+	default:
+		cfg.Mode = genai.FunctionCallingAuto
+	}
+	return &genai.ToolConfig{FunctionCallingConfig: cfg}
+}
+
 // convertSchema converts a JSON schema to Gemini Schema
 func convertSchema(params map[string]interface{}) *genai.Schema {
 	if params == nil {
@@ -197,13 +275,77 @@ func convertPropertySchema(prop map[string]interface{}) *genai.Schema {
 	return schema
 }
 
-// convertResponse converts Gemini response to OpenAI-compatible format
-func convertResponse(resp *genai.GenerateContentResponse, model, provider string) *llmrouter.Response {
+// convertResponse converts Gemini response to OpenAI-compatible format. A
+// response without candidates is an error rather than an empty success.
+func convertResponse(resp *genai.GenerateContentResponse, model, provider string) (*llmrouter.Response, error) {
+	if len(resp.Candidates) == 0 {
+		return nil, noCandidatesError(resp.PromptFeedback)
+	}
+
+	choices := make([]llmrouter.Choice, 0, len(resp.Candidates))
+	var citations []llmrouter.Citation
+	for i, candidate := range resp.Candidates {
+		choice := convertCandidate(candidate, i)
+		choices = append(choices, choice)
+		citations = append(citations, convertCitations(candidate.CitationMetadata, choice.Message.Content)...)
+	}
+
+	return &llmrouter.Response{
+		Model:     model,
+		Provider:  provider,
+		Object:    "chat.completion",
+		Created:   time.Now().Unix(),
+		Choices:   choices,
+		Usage:     convertUsage(resp.UsageMetadata),
+		Citations: citations,
+	}, nil
+}
+
+// convertCitations extracts source attributions, using the attributed
+// segment of text, the reply the indices refer to, as the snippet
+func convertCitations(meta *genai.CitationMetadata, text string) []llmrouter.Citation {
+	if meta == nil {
+		return nil
+	}
+
+	var citations []llmrouter.Citation
+	for _, src := range meta.CitationSources {
+		if src == nil || src.URI == nil {
+			continue
+		}
+		c := llmrouter.Citation{URL: *src.URI}
+		if src.StartIndex != nil && src.EndIndex != nil {
+			start, end := int(*src.StartIndex), int(*src.EndIndex)
+			if start >= 0 && start < end && end <= len(text) {
+				c.Snippet = text[start:end]
+			}
+		}
+		citations = append(citations, c)
+	}
+	return citations
+}
+
+// convertUsage converts Gemini usage metadata. The API's prompt token count
+// already covers the system instruction, tool declarations and any cached
+// content, so no adjustment is needed.
+func convertUsage(md *genai.UsageMetadata) *llmrouter.Usage {
+	if md == nil {
+		return nil
+	}
+	return &llmrouter.Usage{
+		PromptTokens:     int(md.PromptTokenCount),
+		CompletionTokens: int(md.CandidatesTokenCount),
+		TotalTokens:      int(md.TotalTokenCount),
+	}
+}
+
+// convertCandidate converts a single Gemini candidate to a choice
+func convertCandidate(candidate *genai.Candidate, index int) llmrouter.Choice {
 	var content string
 	var toolCalls []llmrouter.ToolCall
 
-	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
-		for _, part := range resp.Candidates[0].Content.Parts {
+	if candidate.Content != nil {
+		for _, part := range candidate.Content.Parts {
 			switch p := part.(type) {
 			case genai.Text:
 				content += string(p)
@@ -221,47 +363,85 @@ func convertResponse(resp *genai.GenerateContentResponse, model, provider string
 		}
 	}
 
-	finishReason := "stop"
-	if len(toolCalls) > 0 {
-		finishReason = "tool_calls"
-	} else if len(resp.Candidates) > 0 {
-		switch resp.Candidates[0].FinishReason {
-		case genai.FinishReasonMaxTokens:
-			finishReason = "length"
-		case genai.FinishReasonStop:
-			finishReason = "stop"
-		case genai.FinishReasonSafety:
-			finishReason = "content_filter"
+	finishReason := "tool_calls"
+	if len(toolCalls) == 0 {
+		finishReason = convertFinishReason(candidate.FinishReason)
+	}
+
+	return llmrouter.Choice{
+		Index: index,
+		Message: &llmrouter.Message{
+			Role:      llmrouter.RoleAssistant,
+			Content:   content,
+			ToolCalls: toolCalls,
+		},
+		FinishReason:  finishReason,
+		FinishDetails: llmrouter.NewFinishDetails(finishReason, rawFinishReason(candidate.FinishReason), len(toolCalls) > 0),
+	}
+}
+
+// convertFinishReason maps a Gemini finish reason to the normalized reason.
+// Reasons without an equivalent, such as recitation, are passed through in
+// lower case, e.g. "recitation".
+func convertFinishReason(r genai.FinishReason) string {
+	switch r {
+	case genai.FinishReasonStop, genai.FinishReasonUnspecified:
+		return "stop"
+	case genai.FinishReasonMaxTokens:
+		return "length"
+	case genai.FinishReasonSafety:
+		return "content_filter"
+	default:
+		name := r.String()
+		if rest := strings.TrimPrefix(name, "FinishReason"); rest != name && !strings.HasPrefix(rest, "(") {
+			name = strings.ToLower(rest)
 		}
+		return llmrouter.UnknownFinishReason("gemini", name)
 	}
+}
 
-	var usage *llmrouter.Usage
-	if resp.UsageMetadata != nil {
-		usage = &llmrouter.Usage{
-			PromptTokens:     int(resp.UsageMetadata.PromptTokenCount),
-			CompletionTokens: int(resp.UsageMetadata.CandidatesTokenCount),
-			TotalTokens:      int(resp.UsageMetadata.TotalTokenCount),
+// rawFinishReason returns the SDK's name for a finish reason, or "" if unset
+func rawFinishReason(r genai.FinishReason) string {
+	if r == genai.FinishReasonUnspecified {
+		return ""
+	}
+	return r.String()
+}
+
+// applyStopSequences truncates each choice at the first stop sequence.
+// Gemini doesn't reliably honor StopSequences (notably alongside tools), so
+// this enforces them locally as a best-effort parity with other providers.
+func applyStopSequences(resp *llmrouter.Response, stop []string) {
+	for i := range resp.Choices {
+		msg := resp.Choices[i].Message
+		if msg == nil {
+			continue
+		}
+		if idx := indexStop(msg.Content, stop); idx >= 0 {
+			msg.Content = msg.Content[:idx]
+			if len(msg.ToolCalls) == 0 {
+				resp.Choices[i].FinishReason = "stop"
+				if d := resp.Choices[i].FinishDetails; d != nil {
+					d.Reason = "stop"
+					d.ContentFiltered = false
+				}
+			}
 		}
 	}
+}
 
-	return &llmrouter.Response{
-		Model:    model,
-		Provider: provider,
-		Object:   "chat.completion",
-		Created:  time.Now().Unix(),
-		Choices: []llmrouter.Choice{
-			{
-				Index: 0,
-				Message: &llmrouter.Message{
-					Role:      llmrouter.RoleAssistant,
-					Content:   content,
-					ToolCalls: toolCalls,
-				},
-				FinishReason: finishReason,
-			},
-		},
-		Usage: usage,
+// indexStop returns the index of the earliest stop sequence in s, or -1
+func indexStop(s string, stop []string) int {
+	first := -1
+	for _, seq := range stop {
+		if seq == "" {
+			continue
+		}
+		if idx := strings.Index(s, seq); idx >= 0 && (first < 0 || idx < first) {
+			first = idx
+		}
 	}
+	return first
 }
 
 // convertFunctionCallArgs converts function call args to JSON string
@@ -273,12 +453,39 @@ func convertFunctionCallArgs(args map[string]interface{}) (string, error) {
 	return string(b), err
 }
 
-// wrapError wraps Gemini errors
+// noCandidatesError reports a response that came back without candidates,
+// as ErrContentFiltered when the prompt was blocked
+func noCandidatesError(feedback *genai.PromptFeedback) error {
+	if feedback != nil && feedback.BlockReason != genai.BlockReasonUnspecified {
+		return &llmrouter.APIError{
+			Provider: "gemini",
+			Message:  "prompt blocked: " + feedback.BlockReason.String(),
+			Err:      llmrouter.ErrContentFiltered,
+		}
+	}
+	return &llmrouter.APIError{
+		Provider: "gemini",
+		Message:  "response has no candidates",
+		Err:      llmrouter.ErrProviderError,
+	}
+}
+
+// wrapError wraps Gemini errors. The SDK reports blocked prompts and
+// responses as a BlockedError, which maps to ErrContentFiltered.
 func wrapError(err error) error {
 	if err == nil {
 		return nil
 	}
 
+	var blocked *genai.BlockedError
+	if errors.As(err, &blocked) {
+		return &llmrouter.APIError{
+			Provider: "gemini",
+			Message:  err.Error(),
+			Err:      fmt.Errorf("%w: %w", llmrouter.ErrContentFiltered, err),
+		}
+	}
+
 	return &llmrouter.APIError{
 		Provider: "gemini",
 		Message:  err.Error(),
@@ -0,0 +1,215 @@
+package gemini
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/google/generative-ai-go/genai"
+)
+
+func TestApplyStopSequences(t *testing.T) {
+	resp := &llmrouter.Response{Choices: []llmrouter.Choice{
+		{
+			Message:       &llmrouter.Message{Content: "one\n\nEND two STOP three"},
+			FinishReason:  "length",
+			FinishDetails: &llmrouter.FinishDetails{Reason: "length", RawReason: "MAX_TOKENS"},
+		},
+		{Message: &llmrouter.Message{Content: "no stop here"}, FinishReason: "stop"},
+		{
+			Message:      &llmrouter.Message{Content: "call END", ToolCalls: []llmrouter.ToolCall{{ID: "1"}}},
+			FinishReason: "tool_calls",
+		},
+		{},
+	}}
+
+	applyStopSequences(resp, []string{"STOP", "", "END"})
+
+	if got := resp.Choices[0].Message.Content; got != "one\n\n" {
+		t.Errorf("choice 0 = %q, want truncated at the earliest stop", got)
+	}
+	if resp.Choices[0].FinishReason != "stop" || resp.Choices[0].FinishDetails.Reason != "stop" {
+		t.Errorf("choice 0 finish = %q, %+v; want stop", resp.Choices[0].FinishReason, resp.Choices[0].FinishDetails)
+	}
+	if resp.Choices[1].Message.Content != "no stop here" {
+		t.Errorf("choice 1 = %q, want unchanged", resp.Choices[1].Message.Content)
+	}
+	if resp.Choices[2].Message.Content != "call " || resp.Choices[2].FinishReason != "tool_calls" {
+		t.Errorf("choice 2 = %q, %q; want truncated with the tool call finish kept", resp.Choices[2].Message.Content, resp.Choices[2].FinishReason)
+	}
+}
+
+func TestApplyStopSequencesWithoutStops(t *testing.T) {
+	resp := &llmrouter.Response{Choices: []llmrouter.Choice{{Message: &llmrouter.Message{Content: "text"}, FinishReason: "length"}}}
+	applyStopSequences(resp, nil)
+	if resp.Choices[0].Message.Content != "text" || resp.Choices[0].FinishReason != "length" {
+		t.Errorf("choice = %+v, want unchanged", resp.Choices[0])
+	}
+}
+
+func TestConvertCandidateFinishDetails(t *testing.T) {
+	tests := []struct {
+		finish genai.FinishReason
+		want   llmrouter.FinishDetails
+	}{
+		{genai.FinishReasonUnspecified, llmrouter.FinishDetails{Reason: "stop"}},
+		{genai.FinishReasonSafety, llmrouter.FinishDetails{Reason: "content_filter", RawReason: "FinishReasonSafety", ContentFiltered: true}},
+		{genai.FinishReasonRecitation, llmrouter.FinishDetails{Reason: "recitation", RawReason: "FinishReasonRecitation"}},
+	}
+	for _, tt := range tests {
+		choice := convertCandidate(&genai.Candidate{FinishReason: tt.finish, Content: &genai.Content{Parts: []genai.Part{genai.Text("hi")}}}, 0)
+		if d := choice.FinishDetails; d == nil || *d != tt.want {
+			t.Errorf("%v: finish details = %+v, want %+v", tt.finish, d, tt.want)
+		}
+	}
+
+	call := &genai.Candidate{FinishReason: genai.FinishReasonStop, Content: &genai.Content{Parts: []genai.Part{genai.FunctionCall{Name: "weather"}}}}
+	want := llmrouter.FinishDetails{Reason: "tool_calls", RawReason: "FinishReasonStop", ToolCalled: true}
+	if d := convertCandidate(call, 0).FinishDetails; d == nil || *d != want {
+		t.Errorf("tool call finish details = %+v, want %+v", d, want)
+	}
+}
+
+func TestConvertResponseNoCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		feedback *genai.PromptFeedback
+		want     error
+	}{
+		{"no feedback", nil, llmrouter.ErrProviderError},
+		{"not blocked", &genai.PromptFeedback{}, llmrouter.ErrProviderError},
+		{"blocked", &genai.PromptFeedback{BlockReason: genai.BlockReasonSafety}, llmrouter.ErrContentFiltered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := convertResponse(&genai.GenerateContentResponse{PromptFeedback: tt.feedback}, "gemini-test", "gemini")
+			if resp != nil {
+				t.Errorf("response = %+v, want nil", resp)
+			}
+			var apiErr *llmrouter.APIError
+			if !errors.As(err, &apiErr) || !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want an APIError wrapping %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertResponseCitations(t *testing.T) {
+	uri := func(s string) *string { return &s }
+	index := func(n int32) *int32 { return &n }
+	resp := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+		Content: &genai.Content{Parts: []genai.Part{genai.Text("The quick "), genai.Text("brown fox")}},
+		CitationMetadata: &genai.CitationMetadata{CitationSources: []*genai.CitationSource{
+			{URI: uri("https://a.example"), StartIndex: index(4), EndIndex: index(15)},
+			{URI: uri("https://b.example")},
+			{URI: uri("https://c.example"), StartIndex: index(10), EndIndex: index(99)},
+			{StartIndex: index(0), EndIndex: index(3)},
+		}},
+	}}}
+
+	got, err := convertResponse(resp, "gemini-test", "gemini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []llmrouter.Citation{
+		{URL: "https://a.example", Snippet: "quick brown"},
+		{URL: "https://b.example"},
+		{URL: "https://c.example"},
+	}
+	if !reflect.DeepEqual(got.Citations, want) {
+		t.Errorf("citations = %+v, want %+v", got.Citations, want)
+	}
+}
+
+func TestConvertToolChoice(t *testing.T) {
+	tests := []struct {
+		name    string
+		choice  *llmrouter.ToolChoice
+		mode    genai.FunctionCallingMode
+		allowed []string
+	}{
+		{"auto", &llmrouter.ToolChoice{Type: "auto"}, genai.FunctionCallingAuto, nil},
+		{"empty", &llmrouter.ToolChoice{}, genai.FunctionCallingAuto, nil},
+		{"required", &llmrouter.ToolChoice{Type: "required"}, genai.FunctionCallingAny, nil},
+		{"none", &llmrouter.ToolChoice{Type: "none"}, genai.FunctionCallingNone, nil},
+		{"function", &llmrouter.ToolChoice{Type: "function", Function: &llmrouter.FuncRef{Name: "weather"}}, genai.FunctionCallingAny, []string{"weather"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := convertToolChoice(tt.choice)
+			if cfg == nil || cfg.FunctionCallingConfig == nil {
+				t.Fatalf("config = %+v, want a function calling config", cfg)
+			}
+			fc := cfg.FunctionCallingConfig
+			if fc.Mode != tt.mode || !slices.Equal(fc.AllowedFunctionNames, tt.allowed) {
+				t.Errorf("config = %v %v, want %v %v", fc.Mode, fc.AllowedFunctionNames, tt.mode, tt.allowed)
+			}
+		})
+	}
+
+	if cfg := convertToolChoice(nil); cfg != nil {
+		t.Errorf("nil choice = %+v, want nil", cfg)
+	}
+}
+
+func TestConvertFinishReason(t *testing.T) {
+	tests := []struct {
+		reason genai.FinishReason
+		want   string
+	}{
+		{genai.FinishReasonUnspecified, "stop"},
+		{genai.FinishReasonStop, "stop"},
+		{genai.FinishReasonMaxTokens, "length"},
+		{genai.FinishReasonSafety, "content_filter"},
+		{genai.FinishReasonRecitation, "recitation"},
+		{genai.FinishReasonOther, "other"},
+		{genai.FinishReason(99), "FinishReason(99)"},
+	}
+	for _, tt := range tests {
+		if got := convertFinishReason(tt.reason); got != tt.want {
+			t.Errorf("convertFinishReason(%v) = %q, want %q", tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestBuildUserPartsDocuments(t *testing.T) {
+	msg := llmrouter.Message{Role: llmrouter.RoleUser, ContentParts: []llmrouter.ContentPart{
+		{Type: "text", Text: "Summarize these."},
+		{Type: "document", Document: &llmrouter.Document{Base64: "JVBERi0=", MediaType: "application/pdf"}},
+		{Type: "document", Document: &llmrouter.Document{URI: "gs://bucket/report.pdf", MediaType: "application/pdf"}},
+		{Type: "document"},
+	}}
+
+	want := []genai.Part{
+		genai.Text("Summarize these."),
+		genai.Blob{MIMEType: "application/pdf", Data: []byte("%PDF-")},
+		genai.FileData{MIMEType: "application/pdf", URI: "gs://bucket/report.pdf"},
+	}
+	if got := buildUserParts(msg); !reflect.DeepEqual(got, want) {
+		t.Errorf("parts = %#v, want %#v", got, want)
+	}
+}
+
+func TestCheckDocuments(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     *llmrouter.Document
+		invalid bool
+	}{
+		{"inline", &llmrouter.Document{Base64: "JVBERi0=", MediaType: "application/pdf"}, false},
+		{"uri", &llmrouter.Document{URI: "gs://bucket/report.pdf", MediaType: "application/pdf"}, false},
+		{"no media type", &llmrouter.Document{URI: "gs://bucket/report.pdf"}, true},
+		{"bad base64", &llmrouter.Document{Base64: "not base64!", MediaType: "application/pdf"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := []llmrouter.Message{{Role: llmrouter.RoleUser, ContentParts: []llmrouter.ContentPart{{Type: "document", Document: tt.doc}}}}
+			err := checkDocuments(msgs)
+			if tt.invalid != errors.Is(err, llmrouter.ErrInvalidRequest) {
+				t.Errorf("err = %v, want invalid %v", err, tt.invalid)
+			}
+		})
+	}
+}
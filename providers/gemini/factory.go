@@ -0,0 +1,14 @@
+package gemini
+
+import (
+	"context"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func init() {
+	// The context is only used while creating the client
+	llmrouter.RegisterProviderFactory("gemini", func(cfg llmrouter.ProviderConfig) (llmrouter.Provider, error) {
+		return New(context.Background(), cfg)
+	})
+}
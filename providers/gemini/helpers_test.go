@@ -0,0 +1,146 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// fakeAPI serves canned Gemini REST responses and records request bodies
+type fakeAPI struct {
+	mu     sync.Mutex
+	bodies []map[string]any
+	paths  []string
+}
+
+// newTestProvider returns a provider talking to a fake API whose handler
+// writes the response for each request
+func newTestProvider(t *testing.T, respond func(w http.ResponseWriter, r *http.Request)) (*Provider, *fakeAPI) {
+	t.Helper()
+	api := &fakeAPI{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(data, &body)
+		api.mu.Lock()
+		api.bodies = append(api.bodies, body)
+		api.paths = append(api.paths, r.URL.Path)
+		api.mu.Unlock()
+		respond(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := genai.NewClient(context.Background(),
+		option.WithAPIKey("test"),
+		option.WithEndpoint(srv.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return &Provider{
+		client:      client,
+		model:       "gemini-test",
+		models:      []string{"gemini-test"},
+		cacheTTL:    time.Hour,
+		caches:      make(map[string]cacheEntry),
+		createCache: client.CreateCachedContent,
+		apiKey:      "test",
+		endpoint:    srv.URL,
+		httpClient:  srv.Client(),
+	}, api
+}
+
+// lastBody returns the last request body sent to the fake API
+func (a *fakeAPI) lastBody() map[string]any {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.bodies) == 0 {
+		return nil
+	}
+	return a.bodies[len(a.bodies)-1]
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// candidate builds a REST candidate with text parts
+func candidate(index int, finish string, texts ...string) map[string]any {
+	parts := make([]map[string]any, len(texts))
+	for i, text := range texts {
+		parts[i] = map[string]any{"text": text}
+	}
+	return map[string]any{
+		"index":        index,
+		"content":      map[string]any{"role": "model", "parts": parts},
+		"finishReason": finish,
+	}
+}
+
+// fakeIterator yields chunks, then iterator.Done or err
+type fakeIterator struct {
+	chunks []*genai.GenerateContentResponse
+	err    error
+}
+
+func (it *fakeIterator) Next() (*genai.GenerateContentResponse, error) {
+	if len(it.chunks) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+		return nil, iterator.Done
+	}
+	chunk := it.chunks[0]
+	it.chunks = it.chunks[1:]
+	return chunk, nil
+}
+
+// textChunk builds a streamed chunk with one candidate
+func textChunk(finish genai.FinishReason, parts ...genai.Part) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+		Content:      &genai.Content{Role: "model", Parts: parts},
+		FinishReason: finish,
+	}}}
+}
+
+// streamChunks runs chunks through the provider's stream conversion
+func streamChunks(p *Provider, req *llmrouter.Request, it *fakeIterator) []llmrouter.Event {
+	ch := make(chan llmrouter.Event)
+	go func() {
+		defer close(ch)
+		p.streamEvents(ch, it, req, "gemini-test")
+	}()
+	return collect(ch)
+}
+
+// userRequest builds a request with a single user message
+func userRequest(content string) *llmrouter.Request {
+	return &llmrouter.Request{
+		Messages: []llmrouter.Message{{Role: llmrouter.RoleUser, Content: content}},
+	}
+}
+
+// collect reads ch until it closes
+func collect(ch <-chan llmrouter.Event) []llmrouter.Event {
+	var events []llmrouter.Event
+	for e := range ch {
+		events = append(events, e)
+	}
+	return events
+}
+
+func intPtr(n int) *int { return &n }
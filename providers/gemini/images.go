@@ -0,0 +1,188 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// DefaultImageModel is used when an image request doesn't name a model
+const DefaultImageModel = "imagen-3.0-generate-002"
+
+// defaultEndpoint is the Generative Language API, used for Imagen calls
+const defaultEndpoint = "https://generativelanguage.googleapis.com"
+
+// imagenAspectRatios are the aspect ratios Imagen accepts
+var imagenAspectRatios = []struct {
+	name  string
+	ratio float64
+}{
+	{"1:1", 1},
+	{"3:4", 3.0 / 4},
+	{"4:3", 4.0 / 3},
+	{"9:16", 9.0 / 16},
+	{"16:9", 16.0 / 9},
+}
+
+// WithHTTPClient sets the client used for Imagen calls, which the genai SDK
+// has no endpoint for. Defaults to http.DefaultClient.
+func (p *Provider) WithHTTPClient(c *http.Client) *Provider {
+	p.httpClient = c
+	return p
+}
+
+// GenerateImage generates images with Imagen through the REST predict
+// endpoint. Imagen returns image data only, so ResponseFormat "url" is not
+// supported, and Size selects the nearest aspect ratio Imagen accepts, e.g.
+// "1024x1024" for 1:1 or Imagen's own "1408x768" for 16:9.
+func (p *Provider) GenerateImage(ctx context.Context, req *llmrouter.ImageRequest) (*llmrouter.ImageResponse, error) {
+	if req.ResponseFormat != "" && req.ResponseFormat != "b64_json" {
+		return nil, fmt.Errorf("%w: gemini image response format %q", llmrouter.ErrNotSupported, req.ResponseFormat)
+	}
+
+	model := req.Model
+	if model == "" || model == p.Name() {
+		model = DefaultImageModel
+	}
+
+	params := map[string]any{}
+	if req.N > 0 {
+		params["sampleCount"] = req.N
+	}
+	if req.Size != "" {
+		ratio, err := aspectRatio(req.Size)
+		if err != nil {
+			return nil, err
+		}
+		params["aspectRatio"] = ratio
+	}
+	body, err := json.Marshal(map[string]any{
+		"instances":  []map[string]string{{"prompt": req.Prompt}},
+		"parameters": params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := p.endpoint
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(endpoint, "/")+"/v1beta/models/"+model+":predict", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", p.apiKey)
+
+	client := p.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp.StatusCode, data)
+	}
+
+	var predicted struct {
+		Predictions []struct {
+			BytesBase64Encoded string `json:"bytesBase64Encoded"`
+		} `json:"predictions"`
+	}
+	if err := json.Unmarshal(data, &predicted); err != nil {
+		return nil, wrapError(fmt.Errorf("decoding imagen response: %w", err))
+	}
+
+	// Filtered images are left out of the predictions
+	images := make([]llmrouter.Image, 0, len(predicted.Predictions))
+	for _, pred := range predicted.Predictions {
+		if pred.BytesBase64Encoded != "" {
+			images = append(images, llmrouter.Image{Base64: pred.BytesBase64Encoded})
+		}
+	}
+	if len(images) == 0 {
+		return nil, &llmrouter.APIError{
+			Provider: "gemini",
+			Message:  "no images generated",
+			Err:      llmrouter.ErrContentFiltered,
+		}
+	}
+
+	return &llmrouter.ImageResponse{
+		Created:  time.Now().Unix(),
+		Model:    model,
+		Images:   images,
+		Provider: p.Name(),
+	}, nil
+}
+
+// aspectRatio converts a "WIDTHxHEIGHT" size to the nearest aspect ratio
+// Imagen accepts, rejecting sizes more than 5% off all of them
+func aspectRatio(size string) (string, error) {
+	w, h, ok := strings.Cut(size, "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if !ok || errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return "", fmt.Errorf("%w: image size %q, want WIDTHxHEIGHT", llmrouter.ErrInvalidRequest, size)
+	}
+
+	// Imagen's own sizes are only close to their ratios, e.g. 1408x768
+	ratio := float64(width) / float64(height)
+	best, bestDiff := "", math.Inf(1)
+	for _, r := range imagenAspectRatios {
+		if diff := math.Abs(ratio-r.ratio) / r.ratio; diff < bestDiff {
+			best, bestDiff = r.name, diff
+		}
+	}
+	if bestDiff > 0.05 {
+		return "", fmt.Errorf("%w: gemini image size %q, want aspect ratio 1:1, 3:4, 4:3, 9:16 or 16:9", llmrouter.ErrInvalidRequest, size)
+	}
+	return best, nil
+}
+
+// statusError converts a failed REST response to an APIError
+func statusError(status int, body []byte) error {
+	var payload struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	message := http.StatusText(status)
+	if json.Unmarshal(body, &payload) == nil && payload.Error.Message != "" {
+		message = payload.Error.Message
+	}
+
+	apiErr := &llmrouter.APIError{
+		Provider:   "gemini",
+		StatusCode: status,
+		Message:    message,
+		Err:        llmrouter.ErrProviderError,
+	}
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		apiErr.Err = llmrouter.ErrAuthFailed
+	case http.StatusTooManyRequests:
+		apiErr.Err = llmrouter.ErrRateLimited
+	case http.StatusBadRequest:
+		apiErr.Err = llmrouter.ErrInvalidRequest
+	}
+	return apiErr
+}
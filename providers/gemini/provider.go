@@ -2,7 +2,11 @@ package gemini
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	llmrouter "github.com/bluefunda/llm-router"
@@ -13,9 +17,21 @@ import (
 
 // Provider handles Google Gemini API
 type Provider struct {
-	client *genai.Client
-	model  string
-	models []string
+	client         *genai.Client
+	model          string
+	models         []string
+	safetySettings []*genai.SafetySetting
+	cacheTTL       time.Duration
+	caches         map[string]cacheEntry
+	cacheMu        sync.Mutex
+	createCache    func(context.Context, *genai.CachedContent) (*genai.CachedContent, error)
+	onClamp        llmrouter.ClampFunc
+	countTokens    llmrouter.TokenCounter
+
+	// Imagen is called over REST, outside the SDK
+	apiKey     string
+	endpoint   string // defaults to defaultEndpoint
+	httpClient *http.Client
 }
 
 // DefaultModels is the list of available Gemini models
@@ -42,6 +58,9 @@ func New(ctx context.Context, cfg llmrouter.ProviderConfig) (*Provider, error) {
 	if cfg.APIKey != "" {
 		opts = append(opts, option.WithAPIKey(cfg.APIKey))
 	}
+	if cfg.UserAgent != "" {
+		opts = append(opts, option.WithUserAgent(cfg.UserAgent))
+	}
 
 	client, err := genai.NewClient(ctx, opts...)
 	if err != nil {
@@ -49,9 +68,13 @@ func New(ctx context.Context, cfg llmrouter.ProviderConfig) (*Provider, error) {
 	}
 
 	return &Provider{
-		client: client,
-		model:  model,
-		models: models,
+		client:      client,
+		model:       model,
+		models:      models,
+		cacheTTL:    time.Hour,
+		caches:      make(map[string]cacheEntry),
+		createCache: client.CreateCachedContent,
+		apiKey:      cfg.APIKey,
 	}, nil
 }
 
@@ -62,15 +85,55 @@ func NewFromEnv(ctx context.Context) (*Provider, error) {
 	})
 }
 
+// Client returns the underlying genai client, an escape hatch for API
+// features the router doesn't model. Calls made through it bypass router
+// middleware.
+func (p *Provider) Client() *genai.Client {
+	return p.client
+}
+
+// WithSafetySettings sets the harm-category thresholds applied to every request.
+// When unset, Gemini's default thresholds apply.
+func (p *Provider) WithSafetySettings(settings ...*genai.SafetySetting) *Provider {
+	p.safetySettings = settings
+	return p
+}
+
+// WithTokenCounter sets the counter used to estimate usage when the API
+// response carries no usage metadata. The estimate includes the system prompt,
+// matching what the API reports. Defaults to llmrouter.EstimateTokens.
+func (p *Provider) WithTokenCounter(count llmrouter.TokenCounter) *Provider {
+	p.countTokens = count
+	return p
+}
+
 // Close closes the Gemini client
 func (p *Provider) Close() error {
 	return p.client.Close()
 }
 
+// temperatureRange is the temperature range the API accepts
+var temperatureRange = llmrouter.TemperatureRange{Min: 0, Max: 2}
+
+// WithTemperatureClamp clamps out-of-range temperatures instead of rejecting
+// them with ErrInvalidRequest, calling warn (if non-nil) for each clamp
+func (p *Provider) WithTemperatureClamp(warn llmrouter.ClampFunc) *Provider {
+	if warn == nil {
+		warn = func(requested, clamped float64) {}
+	}
+	p.onClamp = warn
+	return p
+}
+
 func (p *Provider) Name() string {
 	return "gemini"
 }
 
+// DefaultModel returns the model used when a request names none
+func (p *Provider) DefaultModel() string {
+	return p.model
+}
+
 func (p *Provider) Models() []string {
 	return p.models
 }
@@ -80,142 +143,299 @@ func (p *Provider) SupportsTools() bool {
 }
 
 func (p *Provider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	req, err := llmrouter.CheckTemperature(req, temperatureRange, p.onClamp)
+	if err != nil {
+		return nil, err
+	}
+
 	modelName := req.Model
-	if modelName == "" {
+	if modelName == "" || modelName == p.Name() {
 		modelName = p.model
 	}
 
-	model := p.client.GenerativeModel(modelName)
-	configureModel(model, req)
-
-	// Convert tools if present
-	if len(req.Tools) > 0 {
-		model.Tools = convertTools(req.Tools)
+	model, history, lastParts, err := p.prepare(ctx, req, modelName)
+	if err != nil {
+		return nil, err
 	}
 
-	// Build chat and get history
-	chat := model.StartChat()
-	history, lastParts := convertHistory(req.Messages)
-	chat.History = history
-
-	// Generate response
-	resp, err := chat.SendMessage(ctx, lastParts...)
+	// Chat sessions always request a single candidate, and the SDK can only
+	// send a single turn outside one, so multiple candidates need a request
+	// without history
+	var resp *genai.GenerateContentResponse
+	if req.N != nil && *req.N > 1 {
+		if len(history) > 0 {
+			return nil, fmt.Errorf("%w: gemini N > 1 with conversation history", llmrouter.ErrNotSupported)
+		}
+		resp, err = model.GenerateContent(ctx, lastParts...)
+	} else {
+		chat := model.StartChat()
+		chat.History = history
+		resp, err = chat.SendMessage(ctx, lastParts...)
+	}
 	if err != nil {
 		return nil, wrapError(err)
 	}
 
-	return convertResponse(resp, modelName, p.Name()), nil
+	result, err := convertResponse(resp, modelName, p.Name())
+	if err != nil {
+		return nil, err
+	}
+	applyStopSequences(result, req.Stop)
+	if result.Usage == nil {
+		result.Usage = p.estimateUsage(req, result.Choices[0].Message.Content)
+	}
+	return result, nil
 }
 
 func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	req, err := llmrouter.CheckTemperature(req, temperatureRange, p.onClamp)
+	if err != nil {
+		return nil, err
+	}
+	// Streaming chat sessions always request a single candidate
+	if req.N != nil && *req.N > 1 {
+		return nil, fmt.Errorf("%w: gemini streaming with N > 1", llmrouter.ErrNotSupported)
+	}
+
 	ch := make(chan llmrouter.Event)
 
 	modelName := req.Model
-	if modelName == "" {
+	if modelName == "" || modelName == p.Name() {
 		modelName = p.model
 	}
 
-	model := p.client.GenerativeModel(modelName)
-	configureModel(model, req)
-
-	// Convert tools if present
-	if len(req.Tools) > 0 {
-		model.Tools = convertTools(req.Tools)
+	model, history, lastParts, err := p.prepare(ctx, req, modelName)
+	if err != nil {
+		return nil, err
 	}
 
-	// Build chat and get history
+	// Build chat
 	chat := model.StartChat()
-	history, lastParts := convertHistory(req.Messages)
 	chat.History = history
 
 	go func() {
 		defer close(ch)
+		p.streamEvents(ch, chat.SendMessageStream(ctx, lastParts...), req, modelName)
+	}()
 
-		iter := chat.SendMessageStream(ctx, lastParts...)
+	return ch, nil
+}
 
-		var fullContent string
-		var toolCalls []llmrouter.ToolCall
+// responseIterator yields streamed response chunks. It is implemented by
+// *genai.GenerateContentResponseIterator.
+type responseIterator interface {
+	Next() (*genai.GenerateContentResponse, error)
+}
 
-		for {
-			resp, err := iter.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				ch <- llmrouter.Event{
-					Type:  llmrouter.EventError,
-					Error: wrapError(err),
-				}
-				return
+// streamEvents converts the chunks from iter into events on ch, ending with
+// a done event carrying the full response or an error event
+func (p *Provider) streamEvents(ch chan<- llmrouter.Event, iter responseIterator, req *llmrouter.Request, modelName string) {
+	var fullContent string
+	var toolCalls []llmrouter.ToolCall
+	var sources []*genai.CitationMetadata
+	var usage *llmrouter.Usage
+	var lastReason genai.FinishReason
+	var stopped bool
+	var candidates bool
+	var feedback *genai.PromptFeedback
+
+	for {
+		resp, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			ch <- llmrouter.Event{
+				Type:  llmrouter.EventError,
+				Error: wrapError(err),
 			}
+			return
+		}
 
-			for _, candidate := range resp.Candidates {
-				if candidate.Content == nil {
-					continue
-				}
-				for _, part := range candidate.Content.Parts {
-					switch p := part.(type) {
-					case genai.Text:
-						content := string(p)
-						fullContent += content
+		// Each chunk reports cumulative usage, so the last one wins
+		if u := convertUsage(resp.UsageMetadata); u != nil {
+			usage = u
+		}
+
+		if len(resp.Candidates) > 0 {
+			candidates = true
+		}
+		if resp.PromptFeedback != nil {
+			feedback = resp.PromptFeedback
+		}
+
+		for _, candidate := range resp.Candidates {
+			if candidate.CitationMetadata != nil {
+				sources = append(sources, candidate.CitationMetadata)
+			}
+			// The reason arrives on the final chunk's candidate
+			if candidate.FinishReason != genai.FinishReasonUnspecified {
+				lastReason = candidate.FinishReason
+			}
+			if candidate.Content == nil {
+				continue
+			}
+			for _, part := range candidate.Content.Parts {
+				switch p := part.(type) {
+				case genai.Text:
+					if stopped {
+						continue
+					}
+					prev := len(fullContent)
+					fullContent += string(p)
+					if idx := indexStop(fullContent, req.Stop); idx >= 0 {
+						// Text before prev was already sent, so a stop
+						// sequence split across chunks is only partly hidden
+						fullContent = fullContent[:max(idx, prev)]
+						stopped = true
+					}
+					if content := fullContent[prev:]; content != "" {
 						ch <- llmrouter.Event{
 							Type:    llmrouter.EventContentDelta,
 							Content: content,
 						}
-					case genai.FunctionCall:
-						args, _ := convertFunctionCallArgs(p.Args)
-						tc := llmrouter.ToolCall{
-							ID:   p.Name, // Gemini doesn't have IDs, use name
-							Type: "function",
-							Function: llmrouter.FuncCall{
-								Name:      p.Name,
-								Arguments: args,
-							},
-						}
-						toolCalls = append(toolCalls, tc)
-						ch <- llmrouter.Event{
-							Type: llmrouter.EventToolCallDelta,
-							Delta: &llmrouter.Delta{
-								ToolCalls: []llmrouter.ToolCall{tc},
-							},
-						}
+					}
+				case genai.FunctionCall:
+					args, _ := convertFunctionCallArgs(p.Args)
+					tc := llmrouter.ToolCall{
+						ID:   p.Name, // Gemini doesn't have IDs, use name
+						Type: "function",
+						Function: llmrouter.FuncCall{
+							Name:      p.Name,
+							Arguments: args,
+						},
+					}
+					toolCalls = append(toolCalls, tc)
+					ch <- llmrouter.Event{
+						Type: llmrouter.EventToolCallDelta,
+						Delta: &llmrouter.Delta{
+							ToolCalls: []llmrouter.ToolCall{tc},
+						},
 					}
 				}
 			}
 		}
+	}
 
-		// Send done event with full response
-		finishReason := "stop"
-		if len(toolCalls) > 0 {
-			finishReason = "tool_calls"
+	// A stream without candidates is an error, not an empty reply
+	if !candidates {
+		ch <- llmrouter.Event{
+			Type:  llmrouter.EventError,
+			Error: noCandidatesError(feedback),
 		}
+		return
+	}
 
-		ch <- llmrouter.Event{
-			Type: llmrouter.EventDone,
-			Response: &llmrouter.Response{
-				Model:    modelName,
-				Provider: p.Name(),
-				Object:   "chat.completion",
-				Created:  time.Now().Unix(),
-				Choices: []llmrouter.Choice{
-					{
-						Index: 0,
-						Message: &llmrouter.Message{
-							Role:      llmrouter.RoleAssistant,
-							Content:   fullContent,
-							ToolCalls: toolCalls,
-						},
-						FinishReason: finishReason,
+	// Send done event with full response
+	finishReason := "tool_calls"
+	if len(toolCalls) == 0 {
+		finishReason = convertFinishReason(lastReason)
+	}
+	// A locally enforced stop sequence ends the reply normally
+	if stopped && len(toolCalls) == 0 {
+		finishReason = "stop"
+	}
+	if usage == nil {
+		usage = p.estimateUsage(req, fullContent)
+	}
+	// Citation indices refer to the whole reply, so they're resolved once
+	// it is complete
+	var citations []llmrouter.Citation
+	for _, meta := range sources {
+		citations = append(citations, convertCitations(meta, fullContent)...)
+	}
+
+	ch <- llmrouter.Event{
+		Type: llmrouter.EventDone,
+		Response: &llmrouter.Response{
+			Model:     modelName,
+			Provider:  p.Name(),
+			Object:    "chat.completion",
+			Created:   time.Now().Unix(),
+			Citations: citations,
+			Usage:     usage,
+			Choices: []llmrouter.Choice{
+				{
+					Index: 0,
+					Message: &llmrouter.Message{
+						Role:      llmrouter.RoleAssistant,
+						Content:   fullContent,
+						ToolCalls: toolCalls,
 					},
+					FinishReason:  finishReason,
+					FinishDetails: llmrouter.NewFinishDetails(finishReason, rawFinishReason(lastReason), len(toolCalls) > 0),
 				},
 			},
-		}
-	}()
+		},
+	}
+}
 
-	return ch, nil
+// estimateUsage approximates usage for responses without usage metadata
+func (p *Provider) estimateUsage(req *llmrouter.Request, content string) *llmrouter.Usage {
+	count := p.countTokens
+	if count == nil {
+		count = llmrouter.EstimateTokens
+	}
+	prompt := llmrouter.EstimateRequestTokens(req, count)
+	completion := count(content)
+	return &llmrouter.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}
+}
+
+// prepare builds the model for a request along with the chat history and the
+// parts of the final user message. Any prefix marked with a CacheHint is served
+// from cached content instead of being resent.
+func (p *Provider) prepare(ctx context.Context, req *llmrouter.Request, modelName string) (*genai.GenerativeModel, []*genai.Content, []genai.Part, error) {
+	// The genai SDK in use has no search grounding tool
+	if req.Grounding {
+		return nil, nil, nil, fmt.Errorf("%w: gemini search grounding", llmrouter.ErrNotSupported)
+	}
+	if len(req.BuiltinTools) > 0 {
+		return nil, nil, nil, fmt.Errorf("%w: gemini built-in tools", llmrouter.ErrNotSupported)
+	}
+
+	if err := checkDocuments(req.Messages); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if req.Prefill != "" {
+		prefilled := *req
+		prefilled.Messages = llmrouter.PrefillInstruction(req.Messages, req.Prefill)
+		req = &prefilled
+	}
+
+	model := p.client.GenerativeModel(modelName)
+	p.configureModel(model, req)
+
+	// Convert tools if present
+	if len(req.Tools) > 0 {
+		model.Tools = convertTools(req.Tools)
+		model.ToolConfig = convertToolChoice(req.ToolChoice)
+	}
+
+	messages := req.Messages
+	if cacheName, rest, err := p.cachedPrefix(ctx, req, modelName, model); err != nil {
+		return nil, nil, nil, wrapError(err)
+	} else if cacheName != "" {
+		// System instruction and tools live in the cached content
+		model.CachedContentName = cacheName
+		model.SystemInstruction = nil
+		model.Tools = nil
+		model.ToolConfig = nil
+		messages = rest
+	}
+
+	history, lastParts := convertHistory(messages)
+	return model, history, lastParts, nil
 }
 
-func configureModel(model *genai.GenerativeModel, req *llmrouter.Request) {
+func (p *Provider) configureModel(model *genai.GenerativeModel, req *llmrouter.Request) {
+	if len(p.safetySettings) > 0 {
+		model.SafetySettings = p.safetySettings
+	}
 	if req.Temperature != nil {
 		temp := float32(*req.Temperature)
 		model.Temperature = &temp
@@ -231,9 +451,20 @@ func configureModel(model *genai.GenerativeModel, req *llmrouter.Request) {
 		topP := float32(*req.TopP)
 		model.TopP = &topP
 	}
+	if req.N != nil {
+		model.SetCandidateCount(int32(*req.N))
+	}
 	if len(req.Stop) > 0 {
 		model.StopSequences = req.Stop
 	}
+	if rf := req.ResponseFormat; rf != nil && rf.Type != "text" {
+		model.ResponseMIMEType = "application/json"
+		if rf.JSONSchema != nil {
+			var schema map[string]interface{}
+			_ = json.Unmarshal(rf.JSONSchema.Schema, &schema)
+			model.ResponseSchema = convertSchema(schema)
+		}
+	}
 
 	// Extract system prompt from messages
 	for _, msg := range req.Messages {
@@ -0,0 +1,546 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/google/generative-ai-go/genai"
+)
+
+func TestSafetySettingsReachModel(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+	p.WithSafetySettings(
+		&genai.SafetySetting{Category: genai.HarmCategoryHarassment, Threshold: genai.HarmBlockOnlyHigh},
+		&genai.SafetySetting{Category: genai.HarmCategoryDangerousContent, Threshold: genai.HarmBlockNone},
+	)
+
+	model := p.client.GenerativeModel("gemini-test")
+	p.configureModel(model, userRequest("hi"))
+
+	if len(model.SafetySettings) != 2 {
+		t.Fatalf("got %d safety settings, want 2", len(model.SafetySettings))
+	}
+	if s := model.SafetySettings[0]; s.Category != genai.HarmCategoryHarassment || s.Threshold != genai.HarmBlockOnlyHigh {
+		t.Errorf("setting 0 = %+v", s)
+	}
+	if s := model.SafetySettings[1]; s.Category != genai.HarmCategoryDangerousContent || s.Threshold != genai.HarmBlockNone {
+		t.Errorf("setting 1 = %+v", s)
+	}
+}
+
+func TestSafetySettingsSentToAPI(t *testing.T) {
+	p, api := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"candidates": []any{candidate(0, "STOP", "a"), candidate(1, "STOP", "b")}})
+	})
+	p.WithSafetySettings(&genai.SafetySetting{Category: genai.HarmCategoryHarassment, Threshold: genai.HarmBlockOnlyHigh})
+
+	// Multiple candidates use the unary endpoint
+	req := userRequest("hi")
+	n := 2
+	req.N = &n
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, _ := api.lastBody()["safetySettings"].([]any)
+	if len(settings) != 1 {
+		t.Fatalf("request safetySettings = %v, want one entry", api.lastBody()["safetySettings"])
+	}
+	got := settings[0].(map[string]any)
+	// The REST transport encodes enums as numbers
+	if got["category"] != float64(genai.HarmCategoryHarassment) || got["threshold"] != float64(genai.HarmBlockOnlyHigh) {
+		t.Errorf("safety setting = %v", got)
+	}
+}
+
+func TestCompleteMultipleCandidates(t *testing.T) {
+	p, api := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"candidates": []any{
+			candidate(0, "STOP", "first"),
+			candidate(1, "MAX_TOKENS", "second"),
+		}})
+	})
+
+	req := userRequest("hi")
+	n := 2
+	req.N = &n
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _ := api.lastBody()["generationConfig"].(map[string]any)
+	if cfg["candidateCount"] != float64(2) {
+		t.Errorf("candidateCount = %v, want 2", cfg["candidateCount"])
+	}
+	if len(resp.Choices) != 2 {
+		t.Fatalf("got %d choices, want 2", len(resp.Choices))
+	}
+	want := []struct {
+		content string
+		finish  string
+	}{{"first", "stop"}, {"second", "length"}}
+	for i, w := range want {
+		c := resp.Choices[i]
+		if c.Index != i || c.Message.Content != w.content || c.FinishReason != w.finish {
+			t.Errorf("choice %d = index %d, %q, %q; want %d, %q, %q", i, c.Index, c.Message.Content, c.FinishReason, i, w.content, w.finish)
+		}
+	}
+}
+
+func TestMultipleCandidatesUnsupportedWithHistory(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected API call")
+	})
+
+	n := 2
+	req := &llmrouter.Request{
+		N: &n,
+		Messages: []llmrouter.Message{
+			{Role: llmrouter.RoleUser, Content: "hi"},
+			{Role: llmrouter.RoleAssistant, Content: "hello"},
+			{Role: llmrouter.RoleUser, Content: "again"},
+		},
+	}
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("Complete error = %v, want ErrNotSupported", err)
+	}
+	req = userRequest("hi")
+	req.N = &n
+	if _, err := p.Stream(context.Background(), req); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("Stream error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestGenerateImage(t *testing.T) {
+	var key string
+	p, api := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("x-goog-api-key")
+		writeJSON(w, map[string]any{"predictions": []any{
+			map[string]any{"bytesBase64Encoded": "aW1nMQ==", "mimeType": "image/png"},
+			map[string]any{"raiFilteredReason": "filtered"},
+			map[string]any{"bytesBase64Encoded": "aW1nMg==", "mimeType": "image/png"},
+		}})
+	})
+
+	resp, err := p.GenerateImage(context.Background(), &llmrouter.ImageRequest{Prompt: "a cat", Size: "1408x768", N: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path := api.paths[0]; path != "/v1beta/models/"+DefaultImageModel+":predict" {
+		t.Errorf("path = %q, want the default Imagen model's predict endpoint", path)
+	}
+	if key != "test" {
+		t.Errorf("api key header = %q, want test", key)
+	}
+	want := map[string]any{
+		"instances":  []any{map[string]any{"prompt": "a cat"}},
+		"parameters": map[string]any{"sampleCount": float64(3), "aspectRatio": "16:9"},
+	}
+	if body := api.lastBody(); !reflect.DeepEqual(body, want) {
+		t.Errorf("body = %v, want %v", body, want)
+	}
+	wantImages := []llmrouter.Image{{Base64: "aW1nMQ=="}, {Base64: "aW1nMg=="}}
+	if resp.Model != DefaultImageModel || resp.Provider != "gemini" || !reflect.DeepEqual(resp.Images, wantImages) {
+		t.Errorf("response = %+v, want the two unfiltered images", resp)
+	}
+}
+
+func TestGenerateImageErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *llmrouter.ImageRequest
+		status  int
+		predict map[string]any
+		want    error
+	}{
+		{"url format", &llmrouter.ImageRequest{Prompt: "a cat", ResponseFormat: "url"}, 0, nil, llmrouter.ErrNotSupported},
+		{"bad size", &llmrouter.ImageRequest{Prompt: "a cat", Size: "big"}, 0, nil, llmrouter.ErrInvalidRequest},
+		{"unsupported ratio", &llmrouter.ImageRequest{Prompt: "a cat", Size: "1000x200"}, 0, nil, llmrouter.ErrInvalidRequest},
+		{"all filtered", &llmrouter.ImageRequest{Prompt: "a cat"}, http.StatusOK, map[string]any{"predictions": []any{}}, llmrouter.ErrContentFiltered},
+		{"rate limited", &llmrouter.ImageRequest{Prompt: "a cat"}, http.StatusTooManyRequests, map[string]any{"error": map[string]any{"message": "slow down"}}, llmrouter.ErrRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.status == 0 {
+					t.Error("unexpected API call")
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_ = json.NewEncoder(w).Encode(tt.predict)
+			})
+			if _, err := p.GenerateImage(context.Background(), tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroundingNotSupported(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected API call")
+	})
+
+	req := userRequest("news?")
+	req.Grounding = true
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("error = %v, want ErrNotSupported", err)
+	}
+	if _, err := p.Stream(context.Background(), req); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("stream error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestDocumentSentToAPI(t *testing.T) {
+	p, api := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"candidates": []any{candidate(0, "STOP", "a"), candidate(1, "STOP", "b")}})
+	})
+
+	// Multiple candidates use the unary endpoint
+	req := &llmrouter.Request{N: intPtr(2), Messages: []llmrouter.Message{{Role: llmrouter.RoleUser, ContentParts: []llmrouter.ContentPart{
+		{Type: "text", Text: "Summarize these."},
+		{Type: "document", Document: &llmrouter.Document{Base64: "JVBERi0=", MediaType: "application/pdf"}},
+		{Type: "document", Document: &llmrouter.Document{URI: "gs://bucket/report.pdf", MediaType: "application/pdf"}},
+	}}}}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, _ := api.lastBody()["contents"].([]any)
+	if len(contents) != 1 {
+		t.Fatalf("contents = %v, want one user turn", api.lastBody()["contents"])
+	}
+	parts, _ := contents[0].(map[string]any)["parts"].([]any)
+	if len(parts) != 3 {
+		t.Fatalf("parts = %v, want text and two documents", parts)
+	}
+	inline, _ := parts[1].(map[string]any)["inlineData"].(map[string]any)
+	if inline["mimeType"] != "application/pdf" || inline["data"] != "JVBERi0=" {
+		t.Errorf("parts[1] = %v, want the PDF inline", parts[1])
+	}
+	file, _ := parts[2].(map[string]any)["fileData"].(map[string]any)
+	if file["mimeType"] != "application/pdf" || file["fileUri"] != "gs://bucket/report.pdf" {
+		t.Errorf("parts[2] = %v, want the file reference", parts[2])
+	}
+}
+
+func TestInvalidDocumentRejected(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected API call")
+	})
+
+	req := &llmrouter.Request{Messages: []llmrouter.Message{{Role: llmrouter.RoleUser, ContentParts: []llmrouter.ContentPart{
+		{Type: "document", Document: &llmrouter.Document{URI: "gs://bucket/report.pdf"}},
+	}}}}
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrInvalidRequest) {
+		t.Errorf("error = %v, want ErrInvalidRequest", err)
+	}
+	if _, err := p.Stream(context.Background(), req); !errors.Is(err, llmrouter.ErrInvalidRequest) {
+		t.Errorf("stream error = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestTemperatureRange(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected API call")
+	})
+
+	req := userRequest("hello")
+	temp := 2.5
+	req.Temperature = &temp
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrInvalidRequest) {
+		t.Errorf("error = %v, want ErrInvalidRequest for temperature 2.5", err)
+	}
+
+	var requested, clamped float64
+	p.WithTemperatureClamp(func(r, c float64) { requested, clamped = r, c })
+	model := p.client.GenerativeModel("gemini-test")
+	checked, err := llmrouter.CheckTemperature(req, temperatureRange, p.onClamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.configureModel(model, checked)
+	if requested != 2.5 || clamped != 2 || model.Temperature == nil || *model.Temperature != 2 {
+		t.Errorf("clamped %v to %v, model temperature %v; want 2", requested, clamped, model.Temperature)
+	}
+}
+
+func TestStopSequencesEnforcedLocally(t *testing.T) {
+	p, api := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"candidates": []any{
+			candidate(0, "STOP", "1, 2, 3, END, 4"),
+			candidate(1, "STOP", "a b END c"),
+		}})
+	})
+
+	req := userRequest("count")
+	req.Stop = []string{"END"}
+	req.N = intPtr(2)
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := resp.Choices[0].Message.Content; got != "1, 2, 3, " {
+		t.Errorf("choice 0 = %q", got)
+	}
+	if got := resp.Choices[1].Message.Content; got != "a b " {
+		t.Errorf("choice 1 = %q", got)
+	}
+	config, _ := api.lastBody()["generationConfig"].(map[string]any)
+	if stops, _ := config["stopSequences"].([]any); len(stops) != 1 || stops[0] != "END" {
+		t.Errorf("stopSequences = %v, want them sent as well", config["stopSequences"])
+	}
+}
+
+// systemRequest asks for two candidates, so it takes the unary endpoint
+func systemRequest() *llmrouter.Request {
+	return &llmrouter.Request{
+		Messages: []llmrouter.Message{
+			{Role: llmrouter.RoleSystem, Content: "answer in one short word"},
+			{Role: llmrouter.RoleUser, Content: "capital of France"},
+		},
+		N: intPtr(2),
+	}
+}
+
+func TestUsageIncludesSystemPrompt(t *testing.T) {
+	p, api := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"candidates":    []any{candidate(0, "STOP", "Paris"), candidate(1, "STOP", "Paris")},
+			"usageMetadata": map[string]any{"promptTokenCount": 9, "candidatesTokenCount": 2, "totalTokenCount": 11},
+		})
+	})
+
+	resp, err := p.Complete(context.Background(), systemRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.lastBody()["systemInstruction"] == nil {
+		t.Fatal("system prompt not sent as the system instruction")
+	}
+	// The reported count covers the system instruction, so it's passed through
+	if *resp.Usage != (llmrouter.Usage{PromptTokens: 9, CompletionTokens: 2, TotalTokens: 11}) {
+		t.Errorf("usage = %+v, want the reported counts", resp.Usage)
+	}
+}
+
+func TestEstimatedUsageCountsSystemPrompt(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"candidates": []any{candidate(0, "STOP", "Paris"), candidate(1, "STOP", "Paris")}})
+	})
+	words := func(s string) int { return len(strings.Fields(s)) }
+	p.WithTokenCounter(words)
+
+	resp, err := p.Complete(context.Background(), systemRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5 system words + 3 user words, then 1 completion word
+	if *resp.Usage != (llmrouter.Usage{PromptTokens: 8, CompletionTokens: 1, TotalTokens: 9}) {
+		t.Errorf("usage = %+v, want the counted prompt including the system prompt", resp.Usage)
+	}
+}
+
+func TestFinishDetailsKeepRawReason(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"candidates": []any{
+			candidate(0, "STOP", "first"),
+			candidate(1, "MAX_TOKENS", "second"),
+		}})
+	})
+
+	req := userRequest("hi")
+	req.N = intPtr(2)
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []llmrouter.FinishDetails{
+		{Reason: "stop", RawReason: "FinishReasonStop"},
+		{Reason: "length", RawReason: "FinishReasonMaxTokens"},
+	}
+	for i, w := range want {
+		if d := resp.Choices[i].FinishDetails; d == nil || *d != w {
+			t.Errorf("choice %d finish details = %+v, want %+v", i, d, w)
+		}
+	}
+}
+
+func TestStreamFinishReasonFromLastChunk(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	events := streamChunks(p, userRequest("hi"), &fakeIterator{chunks: []*genai.GenerateContentResponse{
+		textChunk(genai.FinishReasonUnspecified, genai.Text("Once upon")),
+		textChunk(genai.FinishReasonMaxTokens, genai.Text(" a time")),
+	}})
+
+	var content string
+	for _, e := range events[:len(events)-1] {
+		content += e.Content
+	}
+	done := events[len(events)-1]
+	if done.Type != llmrouter.EventDone {
+		t.Fatalf("last event = %+v, want done", done)
+	}
+	c := done.Response.Choices[0]
+	if content != "Once upon a time" || c.Message.Content != content {
+		t.Errorf("content = %q, final %q; want the chunks joined", content, c.Message.Content)
+	}
+	if c.FinishReason != "length" || c.FinishDetails.RawReason != "FinishReasonMaxTokens" {
+		t.Errorf("finish = %q, %+v; want length from FinishReasonMaxTokens", c.FinishReason, c.FinishDetails)
+	}
+}
+
+func TestStreamCitations(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	uri, start, end := "https://a.example", int32(4), int32(15)
+	last := textChunk(genai.FinishReasonStop, genai.Text("brown fox"))
+	last.Candidates[0].CitationMetadata = &genai.CitationMetadata{CitationSources: []*genai.CitationSource{
+		{URI: &uri, StartIndex: &start, EndIndex: &end},
+	}}
+	events := streamChunks(p, userRequest("hi"), &fakeIterator{chunks: []*genai.GenerateContentResponse{
+		textChunk(genai.FinishReasonUnspecified, genai.Text("The quick ")),
+		last,
+	}})
+
+	done := events[len(events)-1]
+	if done.Type != llmrouter.EventDone {
+		t.Fatalf("last event = %+v, want done", done)
+	}
+	want := []llmrouter.Citation{{URL: uri, Snippet: "quick brown"}}
+	if !reflect.DeepEqual(done.Response.Citations, want) {
+		t.Errorf("citations = %+v, want %+v resolved against the whole reply", done.Response.Citations, want)
+	}
+}
+
+func TestStreamFinishReasons(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []*genai.GenerateContentResponse
+		want   string
+	}{
+		{"stop", []*genai.GenerateContentResponse{textChunk(genai.FinishReasonStop, genai.Text("hi"))}, "stop"},
+		{"safety", []*genai.GenerateContentResponse{
+			textChunk(genai.FinishReasonUnspecified, genai.Text("partial")),
+			textChunk(genai.FinishReasonSafety),
+		}, "content_filter"},
+		{"unset reason", []*genai.GenerateContentResponse{textChunk(genai.FinishReasonUnspecified, genai.Text("hi"))}, "stop"},
+		{"tool call", []*genai.GenerateContentResponse{
+			textChunk(genai.FinishReasonStop, genai.FunctionCall{Name: "weather", Args: map[string]any{"city": "Paris"}}),
+		}, "tool_calls"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+			events := streamChunks(p, userRequest("hi"), &fakeIterator{chunks: tt.chunks})
+			done := events[len(events)-1]
+			if done.Type != llmrouter.EventDone || done.Response.Choices[0].FinishReason != tt.want {
+				t.Errorf("last event = %+v, want done with finish %q", done, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamIteratorError(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	events := streamChunks(p, userRequest("hi"), &fakeIterator{
+		chunks: []*genai.GenerateContentResponse{textChunk(genai.FinishReasonUnspecified, genai.Text("hi"))},
+		err:    &genai.BlockedError{Candidate: &genai.Candidate{FinishReason: genai.FinishReasonSafety}},
+	})
+	last := events[len(events)-1]
+	if last.Type != llmrouter.EventError || !errors.Is(last.Error, llmrouter.ErrContentFiltered) {
+		t.Errorf("last event = %+v, want a content filtered error", last)
+	}
+}
+
+func TestCompleteNoCandidates(t *testing.T) {
+	tests := []struct {
+		name string
+		body map[string]any
+		want error
+	}{
+		{"empty", map[string]any{"candidates": []any{}}, llmrouter.ErrProviderError},
+		{"blocked prompt", map[string]any{"promptFeedback": map[string]any{"blockReason": "SAFETY"}}, llmrouter.ErrContentFiltered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, tt.body)
+			})
+
+			req := userRequest("hi")
+			req.N = intPtr(2)
+			resp, err := p.Complete(context.Background(), req)
+			if resp != nil || !errors.Is(err, tt.want) {
+				t.Errorf("Complete = %+v, %v; want nil and %v", resp, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamNoCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		feedback *genai.PromptFeedback
+		want     error
+	}{
+		{"empty", nil, llmrouter.ErrProviderError},
+		{"blocked prompt", &genai.PromptFeedback{BlockReason: genai.BlockReasonOther}, llmrouter.ErrContentFiltered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+
+			events := streamChunks(p, userRequest("hi"), &fakeIterator{chunks: []*genai.GenerateContentResponse{{PromptFeedback: tt.feedback}}})
+			if len(events) != 1 || events[0].Type != llmrouter.EventError || !errors.Is(events[0].Error, tt.want) {
+				t.Errorf("events = %+v, want a single %v error", events, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolChoiceSentToAPI(t *testing.T) {
+	p, api := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"candidates": []any{candidate(0, "STOP", "a"), candidate(1, "STOP", "b")}})
+	})
+
+	// Multiple candidates use the unary endpoint
+	req := userRequest("weather?")
+	req.N = intPtr(2)
+	req.Tools = []llmrouter.Tool{{Type: "function", Function: llmrouter.Function{Name: "weather"}}}
+	req.ToolChoice = &llmrouter.ToolChoice{Type: "function", Function: &llmrouter.FuncRef{Name: "weather"}}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _ := api.lastBody()["toolConfig"].(map[string]any)
+	fc, _ := cfg["functionCallingConfig"].(map[string]any)
+	names, _ := fc["allowedFunctionNames"].([]any)
+	// The REST transport encodes enums as numbers
+	if fc["mode"] != float64(genai.FunctionCallingAny) || len(names) != 1 || names[0] != "weather" {
+		t.Errorf("toolConfig = %v, want ANY restricted to weather", api.lastBody()["toolConfig"])
+	}
+}
+
+func TestClient(t *testing.T) {
+	p, err := New(context.Background(), llmrouter.ProviderConfig{APIKey: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Client().Close() })
+	if p.Client() == nil {
+		t.Fatal("Client() = nil")
+	}
+}
@@ -0,0 +1,104 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/openai/openai-go"
+)
+
+// DefaultTranscriptionModel is used when a transcription request doesn't name a model
+const DefaultTranscriptionModel = openai.AudioModelWhisper1
+
+// DefaultSpeechModel is used when a speech request doesn't name a model
+const DefaultSpeechModel = openai.SpeechModelTTS1
+
+// DefaultVoice is used when a speech request doesn't name a voice
+const DefaultVoice = openai.AudioSpeechNewParamsVoiceAlloy
+
+// Transcribe converts audio to text via the transcriptions endpoint
+func (p *Provider) Transcribe(ctx context.Context, req *llmrouter.TranscribeRequest) (*llmrouter.TranscribeResponse, error) {
+	model := req.Model
+	if model == "" || model == p.name {
+		model = DefaultTranscriptionModel
+	}
+
+	filename := req.Filename
+	if filename == "" {
+		filename = "audio.mp3"
+	}
+	mediaType := req.MediaType
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+
+	params := openai.AudioTranscriptionNewParams{
+		File:  openai.FileParam(bytes.NewReader(req.Audio), filename, mediaType),
+		Model: openai.F(model),
+	}
+	if req.Language != "" {
+		params.Language = openai.F(req.Language)
+	}
+	if req.Prompt != "" {
+		params.Prompt = openai.F(req.Prompt)
+	}
+	if req.Temperature != nil {
+		params.Temperature = openai.F(*req.Temperature)
+	}
+
+	resp, err := p.client.Audio.Transcriptions.New(ctx, params)
+	if err != nil {
+		return nil, wrapError(p.name, err)
+	}
+
+	return &llmrouter.TranscribeResponse{
+		Text:     resp.Text,
+		Model:    model,
+		Provider: p.name,
+	}, nil
+}
+
+// Synthesize converts text to audio via the speech endpoint
+func (p *Provider) Synthesize(ctx context.Context, req *llmrouter.SpeechRequest) (*llmrouter.SpeechResponse, error) {
+	model := req.Model
+	if model == "" || model == p.name {
+		model = DefaultSpeechModel
+	}
+
+	voice := openai.AudioSpeechNewParamsVoice(req.Voice)
+	if voice == "" {
+		voice = DefaultVoice
+	}
+
+	params := openai.AudioSpeechNewParams{
+		Input: openai.F(req.Input),
+		Model: openai.F(model),
+		Voice: openai.F(voice),
+	}
+	if req.Format != "" {
+		params.ResponseFormat = openai.F(openai.AudioSpeechNewParamsResponseFormat(req.Format))
+	}
+	if req.Speed != nil {
+		params.Speed = openai.F(*req.Speed)
+	}
+
+	resp, err := p.client.Audio.Speech.New(ctx, params)
+	if err != nil {
+		return nil, wrapError(p.name, err)
+	}
+	defer resp.Body.Close()
+
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, wrapError(p.name, err)
+	}
+
+	return &llmrouter.SpeechResponse{
+		Audio:     audio,
+		MediaType: resp.Header.Get("Content-Type"),
+		Model:     model,
+		Provider:  p.name,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestTranscribeSendsFile(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"text": "hello there"})
+	})
+
+	audio := []byte("RIFF fake wav data")
+	resp, err := p.Transcribe(context.Background(), &llmrouter.TranscribeRequest{
+		Audio:     audio,
+		Filename:  "clip.wav",
+		MediaType: "audio/wav",
+		Language:  "en",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "hello there" || resp.Model != DefaultTranscriptionModel || resp.Provider != "openai" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	req := api.last()
+	if req.Path != "/audio/transcriptions" {
+		t.Errorf("path = %s", req.Path)
+	}
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := multipart.NewReader(bytes.NewReader(req.Body), params["boundary"])
+	fields := map[string]string{}
+	var file []byte
+	var filename string
+	for {
+		part, err := form.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(part)
+		if part.FormName() == "file" {
+			file, filename = data, part.FileName()
+			continue
+		}
+		fields[part.FormName()] = string(data)
+	}
+
+	if !bytes.Equal(file, audio) || filename != "clip.wav" {
+		t.Errorf("file = %q named %q, want the audio named clip.wav", file, filename)
+	}
+	if fields["model"] != DefaultTranscriptionModel || fields["language"] != "en" {
+		t.Errorf("form fields = %v", fields)
+	}
+}
+
+func TestSynthesize(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		_, _ = w.Write([]byte("ID3 fake mp3"))
+	})
+
+	speed := 1.5
+	resp, err := p.Synthesize(context.Background(), &llmrouter.SpeechRequest{
+		Input: "hello",
+		Voice: "nova",
+		Speed: &speed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Audio) != "ID3 fake mp3" || resp.MediaType != "audio/mpeg" {
+		t.Errorf("response = %q (%s)", resp.Audio, resp.MediaType)
+	}
+	if resp.Model != DefaultSpeechModel || resp.Provider != "openai" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	req := api.last()
+	if req.Path != "/audio/speech" {
+		t.Errorf("path = %s", req.Path)
+	}
+	body := req.JSON()
+	if body["input"] != "hello" || body["voice"] != "nova" || body["speed"] != 1.5 || body["model"] != DefaultSpeechModel {
+		t.Errorf("request body = %v", body)
+	}
+}
+
+func TestSynthesizeDefaultVoice(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("audio"))
+	})
+	if _, err := p.Synthesize(context.Background(), &llmrouter.SpeechRequest{Input: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if voice := api.last().JSON()["voice"]; voice != string(DefaultVoice) {
+		t.Errorf("voice = %v, want %s", voice, DefaultVoice)
+	}
+}
@@ -9,24 +9,56 @@ import (
 	"github.com/openai/openai-go"
 )
 
-func convertMessages(msgs []llmrouter.Message) []openai.ChatCompletionMessageParamUnion {
+// convertMessages converts messages to the chat completions format. With
+// developerRole, system messages are sent as developer messages, which
+// reasoning models require.
+func convertMessages(msgs []llmrouter.Message, developerRole bool) []openai.ChatCompletionMessageParamUnion {
 	result := make([]openai.ChatCompletionMessageParamUnion, 0, len(msgs))
 
+	// Tool messages only carry text, so images returned by tools are sent in
+	// a user message after the run of tool results
+	var toolImages []openai.ChatCompletionContentPartUnionParam
+	flushToolImages := func() {
+		if len(toolImages) == 0 {
+			return
+		}
+		parts := append([]openai.ChatCompletionContentPartUnionParam{
+			openai.TextPart("Images returned by the preceding tool calls:"),
+		}, toolImages...)
+		result = append(result, openai.UserMessageParts(parts...))
+		toolImages = nil
+	}
+
 	for _, msg := range msgs {
+		if msg.Role != llmrouter.RoleTool {
+			flushToolImages()
+		}
+
 		switch msg.Role {
 		case llmrouter.RoleSystem:
-			result = append(result, openai.SystemMessage(msg.Content))
+			if developerRole {
+				result = append(result, openai.ChatCompletionDeveloperMessageParam{
+					Role:    openai.F(openai.ChatCompletionDeveloperMessageParamRoleDeveloper),
+					Content: openai.F([]openai.ChatCompletionContentPartTextParam{openai.TextPart(msg.Text())}),
+				})
+			} else {
+				result = append(result, openai.SystemMessage(msg.Text()))
+			}
 
 		case llmrouter.RoleUser:
 			if len(msg.ContentParts) > 0 {
-				parts := make([]openai.ChatCompletionContentPartUnionParam, 0, len(msg.ContentParts))
+				parts := make([]openai.ChatCompletionContentPartUnionParam, 0, len(msg.ContentParts)+1)
+				// Content set alongside parts leads them, as Message.Text orders it
+				if msg.Content != "" {
+					parts = append(parts, openai.TextPart(msg.Content))
+				}
 				for _, p := range msg.ContentParts {
 					switch p.Type {
 					case "text":
 						parts = append(parts, openai.TextPart(p.Text))
 					case "image_url":
 						if p.ImageURL != nil {
-							parts = append(parts, openai.ImagePart(p.ImageURL.URL))
+							parts = append(parts, convertImagePart(p.ImageURL))
 						}
 					}
 				}
@@ -48,23 +80,53 @@ func convertMessages(msgs []llmrouter.Message) []openai.ChatCompletionMessagePar
 						}),
 					}
 				}
-				result = append(result, openai.ChatCompletionAssistantMessageParam{
+				assistant := openai.ChatCompletionAssistantMessageParam{
 					Role:      openai.F(openai.ChatCompletionAssistantMessageParamRoleAssistant),
-					Content:   openai.F([]openai.ChatCompletionAssistantMessageParamContentUnion{openai.TextPart(msg.Content)}),
 					ToolCalls: openai.F(toolCalls),
-				})
+				}
+				// Leave content unset (null) when empty; some compatible backends reject ""
+				if msg.Content != "" {
+					assistant.Content = openai.F([]openai.ChatCompletionAssistantMessageParamContentUnion{openai.TextPart(msg.Content)})
+				}
+				result = append(result, assistant)
 			} else {
 				result = append(result, openai.AssistantMessage(msg.Content))
 			}
 
 		case llmrouter.RoleTool:
-			result = append(result, openai.ToolMessage(msg.ToolCallID, msg.Content))
+			result = append(result, openai.ToolMessage(msg.ToolCallID, msg.Text()))
+			for _, p := range msg.ContentParts {
+				if p.Type == "image_url" && p.ImageURL != nil {
+					toolImages = append(toolImages, convertImagePart(p.ImageURL))
+				}
+			}
 		}
 	}
+	flushToolImages()
 
 	return result
 }
 
+// convertImagePart converts an image to an image_url part, keeping its detail level
+func convertImagePart(img *llmrouter.ImageURL) openai.ChatCompletionContentPartImageParam {
+	part := openai.ImagePart(imageURL(img))
+	if img.Detail != "" {
+		part.ImageURL = openai.F(openai.ChatCompletionContentPartImageImageURLParam{
+			URL:    openai.F(imageURL(img)),
+			Detail: openai.F(openai.ChatCompletionContentPartImageImageURLDetail(img.Detail)),
+		})
+	}
+	return part
+}
+
+// imageURL returns the image's URL, falling back to a data URL for base64 images
+func imageURL(img *llmrouter.ImageURL) string {
+	if img.URL == "" && img.Base64 != "" {
+		return "data:" + img.MediaType + ";base64," + img.Base64
+	}
+	return img.URL
+}
+
 func convertTools(tools []llmrouter.Tool) []openai.ChatCompletionToolParam {
 	result := make([]openai.ChatCompletionToolParam, len(tools))
 
@@ -87,6 +149,32 @@ func convertTools(tools []llmrouter.Tool) []openai.ChatCompletionToolParam {
 	return result
 }
 
+func convertResponseFormat(rf *llmrouter.ResponseFormat) openai.ChatCompletionNewParamsResponseFormatUnion {
+	switch rf.Type {
+	case "json_object":
+		return openai.ResponseFormatJSONObjectParam{
+			Type: openai.F(openai.ResponseFormatJSONObjectTypeJSONObject),
+		}
+	case "json_schema":
+		if rf.JSONSchema != nil {
+			var schema map[string]interface{}
+			_ = json.Unmarshal(rf.JSONSchema.Schema, &schema)
+			return openai.ResponseFormatJSONSchemaParam{
+				Type: openai.F(openai.ResponseFormatJSONSchemaTypeJSONSchema),
+				JSONSchema: openai.F(openai.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:        openai.F(rf.JSONSchema.Name),
+					Description: openai.F(rf.JSONSchema.Description),
+					Schema:      openai.F[interface{}](schema),
+					Strict:      openai.F(rf.JSONSchema.Strict),
+				}),
+			}
+		}
+	}
+	return openai.ResponseFormatTextParam{
+		Type: openai.F(openai.ResponseFormatTextTypeText),
+	}
+}
+
 func convertToolChoice(tc *llmrouter.ToolChoice) openai.ChatCompletionToolChoiceOptionUnionParam {
 	if tc == nil {
 		return nil
@@ -138,8 +226,11 @@ func convertResponse(resp *openai.ChatCompletion, provider string) *llmrouter.Re
 				Role:      llmrouter.RoleAssistant,
 				Content:   choice.Message.Content,
 				ToolCalls: toolCalls,
+
+				ReasoningContent: extraString(choice.Message.JSON.ExtraFields["reasoning_content"].Raw()),
 			},
-			FinishReason: string(choice.FinishReason),
+			FinishReason:  string(choice.FinishReason),
+			FinishDetails: llmrouter.NewFinishDetails(string(choice.FinishReason), string(choice.FinishReason), len(toolCalls) > 0),
 		}
 	}
 
@@ -153,13 +244,15 @@ func convertResponse(resp *openai.ChatCompletion, provider string) *llmrouter.Re
 	}
 
 	return &llmrouter.Response{
-		ID:       resp.ID,
-		Object:   string(resp.Object),
-		Created:  resp.Created,
-		Model:    resp.Model,
-		Choices:  choices,
-		Usage:    usage,
-		Provider: provider,
+		ID:          resp.ID,
+		Object:      string(resp.Object),
+		Created:     resp.Created,
+		Model:       resp.Model,
+		Choices:     choices,
+		Usage:       usage,
+		Provider:    provider,
+		ServiceTier: string(resp.ServiceTier),
+		Citations:   parseCitations(resp.JSON.ExtraFields["citations"].Raw()),
 	}
 }
 
@@ -190,9 +283,14 @@ func convertChunkResponse(chunk *openai.ChatCompletionChunk, provider string) *l
 				Role:      llmrouter.Role(choice.Delta.Role),
 				Content:   choice.Delta.Content,
 				ToolCalls: toolCalls,
+
+				ReasoningContent: extraString(choice.Delta.JSON.ExtraFields["reasoning_content"].Raw()),
 			},
 			FinishReason: string(choice.FinishReason),
 		}
+		if choice.FinishReason != "" {
+			choices[i].FinishDetails = llmrouter.NewFinishDetails(string(choice.FinishReason), string(choice.FinishReason), false)
+		}
 	}
 
 	var usage *llmrouter.Usage
@@ -205,14 +303,44 @@ func convertChunkResponse(chunk *openai.ChatCompletionChunk, provider string) *l
 	}
 
 	return &llmrouter.Response{
-		ID:       chunk.ID,
-		Object:   string(chunk.Object),
-		Created:  chunk.Created,
-		Model:    chunk.Model,
-		Choices:  choices,
-		Usage:    usage,
-		Provider: provider,
+		ID:          chunk.ID,
+		Object:      string(chunk.Object),
+		Created:     chunk.Created,
+		Model:       chunk.Model,
+		Choices:     choices,
+		Usage:       usage,
+		Provider:    provider,
+		ServiceTier: string(chunk.ServiceTier),
+		Citations:   parseCitations(chunk.JSON.ExtraFields["citations"].Raw()),
+	}
+}
+
+// parseCitations decodes the non-standard "citations" array of source URLs
+// returned by Perplexity
+func parseCitations(raw string) []llmrouter.Citation {
+	if raw == "" {
+		return nil
+	}
+	var urls []string
+	if err := json.Unmarshal([]byte(raw), &urls); err != nil || len(urls) == 0 {
+		return nil
+	}
+
+	citations := make([]llmrouter.Citation, len(urls))
+	for i, u := range urls {
+		citations[i] = llmrouter.Citation{URL: u}
 	}
+	return citations
+}
+
+// extraString decodes a string-valued field the SDK doesn't model, such as
+// DeepSeek's reasoning_content. It returns "" for absent or null fields.
+func extraString(raw string) string {
+	var v string
+	if raw == "" || json.Unmarshal([]byte(raw), &v) != nil {
+		return ""
+	}
+	return v
 }
 
 func convertStreamToolCalls(toolCalls []openai.ChatCompletionChunkChoicesDeltaToolCall) []llmrouter.ToolCall {
@@ -234,6 +362,47 @@ func convertStreamToolCalls(toolCalls []openai.ChatCompletionChunkChoicesDeltaTo
 	return result
 }
 
+// toolCallTracker carries tool call IDs and names forward across stream
+// chunks. OpenAI only sends them on a call's first delta; later argument
+// fragments share its index but have empty IDs and names.
+type toolCallTracker map[[2]int]llmrouter.ToolCall
+
+// fill completes missing IDs and names in place, keyed by choice and tool index
+func (t toolCallTracker) fill(choice int, toolCalls []llmrouter.ToolCall) {
+	for i := range toolCalls {
+		tc := &toolCalls[i]
+		if tc.Index == nil {
+			continue
+		}
+		key := [2]int{choice, *tc.Index}
+		seen := t[key]
+		if tc.ID == "" {
+			tc.ID = seen.ID
+		} else {
+			seen.ID = tc.ID
+		}
+		if tc.Function.Name == "" {
+			tc.Function.Name = seen.Function.Name
+		} else {
+			seen.Function.Name = tc.Function.Name
+		}
+		t[key] = seen
+	}
+}
+
+// sumLogprobs returns the log probability of a delta, the sum over its
+// tokens, or nil if the chunk carried no logprobs
+func sumLogprobs(tokens []openai.ChatCompletionTokenLogprob) *float64 {
+	if len(tokens) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, t := range tokens {
+		sum += t.Logprob
+	}
+	return &sum
+}
+
 func wrapError(provider string, err error) error {
 	if err == nil {
 		return nil
@@ -0,0 +1,169 @@
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// marshalMessages converts msgs and decodes the serialized result
+func marshalMessages(t *testing.T, msgs []llmrouter.Message, developerRole bool) []map[string]any {
+	t.Helper()
+	data, err := json.Marshal(convertMessages(msgs, developerRole))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result []map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestAssistantToolCallWithoutContent(t *testing.T) {
+	call := llmrouter.ToolCall{ID: "call_1", Type: "function", Function: llmrouter.FuncCall{Name: "weather", Arguments: `{"city":"Oslo"}`}}
+	msgs := marshalMessages(t, []llmrouter.Message{
+		{Role: llmrouter.RoleUser, Content: "weather?"},
+		{Role: llmrouter.RoleAssistant, ToolCalls: []llmrouter.ToolCall{call}},
+		{Role: llmrouter.RoleAssistant, Content: "checking", ToolCalls: []llmrouter.ToolCall{call}},
+	}, false)
+
+	if content, ok := msgs[1]["content"]; ok && content != nil {
+		t.Errorf("empty assistant message has content %v, want none", content)
+	}
+	if calls, _ := msgs[1]["tool_calls"].([]any); len(calls) != 1 {
+		t.Errorf("tool_calls = %v", msgs[1]["tool_calls"])
+	}
+
+	parts, _ := msgs[2]["content"].([]any)
+	if len(parts) != 1 || parts[0].(map[string]any)["text"] != "checking" {
+		t.Errorf("content = %v, want the text kept", msgs[2]["content"])
+	}
+}
+
+func TestParseCitations(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{``, nil},
+		{`null`, nil},
+		{`[]`, nil},
+		{`"not a list"`, nil},
+		{`["https://a.example","https://b.example"]`, []string{"https://a.example", "https://b.example"}},
+	}
+	for _, tt := range tests {
+		got := parseCitations(tt.raw)
+		if len(got) != len(tt.want) {
+			t.Errorf("parseCitations(%q) = %+v, want %v", tt.raw, got, tt.want)
+			continue
+		}
+		for i, url := range tt.want {
+			if got[i].URL != url {
+				t.Errorf("parseCitations(%q)[%d] = %+v, want %s", tt.raw, i, got[i], url)
+			}
+		}
+	}
+}
+
+func TestToolCallTrackerSeparatesChoicesAndIndexes(t *testing.T) {
+	tracker := toolCallTracker{}
+	index := func(i int) *int { return &i }
+	first := []llmrouter.ToolCall{
+		{ID: "a", Index: index(0), Function: llmrouter.FuncCall{Name: "weather"}},
+		{ID: "b", Index: index(1), Function: llmrouter.FuncCall{Name: "time"}},
+	}
+	tracker.fill(0, first)
+	tracker.fill(1, []llmrouter.ToolCall{{ID: "c", Index: index(0), Function: llmrouter.FuncCall{Name: "news"}}})
+
+	later := []llmrouter.ToolCall{{Index: index(1)}, {Index: index(0)}}
+	tracker.fill(0, later)
+	if later[0].ID != "b" || later[0].Function.Name != "time" || later[1].ID != "a" || later[1].Function.Name != "weather" {
+		t.Errorf("choice 0 fragments = %+v", later)
+	}
+
+	other := []llmrouter.ToolCall{{Index: index(0)}}
+	tracker.fill(1, other)
+	if other[0].ID != "c" || other[0].Function.Name != "news" {
+		t.Errorf("choice 1 fragment = %+v", other[0])
+	}
+}
+
+func TestUserContentParts(t *testing.T) {
+	msgs := marshalMessages(t, []llmrouter.Message{{
+		Role: llmrouter.RoleUser,
+		ContentParts: []llmrouter.ContentPart{
+			{Type: "text", Text: "What is in these images?"},
+			{Type: "image_url", ImageURL: &llmrouter.ImageURL{URL: "https://example.com/cat.png", Detail: "high"}},
+			{Type: "image_url", ImageURL: &llmrouter.ImageURL{Base64: "iVBORw0KGgo=", MediaType: "image/png"}},
+			{Type: "image_url"},
+		},
+	}}, false)
+
+	if msgs[0]["role"] != "user" {
+		t.Fatalf("role = %v, want user", msgs[0]["role"])
+	}
+	parts, _ := msgs[0]["content"].([]any)
+	if len(parts) != 3 {
+		t.Fatalf("content = %v, want three parts", msgs[0]["content"])
+	}
+	if p := parts[0].(map[string]any); p["type"] != "text" || p["text"] != "What is in these images?" {
+		t.Errorf("part 0 = %v, want the text", p)
+	}
+	first := parts[1].(map[string]any)
+	url, _ := first["image_url"].(map[string]any)
+	if first["type"] != "image_url" || url["url"] != "https://example.com/cat.png" || url["detail"] != "high" {
+		t.Errorf("part 1 = %v, want the URL image with high detail", first)
+	}
+	second := parts[2].(map[string]any)
+	url, _ = second["image_url"].(map[string]any)
+	if second["type"] != "image_url" || url["url"] != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Errorf("part 2 = %v, want the base64 image as a data URL", second)
+	}
+	if _, ok := url["detail"]; ok {
+		t.Errorf("part 2 has detail %v, want none when unset", url["detail"])
+	}
+}
+
+func TestUserStringContent(t *testing.T) {
+	msgs := marshalMessages(t, []llmrouter.Message{{Role: llmrouter.RoleUser, Content: "hello"}}, false)
+	parts, _ := msgs[0]["content"].([]any)
+	if len(parts) != 1 || parts[0].(map[string]any)["text"] != "hello" {
+		t.Errorf("content = %v, want the content as a single text part", msgs[0]["content"])
+	}
+}
+
+func TestUserContentWithParts(t *testing.T) {
+	msgs := marshalMessages(t, []llmrouter.Message{{
+		Role:         llmrouter.RoleUser,
+		Content:      "Describe this image.",
+		ContentParts: []llmrouter.ContentPart{{Type: "image_url", ImageURL: &llmrouter.ImageURL{URL: "https://example.com/cat.png"}}},
+	}}, false)
+
+	parts, _ := msgs[0]["content"].([]any)
+	if len(parts) != 2 {
+		t.Fatalf("content = %v, want the text then the image", msgs[0]["content"])
+	}
+	if p := parts[0].(map[string]any); p["type"] != "text" || p["text"] != "Describe this image." {
+		t.Errorf("part 0 = %v, want the message content", p)
+	}
+	if p := parts[1].(map[string]any); p["type"] != "image_url" {
+		t.Errorf("part 1 = %v, want the image", p)
+	}
+}
+
+func TestSystemMessageRole(t *testing.T) {
+	msgs := []llmrouter.Message{
+		{Role: llmrouter.RoleSystem, Content: "be brief"},
+		{Role: llmrouter.RoleUser, Content: "hi"},
+	}
+
+	for developerRole, want := range map[bool]string{false: "system", true: "developer"} {
+		got := marshalMessages(t, msgs, developerRole)[0]
+		parts, _ := got["content"].([]any)
+		if got["role"] != want || len(parts) != 1 || parts[0].(map[string]any)["text"] != "be brief" {
+			t.Errorf("developerRole %v: system message = %v, want a %s message", developerRole, got, want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package openai
+
+import (
+	"context"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/openai/openai-go"
+)
+
+// DefaultEmbeddingModel is used when an embedding request doesn't name a model
+const DefaultEmbeddingModel = openai.EmbeddingModelTextEmbedding3Small
+
+// Embed embeds the request inputs via the embeddings endpoint
+func (p *Provider) Embed(ctx context.Context, req *llmrouter.EmbeddingRequest) (*llmrouter.EmbeddingResponse, error) {
+	model := req.Model
+	if model == "" || model == p.name {
+		model = DefaultEmbeddingModel
+	}
+
+	params := openai.EmbeddingNewParams{
+		Input: openai.F[openai.EmbeddingNewParamsInputUnion](openai.EmbeddingNewParamsInputArrayOfStrings(req.Input)),
+		Model: openai.F(model),
+	}
+	if req.Dimensions > 0 {
+		params.Dimensions = openai.F(int64(req.Dimensions))
+	}
+
+	resp, err := p.client.Embeddings.New(ctx, params)
+	if err != nil {
+		return nil, wrapError(p.name, err)
+	}
+
+	embeddings := make([][]float64, len(req.Input))
+	for _, e := range resp.Data {
+		if int(e.Index) < len(embeddings) {
+			embeddings[e.Index] = e.Embedding
+		}
+	}
+
+	return &llmrouter.EmbeddingResponse{
+		Model:      resp.Model,
+		Embeddings: embeddings,
+		Usage: &llmrouter.Usage{
+			PromptTokens: int(resp.Usage.PromptTokens),
+			TotalTokens:  int(resp.Usage.TotalTokens),
+		},
+		Provider: p.name,
+	}, nil
+}
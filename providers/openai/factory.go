@@ -0,0 +1,28 @@
+package openai
+
+import llmrouter "github.com/bluefunda/llm-router"
+
+// init registers "openai" and every preset, e.g. "groq" and "ollama"
+func init() {
+	llmrouter.RegisterProviderFactory("openai", newOpenAI)
+	for name := range Presets {
+		if name == "openai" {
+			continue
+		}
+		llmrouter.RegisterProviderFactory(name, func(cfg llmrouter.ProviderConfig) (llmrouter.Provider, error) {
+			return NewWithPreset(name, cfg), nil
+		})
+	}
+}
+
+// newOpenAI builds an OpenAI provider. A custom name pointing at OpenAI itself
+// gets the OpenAI defaults.
+func newOpenAI(cfg llmrouter.ProviderConfig) (llmrouter.Provider, error) {
+	if _, ok := Presets[cfg.Name]; !ok && cfg.BaseURL == "" {
+		return NewWithPreset("openai", cfg), nil
+	}
+	if cfg.Name == "" {
+		cfg.Name = "openai"
+	}
+	return New(cfg), nil
+}
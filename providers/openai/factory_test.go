@@ -0,0 +1,66 @@
+package openai
+
+import (
+	"slices"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestFactoriesRegistered(t *testing.T) {
+	names := llmrouter.ProviderFactories()
+	for name := range Presets {
+		if !slices.Contains(names, name) {
+			t.Errorf("preset %q has no factory", name)
+		}
+	}
+}
+
+func TestPresetFactoryKeepsName(t *testing.T) {
+	tests := []struct {
+		kind  string
+		name  string
+		want  string
+		model string
+	}{
+		{"groq", "fast", "fast", Presets["groq"].DefaultModel},
+		{"groq", "", "groq", Presets["groq"].DefaultModel},
+		{"deepseek", "reasoner", "reasoner", Presets["deepseek"].DefaultModel},
+		{"openai", "work", "work", Presets["openai"].DefaultModel},
+		{"openai", "", "openai", Presets["openai"].DefaultModel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind+"/"+tt.name, func(t *testing.T) {
+			p, err := llmrouter.NewProviderByName(tt.kind, llmrouter.ProviderConfig{Name: tt.name, APIKey: "test"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			op := p.(*Provider)
+			if op.Name() != tt.want {
+				t.Errorf("name = %q, want %q", op.Name(), tt.want)
+			}
+			if op.model != tt.model || !slices.Equal(op.Models(), Presets[tt.kind].Models) {
+				t.Errorf("model %q serving %v, want the %s preset defaults", op.model, op.Models(), tt.kind)
+			}
+		})
+	}
+}
+
+func TestOpenAIFactoryCustomBackend(t *testing.T) {
+	p, err := llmrouter.NewProviderByName("openai", llmrouter.ProviderConfig{
+		Name:    "vllm",
+		BaseURL: "https://vllm.internal/v1",
+		Models:  []string{"llama-local"},
+		Model:   "llama-local",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := p.(*Provider)
+	if op.Name() != "vllm" || op.model != "llama-local" || !slices.Equal(op.Models(), []string{"llama-local"}) {
+		t.Errorf("provider = %q, model %q serving %v; want the configured backend", op.Name(), op.model, op.Models())
+	}
+	if !op.legacyMaxTokens {
+		t.Error("custom backend should default to the legacy max_tokens field")
+	}
+}
@@ -0,0 +1,142 @@
+package openai
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// recordedRequest is a request received by the fake API
+type recordedRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// JSON decodes the request body
+func (r recordedRequest) JSON() map[string]any {
+	var body map[string]any
+	_ = json.Unmarshal(r.Body, &body)
+	return body
+}
+
+// fakeAPI records requests sent to an httptest server
+type fakeAPI struct {
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+func (a *fakeAPI) last() recordedRequest {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.requests) == 0 {
+		return recordedRequest{}
+	}
+	return a.requests[len(a.requests)-1]
+}
+
+func (a *fakeAPI) count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.requests)
+}
+
+// newTestProvider returns a provider named name talking to a fake API whose
+// handler writes each response
+func newTestProvider(t *testing.T, name string, respond http.HandlerFunc) (*Provider, *fakeAPI) {
+	t.Helper()
+	return newTestProviderConfig(t, llmrouter.ProviderConfig{Name: name}, respond)
+}
+
+// newTestProviderConfig is newTestProvider for a provider built from cfg
+func newTestProviderConfig(t *testing.T, cfg llmrouter.ProviderConfig, respond http.HandlerFunc) (*Provider, *fakeAPI) {
+	t.Helper()
+	api := &fakeAPI{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		api.mu.Lock()
+		api.requests = append(api.requests, recordedRequest{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body})
+		api.mu.Unlock()
+		respond(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.APIKey = "test"
+	cfg.BaseURL = srv.URL + "/"
+	return New(cfg), api
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeSSE writes each chunk as a server-sent event, then [DONE]
+func writeSSE(w http.ResponseWriter, chunks ...any) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, c := range chunks {
+		data, _ := json.Marshal(c)
+		_, _ = w.Write([]byte("data: " + string(data) + "\n\n"))
+	}
+	_, _ = w.Write([]byte("data: [DONE]\n\n"))
+}
+
+// chatCompletion builds a chat completion response with one choice
+func chatCompletion(content string) map[string]any {
+	return map[string]any{
+		"id":      "chatcmpl-1",
+		"object":  "chat.completion",
+		"created": 1,
+		"model":   "gpt-test",
+		"choices": []any{map[string]any{
+			"index":         0,
+			"message":       map[string]any{"role": "assistant", "content": content},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]any{"prompt_tokens": 3, "completion_tokens": 2, "total_tokens": 5},
+	}
+}
+
+// userRequest builds a request with a single user message
+func userRequest(content string) *llmrouter.Request {
+	return &llmrouter.Request{
+		Messages: []llmrouter.Message{{Role: llmrouter.RoleUser, Content: content}},
+	}
+}
+
+// collect reads ch until it closes
+func collect(ch <-chan llmrouter.Event) []llmrouter.Event {
+	var events []llmrouter.Event
+	for e := range ch {
+		events = append(events, e)
+	}
+	return events
+}
+
+// chunk builds a streamed chat completion chunk with one choice
+func chunk(index int, delta map[string]any, finish string) map[string]any {
+	choice := map[string]any{"index": index, "delta": delta, "finish_reason": nil}
+	if finish != "" {
+		choice["finish_reason"] = finish
+	}
+	return map[string]any{
+		"id":      "chatcmpl-1",
+		"object":  "chat.completion.chunk",
+		"created": 1,
+		"model":   "gpt-test",
+		"choices": []any{choice},
+	}
+}
+
+func intPtr(n int) *int { return &n }
+
+func floatPtr(f float64) *float64 { return &f }
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,54 @@
+package openai
+
+import (
+	"context"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/openai/openai-go"
+)
+
+// DefaultImageModel is used when an image request doesn't name a model
+const DefaultImageModel = openai.ImageModelDallE3
+
+// GenerateImage generates images via the images endpoint
+func (p *Provider) GenerateImage(ctx context.Context, req *llmrouter.ImageRequest) (*llmrouter.ImageResponse, error) {
+	model := req.Model
+	if model == "" || model == p.name {
+		model = DefaultImageModel
+	}
+
+	params := openai.ImageGenerateParams{
+		Prompt: openai.F(req.Prompt),
+		Model:  openai.F(model),
+	}
+	if req.N > 0 {
+		params.N = openai.F(int64(req.N))
+	}
+	if req.Size != "" {
+		params.Size = openai.F(openai.ImageGenerateParamsSize(req.Size))
+	}
+	if req.ResponseFormat != "" {
+		params.ResponseFormat = openai.F(openai.ImageGenerateParamsResponseFormat(req.ResponseFormat))
+	}
+
+	resp, err := p.client.Images.Generate(ctx, params)
+	if err != nil {
+		return nil, wrapError(p.name, err)
+	}
+
+	images := make([]llmrouter.Image, len(resp.Data))
+	for i, img := range resp.Data {
+		images[i] = llmrouter.Image{
+			URL:           img.URL,
+			Base64:        img.B64JSON,
+			RevisedPrompt: img.RevisedPrompt,
+		}
+	}
+
+	return &llmrouter.ImageResponse{
+		Created:  resp.Created,
+		Model:    model,
+		Images:   images,
+		Provider: p.name,
+	}, nil
+}
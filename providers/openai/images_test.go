@@ -0,0 +1,52 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+func TestGenerateImage(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"created": 42,
+			"data": []any{
+				map[string]any{"url": "https://img/1.png", "revised_prompt": "a red cat"},
+				map[string]any{"b64_json": "aGk="},
+			},
+		})
+	})
+
+	resp, err := p.GenerateImage(context.Background(), &llmrouter.ImageRequest{
+		Prompt: "a cat",
+		N:      2,
+		Size:   "1024x1024",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := api.last()
+	if req.Path != "/images/generations" {
+		t.Errorf("path = %s", req.Path)
+	}
+	body := req.JSON()
+	if body["prompt"] != "a cat" || body["n"] != float64(2) || body["size"] != "1024x1024" || body["model"] != DefaultImageModel {
+		t.Errorf("request body = %v", body)
+	}
+
+	if resp.Created != 42 || resp.Model != DefaultImageModel || resp.Provider != "openai" {
+		t.Errorf("response = %+v", resp)
+	}
+	if len(resp.Images) != 2 {
+		t.Fatalf("got %d images, want 2", len(resp.Images))
+	}
+	if resp.Images[0].URL != "https://img/1.png" || resp.Images[0].RevisedPrompt != "a red cat" {
+		t.Errorf("image 0 = %+v", resp.Images[0])
+	}
+	if resp.Images[1].Base64 != "aGk=" {
+		t.Errorf("image 1 = %+v", resp.Images[1])
+	}
+}
@@ -2,6 +2,8 @@ package openai
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -13,9 +15,11 @@ import (
 
 // Presets contains default configurations for OpenAI-compatible providers
 var Presets = map[string]struct {
-	BaseURL      string
-	DefaultModel string
-	Models       []string
+	BaseURL         string
+	DefaultModel    string
+	Models          []string
+	LegacyMaxTokens bool // send max_tokens rather than max_completion_tokens
+	Grounded        bool // searches the web on every request
 }{
 	"openai": {
 		BaseURL:      "https://api.openai.com/v1/",
@@ -23,38 +27,69 @@ var Presets = map[string]struct {
 		Models:       []string{"gpt-4.1", "gpt-4.1-mini", "gpt-4.1-nano", "gpt-4o", "gpt-4o-mini", "o4-mini"},
 	},
 	"deepseek": {
-		BaseURL:      "https://api.deepseek.com/",
-		DefaultModel: "deepseek-chat",
-		Models:       []string{"deepseek-chat", "deepseek-coder"},
+		BaseURL:         "https://api.deepseek.com/",
+		DefaultModel:    "deepseek-chat",
+		Models:          []string{"deepseek-chat", "deepseek-coder"},
+		LegacyMaxTokens: true,
 	},
 	"groq": {
-		BaseURL:      "https://api.groq.com/openai/v1/",
-		DefaultModel: "llama-3.3-70b-versatile",
-		Models:       []string{"llama-3.3-70b-versatile", "llama-3.1-8b-instant", "mixtral-8x7b-32768"},
+		BaseURL:         "https://api.groq.com/openai/v1/",
+		DefaultModel:    "llama-3.3-70b-versatile",
+		Models:          []string{"llama-3.3-70b-versatile", "llama-3.1-8b-instant", "mixtral-8x7b-32768"},
+		LegacyMaxTokens: true,
 	},
 	"together": {
-		BaseURL:      "https://api.together.xyz/v1/",
-		DefaultModel: "meta-llama/Llama-3.3-70B-Instruct-Turbo",
-		Models:       []string{"meta-llama/Llama-3.3-70B-Instruct-Turbo", "mistralai/Mixtral-8x7B-Instruct-v0.1"},
+		BaseURL:         "https://api.together.xyz/v1/",
+		DefaultModel:    "meta-llama/Llama-3.3-70B-Instruct-Turbo",
+		Models:          []string{"meta-llama/Llama-3.3-70B-Instruct-Turbo", "mistralai/Mixtral-8x7B-Instruct-v0.1"},
+		LegacyMaxTokens: true,
+	},
+	"perplexity": {
+		BaseURL:         "https://api.perplexity.ai/",
+		DefaultModel:    "sonar",
+		Models:          []string{"sonar", "sonar-pro", "sonar-reasoning", "sonar-reasoning-pro"},
+		LegacyMaxTokens: true,
+		Grounded:        true,
 	},
 	"ollama": {
-		BaseURL:      "http://localhost:11434/v1/",
-		DefaultModel: "llama3.2",
-		Models:       []string{}, // Dynamic based on what's installed
+		BaseURL:         "http://localhost:11434/v1/",
+		DefaultModel:    "llama3.2",
+		Models:          []string{}, // Dynamic based on what's installed
+		LegacyMaxTokens: true,
 	},
 }
 
 // Provider handles OpenAI and OpenAI-compatible APIs
 type Provider struct {
-	client *openai.Client
-	name   string
-	model  string
-	models []string
+	client     *openai.Client
+	name       string
+	model      string
+	models     []string
+	rateLimits *llmrouter.RateLimitTracker
+	onClamp    llmrouter.ClampFunc
+	grounded   bool
+
+	legacyMaxTokens bool
+	reasoningModel  func(model string) bool
+	responsesAPI    bool
+	convertDocument func(llmrouter.Document) (string, error)
 }
 
-// New creates a new OpenAI-compatible provider
+// New creates a new OpenAI-compatible provider. If cfg.Name names a preset,
+// its defaults apply.
 func New(cfg llmrouter.ProviderConfig) *Provider {
-	preset, hasPreset := Presets[cfg.Name]
+	return NewWithPreset(cfg.Name, cfg)
+}
+
+// NewWithPreset creates a provider with the defaults of the named preset
+// (base URL, models, token parameter) under its own name from cfg, e.g. a
+// second Groq provider called "fast". An empty cfg.Name defaults to the
+// preset name; an unknown preset applies no defaults.
+func NewWithPreset(presetName string, cfg llmrouter.ProviderConfig) *Provider {
+	preset, hasPreset := Presets[presetName]
+	if cfg.Name == "" {
+		cfg.Name = presetName
+	}
 
 	baseURL := cfg.BaseURL
 	if baseURL == "" && hasPreset {
@@ -80,17 +115,36 @@ func New(cfg llmrouter.ProviderConfig) *Provider {
 	if cfg.Timeout > 0 {
 		opts = append(opts, option.WithRequestTimeout(cfg.Timeout))
 	}
+	if client := cfg.HTTPClient(); client != nil {
+		opts = append(opts, option.WithHTTPClient(client))
+	}
+
+	rateLimits := &llmrouter.RateLimitTracker{}
+	opts = append(opts, option.WithMiddleware(trackRateLimits(rateLimits)))
+	if cfg.UserAgent != "" {
+		opts = append(opts, option.WithMiddleware(appendUserAgent(cfg.UserAgent)))
+	}
 
 	models := cfg.Models
 	if len(models) == 0 && hasPreset {
 		models = preset.Models
 	}
 
+	// Compatible backends outside the presets mostly predate max_completion_tokens
+	legacyMaxTokens := preset.LegacyMaxTokens
+	if !hasPreset && baseURL != "" && !strings.Contains(baseURL, "api.openai.com") {
+		legacyMaxTokens = true
+	}
+
 	return &Provider{
-		client: openai.NewClient(opts...),
-		name:   cfg.Name,
-		model:  model,
-		models: models,
+		client:          openai.NewClient(opts...),
+		name:            cfg.Name,
+		model:           model,
+		models:          models,
+		rateLimits:      rateLimits,
+		grounded:        preset.Grounded,
+		legacyMaxTokens: legacyMaxTokens,
+		reasoningModel:  IsReasoningModel,
 	}
 }
 
@@ -134,6 +188,16 @@ func NewTogether(apiKey string) *Provider {
 	})
 }
 
+// NewPerplexity creates a Perplexity provider. Perplexity always searches the
+// web, so Request.Grounding needs nothing more; the source URLs it returns
+// are surfaced on Response.Citations.
+func NewPerplexity(apiKey string) *Provider {
+	return New(llmrouter.ProviderConfig{
+		Name:   "perplexity",
+		APIKey: apiKey,
+	})
+}
+
 // NewOllama creates an Ollama provider
 func NewOllama(baseURL string) *Provider {
 	if baseURL == "" {
@@ -146,10 +210,130 @@ func NewOllama(baseURL string) *Provider {
 	})
 }
 
+// Client returns the underlying OpenAI SDK client, an escape hatch for API
+// features the router doesn't model. Calls made through it bypass router
+// middleware.
+func (p *Provider) Client() *openai.Client {
+	return p.client
+}
+
+// WithReasoningModels overrides how reasoning models are detected. Reasoning
+// models get developer instead of system messages, and temperature and top_p
+// are omitted since they reject them. Defaults to IsReasoningModel.
+func (p *Provider) WithReasoningModels(detect func(model string) bool) *Provider {
+	if detect == nil {
+		detect = IsReasoningModel
+	}
+	p.reasoningModel = detect
+	return p
+}
+
+// WithLegacyMaxTokens chooses whether the token limit is sent as max_tokens
+// (true) or max_completion_tokens (false), overriding the preset default
+func (p *Provider) WithLegacyMaxTokens(legacy bool) *Provider {
+	p.legacyMaxTokens = legacy
+	return p
+}
+
+// WithDocumentConverter sets how document parts are sent, since the API has
+// no document input: each is replaced by a text part holding fn's result
+// (e.g. the extracted text). Without a converter, requests with documents
+// fail with ErrNotSupported.
+func (p *Provider) WithDocumentConverter(fn func(llmrouter.Document) (string, error)) *Provider {
+	p.convertDocument = fn
+	return p
+}
+
+// convertDocuments returns req with document parts converted to text, or
+// req itself if it has none
+func (p *Provider) convertDocuments(req *llmrouter.Request) (*llmrouter.Request, error) {
+	var msgs []llmrouter.Message
+	for i, msg := range req.Messages {
+		var parts []llmrouter.ContentPart
+		for j, part := range msg.ContentParts {
+			if part.Type != "document" || part.Document == nil {
+				continue
+			}
+			if p.convertDocument == nil {
+				return nil, fmt.Errorf("%w: document input (see WithDocumentConverter)", llmrouter.ErrNotSupported)
+			}
+			text, err := p.convertDocument(*part.Document)
+			if err != nil {
+				return nil, fmt.Errorf("converting document: %w", err)
+			}
+			if parts == nil {
+				parts = append([]llmrouter.ContentPart(nil), msg.ContentParts...)
+			}
+			parts[j] = llmrouter.ContentPart{Type: "text", Text: text}
+		}
+		if parts == nil {
+			continue
+		}
+
+		if msgs == nil {
+			msgs = append([]llmrouter.Message(nil), req.Messages...)
+		}
+		msgs[i].ContentParts = parts
+	}
+	if msgs == nil {
+		return req, nil
+	}
+
+	converted := *req
+	converted.Messages = msgs
+	return &converted, nil
+}
+
+// RateLimitStatus returns the rate limit budget from the latest response headers
+func (p *Provider) RateLimitStatus() (llmrouter.RateLimitStatus, bool) {
+	return p.rateLimits.Status()
+}
+
+// trackRateLimits records rate limit headers from every HTTP response
+func trackRateLimits(t *llmrouter.RateLimitTracker) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		resp, err := next(req)
+		if resp != nil {
+			t.Update(resp.Header)
+		}
+		return resp, err
+	}
+}
+
+// appendUserAgent adds ua to the User-Agent header set by the SDK
+func appendUserAgent(ua string) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		value := ua
+		if existing := req.Header.Get("User-Agent"); existing != "" {
+			value = existing + " " + ua
+		}
+		req.Header.Set("User-Agent", value)
+		return next(req)
+	}
+}
+
+// temperatureRange is the temperature range the API accepts
+var temperatureRange = llmrouter.TemperatureRange{Min: 0, Max: 2}
+
+// WithTemperatureClamp clamps out-of-range temperatures instead of rejecting
+// them with ErrInvalidRequest, calling warn (if non-nil) for each clamp
+func (p *Provider) WithTemperatureClamp(warn llmrouter.ClampFunc) *Provider {
+	if warn == nil {
+		warn = func(requested, clamped float64) {}
+	}
+	p.onClamp = warn
+	return p
+}
+
 func (p *Provider) Name() string {
 	return p.name
 }
 
+// DefaultModel returns the model used when a request names none
+func (p *Provider) DefaultModel() string {
+	return p.model
+}
+
 func (p *Provider) Models() []string {
 	return p.models
 }
@@ -159,37 +343,30 @@ func (p *Provider) SupportsTools() bool {
 }
 
 func (p *Provider) Complete(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
-	model := req.Model
-	if model == "" || model == p.name {
-		// Use default model if not specified or if model matches provider name
-		model = p.model
-	}
-
-	params := openai.ChatCompletionNewParams{
-		Model:    openai.F(model),
-		Messages: openai.F(convertMessages(req.Messages)),
-	}
-
-	if req.Temperature != nil {
-		params.Temperature = openai.F(*req.Temperature)
+	req, err := llmrouter.CheckTemperature(req, temperatureRange, p.onClamp)
+	if err != nil {
+		return nil, err
 	}
-	if req.MaxTokens != nil {
-		params.MaxCompletionTokens = openai.F(int64(*req.MaxTokens))
+	if err := llmrouter.CheckToolMessages(req.Messages); err != nil {
+		return nil, err
 	}
-	if req.TopP != nil {
-		params.TopP = openai.F(*req.TopP)
+	if req, err = p.convertDocuments(req); err != nil {
+		return nil, err
 	}
-	if len(req.Stop) > 0 {
-		params.Stop = openai.F[openai.ChatCompletionNewParamsStopUnion](openai.ChatCompletionNewParamsStopArray(req.Stop))
+	if !p.grounded {
+		req = llmrouter.ApplyGrounding(req)
 	}
-	if len(req.Tools) > 0 {
-		params.Tools = openai.F(convertTools(req.Tools))
+	if len(req.BuiltinTools) > 0 && !p.responsesAPI {
+		return nil, fmt.Errorf("%w: built-in tools with the chat completions API (see WithResponsesAPI)", llmrouter.ErrNotSupported)
 	}
-	if req.ToolChoice != nil {
-		params.ToolChoice = openai.F(convertToolChoice(req.ToolChoice))
+
+	if p.responsesAPI {
+		return p.completeResponses(ctx, req)
 	}
 
-	resp, err := p.client.Chat.Completions.New(ctx, params)
+	params, _ := p.buildParams(req)
+
+	resp, err := p.client.Chat.Completions.New(ctx, params, requestOptions(req)...)
 	if err != nil {
 		return nil, wrapError(p.name, err)
 	}
@@ -198,63 +375,80 @@ func (p *Provider) Complete(ctx context.Context, req *llmrouter.Request) (*llmro
 }
 
 func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
-	ch := make(chan llmrouter.Event)
-
-	model := req.Model
-	if model == "" || model == p.name {
-		// Use default model if not specified or if model matches provider name
-		model = p.model
-	}
-
-	params := openai.ChatCompletionNewParams{
-		Model:    openai.F(model),
-		Messages: openai.F(convertMessages(req.Messages)),
-	}
-
-	if req.Temperature != nil {
-		params.Temperature = openai.F(*req.Temperature)
+	req, err := llmrouter.CheckTemperature(req, temperatureRange, p.onClamp)
+	if err != nil {
+		return nil, err
 	}
-	if req.MaxTokens != nil {
-		params.MaxCompletionTokens = openai.F(int64(*req.MaxTokens))
+	if err := llmrouter.CheckToolMessages(req.Messages); err != nil {
+		return nil, err
 	}
-	if req.TopP != nil {
-		params.TopP = openai.F(*req.TopP)
+	if req, err = p.convertDocuments(req); err != nil {
+		return nil, err
 	}
-	if len(req.Stop) > 0 {
-		params.Stop = openai.F[openai.ChatCompletionNewParamsStopUnion](openai.ChatCompletionNewParamsStopArray(req.Stop))
+	if !p.grounded {
+		req = llmrouter.ApplyGrounding(req)
 	}
-	if len(req.Tools) > 0 {
-		params.Tools = openai.F(convertTools(req.Tools))
+	if len(req.BuiltinTools) > 0 && !p.responsesAPI {
+		return nil, fmt.Errorf("%w: built-in tools with the chat completions API (see WithResponsesAPI)", llmrouter.ErrNotSupported)
 	}
-	if req.ToolChoice != nil {
-		params.ToolChoice = openai.F(convertToolChoice(req.ToolChoice))
+
+	if p.responsesAPI {
+		return p.streamResponses(ctx, req)
 	}
 
+	ch := make(chan llmrouter.Event)
+
+	params, model := p.buildParams(req)
+	// Streams only report usage when asked to, in a final chunk without choices
+	params.StreamOptions = openai.F(openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.F(true)})
+
 	go func() {
 		defer close(ch)
 
-		stream := p.client.Chat.Completions.NewStreaming(ctx, params)
+		stream := p.client.Chat.Completions.NewStreaming(ctx, params, requestOptions(req)...)
 
 		var lastChunk *openai.ChatCompletionChunk
+		tracker := toolCallTracker{}
 		for stream.Next() {
 			chunk := stream.Current()
+			if len(chunk.Choices) == 0 && lastChunk != nil {
+				if chunk.Usage.TotalTokens > 0 {
+					lastChunk.Usage = chunk.Usage
+				}
+				continue
+			}
 			lastChunk = &chunk
 
-			if len(chunk.Choices) > 0 {
-				delta := chunk.Choices[0].Delta
+			for _, choice := range chunk.Choices {
+				delta := choice.Delta
+
+				if reasoning := extraString(delta.JSON.ExtraFields["reasoning_content"].Raw()); reasoning != "" {
+					ch <- llmrouter.Event{
+						Type:  llmrouter.EventReasoningDelta,
+						Index: int(choice.Index),
+						Delta: &llmrouter.Delta{
+							ReasoningContent: reasoning,
+						},
+					}
+				}
 
 				if delta.Content != "" {
 					ch <- llmrouter.Event{
 						Type:    llmrouter.EventContentDelta,
+						Index:   int(choice.Index),
 						Content: delta.Content,
+						Logprob: sumLogprobs(choice.Logprobs.Content),
 					}
 				}
 
 				if len(delta.ToolCalls) > 0 {
+					toolCalls := convertStreamToolCalls(delta.ToolCalls)
+					tracker.fill(int(choice.Index), toolCalls)
 					ch <- llmrouter.Event{
-						Type: llmrouter.EventToolCallDelta,
+						Type:  llmrouter.EventToolCallDelta,
+						Index: int(choice.Index),
 						Delta: &llmrouter.Delta{
-							ToolCalls: convertStreamToolCalls(delta.ToolCalls),
+							ToolCalls: toolCalls,
 						},
 					}
 				}
@@ -290,3 +484,75 @@ func (p *Provider) Stream(ctx context.Context, req *llmrouter.Request) (<-chan l
 
 	return ch, nil
 }
+
+// buildParams converts a request to chat completion params, returning the resolved model
+func (p *Provider) buildParams(req *llmrouter.Request) (openai.ChatCompletionNewParams, string) {
+	model := req.Model
+	if model == "" || model == p.name {
+		// Use default model if not specified or if model matches provider name
+		model = p.model
+	}
+
+	reasoning := p.reasoningModel(model)
+
+	params := openai.ChatCompletionNewParams{
+		Model:    openai.F(model),
+		Messages: openai.F(convertMessages(llmrouter.PrefillInstruction(req.Messages, req.Prefill), reasoning)),
+	}
+
+	// Reasoning models reject sampling parameters
+	if req.Temperature != nil && !reasoning {
+		params.Temperature = openai.F(*req.Temperature)
+	}
+	if req.MaxTokens != nil {
+		if p.legacyMaxTokens {
+			params.MaxTokens = openai.F(int64(*req.MaxTokens))
+		} else {
+			params.MaxCompletionTokens = openai.F(int64(*req.MaxTokens))
+		}
+	}
+	if req.TopP != nil && !reasoning {
+		params.TopP = openai.F(*req.TopP)
+	}
+	if req.N != nil {
+		params.N = openai.F(int64(*req.N))
+	}
+	if len(req.Stop) > 0 {
+		params.Stop = openai.F[openai.ChatCompletionNewParamsStopUnion](openai.ChatCompletionNewParamsStopArray(req.Stop))
+	}
+	if len(req.Tools) > 0 {
+		params.Tools = openai.F(convertTools(req.Tools))
+	}
+	if req.ToolChoice != nil {
+		params.ToolChoice = openai.F(convertToolChoice(req.ToolChoice))
+	}
+	if req.Logprobs {
+		params.Logprobs = openai.F(true)
+	}
+	if req.ServiceTier != "" {
+		params.ServiceTier = openai.F(openai.ChatCompletionNewParamsServiceTier(req.ServiceTier))
+	}
+	if user := req.MetadataString(llmrouter.MetadataUserID); user != "" {
+		params.User = openai.F(user)
+	}
+	if req.ResponseFormat != nil {
+		params.ResponseFormat = openai.F(convertResponseFormat(req.ResponseFormat))
+	}
+
+	return params, model
+}
+
+// requestOptions forwards trace and conversation IDs from request metadata as headers
+func requestOptions(req *llmrouter.Request) []option.RequestOption {
+	var opts []option.RequestOption
+	if id := req.MetadataString(llmrouter.MetadataTraceID); id != "" {
+		opts = append(opts, option.WithHeader("X-Trace-Id", id))
+	}
+	if id := req.MetadataString(llmrouter.MetadataConversationID); id != "" {
+		opts = append(opts, option.WithHeader("X-Conversation-Id", id))
+	}
+	if req.IdempotencyKey != "" {
+		opts = append(opts, option.WithHeader("Idempotency-Key", req.IdempotencyKey))
+	}
+	return opts
+}
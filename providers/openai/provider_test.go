@@ -0,0 +1,886 @@
+package openai
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/bluefunda/llm-router/middleware"
+	"github.com/openai/openai-go"
+)
+
+func TestServiceTier(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletion("hi")
+		resp["service_tier"] = "flex"
+		writeJSON(w, resp)
+	})
+
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+	req.ServiceTier = "flex"
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := api.last().JSON()["service_tier"]; got != "flex" {
+		t.Errorf("request service_tier = %v, want flex", got)
+	}
+	if resp.ServiceTier != "flex" {
+		t.Errorf("response ServiceTier = %q, want flex", resp.ServiceTier)
+	}
+}
+
+func TestServiceTierOmittedByDefault(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.last().JSON()["service_tier"]; ok {
+		t.Error("service_tier sent without being requested")
+	}
+}
+
+func TestCacheHintIgnored(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+	req.Messages[0].CacheHint = true
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	// OpenAI caches prefixes automatically, so the hint isn't sent
+	if strings.Contains(string(api.last().Body), "cache") {
+		t.Errorf("request mentions caching: %s", api.last().Body)
+	}
+}
+
+func TestRateLimitHeadersTracked(t *testing.T) {
+	p, _ := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ratelimit-remaining-requests", "99")
+		w.Header().Set("x-ratelimit-remaining-tokens", "4000")
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	if _, ok := p.RateLimitStatus(); ok {
+		t.Error("status reported before any response")
+	}
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	status, ok := p.RateLimitStatus()
+	if !ok || status.RemainingRequests != 99 || status.RemainingTokens != 4000 {
+		t.Errorf("status = %+v, %v", status, ok)
+	}
+}
+
+func TestStreamMultipleChoices(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			chunk(0, map[string]any{"role": "assistant", "content": "Hel"}, ""),
+			chunk(1, map[string]any{"role": "assistant", "content": "Bon"}, ""),
+			chunk(1, map[string]any{"content": "jour"}, ""),
+			chunk(0, map[string]any{"content": "lo"}, ""),
+			chunk(0, map[string]any{}, "stop"),
+			chunk(1, map[string]any{}, "stop"),
+		)
+	})
+
+	req := userRequest("greet me")
+	req.Model = "gpt-4o"
+	req.N = intPtr(2)
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := llmrouter.NewStream(ch, nil)
+	for {
+		if _, ok := s.Next(); !ok {
+			break
+		}
+	}
+	if s.Err() != nil {
+		t.Fatal(s.Err())
+	}
+	if s.ChoiceContent(0) != "Hello" || s.ChoiceContent(1) != "Bonjour" {
+		t.Errorf("choices = %q, %q; want Hello, Bonjour", s.ChoiceContent(0), s.ChoiceContent(1))
+	}
+	if got := api.last().JSON()["n"]; got != float64(2) {
+		t.Errorf("n = %v, want 2", got)
+	}
+}
+
+func TestPerplexityCitations(t *testing.T) {
+	citations := []string{"https://example.com/a", "https://example.com/b"}
+	p, _ := newTestProvider(t, "perplexity", func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletion("Answer [1][2]")
+		resp["citations"] = citations
+		writeJSON(w, resp)
+	})
+
+	resp, err := p.Complete(context.Background(), userRequest("news?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Citations) != 2 || resp.Citations[0].URL != citations[0] || resp.Citations[1].URL != citations[1] {
+		t.Errorf("citations = %+v", resp.Citations)
+	}
+}
+
+func TestPerplexityStreamCitations(t *testing.T) {
+	p, _ := newTestProvider(t, "perplexity", func(w http.ResponseWriter, r *http.Request) {
+		first := chunk(0, map[string]any{"role": "assistant", "content": "Answer [1]"}, "")
+		last := chunk(0, map[string]any{}, "stop")
+		last["citations"] = []string{"https://example.com/a"}
+		writeSSE(w, first, last)
+	})
+
+	ch, err := p.Stream(context.Background(), userRequest("news?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	done := events[len(events)-1]
+	if done.Type != llmrouter.EventDone || len(done.Response.Citations) != 1 || done.Response.Citations[0].URL != "https://example.com/a" {
+		t.Errorf("final event = %+v", done)
+	}
+}
+
+func TestGroundingNotSupportedByChat(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	req := userRequest("news?")
+	req.Model = "gpt-4o"
+	req.Grounding = true
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("error = %v, want ErrNotSupported", err)
+	}
+	if _, err := p.Stream(context.Background(), req); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("stream error = %v, want ErrNotSupported", err)
+	}
+	if api.count() != 0 {
+		t.Error("grounded request reached the API")
+	}
+}
+
+func TestGroundingPassesForPerplexity(t *testing.T) {
+	p, api := newTestProvider(t, "perplexity", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	req := userRequest("news?")
+	req.Grounding = true
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.last().JSON()["tools"]; ok {
+		t.Error("tools sent for a provider that always searches")
+	}
+}
+
+func TestTemperatureRange(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+	temp := 1.5
+	req.Temperature = &temp
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatalf("temperature 1.5 rejected: %v", err)
+	}
+	if got := api.last().JSON()["temperature"]; got != 1.5 {
+		t.Errorf("temperature = %v, want 1.5", got)
+	}
+
+	temp = 2.5
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrInvalidRequest) {
+		t.Errorf("error = %v, want ErrInvalidRequest for temperature 2.5", err)
+	}
+}
+
+func TestStreamLogprobs(t *testing.T) {
+	// withLogprobs attaches per-token logprobs to a chunk's choice
+	withLogprobs := func(c map[string]any, logprobs ...float64) map[string]any {
+		tokens := make([]any, len(logprobs))
+		for i, lp := range logprobs {
+			tokens[i] = map[string]any{"token": "t", "logprob": lp, "bytes": []int{116}, "top_logprobs": []any{}}
+		}
+		c["choices"].([]any)[0].(map[string]any)["logprobs"] = map[string]any{"content": tokens}
+		return c
+	}
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			withLogprobs(chunk(0, map[string]any{"content": "Hello"}, ""), -0.25),
+			withLogprobs(chunk(0, map[string]any{"content": " there"}, ""), -0.5, -1),
+			chunk(0, map[string]any{"content": "!"}, ""),
+			chunk(0, map[string]any{}, "stop"),
+		)
+	})
+
+	req := userRequest("hi")
+	req.Logprobs = true
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+
+	if got := api.last().JSON()["logprobs"]; got != true {
+		t.Errorf("logprobs = %v, want true", got)
+	}
+	var deltas []llmrouter.Event
+	for _, e := range events {
+		if e.Type == llmrouter.EventContentDelta {
+			deltas = append(deltas, e)
+		}
+	}
+	if len(deltas) != 3 {
+		t.Fatalf("deltas = %d, want 3", len(deltas))
+	}
+	if lp := deltas[0].Logprob; lp == nil || *lp != -0.25 {
+		t.Errorf("delta 0 logprob = %v, want -0.25", lp)
+	}
+	if lp := deltas[1].Logprob; lp == nil || *lp != -1.5 {
+		t.Errorf("delta 1 logprob = %v, want the token sum -1.5", lp)
+	}
+	if lp := deltas[2].Logprob; lp != nil {
+		t.Errorf("delta 2 logprob = %v, want nil without logprobs", *lp)
+	}
+}
+
+func TestLogprobsNotRequested(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+	if _, err := p.Complete(context.Background(), userRequest("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.last().JSON()["logprobs"]; ok {
+		t.Error("logprobs sent without being requested")
+	}
+}
+
+func TestStreamToolCallFragmentsKeepID(t *testing.T) {
+	toolDelta := func(id, name, args string) map[string]any {
+		fn := map[string]any{"arguments": args}
+		if name != "" {
+			fn["name"] = name
+		}
+		call := map[string]any{"index": 0, "function": fn}
+		if id != "" {
+			call["id"] = id
+			call["type"] = "function"
+		}
+		return map[string]any{"tool_calls": []any{call}}
+	}
+	p, _ := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			chunk(0, toolDelta("call_1", "weather", ""), ""),
+			chunk(0, toolDelta("", "", `{"city":`), ""),
+			chunk(0, toolDelta("", "", `"Oslo"}`), ""),
+			chunk(0, map[string]any{}, "tool_calls"),
+		)
+	})
+
+	req := userRequest("weather?")
+	req.Model = "gpt-4o"
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var args string
+	for _, event := range collect(ch) {
+		if event.Type != llmrouter.EventToolCallDelta {
+			continue
+		}
+		tc := event.Delta.ToolCalls[0]
+		if tc.ID != "call_1" || tc.Function.Name != "weather" {
+			t.Errorf("fragment %+v lost its call's ID or name", tc)
+		}
+		args += tc.Function.Arguments
+	}
+	if args != `{"city":"Oslo"}` {
+		t.Errorf("arguments = %q", args)
+	}
+}
+
+func TestUserAgentAppended(t *testing.T) {
+	p, api := newTestProviderConfig(t, llmrouter.ProviderConfig{Name: "openai", UserAgent: "my-app/1.2"}, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	ua := api.last().Header.Get("User-Agent")
+	if !strings.HasSuffix(ua, " my-app/1.2") || strings.HasPrefix(ua, "my-app") {
+		t.Errorf("User-Agent = %q, want the SDK's followed by my-app/1.2", ua)
+	}
+}
+
+func TestFinishDetailsKeepRawReason(t *testing.T) {
+	p, _ := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletion("hi")
+		resp["choices"].([]any)[0].(map[string]any)["finish_reason"] = "content_filter"
+		writeJSON(w, resp)
+	})
+
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := llmrouter.FinishDetails{Reason: "content_filter", RawReason: "content_filter", ContentFiltered: true}
+	if d := resp.Choices[0].FinishDetails; d == nil || *d != want {
+		t.Errorf("finish details = %+v, want %+v", d, want)
+	}
+}
+
+func TestUnknownFinishReasonPassedThrough(t *testing.T) {
+	p, _ := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletion("hi")
+		resp["choices"].([]any)[0].(map[string]any)["finish_reason"] = "function_call"
+		writeJSON(w, resp)
+	})
+
+	resp, err := p.Complete(context.Background(), userRequest("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := resp.Choices[0]; c.FinishReason != "function_call" || c.FinishDetails.RawReason != "function_call" {
+		t.Errorf("finish = %q, %+v; want function_call passed through", c.FinishReason, c.FinishDetails)
+	}
+}
+
+func TestStreamFinishDetailsKeepRawReason(t *testing.T) {
+	p, _ := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			chunk(0, map[string]any{"role": "assistant", "content": "hi"}, ""),
+			chunk(0, map[string]any{}, "length"),
+		)
+	})
+
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	done := events[len(events)-1]
+	if done.Type != llmrouter.EventDone || done.Response == nil {
+		t.Fatalf("last event = %+v, want done with a response", done)
+	}
+	if d := done.Response.Choices[0].FinishDetails; d == nil || d.Reason != "length" || d.RawReason != "length" {
+		t.Errorf("finish details = %+v, want length", d)
+	}
+}
+
+func TestStreamRequestsUsage(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			chunk(0, map[string]any{"role": "assistant", "content": "hi"}, ""),
+			chunk(0, map[string]any{}, "stop"),
+			map[string]any{
+				"id":      "chatcmpl-1",
+				"object":  "chat.completion.chunk",
+				"created": 1,
+				"model":   "gpt-test",
+				"choices": []any{},
+				"usage":   map[string]any{"prompt_tokens": 5, "completion_tokens": 1, "total_tokens": 6},
+			},
+		)
+	})
+
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+
+	opts, _ := api.last().JSON()["stream_options"].(map[string]any)
+	if opts["include_usage"] != true {
+		t.Errorf("stream_options = %v, want include_usage", api.last().JSON()["stream_options"])
+	}
+	done := events[len(events)-1].Response
+	if done == nil || done.Usage == nil || done.Usage.TotalTokens != 6 {
+		t.Fatalf("done response = %+v, want the usage chunk's 6 tokens", done)
+	}
+	if len(done.Choices) != 1 || done.Choices[0].FinishReason != "stop" {
+		t.Errorf("choices = %+v, want the last chunk with choices kept", done.Choices)
+	}
+}
+
+func TestMetadataForwarded(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+	req.Metadata = map[string]any{
+		llmrouter.MetadataUserID:         "user-1",
+		llmrouter.MetadataTraceID:        "trace-42",
+		llmrouter.MetadataConversationID: "conv-1",
+		"internal":                       "local only",
+	}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := api.last()
+	if user := got.JSON()["user"]; user != "user-1" {
+		t.Errorf("user = %v, want user-1", user)
+	}
+	if id := got.Header.Get("X-Trace-Id"); id != "trace-42" {
+		t.Errorf("X-Trace-Id = %q, want trace-42", id)
+	}
+	if id := got.Header.Get("X-Conversation-Id"); id != "conv-1" {
+		t.Errorf("X-Conversation-Id = %q, want conv-1", id)
+	}
+	if _, ok := got.JSON()["metadata"]; ok {
+		t.Error("unrecognized metadata sent to the API")
+	}
+}
+
+func TestIdempotencyKeyHeader(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	req := userRequest("hello")
+	req.IdempotencyKey = "key-1"
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.last().Header.Get("Idempotency-Key"); got != "key-1" {
+		t.Errorf("Idempotency-Key = %q, want key-1", got)
+	}
+
+	if _, err := p.Complete(context.Background(), userRequest("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.last().Header.Get("Idempotency-Key"); got != "" {
+		t.Errorf("Idempotency-Key = %q, want none without a key", got)
+	}
+}
+
+func TestIdempotencyKeyStableAcrossRetries(t *testing.T) {
+	var attempts int
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		if attempts++; attempts < 3 {
+			// A 400 isn't retried by the SDK itself, so each attempt comes
+			// from the retry middleware
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]any{"error": map[string]any{"message": "try again"}})
+			return
+		}
+		writeJSON(w, chatCompletion("hi"))
+	})
+	retrying := middleware.NewRetryMiddleware(3, time.Millisecond).
+		WithIdempotencyKeys().
+		WithRetryFunc(func(error) bool { return true }).
+		Wrap(p)
+
+	if _, err := retrying.Complete(context.Background(), userRequest("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.requests) != 3 {
+		t.Fatalf("requests = %d, want 3", len(api.requests))
+	}
+	key := api.requests[0].Header.Get("Idempotency-Key")
+	if key == "" {
+		t.Fatal("no Idempotency-Key sent")
+	}
+	for i, r := range api.requests[1:] {
+		if got := r.Header.Get("Idempotency-Key"); got != key {
+			t.Errorf("attempt %d Idempotency-Key = %q, want %q", i+2, got, key)
+		}
+	}
+}
+
+func TestOrphanedToolMessageRejected(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	req := &llmrouter.Request{Messages: []llmrouter.Message{
+		{Role: llmrouter.RoleUser, Content: "weather?"},
+		{Role: llmrouter.RoleTool, ToolCallID: "call_1", Content: "sunny"},
+	}}
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrInvalidRequest) {
+		t.Errorf("Complete err = %v, want ErrInvalidRequest", err)
+	}
+	if _, err := p.Stream(context.Background(), req); !errors.Is(err, llmrouter.ErrInvalidRequest) {
+		t.Errorf("Stream err = %v, want ErrInvalidRequest", err)
+	}
+	if api.count() != 0 {
+		t.Errorf("%d requests sent, want none", api.count())
+	}
+}
+
+func TestCustomRootCAs(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	}))
+	// The untrusted client's failed handshakes are expected
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	req := userRequest("hello")
+	req.Model = "gpt-4o"
+
+	trusted := New(llmrouter.ProviderConfig{APIKey: "test", BaseURL: srv.URL + "/", RootCAs: pool})
+	if _, err := trusted.Complete(context.Background(), req); err != nil {
+		t.Errorf("with the server's CA: %v", err)
+	}
+
+	untrusted := New(llmrouter.ProviderConfig{APIKey: "test", BaseURL: srv.URL + "/"})
+	if _, err := untrusted.Complete(context.Background(), req); err == nil {
+		t.Error("request to a server with an unknown CA succeeded")
+	}
+}
+
+func TestMaxTokensField(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    llmrouter.ProviderConfig
+		legacy *bool
+		field  string
+	}{
+		{"openai", llmrouter.ProviderConfig{Name: "openai"}, nil, "max_completion_tokens"},
+		{"groq preset", llmrouter.ProviderConfig{Name: "groq"}, nil, "max_tokens"},
+		{"ollama preset", llmrouter.ProviderConfig{Name: "ollama"}, nil, "max_tokens"},
+		{"custom backend", llmrouter.ProviderConfig{Name: "vllm"}, nil, "max_tokens"},
+		{"custom backend overridden", llmrouter.ProviderConfig{Name: "vllm"}, boolPtr(false), "max_completion_tokens"},
+		{"openai overridden", llmrouter.ProviderConfig{Name: "openai"}, boolPtr(true), "max_tokens"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, api := newTestProviderConfig(t, tt.cfg, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, chatCompletion("hi"))
+			})
+			if tt.legacy != nil {
+				p.WithLegacyMaxTokens(*tt.legacy)
+			}
+
+			req := userRequest("hello")
+			req.Model = "some-model"
+			req.MaxTokens = intPtr(128)
+			if _, err := p.Complete(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+
+			body := api.last().JSON()
+			other := "max_tokens"
+			if tt.field == "max_tokens" {
+				other = "max_completion_tokens"
+			}
+			if body[tt.field] != float64(128) {
+				t.Errorf("%s = %v, want 128", tt.field, body[tt.field])
+			}
+			if _, ok := body[other]; ok {
+				t.Errorf("%s also sent", other)
+			}
+		})
+	}
+}
+
+func TestDeepSeekReasoningContent(t *testing.T) {
+	p, _ := newTestProvider(t, "deepseek", func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletion("The answer is 4.")
+		msg := resp["choices"].([]any)[0].(map[string]any)["message"].(map[string]any)
+		msg["reasoning_content"] = "2 plus 2 is 4."
+		writeJSON(w, resp)
+	})
+
+	req := userRequest("what is 2+2?")
+	req.Model = "deepseek-reasoner"
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := resp.Choices[0].Message
+	if msg.Content != "The answer is 4." || msg.ReasoningContent != "2 plus 2 is 4." {
+		t.Errorf("message = %q with reasoning %q, want them kept apart", msg.Content, msg.ReasoningContent)
+	}
+}
+
+func TestDeepSeekReasoningContentNull(t *testing.T) {
+	p, _ := newTestProvider(t, "deepseek", func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletion("hi")
+		resp["choices"].([]any)[0].(map[string]any)["message"].(map[string]any)["reasoning_content"] = nil
+		writeJSON(w, resp)
+	})
+
+	req := userRequest("hello")
+	req.Model = "deepseek-chat"
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Choices[0].Message.ReasoningContent; got != "" {
+		t.Errorf("reasoning = %q, want none for null", got)
+	}
+}
+
+func TestDeepSeekStreamReasoningContent(t *testing.T) {
+	p, _ := newTestProvider(t, "deepseek", func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			chunk(0, map[string]any{"role": "assistant", "content": nil, "reasoning_content": "2 plus 2"}, ""),
+			chunk(0, map[string]any{"content": nil, "reasoning_content": " is 4."}, ""),
+			chunk(0, map[string]any{"content": "4", "reasoning_content": nil}, ""),
+			chunk(0, map[string]any{}, "stop"),
+		)
+	})
+
+	req := userRequest("what is 2+2?")
+	req.Model = "deepseek-reasoner"
+	ch, err := p.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reasoning, content string
+	for _, event := range collect(ch) {
+		switch event.Type {
+		case llmrouter.EventReasoningDelta:
+			reasoning += event.Delta.ReasoningContent
+		case llmrouter.EventContentDelta:
+			content += event.Content
+		case llmrouter.EventError:
+			t.Fatal(event.Error)
+		}
+	}
+	if reasoning != "2 plus 2 is 4." || content != "4" {
+		t.Errorf("reasoning %q, content %q; want them streamed separately", reasoning, content)
+	}
+}
+
+func TestClient(t *testing.T) {
+	if New(llmrouter.ProviderConfig{Name: "openai", APIKey: "test"}).Client() == nil {
+		t.Fatal("Client() = nil")
+	}
+
+	// The client shares the provider's configuration
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+	_, err := p.Client().Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+		Model:    openai.F("gpt-4o"),
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{openai.UserMessage("hello")}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.count() != 1 || api.last().Path != "/chat/completions" {
+		t.Errorf("direct SDK call didn't reach the provider's endpoint")
+	}
+}
+
+func TestDeveloperRoleForReasoningModels(t *testing.T) {
+	tests := []struct {
+		model  string
+		detect func(string) bool
+		role   string
+	}{
+		{"o3-mini", nil, "developer"},
+		{"o1", nil, "developer"},
+		{"gpt-4o", nil, "system"},
+		{"future-reasoner", func(model string) bool { return model == "future-reasoner" }, "developer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, chatCompletion("hi"))
+			})
+			p.WithReasoningModels(tt.detect)
+
+			req := userRequest("hello")
+			req.Model = tt.model
+			req.Messages = append([]llmrouter.Message{{Role: llmrouter.RoleSystem, Content: "be brief"}}, req.Messages...)
+			if _, err := p.Complete(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+
+			messages := api.last().JSON()["messages"].([]any)
+			if role := messages[0].(map[string]any)["role"]; role != tt.role {
+				t.Errorf("first message role = %v, want %s", role, tt.role)
+			}
+		})
+	}
+}
+
+func TestSamplingDroppedForReasoningModels(t *testing.T) {
+	tests := []struct {
+		model     string
+		detect    func(string) bool
+		responses bool
+		kept      bool
+	}{
+		{"gpt-4o", nil, false, true},
+		{"o3-mini", nil, false, false},
+		{"o1", nil, true, false},
+		{"gpt-4o", nil, true, true},
+		{"gpt-4o", func(string) bool { return true }, false, false},
+		{"o3-mini", func(string) bool { return false }, false, true},
+	}
+	for _, tt := range tests {
+		name := tt.model
+		if tt.responses {
+			name += " responses"
+		}
+		if tt.detect != nil {
+			name += " overridden"
+		}
+		t.Run(name, func(t *testing.T) {
+			p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/responses") {
+					writeJSON(w, map[string]any{"id": "resp_1", "model": tt.model, "status": "completed", "output": []any{}})
+					return
+				}
+				writeJSON(w, chatCompletion("hi"))
+			})
+			p.WithReasoningModels(tt.detect)
+			if tt.responses {
+				p.WithResponsesAPI()
+			}
+
+			req := userRequest("hello")
+			req.Model = tt.model
+			req.Temperature = floatPtr(0.5)
+			req.TopP = floatPtr(0.9)
+			if _, err := p.Complete(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+
+			body := api.last().JSON()
+			_, hasTemperature := body["temperature"]
+			_, hasTopP := body["top_p"]
+			if hasTemperature != tt.kept || hasTopP != tt.kept {
+				t.Errorf("temperature sent %v, top_p sent %v; want both %v", hasTemperature, hasTopP, tt.kept)
+			}
+		})
+	}
+}
+
+func TestForcedToolChoice(t *testing.T) {
+	tests := []struct {
+		name     string
+		function string
+		want     any
+	}{
+		{"any tool", "", "required"},
+		{"named function", "weather", map[string]any{"type": "function", "function": map[string]any{"name": "weather"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, chatCompletion("hi"))
+			})
+			forced := middleware.NewForceToolMiddleware().WithFunction(tt.function).Wrap(p)
+
+			req := userRequest("weather in Paris?")
+			req.Tools = []llmrouter.Tool{{Type: "function", Function: llmrouter.Function{Name: "weather"}}}
+			if _, err := forced.Complete(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+			if got := api.last().JSON()["tool_choice"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tool_choice = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// documentRequest asks about a PDF passed as a document part
+func documentRequest() *llmrouter.Request {
+	return &llmrouter.Request{Messages: []llmrouter.Message{{Role: llmrouter.RoleUser, ContentParts: []llmrouter.ContentPart{
+		{Type: "text", Text: "Summarize this."},
+		{Type: "document", Document: &llmrouter.Document{Base64: "JVBERi0=", MediaType: "application/pdf"}},
+	}}}}
+}
+
+func TestDocumentNotSupported(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	if _, err := p.Complete(context.Background(), documentRequest()); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("Complete err = %v, want ErrNotSupported", err)
+	}
+	if _, err := p.Stream(context.Background(), documentRequest()); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("Stream err = %v, want ErrNotSupported", err)
+	}
+	if api.count() != 0 {
+		t.Errorf("%d requests sent, want none", api.count())
+	}
+}
+
+func TestDocumentConverter(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+	var converted llmrouter.Document
+	p.WithDocumentConverter(func(doc llmrouter.Document) (string, error) {
+		converted = doc
+		return "Quarterly revenue rose.", nil
+	})
+
+	req := documentRequest()
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if converted.Base64 != "JVBERi0=" || converted.MediaType != "application/pdf" {
+		t.Errorf("converter got %+v, want the document", converted)
+	}
+	messages, _ := api.last().JSON()["messages"].([]any)
+	content, _ := messages[0].(map[string]any)["content"].([]any)
+	if len(content) != 2 || content[1].(map[string]any)["text"] != "Quarterly revenue rose." {
+		t.Errorf("content = %v, want the document sent as its text", content)
+	}
+	if req.Messages[0].ContentParts[1].Type != "document" {
+		t.Error("caller's request was modified")
+	}
+
+	p.WithDocumentConverter(func(llmrouter.Document) (string, error) { return "", errors.New("unreadable") })
+	if _, err := p.Complete(context.Background(), documentRequest()); err == nil || !strings.Contains(err.Error(), "unreadable") {
+		t.Errorf("err = %v, want the converter's error", err)
+	}
+}
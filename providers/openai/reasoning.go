@@ -0,0 +1,12 @@
+package openai
+
+import "strings"
+
+// IsReasoningModel reports whether model is an o-series reasoning model (o1,
+// o3-mini, o4-mini, ...). Gateway prefixes such as "openai/" are ignored.
+func IsReasoningModel(model string) bool {
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	return len(model) >= 2 && model[0] == 'o' && model[1] >= '1' && model[1] <= '9'
+}
@@ -0,0 +1,27 @@
+package openai
+
+import "testing"
+
+func TestIsReasoningModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{"o1", true},
+		{"o1-preview", true},
+		{"o3-mini", true},
+		{"o4-mini", true},
+		{"openai/o3", true},
+		{"gpt-4o", false},
+		{"gpt-4.1-mini", false},
+		{"o", false},
+		{"omni-moderation-latest", false},
+		{"ollama-model", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsReasoningModel(tt.model); got != tt.want {
+			t.Errorf("IsReasoningModel(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
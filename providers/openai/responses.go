@@ -0,0 +1,492 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	llmrouter "github.com/bluefunda/llm-router"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/packages/ssestream"
+)
+
+// WithResponsesAPI sends requests to the /responses endpoint instead of
+// /chat/completions. The SDK version in use doesn't model that endpoint, so
+// requests and responses are mapped here. Stop sequences and N > 1 aren't
+// supported by the endpoint and are rejected with ErrNotSupported.
+func (p *Provider) WithResponsesAPI() *Provider {
+	p.responsesAPI = true
+	return p
+}
+
+// responsesRequest is the /responses request body
+type responsesRequest struct {
+	Model           string           `json:"model"`
+	Input           []responsesInput `json:"input"`
+	Tools           []responsesTool  `json:"tools,omitempty"`
+	ToolChoice      any              `json:"tool_choice,omitempty"`
+	Temperature     *float64         `json:"temperature,omitempty"`
+	TopP            *float64         `json:"top_p,omitempty"`
+	MaxOutputTokens *int             `json:"max_output_tokens,omitempty"`
+	ServiceTier     string           `json:"service_tier,omitempty"`
+	User            string           `json:"user,omitempty"`
+	Text            *responsesText   `json:"text,omitempty"`
+	Stream          bool             `json:"stream,omitempty"`
+}
+
+// responsesInput is a message or a function call item in the input list
+type responsesInput struct {
+	Type      string `json:"type,omitempty"`    // "message" (default), "function_call" or "function_call_output"
+	Role      string `json:"role,omitempty"`    // messages only
+	Content   any    `json:"content,omitempty"` // string or []responsesContent
+	CallID    string `json:"call_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
+type responsesContent struct {
+	Type     string `json:"type"` // "input_text" or "input_image"
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+type responsesTool struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Container   any             `json:"container,omitempty"` // code_interpreter only
+}
+
+// builtinResponsesTools maps Request.BuiltinTools names to hosted tools
+var builtinResponsesTools = map[string]responsesTool{
+	llmrouter.BuiltinWebSearch:       {Type: "web_search"},
+	llmrouter.BuiltinCodeInterpreter: {Type: "code_interpreter", Container: map[string]string{"type": "auto"}},
+}
+
+type responsesText struct {
+	Format responsesFormat `json:"format"`
+}
+
+type responsesFormat struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+	Strict      bool            `json:"strict,omitempty"`
+}
+
+// responsesResponse is the /responses response body
+type responsesResponse struct {
+	ID                string                `json:"id"`
+	Object            string                `json:"object"`
+	CreatedAt         int64                 `json:"created_at"`
+	Model             string                `json:"model"`
+	Status            string                `json:"status"`
+	Output            []responsesOutputItem `json:"output"`
+	ServiceTier       string                `json:"service_tier"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+type responsesOutputItem struct {
+	Type      string `json:"type"` // "message", "function_call", "reasoning", ...
+	ID        string `json:"id"`
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Code      string `json:"code"` // code_interpreter_call
+	Outputs   []struct {
+		Type string `json:"type"` // "logs" or "image"
+		Logs string `json:"logs"`
+	} `json:"outputs"`
+	Action *struct {
+		Query string `json:"query"`
+	} `json:"action"` // web_search_call
+	Content []struct {
+		Type        string `json:"type"` // "output_text" or "refusal"
+		Text        string `json:"text"`
+		Refusal     string `json:"refusal"`
+		Annotations []struct {
+			Type  string `json:"type"`
+			URL   string `json:"url"`
+			Title string `json:"title"`
+		} `json:"annotations"`
+	} `json:"content"`
+}
+
+// responsesStreamEvent covers the stream events this provider consumes
+type responsesStreamEvent struct {
+	Type        string              `json:"type"`
+	OutputIndex int                 `json:"output_index"`
+	Delta       string              `json:"delta"`
+	Item        responsesOutputItem `json:"item"`
+	Response    *responsesResponse  `json:"response"`
+	Message     string              `json:"message"`
+}
+
+// buildResponsesRequest maps a request to a /responses body, returning the resolved model
+func (p *Provider) buildResponsesRequest(req *llmrouter.Request) (*responsesRequest, string, error) {
+	if len(req.Stop) > 0 {
+		return nil, "", fmt.Errorf("%w: stop sequences with the responses API", llmrouter.ErrNotSupported)
+	}
+	if req.N != nil && *req.N > 1 {
+		return nil, "", fmt.Errorf("%w: multiple choices with the responses API", llmrouter.ErrNotSupported)
+	}
+
+	model := req.Model
+	if model == "" || model == p.name {
+		model = p.model
+	}
+	reasoning := p.reasoningModel(model)
+
+	body := &responsesRequest{
+		Model:           model,
+		Input:           convertResponsesInput(llmrouter.PrefillInstruction(req.Messages, req.Prefill), reasoning),
+		MaxOutputTokens: req.MaxTokens,
+		ServiceTier:     req.ServiceTier,
+		User:            req.MetadataString(llmrouter.MetadataUserID),
+	}
+	if !reasoning {
+		body.Temperature = req.Temperature
+		body.TopP = req.TopP
+	}
+	for _, name := range req.BuiltinTools {
+		tool, ok := builtinResponsesTools[name]
+		if !ok {
+			return nil, "", fmt.Errorf("%w: built-in tool %q", llmrouter.ErrNotSupported, name)
+		}
+		body.Tools = append(body.Tools, tool)
+	}
+	for _, t := range req.Tools {
+		body.Tools = append(body.Tools, responsesTool{
+			Type:        "function",
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  t.Function.Parameters,
+		})
+	}
+	if tc := req.ToolChoice; tc != nil {
+		if tc.Type == "function" && tc.Function != nil {
+			body.ToolChoice = map[string]string{"type": "function", "name": tc.Function.Name}
+		} else {
+			body.ToolChoice = tc.Type
+		}
+	}
+	if rf := req.ResponseFormat; rf != nil {
+		format := responsesFormat{Type: rf.Type}
+		if rf.JSONSchema != nil {
+			format.Name = rf.JSONSchema.Name
+			format.Description = rf.JSONSchema.Description
+			format.Schema = rf.JSONSchema.Schema
+			format.Strict = rf.JSONSchema.Strict
+		}
+		body.Text = &responsesText{Format: format}
+	}
+	return body, model, nil
+}
+
+// convertResponsesInput converts messages to /responses input items
+func convertResponsesInput(msgs []llmrouter.Message, developerRole bool) []responsesInput {
+	result := make([]responsesInput, 0, len(msgs))
+
+	for _, msg := range msgs {
+		switch msg.Role {
+		case llmrouter.RoleSystem:
+			role := "system"
+			if developerRole {
+				role = "developer"
+			}
+			result = append(result, responsesInput{Role: role, Content: msg.Text()})
+
+		case llmrouter.RoleUser:
+			if len(msg.ContentParts) == 0 {
+				result = append(result, responsesInput{Role: "user", Content: msg.Content})
+				continue
+			}
+			var parts []responsesContent
+			for _, p := range msg.ContentParts {
+				switch p.Type {
+				case "text":
+					parts = append(parts, responsesContent{Type: "input_text", Text: p.Text})
+				case "image_url":
+					if p.ImageURL != nil {
+						parts = append(parts, responsesContent{Type: "input_image", ImageURL: imageURL(p.ImageURL), Detail: p.ImageURL.Detail})
+					}
+				}
+			}
+			result = append(result, responsesInput{Role: "user", Content: parts})
+
+		case llmrouter.RoleAssistant:
+			if msg.Content != "" {
+				result = append(result, responsesInput{Role: "assistant", Content: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				result = append(result, responsesInput{
+					Type:      "function_call",
+					CallID:    tc.ID,
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				})
+			}
+
+		case llmrouter.RoleTool:
+			result = append(result, responsesInput{
+				Type:   "function_call_output",
+				CallID: msg.ToolCallID,
+				Output: msg.Text(),
+			})
+		}
+	}
+
+	return result
+}
+
+// convertResponsesResponse converts a /responses response to our format
+func convertResponsesResponse(resp *responsesResponse, provider string) *llmrouter.Response {
+	msg := &llmrouter.Message{Role: llmrouter.RoleAssistant}
+	var citations []llmrouter.Citation
+	var builtinCalls []llmrouter.BuiltinToolCall
+
+	for _, item := range resp.Output {
+		switch item.Type {
+		case "message":
+			for _, c := range item.Content {
+				switch c.Type {
+				case "output_text":
+					msg.Content += c.Text
+					for _, a := range c.Annotations {
+						if a.Type == "url_citation" {
+							citations = append(citations, llmrouter.Citation{URL: a.URL, Title: a.Title})
+						}
+					}
+				case "refusal":
+					msg.Content += c.Refusal
+				}
+			}
+		case "web_search_call":
+			call := llmrouter.BuiltinToolCall{Type: llmrouter.BuiltinWebSearch, ID: item.ID}
+			if item.Action != nil {
+				call.Input = item.Action.Query
+			}
+			builtinCalls = append(builtinCalls, call)
+		case "code_interpreter_call":
+			call := llmrouter.BuiltinToolCall{Type: llmrouter.BuiltinCodeInterpreter, ID: item.ID, Input: item.Code}
+			for _, out := range item.Outputs {
+				if out.Type == "logs" {
+					call.Output += out.Logs
+				}
+			}
+			builtinCalls = append(builtinCalls, call)
+		case "function_call":
+			msg.ToolCalls = append(msg.ToolCalls, llmrouter.ToolCall{
+				ID:   item.CallID,
+				Type: "function",
+				Function: llmrouter.FuncCall{
+					Name:      item.Name,
+					Arguments: item.Arguments,
+				},
+			})
+		}
+	}
+
+	raw := resp.Status
+	finishReason := "stop"
+	switch {
+	case len(msg.ToolCalls) > 0:
+		finishReason = "tool_calls"
+	case resp.IncompleteDetails != nil:
+		raw = resp.IncompleteDetails.Reason
+		switch raw {
+		case "max_output_tokens":
+			finishReason = "length"
+		case "content_filter":
+			finishReason = "content_filter"
+		}
+	}
+
+	var usage *llmrouter.Usage
+	if resp.Usage != nil {
+		usage = &llmrouter.Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		}
+	}
+
+	return &llmrouter.Response{
+		ID:       resp.ID,
+		Object:   "chat.completion",
+		Created:  resp.CreatedAt,
+		Model:    resp.Model,
+		Provider: provider,
+		Choices: []llmrouter.Choice{
+			{
+				Index:         0,
+				Message:       msg,
+				FinishReason:  finishReason,
+				FinishDetails: llmrouter.NewFinishDetails(finishReason, raw, len(msg.ToolCalls) > 0),
+			},
+		},
+		Usage:            usage,
+		ServiceTier:      resp.ServiceTier,
+		Citations:        citations,
+		BuiltinToolCalls: builtinCalls,
+	}
+}
+
+// completeResponses performs a completion via the /responses endpoint
+func (p *Provider) completeResponses(ctx context.Context, req *llmrouter.Request) (*llmrouter.Response, error) {
+	body, _, err := p.buildResponsesRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw *http.Response
+	if err := p.client.Post(ctx, "responses", body, &raw, requestOptions(req)...); err != nil {
+		return nil, wrapError(p.name, err)
+	}
+	defer raw.Body.Close()
+
+	var resp responsesResponse
+	if err := json.NewDecoder(raw.Body).Decode(&resp); err != nil {
+		return nil, wrapError(p.name, err)
+	}
+	if resp.Error != nil {
+		return nil, wrapError(p.name, fmt.Errorf("%w: %s", llmrouter.ErrProviderError, resp.Error.Message))
+	}
+
+	return convertResponsesResponse(&resp, p.name), nil
+}
+
+// streamResponses performs a streaming completion via the /responses endpoint
+func (p *Provider) streamResponses(ctx context.Context, req *llmrouter.Request) (<-chan llmrouter.Event, error) {
+	body, model, err := p.buildResponsesRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	body.Stream = true
+
+	ch := make(chan llmrouter.Event)
+
+	go func() {
+		defer close(ch)
+
+		var raw *http.Response
+		opts := append([]option.RequestOption{option.WithHeader("Accept", "text/event-stream")}, requestOptions(req)...)
+		if err := p.client.Post(ctx, "responses", body, &raw, opts...); err != nil {
+			ch <- llmrouter.Event{
+				Type:  llmrouter.EventError,
+				Error: wrapError(p.name, err),
+			}
+			return
+		}
+		decoder := ssestream.NewDecoder(raw)
+		defer decoder.Close()
+
+		// Argument deltas only carry the output index, so remember each
+		// call's ID and name to fill them in
+		calls := make(map[int]llmrouter.ToolCall)
+
+		for decoder.Next() {
+			var event responsesStreamEvent
+			if err := json.Unmarshal(decoder.Event().Data, &event); err != nil {
+				continue
+			}
+
+			switch event.Type {
+			case "response.output_text.delta":
+				ch <- llmrouter.Event{
+					Type:    llmrouter.EventContentDelta,
+					Content: event.Delta,
+				}
+
+			case "response.output_item.added":
+				if event.Item.Type != "function_call" {
+					continue
+				}
+				idx := event.OutputIndex
+				tc := llmrouter.ToolCall{
+					ID:    event.Item.CallID,
+					Type:  "function",
+					Index: &idx,
+					Function: llmrouter.FuncCall{
+						Name: event.Item.Name,
+					},
+				}
+				calls[idx] = tc
+				ch <- llmrouter.Event{
+					Type:  llmrouter.EventToolCallDelta,
+					Delta: &llmrouter.Delta{ToolCalls: []llmrouter.ToolCall{tc}},
+				}
+
+			case "response.function_call_arguments.delta":
+				tc := calls[event.OutputIndex]
+				idx := event.OutputIndex
+				tc.Type = "function"
+				tc.Index = &idx
+				tc.Function.Arguments = event.Delta
+				ch <- llmrouter.Event{
+					Type:  llmrouter.EventToolCallDelta,
+					Delta: &llmrouter.Delta{ToolCalls: []llmrouter.ToolCall{tc}},
+				}
+
+			case "response.completed", "response.incomplete":
+				if event.Response == nil {
+					continue
+				}
+				ch <- llmrouter.Event{
+					Type:     llmrouter.EventDone,
+					Response: convertResponsesResponse(event.Response, p.name),
+				}
+				return
+
+			case "response.failed", "error":
+				message := event.Message
+				if event.Response != nil && event.Response.Error != nil {
+					message = event.Response.Error.Message
+				}
+				ch <- llmrouter.Event{
+					Type:  llmrouter.EventError,
+					Error: wrapError(p.name, fmt.Errorf("%w: %s", llmrouter.ErrProviderError, message)),
+				}
+				return
+			}
+		}
+
+		if err := decoder.Err(); err != nil {
+			ch <- llmrouter.Event{
+				Type:  llmrouter.EventError,
+				Error: wrapError(p.name, err),
+			}
+			return
+		}
+
+		// The stream ended without a terminal event
+		ch <- llmrouter.Event{
+			Type: llmrouter.EventDone,
+			Response: &llmrouter.Response{
+				Provider: p.name,
+				Model:    model,
+				Object:   "chat.completion",
+				Created:  time.Now().Unix(),
+			},
+		}
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,385 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	llmrouter "github.com/bluefunda/llm-router"
+)
+
+// responsesBody is a completed /responses body with a text message, a
+// function call and usage
+func responsesBody() map[string]any {
+	return map[string]any{
+		"id":           "resp_1",
+		"object":       "response",
+		"created_at":   1700000000,
+		"model":        "gpt-4o",
+		"status":       "completed",
+		"service_tier": "default",
+		"output": []any{
+			map[string]any{"type": "reasoning", "id": "rs_1"},
+			map[string]any{
+				"type": "message",
+				"id":   "msg_1",
+				"role": "assistant",
+				"content": []any{
+					map[string]any{"type": "output_text", "text": "Sunny "},
+					map[string]any{"type": "output_text", "text": "in Paris"},
+				},
+			},
+			map[string]any{
+				"type":      "function_call",
+				"id":        "fc_1",
+				"call_id":   "call_1",
+				"name":      "weather",
+				"arguments": `{"city":"Paris"}`,
+			},
+		},
+		"usage": map[string]any{"input_tokens": 10, "output_tokens": 4, "total_tokens": 14},
+	}
+}
+
+func TestResponsesRequestMapping(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, responsesBody())
+	})
+	p.WithResponsesAPI()
+
+	req := &llmrouter.Request{
+		Model: "gpt-4o",
+		Messages: []llmrouter.Message{
+			{Role: llmrouter.RoleSystem, Content: "Be brief."},
+			{Role: llmrouter.RoleUser, ContentParts: []llmrouter.ContentPart{
+				{Type: "text", Text: "What is this?"},
+				{Type: "image_url", ImageURL: &llmrouter.ImageURL{URL: "https://example.com/a.png", Detail: "low"}},
+			}},
+			{Role: llmrouter.RoleAssistant, ToolCalls: []llmrouter.ToolCall{{
+				ID: "call_0", Type: "function", Function: llmrouter.FuncCall{Name: "weather", Arguments: `{"city":"Rome"}`},
+			}}},
+			{Role: llmrouter.RoleTool, ToolCallID: "call_0", Content: "rain"},
+		},
+		Tools: []llmrouter.Tool{{Type: "function", Function: llmrouter.Function{
+			Name:        "weather",
+			Description: "Current weather",
+			Parameters:  json.RawMessage(`{"type":"object"}`),
+		}}},
+		ToolChoice: &llmrouter.ToolChoice{Type: "function", Function: &llmrouter.FuncRef{Name: "weather"}},
+		ResponseFormat: &llmrouter.ResponseFormat{Type: "json_schema", JSONSchema: &llmrouter.JSONSchema{
+			Name:   "answer",
+			Schema: json.RawMessage(`{"type":"object"}`),
+			Strict: true,
+		}},
+		MaxTokens:   intPtr(100),
+		Temperature: floatPtr(0.2),
+	}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	last := api.last()
+	if last.Path != "/responses" {
+		t.Errorf("path = %q, want /responses", last.Path)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(last.Body, &got); err != nil {
+		t.Fatal(err)
+	}
+	var want map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"model": "gpt-4o",
+		"input": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": [
+				{"type": "input_text", "text": "What is this?"},
+				{"type": "input_image", "image_url": "https://example.com/a.png", "detail": "low"}
+			]},
+			{"type": "function_call", "call_id": "call_0", "name": "weather", "arguments": "{\"city\":\"Rome\"}"},
+			{"type": "function_call_output", "call_id": "call_0", "output": "rain"}
+		],
+		"tools": [{"type": "function", "name": "weather", "description": "Current weather", "parameters": {"type": "object"}}],
+		"tool_choice": {"type": "function", "name": "weather"},
+		"temperature": 0.2,
+		"max_output_tokens": 100,
+		"text": {"format": {"type": "json_schema", "name": "answer", "schema": {"type": "object"}, "strict": true}}
+	}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %s\nwant the mapped request", last.Body)
+	}
+}
+
+func TestResponsesToolChoiceMode(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, responsesBody())
+	})
+	p.WithResponsesAPI()
+
+	req := userRequest("hello")
+	req.ToolChoice = &llmrouter.ToolChoice{Type: "required"}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.last().JSON()["tool_choice"]; got != "required" {
+		t.Errorf("tool_choice = %v, want required", got)
+	}
+}
+
+func TestResponsesUnsupportedParameters(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*llmrouter.Request)
+	}{
+		{"stop", func(r *llmrouter.Request) { r.Stop = []string{"END"} }},
+		{"n", func(r *llmrouter.Request) { r.N = intPtr(2) }},
+		{"builtin tool", func(r *llmrouter.Request) { r.BuiltinTools = []string{"file_search"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, responsesBody())
+			})
+			p.WithResponsesAPI()
+
+			req := userRequest("hello")
+			tt.edit(req)
+			if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrNotSupported) {
+				t.Errorf("err = %v, want ErrNotSupported", err)
+			}
+			if api.count() != 0 {
+				t.Errorf("%d requests sent, want none", api.count())
+			}
+		})
+	}
+}
+
+func TestResponsesResponse(t *testing.T) {
+	p, _ := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, responsesBody())
+	})
+	p.WithResponsesAPI()
+
+	resp, err := p.Complete(context.Background(), userRequest("weather?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.ID != "resp_1" || resp.Model != "gpt-4o" || resp.Created != 1700000000 || resp.Provider != "openai" || resp.ServiceTier != "default" {
+		t.Errorf("response = %+v, want the id, model, created, provider and tier mapped", resp)
+	}
+	if len(resp.Choices) != 1 {
+		t.Fatalf("choices = %d, want 1", len(resp.Choices))
+	}
+	choice := resp.Choices[0]
+	if choice.Message.Role != llmrouter.RoleAssistant || choice.Message.Content != "Sunny in Paris" {
+		t.Errorf("message = %+v, want the output text joined", choice.Message)
+	}
+	want := []llmrouter.ToolCall{{ID: "call_1", Type: "function", Function: llmrouter.FuncCall{Name: "weather", Arguments: `{"city":"Paris"}`}}}
+	if !reflect.DeepEqual(choice.Message.ToolCalls, want) {
+		t.Errorf("tool calls = %+v, want %+v", choice.Message.ToolCalls, want)
+	}
+	if choice.FinishReason != "tool_calls" {
+		t.Errorf("finish reason = %q, want tool_calls", choice.FinishReason)
+	}
+	if u := resp.Usage; u == nil || *u != (llmrouter.Usage{PromptTokens: 10, CompletionTokens: 4, TotalTokens: 14}) {
+		t.Errorf("usage = %+v, want 10/4/14", u)
+	}
+}
+
+func TestResponsesIncomplete(t *testing.T) {
+	p, _ := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"id":                 "resp_1",
+			"model":              "gpt-4o",
+			"status":             "incomplete",
+			"incomplete_details": map[string]any{"reason": "max_output_tokens"},
+			"output": []any{map[string]any{
+				"type":    "message",
+				"content": []any{map[string]any{"type": "output_text", "text": "Once upon"}},
+			}},
+		})
+	})
+	p.WithResponsesAPI()
+
+	resp, err := p.Complete(context.Background(), userRequest("story"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	choice := resp.Choices[0]
+	if choice.FinishReason != "length" || choice.FinishDetails.RawReason != "max_output_tokens" {
+		t.Errorf("finish = %q, %+v; want length from max_output_tokens", choice.FinishReason, choice.FinishDetails)
+	}
+}
+
+func TestResponsesError(t *testing.T) {
+	p, _ := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"id":     "resp_1",
+			"status": "failed",
+			"error":  map[string]any{"code": "server_error", "message": "boom"},
+		})
+	})
+	p.WithResponsesAPI()
+
+	if _, err := p.Complete(context.Background(), userRequest("hello")); !errors.Is(err, llmrouter.ErrProviderError) {
+		t.Errorf("err = %v, want ErrProviderError", err)
+	}
+}
+
+func TestResponsesStream(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			map[string]any{"type": "response.output_text.delta", "output_index": 0, "delta": "Sunny"},
+			map[string]any{"type": "response.output_item.added", "output_index": 1, "item": map[string]any{"type": "function_call", "call_id": "call_1", "name": "weather"}},
+			map[string]any{"type": "response.function_call_arguments.delta", "output_index": 1, "delta": `{"city":`},
+			map[string]any{"type": "response.function_call_arguments.delta", "output_index": 1, "delta": `"Paris"}`},
+			map[string]any{"type": "response.completed", "response": responsesBody()},
+		)
+	})
+	p.WithResponsesAPI()
+
+	ch, err := p.Stream(context.Background(), userRequest("weather?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+
+	if got := api.last().JSON()["stream"]; got != true {
+		t.Errorf("stream = %v, want true", got)
+	}
+	if len(events) != 5 {
+		t.Fatalf("events = %d, want 5: %+v", len(events), events)
+	}
+	if events[0].Type != llmrouter.EventContentDelta || events[0].Content != "Sunny" {
+		t.Errorf("event 0 = %+v, want the text delta", events[0])
+	}
+	for i, args := range []string{"", `{"city":`, `"Paris"}`} {
+		e := events[i+1]
+		if e.Type != llmrouter.EventToolCallDelta || len(e.Delta.ToolCalls) != 1 {
+			t.Fatalf("event %d = %+v, want a tool call delta", i+1, e)
+		}
+		tc := e.Delta.ToolCalls[0]
+		if tc.ID != "call_1" || tc.Function.Name != "weather" || tc.Function.Arguments != args || tc.Index == nil || *tc.Index != 1 {
+			t.Errorf("event %d tool call = %+v, want call_1 weather at index 1 with %q", i+1, tc, args)
+		}
+	}
+	done := events[4]
+	if done.Type != llmrouter.EventDone || done.Response == nil || done.Response.Usage == nil || done.Response.Usage.TotalTokens != 14 {
+		t.Errorf("done = %+v, want the completed response", done)
+	}
+}
+
+func TestResponsesStreamFailed(t *testing.T) {
+	p, _ := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w, map[string]any{"type": "response.failed", "response": map[string]any{"error": map[string]any{"message": "boom"}}})
+	})
+	p.WithResponsesAPI()
+
+	ch, err := p.Stream(context.Background(), userRequest("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	if len(events) != 1 || events[0].Type != llmrouter.EventError || !errors.Is(events[0].Error, llmrouter.ErrProviderError) {
+		t.Errorf("events = %+v, want one provider error", events)
+	}
+}
+
+func TestResponsesBuiltinToolsMapping(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, responsesBody())
+	})
+	p.WithResponsesAPI()
+
+	req := userRequest("weather?")
+	req.BuiltinTools = []string{llmrouter.BuiltinWebSearch, llmrouter.BuiltinCodeInterpreter}
+	req.Tools = []llmrouter.Tool{{Type: "function", Function: llmrouter.Function{Name: "weather"}}}
+	if _, err := p.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	var want any
+	if err := json.Unmarshal([]byte(`[
+		{"type": "web_search"},
+		{"type": "code_interpreter", "container": {"type": "auto"}},
+		{"type": "function", "name": "weather"}
+	]`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if got := api.last().JSON()["tools"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("tools = %v, want %v", got, want)
+	}
+}
+
+func TestResponsesBuiltinToolOutputs(t *testing.T) {
+	p, _ := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"id":     "resp_1",
+			"model":  "gpt-4o",
+			"status": "completed",
+			"output": []any{
+				map[string]any{"type": "web_search_call", "id": "ws_1", "status": "completed", "action": map[string]any{"type": "search", "query": "paris weather"}},
+				map[string]any{"type": "code_interpreter_call", "id": "ci_1", "code": "print(1+1)", "outputs": []any{
+					map[string]any{"type": "logs", "logs": "2\n"},
+					map[string]any{"type": "image", "url": "https://example.com/plot.png"},
+				}},
+				map[string]any{"type": "message", "content": []any{map[string]any{
+					"type": "output_text",
+					"text": "Sunny.",
+					"annotations": []any{
+						map[string]any{"type": "url_citation", "url": "https://weather.example", "title": "Forecast"},
+						map[string]any{"type": "file_citation", "file_id": "file_1"},
+					},
+				}}},
+			},
+		})
+	})
+	p.WithResponsesAPI()
+
+	req := userRequest("weather?")
+	req.BuiltinTools = []string{llmrouter.BuiltinWebSearch, llmrouter.BuiltinCodeInterpreter}
+	resp, err := p.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantCalls := []llmrouter.BuiltinToolCall{
+		{Type: llmrouter.BuiltinWebSearch, ID: "ws_1", Input: "paris weather"},
+		{Type: llmrouter.BuiltinCodeInterpreter, ID: "ci_1", Input: "print(1+1)", Output: "2\n"},
+	}
+	if !reflect.DeepEqual(resp.BuiltinToolCalls, wantCalls) {
+		t.Errorf("builtin tool calls = %+v, want %+v", resp.BuiltinToolCalls, wantCalls)
+	}
+	wantCitations := []llmrouter.Citation{{URL: "https://weather.example", Title: "Forecast"}}
+	if !reflect.DeepEqual(resp.Citations, wantCitations) {
+		t.Errorf("citations = %+v, want %+v", resp.Citations, wantCitations)
+	}
+	choice := resp.Choices[0]
+	if choice.Message.Content != "Sunny." || len(choice.Message.ToolCalls) != 0 || choice.FinishReason != "stop" {
+		t.Errorf("choice = %+v, want the text with no tool calls to run", choice)
+	}
+}
+
+func TestBuiltinToolsNeedResponsesAPI(t *testing.T) {
+	p, api := newTestProvider(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, chatCompletion("hi"))
+	})
+
+	req := userRequest("weather?")
+	req.BuiltinTools = []string{llmrouter.BuiltinWebSearch}
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("Complete err = %v, want ErrNotSupported", err)
+	}
+	if _, err := p.Stream(context.Background(), req); !errors.Is(err, llmrouter.ErrNotSupported) {
+		t.Errorf("Stream err = %v, want ErrNotSupported", err)
+	}
+	if api.count() != 0 {
+		t.Errorf("%d requests sent, want none", api.count())
+	}
+}
@@ -0,0 +1,97 @@
+package llmrouter
+
+import (
+	"context"
+	"time"
+)
+
+type providerTimeoutKey struct{}
+
+// ProviderTimeout reports the per-provider timeout the router applies to
+// each call beneath the middleware, if any. Timeout middleware uses it to
+// defer to the provider's own timeout.
+func ProviderTimeout(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(providerTimeoutKey{}).(time.Duration)
+	return d, ok
+}
+
+// timeoutTagProvider marks the context with the provider timeout, so
+// middleware in the chain can see that one applies
+type timeoutTagProvider struct {
+	Provider
+	timeout time.Duration
+}
+
+func (p *timeoutTagProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	return p.Provider.Complete(context.WithValue(ctx, providerTimeoutKey{}, p.timeout), req)
+}
+
+func (p *timeoutTagProvider) Stream(ctx context.Context, req *Request) (<-chan Event, error) {
+	return p.Provider.Stream(context.WithValue(ctx, providerTimeoutKey{}, p.timeout), req)
+}
+
+// timeoutOverrideProvider bounds each call to the provider itself by a
+// provider-specific timeout. It sits beneath the middleware, so each retry
+// attempt gets the full timeout.
+type timeoutOverrideProvider struct {
+	Provider
+	timeout time.Duration
+}
+
+// withTimeout bounds ctx by the provider timeout, or by the request's own
+// timeout override if it sets one
+func (p *timeoutOverrideProvider) withTimeout(ctx context.Context, req *Request) (context.Context, context.CancelFunc) {
+	timeout := p.timeout
+	if d, ok := req.MetadataDuration(MetadataTimeout); ok && d > 0 {
+		timeout = d
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (p *timeoutOverrideProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	ctx, cancel := p.withTimeout(ctx, req)
+	defer cancel()
+
+	return p.Provider.Complete(ctx, req)
+}
+
+func (p *timeoutOverrideProvider) Stream(ctx context.Context, req *Request) (<-chan Event, error) {
+	ctx, cancel := p.withTimeout(ctx, req)
+
+	ch, err := p.Provider.Stream(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return WatchStream(ctx, cancel, ch), nil
+}
+
+// WatchStream forwards ch until it closes. If ctx is done first, the stream
+// ends with an EventError carrying ctx.Err(). cancel, which should release
+// ctx, is called once the stream ends.
+func WatchStream(ctx context.Context, cancel context.CancelFunc, ch <-chan Event) <-chan Event {
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		defer cancel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				outCh <- Event{Type: EventError, Error: ctx.Err()}
+				return
+			case event, ok := <-ch:
+				if !ok {
+					return
+				}
+				select {
+				case outCh <- event:
+				case <-ctx.Done():
+					outCh <- Event{Type: EventError, Error: ctx.Err()}
+					return
+				}
+			}
+		}
+	}()
+	return outCh
+}
@@ -0,0 +1,139 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// deadlineIn returns how far ctx's deadline is from start
+func deadlineIn(t *testing.T, ctx context.Context, start time.Time) time.Duration {
+	t.Helper()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("context has no deadline")
+	}
+	return deadline.Sub(start)
+}
+
+func TestProviderTimeoutPerProvider(t *testing.T) {
+	fast := &stubProvider{name: "fast", models: []string{"fast-model"}}
+	slow := &stubProvider{name: "slow", models: []string{"slow-model"}}
+	plain := &stubProvider{name: "plain", models: []string{"plain-model"}}
+	r := New(
+		WithProvider("fast", fast), WithProvider("slow", slow), WithProvider("plain", plain),
+		WithProviderTimeout("fast", time.Second),
+		WithProviderTimeout("slow", time.Hour),
+	)
+
+	start := time.Now()
+	for _, model := range []string{"fast-model", "slow-model", "plain-model"} {
+		req := userRequest("hi")
+		req.Model = model
+		if _, err := r.Complete(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if d := deadlineIn(t, fast.ctxs[0], start); d < time.Second || d > 2*time.Second {
+		t.Errorf("fast deadline in %v, want about 1s", d)
+	}
+	if d := deadlineIn(t, slow.ctxs[0], start); d < time.Hour || d > time.Hour+time.Second {
+		t.Errorf("slow deadline in %v, want about 1h", d)
+	}
+	if _, ok := plain.ctxs[0].Deadline(); ok {
+		t.Error("provider without a timeout got a deadline")
+	}
+}
+
+// attemptsMiddleware calls the provider once per attempt, as a retry would,
+// recording whether each call could see the provider timeout
+type attemptsMiddleware struct {
+	attempts int
+	seen     []time.Duration
+}
+
+func (m *attemptsMiddleware) Wrap(next Provider) Provider {
+	return &attemptsProvider{Provider: next, m: m}
+}
+
+type attemptsProvider struct {
+	Provider
+	m *attemptsMiddleware
+}
+
+func (p *attemptsProvider) Complete(ctx context.Context, req *Request) (resp *Response, err error) {
+	for i := 0; i < p.m.attempts; i++ {
+		if d, ok := ProviderTimeout(ctx); ok {
+			p.m.seen = append(p.m.seen, d)
+		}
+		if resp, err = p.Provider.Complete(ctx, req); err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+func TestProviderTimeoutPerAttempt(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	attempts := &attemptsMiddleware{attempts: 2}
+	r := New(WithProvider("stub", stub), WithMiddleware(attempts), WithProviderTimeout("stub", 20*time.Millisecond))
+
+	req := userRequest("hi")
+	req.Model = "m"
+	start := time.Now()
+	_, err := r.Complete(context.Background(), req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+
+	if stub.callCount() != 2 {
+		t.Fatalf("provider called %d times, want 2", stub.callCount())
+	}
+	first, second := deadlineIn(t, stub.ctxs[0], start), deadlineIn(t, stub.ctxs[1], start)
+	if second-first < 20*time.Millisecond {
+		t.Errorf("attempt deadlines in %v and %v, want a fresh deadline for the second attempt", first, second)
+	}
+	if len(attempts.seen) != 2 || attempts.seen[0] != 20*time.Millisecond {
+		t.Errorf("middleware saw provider timeouts %v, want 20ms on each attempt", attempts.seen)
+	}
+}
+
+func TestProviderTimeoutStream(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return make(chan Event), nil
+	}}
+	r := New(WithProvider("stub", stub), WithProviderTimeout("stub", 10*time.Millisecond))
+
+	req := userRequest("hi")
+	req.Model = "m"
+	ch, err := r.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	last := events[len(events)-1]
+	if last.Type != EventError || !errors.Is(last.Error, context.DeadlineExceeded) {
+		t.Errorf("last event = %+v, want a deadline exceeded error", last)
+	}
+}
+
+func TestProviderTimeoutOverriddenByMetadata(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}}
+	r := New(WithProvider("stub", stub), WithProviderTimeout("stub", time.Hour))
+
+	req := userRequest("hi")
+	req.Model = "m"
+	req.Metadata = map[string]any{MetadataTimeout: "2s"}
+	start := time.Now()
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if d := deadlineIn(t, stub.ctxs[0], start); d < 2*time.Second || d > 3*time.Second {
+		t.Errorf("deadline in %v, want the 2s request override", d)
+	}
+}
@@ -0,0 +1,99 @@
+package llmrouter
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitStatus is the most recent rate limit budget reported by a provider
+type RateLimitStatus struct {
+	LimitRequests     int
+	RemainingRequests int
+	ResetRequests     string // raw reset value, e.g. "1s" or an RFC 3339 timestamp
+	LimitTokens       int
+	RemainingTokens   int
+	ResetTokens       string
+	UpdatedAt         time.Time
+}
+
+// RateLimitReporter is implemented by providers that track rate limit headers
+type RateLimitReporter interface {
+	RateLimitStatus() (RateLimitStatus, bool)
+}
+
+// RateLimitTracker records rate limit headers from provider responses.
+// It is safe for concurrent use.
+type RateLimitTracker struct {
+	mu     sync.RWMutex
+	status RateLimitStatus
+	seen   bool
+}
+
+// rateLimitHeaders lists the header names used by each provider family
+var rateLimitHeaders = []struct {
+	limitReq, remainingReq, resetReq       string
+	limitTokens, remainingTokens, resetTok string
+}{
+	{ // OpenAI and compatible APIs
+		"x-ratelimit-limit-requests", "x-ratelimit-remaining-requests", "x-ratelimit-reset-requests",
+		"x-ratelimit-limit-tokens", "x-ratelimit-remaining-tokens", "x-ratelimit-reset-tokens",
+	},
+	{ // Anthropic
+		"anthropic-ratelimit-requests-limit", "anthropic-ratelimit-requests-remaining", "anthropic-ratelimit-requests-reset",
+		"anthropic-ratelimit-tokens-limit", "anthropic-ratelimit-tokens-remaining", "anthropic-ratelimit-tokens-reset",
+	},
+}
+
+// Update parses rate limit headers, leaving the status unchanged if none are present
+func (t *RateLimitTracker) Update(h http.Header) {
+	for _, names := range rateLimitHeaders {
+		if h.Get(names.remainingReq) == "" && h.Get(names.remainingTokens) == "" {
+			continue
+		}
+
+		status := RateLimitStatus{
+			LimitRequests:     headerInt(h, names.limitReq),
+			RemainingRequests: headerInt(h, names.remainingReq),
+			ResetRequests:     h.Get(names.resetReq),
+			LimitTokens:       headerInt(h, names.limitTokens),
+			RemainingTokens:   headerInt(h, names.remainingTokens),
+			ResetTokens:       h.Get(names.resetTok),
+			UpdatedAt:         time.Now(),
+		}
+
+		t.mu.Lock()
+		t.status = status
+		t.seen = true
+		t.mu.Unlock()
+		return
+	}
+}
+
+// Status returns the last recorded status and whether any has been seen
+func (t *RateLimitTracker) Status() (RateLimitStatus, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.status, t.seen
+}
+
+func headerInt(h http.Header, key string) int {
+	n, _ := strconv.Atoi(h.Get(key))
+	return n
+}
+
+// RateLimitStatus returns the latest rate limit budget reported by a provider.
+// It returns false if the provider doesn't track rate limits or hasn't seen any yet.
+func (r *Router) RateLimitStatus(provider string) (RateLimitStatus, bool) {
+	p, ok := r.GetProvider(provider)
+	if !ok {
+		return RateLimitStatus{}, false
+	}
+
+	reporter, ok := p.(RateLimitReporter)
+	if !ok {
+		return RateLimitStatus{}, false
+	}
+	return reporter.RateLimitStatus()
+}
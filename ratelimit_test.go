@@ -0,0 +1,95 @@
+package llmrouter
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRateLimitTrackerUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   RateLimitStatus
+	}{
+		{
+			name: "openai",
+			header: http.Header{
+				"X-Ratelimit-Limit-Requests":     {"500"},
+				"X-Ratelimit-Remaining-Requests": {"499"},
+				"X-Ratelimit-Reset-Requests":     {"120ms"},
+				"X-Ratelimit-Limit-Tokens":       {"30000"},
+				"X-Ratelimit-Remaining-Tokens":   {"29950"},
+				"X-Ratelimit-Reset-Tokens":       {"100ms"},
+			},
+			want: RateLimitStatus{LimitRequests: 500, RemainingRequests: 499, ResetRequests: "120ms", LimitTokens: 30000, RemainingTokens: 29950, ResetTokens: "100ms"},
+		},
+		{
+			name: "anthropic",
+			header: http.Header{
+				"Anthropic-Ratelimit-Requests-Limit":     {"50"},
+				"Anthropic-Ratelimit-Requests-Remaining": {"49"},
+				"Anthropic-Ratelimit-Requests-Reset":     {"2025-01-01T00:00:01Z"},
+				"Anthropic-Ratelimit-Tokens-Remaining":   {"8000"},
+			},
+			want: RateLimitStatus{LimitRequests: 50, RemainingRequests: 49, ResetRequests: "2025-01-01T00:00:01Z", RemainingTokens: 8000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tracker RateLimitTracker
+			tracker.Update(tt.header)
+
+			got, ok := tracker.Status()
+			if !ok {
+				t.Fatal("no status recorded")
+			}
+			if got.UpdatedAt.IsZero() {
+				t.Error("UpdatedAt not set")
+			}
+			got.UpdatedAt = tt.want.UpdatedAt
+			if got != tt.want {
+				t.Errorf("status = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitTrackerIgnoresResponsesWithoutHeaders(t *testing.T) {
+	var tracker RateLimitTracker
+	tracker.Update(http.Header{})
+	if _, ok := tracker.Status(); ok {
+		t.Error("status recorded without rate limit headers")
+	}
+
+	tracker.Update(http.Header{"X-Ratelimit-Remaining-Requests": {"10"}})
+	tracker.Update(http.Header{"Content-Type": {"application/json"}})
+	if got, _ := tracker.Status(); got.RemainingRequests != 10 {
+		t.Errorf("RemainingRequests = %d, want the last reported 10", got.RemainingRequests)
+	}
+}
+
+// rateLimitedProvider is a stub that reports a rate limit status
+type rateLimitedProvider struct {
+	stubProvider
+	tracker RateLimitTracker
+}
+
+func (p *rateLimitedProvider) RateLimitStatus() (RateLimitStatus, bool) {
+	return p.tracker.Status()
+}
+
+func TestRouterRateLimitStatus(t *testing.T) {
+	limited := &rateLimitedProvider{}
+	limited.tracker.Update(http.Header{"X-Ratelimit-Remaining-Tokens": {"42"}})
+	r := New(WithProvider("limited", limited), WithProvider("plain", &stubProvider{}))
+
+	if status, ok := r.RateLimitStatus("limited"); !ok || status.RemainingTokens != 42 {
+		t.Errorf("status = %+v, %v; want 42 remaining tokens", status, ok)
+	}
+	if _, ok := r.RateLimitStatus("plain"); ok {
+		t.Error("status reported for a provider that doesn't track rate limits")
+	}
+	if _, ok := r.RateLimitStatus("missing"); ok {
+		t.Error("status reported for an unknown provider")
+	}
+}
@@ -0,0 +1,173 @@
+package llmrouter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Region is one regional endpoint of a provider
+type Region struct {
+	Name     string
+	Provider Provider
+}
+
+// LatencyProbe measures the latency of a region, returning an error if the
+// region is unhealthy
+type LatencyProbe func(ctx context.Context, region Region) (time.Duration, error)
+
+// RegionalProvider routes requests to the lowest-latency healthy region among
+// several endpoints of the same provider, e.g. one OpenAI-compatible provider
+// per regional base URL. Latencies are measured by Check, which Start runs
+// periodically, with the probe set by WithProbe. Without a probe, or until the
+// first check completes, the first region is used.
+type RegionalProvider struct {
+	regions  []Region
+	probe    LatencyProbe
+	interval time.Duration
+
+	mu        sync.RWMutex
+	latencies map[string]time.Duration // healthy regions only
+	best      int
+}
+
+// NewRegionalProvider creates a provider over regions, re-checking latency
+// every interval once started. regions must not be empty.
+func NewRegionalProvider(interval time.Duration, regions ...Region) *RegionalProvider {
+	return &RegionalProvider{
+		regions:  regions,
+		interval: interval,
+	}
+}
+
+// WithProbe sets how region latency is measured. There is no default, since
+// any request that reaches the API may be billed: probing is opt-in, e.g. with
+// CompletionProbe or a probe timing a free endpoint such as a model listing.
+func (p *RegionalProvider) WithProbe(probe LatencyProbe) *RegionalProvider {
+	p.probe = probe
+	return p
+}
+
+// CompletionProbe times a one-token completion, billed as such on every
+// check of every region
+func CompletionProbe(ctx context.Context, region Region) (time.Duration, error) {
+	maxTokens := 1
+	start := time.Now()
+	_, err := region.Provider.Complete(ctx, &Request{
+		Messages:  []Message{{Role: RoleUser, Content: "ping"}},
+		MaxTokens: &maxTokens,
+	})
+	return time.Since(start), err
+}
+
+// Start checks every region now and then every interval until ctx is done.
+// It does nothing without a probe.
+func (p *RegionalProvider) Start(ctx context.Context) {
+	if p.probe == nil {
+		return
+	}
+	p.Check(ctx)
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.Check(ctx)
+			}
+		}
+	}()
+}
+
+// Check probes every region in parallel and selects the fastest healthy one.
+// If no region is healthy, the previous selection is kept. It does nothing
+// without a probe.
+func (p *RegionalProvider) Check(ctx context.Context) {
+	if p.probe == nil {
+		return
+	}
+	latencies := make([]time.Duration, len(p.regions))
+	errs := make([]error, len(p.regions))
+
+	var wg sync.WaitGroup
+	for i, region := range p.regions {
+		wg.Add(1)
+		go func(i int, region Region) {
+			defer wg.Done()
+			latencies[i], errs[i] = p.probe(ctx, region)
+		}(i, region)
+	}
+	wg.Wait()
+
+	healthy := make(map[string]time.Duration, len(p.regions))
+	best := -1
+	for i, region := range p.regions {
+		if errs[i] != nil {
+			continue
+		}
+		healthy[region.Name] = latencies[i]
+		if best < 0 || latencies[i] < latencies[best] {
+			best = i
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latencies = healthy
+	if best >= 0 {
+		p.best = best
+	}
+}
+
+// Fastest returns the name of the region requests are currently routed to
+func (p *RegionalProvider) Fastest() string {
+	return p.current().Name
+}
+
+// Latencies returns the latest measured latency of each healthy region
+func (p *RegionalProvider) Latencies() map[string]time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make(map[string]time.Duration, len(p.latencies))
+	for name, d := range p.latencies {
+		result[name] = d
+	}
+	return result
+}
+
+func (p *RegionalProvider) current() Region {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.regions[p.best]
+}
+
+func (p *RegionalProvider) Name() string {
+	return p.regions[0].Provider.Name()
+}
+
+func (p *RegionalProvider) Models() []string {
+	return p.regions[0].Provider.Models()
+}
+
+// DefaultModel returns the regions' default model, if they report one
+func (p *RegionalProvider) DefaultModel() string {
+	if d, ok := p.regions[0].Provider.(DefaultModeler); ok {
+		return d.DefaultModel()
+	}
+	return ""
+}
+
+func (p *RegionalProvider) SupportsTools() bool {
+	return p.regions[0].Provider.SupportsTools()
+}
+
+func (p *RegionalProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	return p.current().Provider.Complete(ctx, req)
+}
+
+func (p *RegionalProvider) Stream(ctx context.Context, req *Request) (<-chan Event, error) {
+	return p.current().Provider.Stream(ctx, req)
+}
@@ -0,0 +1,162 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLatencies is a LatencyProbe reporting set latencies, or an error for
+// regions without one
+type fakeLatencies struct {
+	mu        sync.Mutex
+	latencies map[string]time.Duration
+}
+
+func (f *fakeLatencies) set(latencies map[string]time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latencies = latencies
+}
+
+func (f *fakeLatencies) probe(ctx context.Context, region Region) (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.latencies[region.Name]
+	if !ok {
+		return 0, errors.New("unreachable")
+	}
+	return d, nil
+}
+
+// newRegions builds one stub provider per region name
+func newRegions(names ...string) ([]Region, map[string]*stubProvider) {
+	regions := make([]Region, len(names))
+	stubs := make(map[string]*stubProvider, len(names))
+	for i, name := range names {
+		stubs[name] = &stubProvider{name: "openai"}
+		regions[i] = Region{Name: name, Provider: stubs[name]}
+	}
+	return regions, stubs
+}
+
+func TestRegionalProviderRoutesToFastest(t *testing.T) {
+	regions, stubs := newRegions("us", "eu", "asia")
+	latencies := &fakeLatencies{}
+	p := NewRegionalProvider(time.Minute, regions...).WithProbe(latencies.probe)
+
+	if p.Fastest() != "us" {
+		t.Errorf("before checking, routed to %q, want the first region", p.Fastest())
+	}
+
+	latencies.set(map[string]time.Duration{"us": 120 * time.Millisecond, "eu": 40 * time.Millisecond, "asia": 90 * time.Millisecond})
+	p.Check(context.Background())
+	if p.Fastest() != "eu" {
+		t.Errorf("routed to %q, want eu", p.Fastest())
+	}
+	if _, err := p.Complete(context.Background(), userRequest("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if stubs["eu"].callCount() != 1 || stubs["us"].callCount() != 0 || stubs["asia"].callCount() != 0 {
+		t.Error("completion not sent to the fastest region")
+	}
+
+	// eu goes down: the fastest healthy region takes over
+	latencies.set(map[string]time.Duration{"us": 120 * time.Millisecond, "asia": 90 * time.Millisecond})
+	p.Check(context.Background())
+	if p.Fastest() != "asia" {
+		t.Errorf("routed to %q, want asia", p.Fastest())
+	}
+	if want := map[string]time.Duration{"us": 120 * time.Millisecond, "asia": 90 * time.Millisecond}; !maps.Equal(p.Latencies(), want) {
+		t.Errorf("latencies = %v, want %v", p.Latencies(), want)
+	}
+	ch, err := p.Stream(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	if stubs["asia"].callCount() != 1 {
+		t.Error("stream not sent to the fastest region")
+	}
+}
+
+func TestRegionalProviderKeepsSelectionWhenAllDown(t *testing.T) {
+	regions, _ := newRegions("us", "eu")
+	latencies := &fakeLatencies{}
+	p := NewRegionalProvider(time.Minute, regions...).WithProbe(latencies.probe)
+
+	latencies.set(map[string]time.Duration{"us": time.Second, "eu": time.Millisecond})
+	p.Check(context.Background())
+	latencies.set(nil)
+	p.Check(context.Background())
+
+	if p.Fastest() != "eu" {
+		t.Errorf("routed to %q, want the last healthy selection kept", p.Fastest())
+	}
+	if len(p.Latencies()) != 0 {
+		t.Errorf("latencies = %v, want none healthy", p.Latencies())
+	}
+}
+
+func TestRegionalProviderStart(t *testing.T) {
+	regions, _ := newRegions("us", "eu")
+	latencies := &fakeLatencies{}
+	latencies.set(map[string]time.Duration{"us": time.Second, "eu": time.Millisecond})
+	p := NewRegionalProvider(5*time.Millisecond, regions...).WithProbe(latencies.probe)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	if p.Fastest() != "eu" {
+		t.Fatalf("routed to %q after Start, want eu", p.Fastest())
+	}
+
+	latencies.set(map[string]time.Duration{"us": time.Millisecond, "eu": time.Second})
+	deadline := time.Now().Add(time.Second)
+	for p.Fastest() != "us" {
+		if time.Now().After(deadline) {
+			t.Fatal("periodic check never switched to us")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRegionalProviderWithoutProbe(t *testing.T) {
+	regions, stubs := newRegions("us", "eu")
+	p := NewRegionalProvider(time.Millisecond, regions...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	p.Check(ctx)
+	if p.Fastest() != "us" || len(p.Latencies()) != 0 {
+		t.Errorf("routed to %q with latencies %v, want the first region unprobed", p.Fastest(), p.Latencies())
+	}
+	if stubs["us"].callCount() != 0 || stubs["eu"].callCount() != 0 {
+		t.Error("regions were sent requests without a probe set")
+	}
+}
+
+func TestRegionalProviderCompletionProbe(t *testing.T) {
+	regions, stubs := newRegions("us")
+	p := NewRegionalProvider(time.Minute, regions...).WithProbe(CompletionProbe)
+
+	p.Check(context.Background())
+	if _, ok := p.Latencies()["us"]; !ok {
+		t.Errorf("latencies = %v, want us measured", p.Latencies())
+	}
+	if got := stubs["us"].lastCall(); got.MaxTokens == nil || *got.MaxTokens != 1 {
+		t.Errorf("probe request = %+v, want a one-token completion", got)
+	}
+}
+
+func TestRegionalProviderDefaultModel(t *testing.T) {
+	p := NewRegionalProvider(time.Minute, Region{Name: "us", Provider: &stubProvider{name: "openai", model: "gpt-4o"}})
+	var dm DefaultModeler = p
+	if got := dm.DefaultModel(); got != "gpt-4o" {
+		t.Errorf("DefaultModel = %q, want the regions' gpt-4o", got)
+	}
+}
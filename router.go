@@ -3,16 +3,39 @@ package llmrouter
 import (
 	"context"
 	"fmt"
+	"path"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
+// modelPattern routes models matching a glob to a provider
+type modelPattern struct {
+	pattern  string
+	provider string
+}
+
 // Router manages multiple LLM providers and routes requests
 type Router struct {
-	providers  map[string]Provider
-	modelMap   map[string]string // model -> provider mapping
-	fallbacks  []string          // ordered fallback providers
-	middleware []Middleware
-	mu         sync.RWMutex
+	providers        map[string]Provider
+	modelMap         map[string]string // model -> provider mapping
+	patterns         []modelPattern    // glob -> provider, in registration order
+	fallbacks        []string          // ordered fallback providers
+	middleware       []Middleware
+	allowed          map[string]bool          // model allow-list, nil allows all
+	stats            *statsRecorder           // nil unless WithStats
+	timeouts         map[string]time.Duration // provider name -> timeout
+	streamToComplete bool                     // emulate streams via Complete when Stream fails
+	defaultTools     []Tool                   // merged into every request
+	errorOnEmpty     bool                     // fail completions with no content or tool calls
+	stripStops       bool                     // strip a trailing stop sequence from replies
+	sessionKey       func(*Request) string    // sticky routing key, nil disables
+	modelDefaults    map[string]Request       // model -> sampling defaults
+	rewriters        []RequestRewriter        // applied before routing
+	tokenBudget      *budget                  // nil unless WithTokenBudget
+	costBudget       *budget                  // nil unless WithCostBudget
+	mu               sync.RWMutex
 }
 
 // New creates a new Router with the given options
@@ -27,9 +50,16 @@ func New(opts ...Option) *Router {
 	return r
 }
 
-// Route sends a request to the appropriate provider and streams the response
+// Route sends a request to the appropriate provider and streams the response.
+// If the stream can't be established, fallback providers are tried in order.
 func (r *Router) Route(ctx context.Context, req *Request) (<-chan Event, error) {
-	provider, err := r.resolveProvider(req.Model)
+	req = r.rewrite(req)
+	req = r.withDefaultTools(req)
+	if err := r.checkBudgets(); err != nil {
+		return nil, err
+	}
+
+	provider, err := r.resolveForRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -37,18 +67,144 @@ func (r *Router) Route(ctx context.Context, req *Request) (<-chan Event, error)
 	// Apply middleware chain
 	handler := r.buildChain(provider)
 
-	return handler.Stream(ctx, req)
+	ch, err := r.streamOrComplete(ctx, handler, r.withModelDefaults(provider, req))
+	if err == nil {
+		return ch, nil
+	}
+
+	multi := &MultiError{}
+	multi.Add(provider.Name(), err)
+	for _, fb := range r.fallbackProviders(ctx, provider) {
+		ch, err := r.streamOrComplete(ctx, r.buildChain(fb), r.withModelDefaults(fb, fallbackRequest(req)))
+		if err == nil {
+			return ch, nil
+		}
+		multi.Add(fb.Name(), err)
+	}
+	return nil, multi.orSingle()
+}
+
+// RequestRewriter rewrites a request before the router picks its provider,
+// e.g. to change the model. It must not modify req in place; return a copy.
+type RequestRewriter func(req *Request) *Request
+
+// rewrite applies the router's request rewriters in order
+func (r *Router) rewrite(req *Request) *Request {
+	for _, fn := range r.rewriters {
+		req = fn(req)
+	}
+	return req
+}
+
+// withDefaultTools returns req with the router's default tools appended,
+// skipping any whose function name the request already defines
+func (r *Router) withDefaultTools(req *Request) *Request {
+	if len(r.defaultTools) == 0 {
+		return req
+	}
+
+	defined := make(map[string]bool, len(req.Tools))
+	for _, t := range req.Tools {
+		defined[t.Function.Name] = true
+	}
+
+	tools := append([]Tool(nil), req.Tools...)
+	for _, t := range r.defaultTools {
+		if !defined[t.Function.Name] {
+			defined[t.Function.Name] = true
+			tools = append(tools, t)
+		}
+	}
+	if len(tools) == len(req.Tools) {
+		return req
+	}
+
+	merged := *req
+	merged.Tools = tools
+	return &merged
+}
+
+// streamOrComplete starts a stream, falling back to an emulated single-chunk
+// stream over Complete if enabled and the stream can't be established
+func (r *Router) streamOrComplete(ctx context.Context, handler Provider, req *Request) (<-chan Event, error) {
+	ch, err := handler.Stream(ctx, req)
+	if err == nil || !r.streamToComplete || ctx.Err() != nil {
+		return ch, err
+	}
+
+	resp, cerr := handler.Complete(ctx, req)
+	if cerr != nil {
+		return nil, err
+	}
+	return StreamFromResponse(resp), nil
 }
 
-// Complete performs a non-streaming completion
+// Complete performs a non-streaming completion.
+// On failure, fallback providers are tried in order.
 func (r *Router) Complete(ctx context.Context, req *Request) (*Response, error) {
-	provider, err := r.resolveProvider(req.Model)
+	req = r.rewrite(req)
+	req = r.withDefaultTools(req)
+	if err := r.checkBudgets(); err != nil {
+		return nil, err
+	}
+
+	provider, err := r.resolveForRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
 	handler := r.buildChain(provider)
-	return handler.Complete(ctx, req)
+	resp, err := handler.Complete(ctx, r.withModelDefaults(provider, req))
+	if err == nil {
+		return resp, nil
+	}
+
+	multi := &MultiError{}
+	multi.Add(provider.Name(), err)
+	for _, fb := range r.fallbackProviders(ctx, provider) {
+		resp, err := r.buildChain(fb).Complete(ctx, r.withModelDefaults(fb, fallbackRequest(req)))
+		if err == nil {
+			return resp, nil
+		}
+		multi.Add(fb.Name(), err)
+	}
+	return nil, multi.orSingle()
+}
+
+// fallbackProviders returns the registered fallback providers, excluding the
+// one that already failed. Fallbacks serve their default model, so with an
+// allow-list only those whose default model (see DefaultModeler) is allowed
+// are returned. It returns nil once the context is done.
+func (r *Router) fallbackProviders(ctx context.Context, failed Provider) []Provider {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []Provider
+	for _, name := range r.fallbacks {
+		p, ok := r.providers[name]
+		if !ok || p == failed {
+			continue
+		}
+		if r.allowed != nil {
+			dm, ok := p.(DefaultModeler)
+			if !ok || !r.allowed[dm.DefaultModel()] {
+				continue
+			}
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// fallbackRequest copies req so a fallback provider uses its default model
+func fallbackRequest(req *Request) *Request {
+	fb := *req
+	fb.Model = ""
+	return &fb
 }
 
 // Stream is an alias for Route for clarity
@@ -56,46 +212,181 @@ func (r *Router) Stream(ctx context.Context, req *Request) (<-chan Event, error)
 	return r.Route(ctx, req)
 }
 
+// GenerateImage routes an image generation request to the provider serving its model
+func (r *Router) GenerateImage(ctx context.Context, req *ImageRequest) (*ImageResponse, error) {
+	if err := r.checkBudgets(); err != nil {
+		return nil, err
+	}
+	provider, err := r.resolveProvider(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	gen, ok := provider.(ImageGenerator)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not support image generation", ErrNotSupported, provider.Name())
+	}
+	return gen.GenerateImage(ctx, req)
+}
+
+// Embed routes an embedding request to the provider serving its model
+func (r *Router) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if err := r.checkBudgets(); err != nil {
+		return nil, err
+	}
+	provider, err := r.resolveProvider(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	e, ok := provider.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not support embeddings", ErrNotSupported, provider.Name())
+	}
+	resp, err := e.Embed(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	r.chargeEmbedding(resp)
+	return resp, nil
+}
+
+// Transcribe routes a speech-to-text request to the provider serving its model
+func (r *Router) Transcribe(ctx context.Context, req *TranscribeRequest) (*TranscribeResponse, error) {
+	if err := r.checkBudgets(); err != nil {
+		return nil, err
+	}
+	provider, err := r.resolveProvider(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := provider.(Transcriber)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not support transcription", ErrNotSupported, provider.Name())
+	}
+	return t.Transcribe(ctx, req)
+}
+
+// Synthesize routes a text-to-speech request to the provider serving its model
+func (r *Router) Synthesize(ctx context.Context, req *SpeechRequest) (*SpeechResponse, error) {
+	if err := r.checkBudgets(); err != nil {
+		return nil, err
+	}
+	provider, err := r.resolveProvider(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := provider.(Speaker)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not support speech synthesis", ErrNotSupported, provider.Name())
+	}
+	return s.Synthesize(ctx, req)
+}
+
 // resolveProvider finds the right provider for a model
 func (r *Router) resolveProvider(model string) (Provider, error) {
+	p, exp := r.resolve(model)
+	return p, exp.Err
+}
+
+// resolve finds the provider serving model and explains the decision. Rules
+// are tried in order: explicit model mapping, provider name, patterns in
+// registration order, then the providers' model lists by provider name.
+func (r *Router) resolve(model string) (Provider, RoutingExplanation) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	exp := RoutingExplanation{Model: model, Rule: RuleNone}
+	found := func(name string, rule RoutingRule, detail string) (Provider, RoutingExplanation) {
+		exp.Provider, exp.Rule, exp.Detail = name, rule, detail
+		return r.providers[name], exp
+	}
+
 	if len(r.providers) == 0 {
-		return nil, ErrNoProviders
+		exp.Err = ErrNoProviders
+		return nil, exp
+	}
+
+	if r.allowed != nil && !r.allowed[model] {
+		exp.Err = fmt.Errorf("%w: %s", ErrModelNotAllowed, model)
+		return nil, exp
 	}
 
 	// Check explicit model mapping first
 	if providerName, ok := r.modelMap[model]; ok {
-		if p, ok := r.providers[providerName]; ok {
-			return p, nil
+		if _, ok := r.providers[providerName]; ok {
+			return found(providerName, RuleModelMapping, "")
 		}
 	}
 
 	// Check if model name matches a provider name directly
-	if p, ok := r.providers[model]; ok {
-		return p, nil
+	if _, ok := r.providers[model]; ok {
+		return found(model, RuleProviderName, "")
+	}
+
+	// Check patterns in the order they were added
+	for _, mp := range r.patterns {
+		if ok, _ := path.Match(mp.pattern, model); ok {
+			if _, ok := r.providers[mp.provider]; ok {
+				return found(mp.provider, RulePattern, mp.pattern)
+			}
+		}
 	}
 
 	// Try each provider to see if it supports this model
-	for _, p := range r.providers {
-		for _, m := range p.Models() {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, m := range r.providers[name].Models() {
 			if m == model {
-				return p, nil
+				return found(name, RuleModelList, "")
 			}
 		}
 	}
 
-	return nil, fmt.Errorf("%w: %s", ErrUnknownModel, model)
+	exp.Err = fmt.Errorf("%w: %s", ErrUnknownModel, model)
+	return nil, exp
 }
 
 // buildChain wraps the provider with middleware
 func (r *Router) buildChain(provider Provider) Provider {
 	result := provider
+	// The provider timeout bounds each call beneath the middleware, so
+	// every retry attempt gets its own deadline
+	timeout, hasTimeout := r.timeouts[provider.Name()]
+	if hasTimeout {
+		result = &timeoutOverrideProvider{Provider: result, timeout: timeout}
+	}
 	// Apply middleware in reverse order so first middleware is outermost
 	for i := len(r.middleware) - 1; i >= 0; i-- {
 		result = r.middleware[i].Wrap(result)
 	}
+	if r.stripStops {
+		result = &stopStripProvider{Provider: result}
+	}
+	if r.errorOnEmpty {
+		result = &emptyCompletionProvider{Provider: result}
+	}
+	if hasTimeout {
+		result = &timeoutTagProvider{Provider: result, timeout: timeout}
+	}
+	if r.tokenBudget != nil || r.costBudget != nil {
+		var budgets []*budget
+		for _, b := range []*budget{r.tokenBudget, r.costBudget} {
+			if b != nil {
+				budgets = append(budgets, b)
+			}
+		}
+		result = &budgetProvider{Provider: result, budgets: budgets}
+	}
+	if r.stats != nil {
+		result = &statsProvider{Provider: result, counters: r.stats.get(provider.Name())}
+	}
 	return result
 }
 
@@ -139,9 +430,116 @@ func (r *Router) SetFallbacks(providers ...string) {
 	r.fallbacks = providers
 }
 
+// Validate checks that every model pattern is a well-formed glob, returning
+// ErrInvalidRequest otherwise, and that every model mapping, model pattern and
+// fallback names a registered provider, returning an ErrUnknownProvider error
+// listing all dangling references
+func (r *Router) Validate() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.patterns {
+		if _, err := path.Match(p.pattern, ""); err != nil {
+			return fmt.Errorf("%w: model pattern %q: %v", ErrInvalidRequest, p.pattern, err)
+		}
+	}
+
+	var dangling []string
+	models := make([]string, 0, len(r.modelMap))
+	for model := range r.modelMap {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		if name := r.modelMap[model]; r.providers[name] == nil {
+			dangling = append(dangling, fmt.Sprintf("model %q -> %q", model, name))
+		}
+	}
+	for _, p := range r.patterns {
+		if r.providers[p.provider] == nil {
+			dangling = append(dangling, fmt.Sprintf("pattern %q -> %q", p.pattern, p.provider))
+		}
+	}
+	for _, name := range r.fallbacks {
+		if r.providers[name] == nil {
+			dangling = append(dangling, fmt.Sprintf("fallback %q", name))
+		}
+	}
+
+	if len(dangling) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownProvider, strings.Join(dangling, ", "))
+	}
+	return nil
+}
+
 // AddMiddleware adds middleware to the router
 func (r *Router) AddMiddleware(m Middleware) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.middleware = append(r.middleware, m)
 }
+
+// Clone returns a copy of the router that can be reconfigured independently,
+// e.g. per tenant. Providers are shared, but the model map, fallbacks,
+// middleware and allow-list are copied, so changes to the clone don't affect
+// the original. Budgets are shared; apply WithFreshBudgets to separate them.
+func (r *Router) Clone() *Router {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c := &Router{
+		providers:        make(map[string]Provider, len(r.providers)),
+		modelMap:         make(map[string]string, len(r.modelMap)),
+		patterns:         append([]modelPattern(nil), r.patterns...),
+		fallbacks:        append([]string(nil), r.fallbacks...),
+		middleware:       append([]Middleware(nil), r.middleware...),
+		streamToComplete: r.streamToComplete,
+		defaultTools:     append([]Tool(nil), r.defaultTools...),
+		errorOnEmpty:     r.errorOnEmpty,
+		stripStops:       r.stripStops,
+		sessionKey:       r.sessionKey,
+		rewriters:        append([]RequestRewriter(nil), r.rewriters...),
+	}
+	if r.modelDefaults != nil {
+		c.modelDefaults = make(map[string]Request, len(r.modelDefaults))
+		for model, defaults := range r.modelDefaults {
+			c.modelDefaults[model] = defaults
+		}
+	}
+	for name, p := range r.providers {
+		c.providers[name] = p
+	}
+	for model, provider := range r.modelMap {
+		c.modelMap[model] = provider
+	}
+	if r.allowed != nil {
+		c.allowed = make(map[string]bool, len(r.allowed))
+		for m := range r.allowed {
+			c.allowed[m] = true
+		}
+	}
+	if r.timeouts != nil {
+		c.timeouts = make(map[string]time.Duration, len(r.timeouts))
+		for name, d := range r.timeouts {
+			c.timeouts[name] = d
+		}
+	}
+	if r.stats != nil {
+		// Clones count independently of the original
+		c.stats = newStatsRecorder()
+	}
+	// Clones spend from the same budgets unless given fresh ones
+	c.tokenBudget = r.tokenBudget
+	c.costBudget = r.costBudget
+	return c
+}
+
+// Apply applies options to the router, e.g. to customize a clone
+func (r *Router) Apply(opts ...Option) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
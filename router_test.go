@@ -0,0 +1,338 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// imageProvider is a stub that also generates images
+type imageProvider struct {
+	stubProvider
+	got *ImageRequest
+}
+
+func (p *imageProvider) GenerateImage(ctx context.Context, req *ImageRequest) (*ImageResponse, error) {
+	p.got = req
+	return &ImageResponse{Model: req.Model, Provider: p.Name(), Images: []Image{{URL: "https://img"}}}, nil
+}
+
+func TestGenerateImageRouting(t *testing.T) {
+	images := &imageProvider{stubProvider: stubProvider{name: "images", models: []string{"dall-e-3"}}}
+	text := &stubProvider{name: "text", models: []string{"chat-model"}}
+	r := New(WithProvider("images", images), WithProvider("text", text))
+
+	resp, err := r.GenerateImage(context.Background(), &ImageRequest{Model: "dall-e-3", Prompt: "a cat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if images.got == nil || images.got.Prompt != "a cat" || resp.Provider != "images" {
+		t.Errorf("request was not routed to the image provider: %+v", resp)
+	}
+
+	_, err = r.GenerateImage(context.Background(), &ImageRequest{Model: "chat-model", Prompt: "a cat"})
+	if !errors.Is(err, ErrNotSupported) {
+		t.Errorf("error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestAllowedModels(t *testing.T) {
+	stub := &stubProvider{models: []string{"approved", "other"}}
+	r := New(WithProvider("stub", stub), WithAllowedModels("approved"))
+
+	req := userRequest("hi")
+	req.Model = "approved"
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Errorf("allowed model: %v", err)
+	}
+
+	req.Model = "other"
+	if _, err := r.Complete(context.Background(), req); !errors.Is(err, ErrModelNotAllowed) {
+		t.Errorf("denied model: error = %v, want ErrModelNotAllowed", err)
+	}
+	if _, err := r.Stream(context.Background(), req); !errors.Is(err, ErrModelNotAllowed) {
+		t.Errorf("denied model stream: error = %v, want ErrModelNotAllowed", err)
+	}
+	if stub.callCount() != 1 {
+		t.Errorf("provider called %d times, want only for the allowed model", stub.callCount())
+	}
+}
+
+func TestAllowedModelsSkipsFallbacks(t *testing.T) {
+	primary := &stubProvider{name: "primary", models: []string{"approved"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, ErrProviderError
+	}}
+	denied := &stubProvider{name: "denied", model: "unapproved"}
+	allowed := &stubProvider{name: "allowed", model: "approved"}
+	r := New(
+		WithProvider("primary", primary),
+		WithProvider("denied", denied),
+		WithProvider("allowed", allowed),
+		WithFallback("denied", "allowed"),
+		WithAllowedModels("approved"),
+	)
+
+	req := userRequest("hi")
+	req.Model = "approved"
+	resp, err := r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != "allowed" {
+		t.Errorf("served by %q, want the allowed fallback", resp.Provider)
+	}
+	if denied.callCount() != 0 {
+		t.Error("fallback with a disallowed default model was tried")
+	}
+}
+
+func TestStreamFallbackToComplete(t *testing.T) {
+	stub := &stubProvider{
+		models: []string{"m"},
+		stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+			return nil, ErrProviderError
+		},
+		complete: func(ctx context.Context, req *Request) (*Response, error) {
+			return textResponse("stub", "from complete"), nil
+		},
+	}
+	req := userRequest("hi")
+	req.Model = "m"
+
+	r := New(WithProvider("stub", stub))
+	if _, err := r.Stream(context.Background(), req); !errors.Is(err, ErrProviderError) {
+		t.Errorf("without the option: error = %v, want the stream error", err)
+	}
+
+	r = New(WithProvider("stub", stub), WithStreamFallbackToComplete())
+	ch, err := r.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	if len(events) != 2 || events[0].Content != "from complete" || events[1].Type != EventDone {
+		t.Errorf("events = %+v, want one delta and done", events)
+	}
+}
+
+func TestStreamFallbackToCompleteReportsStreamError(t *testing.T) {
+	stub := &stubProvider{
+		models: []string{"m"},
+		stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+			return nil, ErrProviderError
+		},
+		complete: func(ctx context.Context, req *Request) (*Response, error) {
+			return nil, ErrRateLimited
+		},
+	}
+	r := New(WithProvider("stub", stub), WithStreamFallbackToComplete())
+
+	req := userRequest("hi")
+	req.Model = "m"
+	if _, err := r.Stream(context.Background(), req); !errors.Is(err, ErrProviderError) {
+		t.Errorf("error = %v, want the original stream error", err)
+	}
+}
+
+// tagMiddleware appends its tag to every response's content
+type tagMiddleware string
+
+func (m tagMiddleware) Wrap(next Provider) Provider {
+	return &tagProvider{Provider: next, tag: string(m)}
+}
+
+type tagProvider struct {
+	Provider
+	tag string
+}
+
+func (p *tagProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Choices[0].Message.Content += " " + p.tag
+	return resp, nil
+}
+
+func TestCloneIndependence(t *testing.T) {
+	a := &stubProvider{name: "a", models: []string{"model-a"}}
+	b := &stubProvider{name: "b", models: []string{"model-b"}}
+	base := New(WithProvider("a", a), WithProvider("b", b), WithMiddleware(tagMiddleware("base")))
+
+	clone := base.Clone()
+	clone.AddMiddleware(tagMiddleware("tenant"))
+	clone.Apply(WithModelMapping("shared", "b"), WithAllowedModels("shared"))
+	base.Apply(WithModelMapping("shared", "a"))
+
+	complete := func(r *Router, model string) (*Response, error) {
+		req := userRequest("hi")
+		req.Model = model
+		return r.Complete(context.Background(), req)
+	}
+
+	resp, err := complete(base, "shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != "a" || resp.Text() != "ok base" {
+		t.Errorf("base: served by %q with %q, want a with only its own middleware", resp.Provider, resp.Text())
+	}
+
+	resp, err = complete(clone, "shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != "b" || resp.Text() != "ok tenant base" {
+		t.Errorf("clone: served by %q with %q, want b with both middleware", resp.Provider, resp.Text())
+	}
+
+	if _, err := complete(clone, "model-a"); !errors.Is(err, ErrModelNotAllowed) {
+		t.Errorf("clone allow-list not applied: %v", err)
+	}
+	if _, err := complete(base, "model-a"); err != nil {
+		t.Errorf("clone allow-list leaked into the base: %v", err)
+	}
+}
+
+func TestModelPatternRouting(t *testing.T) {
+	r := New(
+		WithProvider("openai", &stubProvider{name: "openai"}),
+		WithProvider("anthropic", &stubProvider{name: "anthropic", models: []string{"gpt-4o-via-anthropic"}}),
+		WithProvider("azure", &stubProvider{name: "azure"}),
+		WithModelPattern("gpt-4o*", "azure"),
+		WithModelPattern("gpt-*", "openai"),
+		WithModelPattern("claude-*", "anthropic"),
+		WithModelMapping("gpt-4o-mini", "openai"),
+	)
+
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"gpt-3.5-turbo", "openai"},
+		{"claude-sonnet-4-0", "anthropic"},
+		// The first matching pattern wins
+		{"gpt-4o", "azure"},
+		// Exact mappings take precedence over patterns
+		{"gpt-4o-mini", "openai"},
+		// Patterns take precedence over provider model lists
+		{"gpt-4o-via-anthropic", "azure"},
+		// Provider names match directly
+		{"anthropic", "anthropic"},
+	}
+	for _, tt := range tests {
+		p, err := r.resolveProvider(tt.model)
+		if err != nil {
+			t.Errorf("%s: %v", tt.model, err)
+			continue
+		}
+		if p.Name() != tt.want {
+			t.Errorf("%s routed to %s, want %s", tt.model, p.Name(), tt.want)
+		}
+	}
+
+	if _, err := r.resolveProvider("llama3"); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("unmatched model: error = %v, want ErrUnknownModel", err)
+	}
+}
+
+func TestModelPatternValidate(t *testing.T) {
+	r := New(WithProvider("openai", &stubProvider{}), WithModelPattern("gpt-[", "openai"))
+	if err := r.Validate(); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("malformed pattern: error = %v, want ErrInvalidRequest", err)
+	}
+	// A malformed pattern never matches
+	if _, err := r.resolveProvider("gpt-["); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("error = %v, want ErrUnknownModel", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r := New(
+		WithProvider("openai", &stubProvider{}),
+		WithProvider("anthropic", &stubProvider{}),
+		WithModelMapping("gpt-4o", "openai"),
+		WithModelPattern("claude-*", "anthropic"),
+		WithFallback("openai", "anthropic"),
+	)
+	if err := r.Validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+}
+
+func TestValidateDangling(t *testing.T) {
+	r := New(
+		WithProvider("openai", &stubProvider{}),
+		WithModelMapping("gpt-4o", "openai"),
+		WithModelMapping("gemini-pro", "gemini"),
+		WithModelMapping("claude-3", "anthropic"),
+		WithModelPattern("mistral-*", "mistral"),
+		WithFallback("openai", "groq"),
+	)
+	err := r.Validate()
+	if !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("error = %v, want ErrUnknownProvider", err)
+	}
+	want := `unknown provider: model "claude-3" -> "anthropic", model "gemini-pro" -> "gemini", pattern "mistral-*" -> "mistral", fallback "groq"`
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+}
+
+// toolNamed builds a function tool with a name and description
+func toolNamed(name, description string) Tool {
+	return Tool{Type: "function", Function: Function{Name: name, Description: description}}
+}
+
+func TestDefaultTools(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}}
+	r := New(
+		WithProvider("stub", stub),
+		WithDefaultTools(toolNamed("calculator", "default"), toolNamed("clock", "default")),
+	)
+
+	req := userRequest("hi")
+	req.Model = "m"
+	req.Tools = []Tool{toolNamed("calculator", "request"), toolNamed("search", "request")}
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := stub.lastCall().Tools
+	want := []Tool{toolNamed("calculator", "request"), toolNamed("search", "request"), toolNamed("clock", "default")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tools = %+v, want %+v", got, want)
+	}
+	if len(req.Tools) != 2 {
+		t.Errorf("caller's tools = %+v, want unchanged", req.Tools)
+	}
+
+	// Streams get them too, and a request without tools gets all defaults
+	stream := userRequest("hi")
+	stream.Model = "m"
+	ch, err := r.Stream(context.Background(), stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+	if got := stub.lastCall().Tools; len(got) != 2 || got[0].Function.Name != "calculator" || got[1].Function.Name != "clock" {
+		t.Errorf("stream tools = %+v, want both defaults", got)
+	}
+}
+
+func TestDefaultToolsAllDefined(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}}
+	r := New(WithProvider("stub", stub), WithDefaultTools(toolNamed("calculator", "default")))
+
+	req := userRequest("hi")
+	req.Model = "m"
+	req.Tools = []Tool{toolNamed("calculator", "request")}
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := stub.lastCall(); got != req {
+		t.Errorf("request was copied although no default tool was added")
+	}
+}
@@ -0,0 +1,136 @@
+package llmrouter
+
+import (
+	"context"
+	"sort"
+)
+
+// AsProvider adapts the router to the Provider interface under the given
+// name, so routers can be nested inside other routers or wrapped by
+// middleware. Models are aggregated from the registered providers and model
+// mappings, and tools are supported if any provider supports them. Image,
+// transcription, speech and embedding requests are routed as well.
+//
+// Like other providers, requests for the model "" (e.g. from a fallback) or
+// for the provider's own name use a default: the router's first fallback
+// provider. Use AsProviderWithModel to choose the default model instead.
+func (r *Router) AsProvider(name string) Provider {
+	return &routerProvider{Router: r, name: name}
+}
+
+// AsProviderWithModel is like AsProvider, with defaultModel routed when a
+// request names no model or the provider itself
+func (r *Router) AsProviderWithModel(name, defaultModel string) Provider {
+	return &routerProvider{Router: r, name: name, model: defaultModel}
+}
+
+// routerProvider exposes a Router as a Provider. Complete, Stream and the
+// optional provider methods come from the embedded Router, after mapping the
+// default model.
+type routerProvider struct {
+	*Router
+	name  string
+	model string // default model; the first fallback if empty
+}
+
+func (p *routerProvider) Name() string {
+	return p.name
+}
+
+// resolveModel maps "" and the provider's own name to the default model
+func (p *routerProvider) resolveModel(model string) string {
+	if model != "" && model != p.name {
+		return model
+	}
+	if p.model != "" {
+		return p.model
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.fallbacks) > 0 {
+		return p.fallbacks[0]
+	}
+	return model
+}
+
+func (p *routerProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	if model := p.resolveModel(req.Model); model != req.Model {
+		mapped := *req
+		mapped.Model = model
+		req = &mapped
+	}
+	return p.Router.Complete(ctx, req)
+}
+
+func (p *routerProvider) Stream(ctx context.Context, req *Request) (<-chan Event, error) {
+	if model := p.resolveModel(req.Model); model != req.Model {
+		mapped := *req
+		mapped.Model = model
+		req = &mapped
+	}
+	return p.Router.Stream(ctx, req)
+}
+
+func (p *routerProvider) GenerateImage(ctx context.Context, req *ImageRequest) (*ImageResponse, error) {
+	mapped := *req
+	mapped.Model = p.resolveModel(req.Model)
+	return p.Router.GenerateImage(ctx, &mapped)
+}
+
+func (p *routerProvider) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	mapped := *req
+	mapped.Model = p.resolveModel(req.Model)
+	return p.Router.Embed(ctx, &mapped)
+}
+
+func (p *routerProvider) Transcribe(ctx context.Context, req *TranscribeRequest) (*TranscribeResponse, error) {
+	mapped := *req
+	mapped.Model = p.resolveModel(req.Model)
+	return p.Router.Transcribe(ctx, &mapped)
+}
+
+func (p *routerProvider) Synthesize(ctx context.Context, req *SpeechRequest) (*SpeechResponse, error) {
+	mapped := *req
+	mapped.Model = p.resolveModel(req.Model)
+	return p.Router.Synthesize(ctx, &mapped)
+}
+
+// DefaultModel returns the configured default model, if any
+func (p *routerProvider) DefaultModel() string {
+	return p.model
+}
+
+func (p *routerProvider) Models() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	for model := range p.modelMap {
+		seen[model] = true
+	}
+	for _, provider := range p.providers {
+		for _, model := range provider.Models() {
+			seen[model] = true
+		}
+	}
+
+	models := make([]string, 0, len(seen))
+	for model := range seen {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+func (p *routerProvider) SupportsTools() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, provider := range p.providers {
+		if provider.SupportsTools() {
+			return true
+		}
+	}
+	return false
+}
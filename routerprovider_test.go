@@ -0,0 +1,143 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// noToolsProvider is a stub without tool support
+type noToolsProvider struct {
+	stubProvider
+}
+
+func (p *noToolsProvider) SupportsTools() bool { return false }
+
+func TestNestedRouter(t *testing.T) {
+	a := &stubProvider{name: "a", models: []string{"a-model"}}
+	b := &stubProvider{name: "b", models: []string{"b-model"}}
+	inner := New(WithProvider("a", a), WithProvider("b", b), WithModelMapping("alias", "b"))
+
+	direct := &stubProvider{name: "direct", models: []string{"direct-model"}}
+	outer := New(WithProvider("inner", inner.AsProvider("inner")), WithProvider("direct", direct))
+
+	for _, tt := range []struct {
+		model string
+		stub  *stubProvider
+	}{
+		{"a-model", a},
+		{"b-model", b},
+		{"alias", b},
+		{"direct-model", direct},
+	} {
+		req := userRequest("hi")
+		req.Model = tt.model
+		resp, err := outer.Complete(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.model, err)
+		}
+		if resp.Provider != tt.stub.Name() {
+			t.Errorf("%s completed by %q, want %q", tt.model, resp.Provider, tt.stub.Name())
+		}
+	}
+
+	req := userRequest("hi")
+	req.Model = "b-model"
+	ch, err := outer.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(ch)
+	if done := events[len(events)-1]; done.Type != EventDone || done.Response.Provider != "b" {
+		t.Errorf("stream ended with %+v, want b's done event", done)
+	}
+
+	req.Model = "missing"
+	if _, err := outer.Complete(context.Background(), req); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("unknown model: err = %v, want ErrUnknownModel", err)
+	}
+}
+
+func TestRouterProviderMetadata(t *testing.T) {
+	inner := New(
+		WithProvider("a", &noToolsProvider{stubProvider{name: "a", models: []string{"z-model", "a-model"}}}),
+		WithProvider("b", &noToolsProvider{stubProvider{name: "b", models: []string{"a-model"}}}),
+		WithModelMapping("alias", "b"),
+	)
+	p := inner.AsProvider("inner")
+
+	if p.Name() != "inner" {
+		t.Errorf("Name = %q, want inner", p.Name())
+	}
+	if want := []string{"a-model", "alias", "z-model"}; !slices.Equal(p.Models(), want) {
+		t.Errorf("Models = %v, want %v", p.Models(), want)
+	}
+	if p.SupportsTools() {
+		t.Error("SupportsTools = true, but no provider supports tools")
+	}
+
+	inner.RegisterProvider("c", &stubProvider{name: "c"})
+	if !p.SupportsTools() {
+		t.Error("SupportsTools = false, but a provider supports tools")
+	}
+}
+
+func TestRouterProviderDefaultModel(t *testing.T) {
+	a := &stubProvider{name: "a", models: []string{"a-model"}}
+	b := &stubProvider{name: "b", models: []string{"b-model"}}
+	inner := New(WithProvider("a", a), WithProvider("b", b), WithFallback("b"))
+
+	tests := []struct {
+		name     string
+		provider Provider
+		model    string
+		want     string
+	}{
+		{"empty model uses the first fallback", inner.AsProvider("inner"), "", "b"},
+		{"own name uses the first fallback", inner.AsProvider("inner"), "inner", "b"},
+		{"configured default", inner.AsProviderWithModel("inner", "a-model"), "", "a"},
+		{"configured default for own name", inner.AsProviderWithModel("inner", "a-model"), "inner", "a"},
+		{"explicit model", inner.AsProviderWithModel("inner", "b-model"), "a-model", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := userRequest("hi")
+			req.Model = tt.model
+			resp, err := tt.provider.Complete(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Provider != tt.want {
+				t.Errorf("completed by %q, want %q", resp.Provider, tt.want)
+			}
+			if req.Model != tt.model {
+				t.Errorf("caller's model changed to %q", req.Model)
+			}
+		})
+	}
+}
+
+func TestNestedRouterAsFallback(t *testing.T) {
+	broken := &stubProvider{name: "broken", models: []string{"m"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		return nil, ErrProviderError
+	}}
+	backup := &stubProvider{name: "backup", models: []string{"backup-model"}}
+	inner := New(WithProvider("backup", backup))
+
+	outer := New(
+		WithProvider("broken", broken),
+		WithProvider("inner", inner.AsProviderWithModel("inner", "backup-model")),
+		WithFallback("inner"),
+	)
+
+	req := userRequest("hi")
+	req.Model = "m"
+	resp, err := outer.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != "backup" || backup.lastCall().Model != "backup-model" {
+		t.Errorf("fallback completed by %q with model %q, want backup with backup-model", resp.Provider, backup.lastCall().Model)
+	}
+}
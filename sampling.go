@@ -0,0 +1,40 @@
+package llmrouter
+
+import "fmt"
+
+// TemperatureRange is the inclusive temperature range a provider accepts
+type TemperatureRange struct {
+	Min float64
+	Max float64
+}
+
+// ClampFunc is called when a temperature is clamped into a provider's range
+type ClampFunc func(requested, clamped float64)
+
+// CheckTemperature validates req.Temperature against rng. Out-of-range values
+// return ErrInvalidRequest, unless onClamp is non-nil, in which case the value
+// is clamped on a copy of the request and onClamp is notified.
+func CheckTemperature(req *Request, rng TemperatureRange, onClamp ClampFunc) (*Request, error) {
+	if req.Temperature == nil {
+		return req, nil
+	}
+
+	t := *req.Temperature
+	if t >= rng.Min && t <= rng.Max {
+		return req, nil
+	}
+
+	if onClamp == nil {
+		return nil, fmt.Errorf("%w: temperature %g outside [%g, %g]", ErrInvalidRequest, t, rng.Min, rng.Max)
+	}
+
+	clamped := rng.Min
+	if t > rng.Max {
+		clamped = rng.Max
+	}
+	onClamp(t, clamped)
+
+	c := *req
+	c.Temperature = &clamped
+	return &c, nil
+}
@@ -0,0 +1,59 @@
+package llmrouter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckTemperature(t *testing.T) {
+	rng := TemperatureRange{Min: 0, Max: 1}
+	tests := []struct {
+		name    string
+		temp    *float64
+		clamp   bool
+		want    *float64
+		wantErr bool
+	}{
+		{"unset", nil, false, nil, false},
+		{"in range", floatPtr(0.7), false, floatPtr(0.7), false},
+		{"upper bound", floatPtr(1), false, floatPtr(1), false},
+		{"too high rejected", floatPtr(1.5), false, nil, true},
+		{"negative rejected", floatPtr(-0.1), false, nil, true},
+		{"too high clamped", floatPtr(1.5), true, floatPtr(1), false},
+		{"negative clamped", floatPtr(-0.1), true, floatPtr(0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := userRequest("hi")
+			req.Temperature = tt.temp
+
+			var clamps [][2]float64
+			var onClamp ClampFunc
+			if tt.clamp {
+				onClamp = func(requested, clamped float64) {
+					clamps = append(clamps, [2]float64{requested, clamped})
+				}
+			}
+
+			got, err := CheckTemperature(req, rng, onClamp)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRequest) {
+					t.Errorf("error = %v, want ErrInvalidRequest", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got.Temperature == nil) != (tt.want == nil) || (tt.want != nil && *got.Temperature != *tt.want) {
+				t.Errorf("temperature = %v, want %v", got.Temperature, tt.want)
+			}
+			if tt.clamp && (len(clamps) != 1 || clamps[0] != [2]float64{*tt.temp, *tt.want}) {
+				t.Errorf("clamp callbacks = %v", clamps)
+			}
+			if tt.temp != nil && *req.Temperature != *tt.temp {
+				t.Error("input request was modified")
+			}
+		})
+	}
+}
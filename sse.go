@@ -0,0 +1,84 @@
+package llmrouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// LastEventID returns the event ID a reconnecting client last saw, from its
+// Last-Event-ID header, or 0 if there is none
+func LastEventID(r *http.Request) int {
+	id, err := strconv.Atoi(strings.TrimSpace(r.Header.Get("Last-Event-ID")))
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// WriteSSE writes events to w as server-sent events until the channel closes
+// or the client goes away. Each event is sent as "event: <type>" with its
+// JSON as data and an incrementing id, starting at 1. Events numbered up to
+// and including LastEventID(r) are skipped, so a browser reconnecting to a
+// replayed stream picks up where it left off. That only works if the replay
+// is chunked the same way, e.g. StreamFromResponse over a cached response.
+//
+// Heartbeats are sent as comments and usage updates without an id, since
+// neither repeats reliably on replay.
+func WriteSSE(w http.ResponseWriter, r *http.Request, events <-chan Event) error {
+	// Drain so the producer isn't blocked if we stop early
+	defer func() {
+		for range events {
+		}
+	}()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	flusher, _ := w.(http.Flusher)
+
+	skip := LastEventID(r)
+	id := 0
+	for {
+		var event Event
+		var ok bool
+		select {
+		case event, ok = <-events:
+			if !ok {
+				return nil
+			}
+		case <-r.Context().Done():
+			return r.Context().Err()
+		}
+
+		var frame string
+		switch event.Type {
+		case EventHeartbeat:
+			frame = ": heartbeat\n\n"
+
+		default:
+			data, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("encoding %s event: %w", event.Type, err)
+			}
+			frame = fmt.Sprintf("event: %s\ndata: %s\n\n", event.Type, data)
+			if event.Type != EventUsageUpdate {
+				id++
+				if id <= skip {
+					continue
+				}
+				frame = fmt.Sprintf("id: %d\n", id) + frame
+			}
+		}
+
+		if _, err := w.Write([]byte(frame)); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sseFrames splits a recorded SSE body into its frames
+func sseFrames(body string) []string {
+	return strings.Split(strings.TrimSuffix(body, "\n\n"), "\n\n")
+}
+
+// sseIDs returns the id of each numbered frame in body
+func sseIDs(body string) []string {
+	var ids []string
+	for _, f := range sseFrames(body) {
+		if id, ok := strings.CutPrefix(f, "id: "); ok {
+			ids = append(ids, id[:strings.IndexByte(id, '\n')])
+		}
+	}
+	return ids
+}
+
+func TestWriteSSEEventIDs(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/stream", nil)
+	events := eventStream(
+		Event{Type: EventContentDelta, Content: "a"},
+		Event{Type: EventContentDelta, Content: "b"},
+		Event{Type: EventDone, Response: textResponse("stub", "ab")},
+	)
+	if err := WriteSSE(w, r, events); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+	frames := sseFrames(w.Body.String())
+	if len(frames) != 3 {
+		t.Fatalf("frames = %q, want 3", frames)
+	}
+	for i, want := range []string{"id: 1\nevent: content_delta\n", "id: 2\nevent: content_delta\n", "id: 3\nevent: done\n"} {
+		if !strings.HasPrefix(frames[i], want) {
+			t.Errorf("frame %d = %q, want prefix %q", i, frames[i], want)
+		}
+	}
+	if !strings.Contains(frames[1], `"content":"b"`) {
+		t.Errorf("frame 1 = %q, want the event JSON as data", frames[1])
+	}
+}
+
+func TestWriteSSEResumesFromLastEventID(t *testing.T) {
+	cached := textResponse("stub", "one two three")
+
+	first := httptest.NewRecorder()
+	if err := WriteSSE(first, httptest.NewRequest("GET", "/stream", nil), StreamFromResponse(cached, ChunkByWord())); err != nil {
+		t.Fatal(err)
+	}
+	full := sseFrames(first.Body.String())
+
+	// The browser saw the first two words before the connection dropped
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/stream", nil)
+	r.Header.Set("Last-Event-ID", "2")
+	if err := WriteSSE(w, r, StreamFromResponse(cached, ChunkByWord())); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed := sseFrames(w.Body.String())
+	if len(resumed) != 2 || resumed[0] != full[2] || resumed[1] != full[3] {
+		t.Errorf("resumed frames = %q, want %q", resumed, full[2:])
+	}
+	if ids := sseIDs(w.Body.String()); strings.Join(ids, ",") != "3,4" {
+		t.Errorf("resumed ids = %v, want the numbering kept", ids)
+	}
+}
+
+func TestLastEventID(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", 0},
+		{"7", 7},
+		{" 7 ", 7},
+		{"-1", 0},
+		{"abc", 0},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/stream", nil)
+		if tt.header != "" {
+			r.Header.Set("Last-Event-ID", tt.header)
+		}
+		if got := LastEventID(r); got != tt.want {
+			t.Errorf("LastEventID(%q) = %d, want %d", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSSEUsageUpdatesUnnumbered(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/stream", nil)
+	events := eventStream(
+		Event{Type: EventContentDelta, Content: "a"},
+		Event{Type: EventUsageUpdate, Usage: &Usage{CompletionTokens: 1}},
+		Event{Type: EventContentDelta, Content: "b"},
+	)
+	if err := WriteSSE(w, r, events); err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sseFrames(w.Body.String())
+	if len(frames) != 3 || !strings.HasPrefix(frames[1], "event: usage_update\n") {
+		t.Fatalf("frames = %q, want the usage update without an id", frames)
+	}
+	if ids := sseIDs(w.Body.String()); strings.Join(ids, ",") != "1,2" {
+		t.Errorf("ids = %v, want 1,2", ids)
+	}
+}
+
+func TestWriteSSEClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest("GET", "/stream", nil).WithContext(ctx)
+
+	const n = 1000
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for i := 0; i < n; i++ {
+			events <- Event{Type: EventContentDelta, Content: "a"}
+		}
+	}()
+
+	// Returns once it notices the cancellation, and drains the rest so the
+	// producer can finish
+	w := httptest.NewRecorder()
+	if err := WriteSSE(w, r, events); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if got := strings.Count(w.Body.String(), "event: "); got == n {
+		t.Errorf("wrote all %d events to a client that went away", got)
+	}
+}
+
+func TestWriteSSEHeartbeat(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/stream", nil)
+	events := eventStream(
+		Event{Type: EventContentDelta, Content: "a"},
+		Event{Type: EventHeartbeat},
+		Event{Type: EventContentDelta, Content: "b"},
+	)
+	if err := WriteSSE(w, r, events); err != nil {
+		t.Fatal(err)
+	}
+
+	frames := sseFrames(w.Body.String())
+	if len(frames) != 3 || frames[1] != ": heartbeat" {
+		t.Fatalf("frames = %q, want the heartbeat as a comment between the deltas", frames)
+	}
+	if !strings.HasPrefix(frames[2], "id: 2\n") {
+		t.Errorf("frame after the heartbeat = %q, want id 2 since heartbeats aren't numbered", frames[2])
+	}
+}
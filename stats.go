@@ -0,0 +1,141 @@
+package llmrouter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ProviderStats holds aggregate counters for a provider
+type ProviderStats struct {
+	Requests         int64
+	Errors           int64
+	PromptTokens     int64
+	CompletionTokens int64
+	TotalTokens      int64
+}
+
+// statsRecorder maintains per-provider counters. It is safe for concurrent use.
+type statsRecorder struct {
+	mu       sync.RWMutex
+	counters map[string]*providerCounters
+}
+
+type providerCounters struct {
+	requests         atomic.Int64
+	errors           atomic.Int64
+	promptTokens     atomic.Int64
+	completionTokens atomic.Int64
+	totalTokens      atomic.Int64
+}
+
+func newStatsRecorder() *statsRecorder {
+	return &statsRecorder{counters: make(map[string]*providerCounters)}
+}
+
+func (s *statsRecorder) get(provider string) *providerCounters {
+	s.mu.RLock()
+	c, ok := s.counters[provider]
+	s.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.counters[provider]; ok {
+		return c
+	}
+	c = &providerCounters{}
+	s.counters[provider] = c
+	return c
+}
+
+func (c *providerCounters) record(usage *Usage, err error) {
+	c.requests.Add(1)
+	if err != nil {
+		c.errors.Add(1)
+		return
+	}
+	if usage != nil {
+		c.promptTokens.Add(int64(usage.PromptTokens))
+		c.completionTokens.Add(int64(usage.CompletionTokens))
+		c.totalTokens.Add(int64(usage.TotalTokens))
+	}
+}
+
+func (s *statsRecorder) snapshot() map[string]ProviderStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]ProviderStats, len(s.counters))
+	for name, c := range s.counters {
+		result[name] = ProviderStats{
+			Requests:         c.requests.Load(),
+			Errors:           c.errors.Load(),
+			PromptTokens:     c.promptTokens.Load(),
+			CompletionTokens: c.completionTokens.Load(),
+			TotalTokens:      c.totalTokens.Load(),
+		}
+	}
+	return result
+}
+
+// Stats returns a snapshot of per-provider counters, keyed by provider name.
+// It returns nil unless the router was created with WithStats.
+func (r *Router) Stats() map[string]ProviderStats {
+	if r.stats == nil {
+		return nil
+	}
+	return r.stats.snapshot()
+}
+
+// statsProvider records request outcomes and token usage for the provider it
+// wraps. Streams that end without reported usage are counted by estimate.
+type statsProvider struct {
+	Provider
+	counters *providerCounters
+}
+
+func (p *statsProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil {
+		p.counters.record(nil, err)
+		return nil, err
+	}
+	p.counters.record(resp.Usage, nil)
+	return resp, nil
+}
+
+func (p *statsProvider) Stream(ctx context.Context, req *Request) (<-chan Event, error) {
+	ch, err := p.Provider.Stream(ctx, req)
+	if err != nil {
+		p.counters.record(nil, err)
+		return nil, err
+	}
+
+	tally := newStreamTally(req)
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		for event := range ch {
+			tally.add(event)
+			switch event.Type {
+			case EventDone:
+				p.counters.record(tally.usage(event.Response), nil)
+			case EventError:
+				p.counters.record(nil, event.Error)
+			}
+			select {
+			case outCh <- event:
+			case <-ctx.Done():
+				go func() {
+					for range ch {
+					}
+				}()
+				return
+			}
+		}
+	}()
+	return outCh, nil
+}
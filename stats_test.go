@@ -0,0 +1,115 @@
+package llmrouter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	usage := &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+	fail := false
+	ok := &stubProvider{name: "ok", models: []string{"ok-model"}, complete: func(ctx context.Context, req *Request) (*Response, error) {
+		if fail {
+			return nil, ErrProviderError
+		}
+		resp := textResponse("ok", "hi")
+		resp.Usage = usage
+		return resp, nil
+	}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		resp := textResponse("ok", "hi")
+		resp.Usage = usage
+		return eventStream(Event{Type: EventContentDelta, Content: "hi"}, Event{Type: EventDone, Response: resp}), nil
+	}}
+	broken := &stubProvider{name: "broken", models: []string{"broken-model"}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return eventStream(Event{Type: EventError, Error: ErrProviderError}), nil
+	}}
+	r := New(WithProvider("ok", ok), WithProvider("broken", broken), WithStats())
+
+	run := func(model string, stream bool) {
+		req := userRequest("hi")
+		req.Model = model
+		if stream {
+			ch, err := r.Stream(context.Background(), req)
+			if err == nil {
+				collect(ch)
+			}
+			return
+		}
+		_, _ = r.Complete(context.Background(), req)
+	}
+	run("ok-model", false)
+	run("ok-model", true)
+	fail = true
+	run("ok-model", false)
+	run("broken-model", true)
+
+	stats := r.Stats()
+	want := ProviderStats{Requests: 3, Errors: 1, PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30}
+	if stats["ok"] != want {
+		t.Errorf("ok stats = %+v, want %+v", stats["ok"], want)
+	}
+	if got := stats["broken"]; got != (ProviderStats{Requests: 1, Errors: 1}) {
+		t.Errorf("broken stats = %+v, want one failed request", got)
+	}
+}
+
+func TestStatsDisabled(t *testing.T) {
+	r := New(WithProvider("stub", &stubProvider{}))
+	if r.Stats() != nil {
+		t.Error("Stats returned counters without WithStats")
+	}
+}
+
+func TestStatsEstimateUnreportedStreamUsage(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return eventStream(
+			Event{Type: EventContentDelta, Content: "twelve chars"},
+			Event{Type: EventDone, Response: textResponse("stub", "twelve chars")},
+		), nil
+	}}
+	r := New(WithProvider("stub", stub), WithStats())
+
+	req := modelRequest()
+	req.Messages = []Message{{Role: RoleUser, Content: "hello"}}
+	ch, err := r.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collect(ch)
+
+	want := ProviderStats{Requests: 1, PromptTokens: 2, CompletionTokens: 3, TotalTokens: 5}
+	if got := r.Stats()["stub"]; got != want {
+		t.Errorf("stats = %+v, want %+v estimated from the stream", got, want)
+	}
+}
+
+func TestStatsStreamCanceled(t *testing.T) {
+	finished := make(chan struct{})
+	stub := &stubProvider{models: []string{"m"}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		ch := make(chan Event)
+		go func() {
+			defer close(finished)
+			defer close(ch)
+			for i := 0; i < 3; i++ {
+				ch <- Event{Type: EventContentDelta, Content: "x"}
+			}
+		}()
+		return ch, nil
+	}}
+	r := New(WithProvider("stub", stub), WithStats())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out, err := r.Stream(ctx, modelRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-out
+	cancel()
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("provider stream not drained after the caller canceled")
+	}
+}
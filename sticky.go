@@ -0,0 +1,83 @@
+package llmrouter
+
+import (
+	"hash/fnv"
+	"path"
+)
+
+// resolveForRequest resolves the provider for req. With sticky routing and
+// a non-empty session key, the provider is chosen among every provider that
+// can serve the model by rendezvous hashing on the key, so a session keeps
+// hitting the same provider and only moves if that provider is removed. An
+// explicit model mapping always decides the provider.
+func (r *Router) resolveForRequest(req *Request) (Provider, error) {
+	provider, err := r.resolveProvider(req.Model)
+	if err != nil || r.sessionKey == nil || r.mapped(req.Model) {
+		return provider, err
+	}
+
+	key := r.sessionKey(req)
+	if key == "" {
+		return provider, nil
+	}
+
+	var best Provider
+	var bestScore uint64
+	for _, p := range r.candidateProviders(req.Model) {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(p.Name()))
+		if score := h.Sum64(); best == nil || score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	if best == nil {
+		return provider, nil
+	}
+	return best, nil
+}
+
+// mapped reports whether model has an explicit mapping to a registered provider
+func (r *Router) mapped(model string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	name, ok := r.modelMap[model]
+	if !ok {
+		return false
+	}
+	_, ok = r.providers[name]
+	return ok
+}
+
+// candidateProviders returns every registered provider that can serve model,
+// through its name, a pattern or its model list
+func (r *Router) candidateProviders(model string) []Provider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var result []Provider
+	add := func(name string) {
+		if p, ok := r.providers[name]; ok && !seen[name] {
+			seen[name] = true
+			result = append(result, p)
+		}
+	}
+
+	add(model)
+	for _, mp := range r.patterns {
+		if ok, _ := path.Match(mp.pattern, model); ok {
+			add(mp.provider)
+		}
+	}
+	for name, p := range r.providers {
+		for _, m := range p.Models() {
+			if m == model {
+				add(name)
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package llmrouter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// stickyRouter returns a router with the named providers all serving
+// "shared", pinning sessions by conversation ID
+func stickyRouter(names ...string) *Router {
+	opts := []Option{WithStickyRouting(func(req *Request) string {
+		return req.MetadataString(MetadataConversationID)
+	})}
+	for _, name := range names {
+		opts = append(opts, WithProvider(name, &stubProvider{name: name, models: []string{"shared"}}))
+	}
+	return New(opts...)
+}
+
+// sessionRequest builds a request for "shared" in the given session
+func sessionRequest(session string) *Request {
+	req := userRequest("hi")
+	req.Model = "shared"
+	if session != "" {
+		req.Metadata = map[string]any{MetadataConversationID: session}
+	}
+	return req
+}
+
+// servedBy returns the provider that completed req
+func servedBy(t *testing.T, r *Router, req *Request) string {
+	t.Helper()
+	resp, err := r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Provider
+}
+
+func TestStickyRoutingSameSession(t *testing.T) {
+	r := stickyRouter("a", "b", "c")
+
+	used := make(map[string]bool)
+	for i := 0; i < 30; i++ {
+		session := fmt.Sprintf("session-%d", i)
+		first := servedBy(t, r, sessionRequest(session))
+		used[first] = true
+		for turn := 0; turn < 5; turn++ {
+			if got := servedBy(t, r, sessionRequest(session)); got != first {
+				t.Fatalf("%s turn %d went to %s, want %s", session, turn, got, first)
+			}
+		}
+
+		ch, err := r.Stream(context.Background(), sessionRequest(session))
+		if err != nil {
+			t.Fatal(err)
+		}
+		events := collect(ch)
+		if done := events[len(events)-1]; done.Response == nil || done.Response.Provider != first {
+			t.Errorf("%s stream went to %+v, want %s", session, done.Response, first)
+		}
+	}
+	if len(used) < 2 {
+		t.Errorf("30 sessions all went to %v, want them spread across providers", used)
+	}
+}
+
+func TestStickyRoutingStableWhenProviderRemoved(t *testing.T) {
+	full := stickyRouter("a", "b", "c")
+	reduced := stickyRouter("a", "b")
+
+	for i := 0; i < 30; i++ {
+		req := sessionRequest(fmt.Sprintf("session-%d", i))
+		before := servedBy(t, full, req)
+		if before == "c" {
+			continue
+		}
+		if after := servedBy(t, reduced, req); after != before {
+			t.Errorf("%s moved from %s to %s when c was removed", req.MetadataString(MetadataConversationID), before, after)
+		}
+	}
+}
+
+func TestStickyRoutingWithoutKey(t *testing.T) {
+	sticky := stickyRouter("a", "b", "c")
+	plain := New(
+		WithProvider("a", &stubProvider{name: "a", models: []string{"shared"}}),
+		WithProvider("b", &stubProvider{name: "b", models: []string{"shared"}}),
+		WithProvider("c", &stubProvider{name: "c", models: []string{"shared"}}),
+	)
+
+	want := servedBy(t, plain, sessionRequest(""))
+	for i := 0; i < 5; i++ {
+		if got := servedBy(t, sticky, sessionRequest("")); got != want {
+			t.Errorf("request without a session went to %s, want the usual %s", got, want)
+		}
+	}
+}
+
+func TestStickyRoutingSingleCandidate(t *testing.T) {
+	r := New(
+		WithProvider("only", &stubProvider{name: "only", models: []string{"shared"}}),
+		WithProvider("other", &stubProvider{name: "other", models: []string{"different"}}),
+		WithStickyRouting(func(req *Request) string { return req.MetadataString(MetadataConversationID) }),
+	)
+	for i := 0; i < 10; i++ {
+		if got := servedBy(t, r, sessionRequest(fmt.Sprintf("session-%d", i))); got != "only" {
+			t.Errorf("session %d went to %s, want the only provider serving the model", i, got)
+		}
+	}
+}
+
+func TestStickyRoutingKeepsModelMapping(t *testing.T) {
+	r := New(
+		WithProvider("a", &stubProvider{name: "a", models: []string{"shared"}}),
+		WithProvider("b", &stubProvider{name: "b", models: []string{"shared"}}),
+		WithProvider("c", &stubProvider{name: "c", models: []string{"shared"}}),
+		WithModelMapping("shared", "b"),
+		WithStickyRouting(func(req *Request) string { return req.MetadataString(MetadataConversationID) }),
+	)
+
+	for i := 0; i < 10; i++ {
+		if got := servedBy(t, r, sessionRequest(fmt.Sprintf("session-%d", i))); got != "b" {
+			t.Errorf("session %d went to %s, want the mapped provider b", i, got)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package llmrouter
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// trimStop removes a stop sequence from the end of s, if one is there
+func trimStop(s string, stop []string) string {
+	for _, seq := range stop {
+		if seq != "" && strings.HasSuffix(s, seq) {
+			return strings.TrimSuffix(s, seq)
+		}
+	}
+	return s
+}
+
+// stopStripProvider strips a trailing stop sequence from the reply, since
+// some providers include the matched sequence in the content and others don't
+type stopStripProvider struct {
+	Provider
+}
+
+func (p *stopStripProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err != nil || len(req.Stop) == 0 {
+		return resp, err
+	}
+
+	stripStops(resp, req.Stop)
+	return resp, nil
+}
+
+// stripStops strips a trailing stop sequence from every choice
+func stripStops(resp *Response, stop []string) {
+	if resp == nil {
+		return
+	}
+	for i := range resp.Choices {
+		if msg := resp.Choices[i].Message; msg != nil {
+			msg.SetContent(trimStop(msg.Content, stop))
+		}
+	}
+}
+
+// Stream holds back the tail of each choice's content that could still turn
+// out to be a trailing stop sequence, and releases it, stripped, once the
+// stream completes
+func (p *stopStripProvider) Stream(ctx context.Context, req *Request) (<-chan Event, error) {
+	ch, err := p.Provider.Stream(ctx, req)
+	if err != nil || len(req.Stop) == 0 {
+		return ch, err
+	}
+
+	longest := 0
+	for _, seq := range req.Stop {
+		longest = max(longest, len(seq))
+	}
+
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+
+		send := func(event Event) bool {
+			select {
+			case outCh <- event:
+				return true
+			case <-ctx.Done():
+				go func() {
+					for range ch {
+					}
+				}()
+				return false
+			}
+		}
+
+		// Content not yet sent, per choice index
+		held := make(map[int]string)
+		flush := func() bool {
+			for index, pending := range held {
+				if rest := trimStop(pending, req.Stop); rest != "" {
+					if !send(Event{Type: EventContentDelta, Index: index, Content: rest}) {
+						return false
+					}
+				}
+			}
+			held = make(map[int]string)
+			return true
+		}
+
+		for event := range ch {
+			switch event.Type {
+			case EventContentDelta:
+				pending := held[event.Index] + event.Content
+				cut := max(len(pending)-longest, 0)
+				for cut > 0 && !utf8.RuneStart(pending[cut]) {
+					cut--
+				}
+				held[event.Index] = pending[cut:]
+				if cut == 0 {
+					continue
+				}
+				event.Content = pending[:cut]
+
+			case EventDone:
+				if !flush() {
+					return
+				}
+				stripStops(event.Response, req.Stop)
+
+			case EventError:
+				if !flush() {
+					return
+				}
+			}
+			if !send(event) {
+				return
+			}
+		}
+		flush()
+	}()
+
+	return outCh, nil
+}
@@ -0,0 +1,180 @@
+package llmrouter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTrimStop(t *testing.T) {
+	tests := []struct {
+		in   string
+		stop []string
+		want string
+	}{
+		{"answer END", []string{"END"}, "answer "},
+		{"answer\n\n", []string{"", "\n\n"}, "answer"},
+		{"END in the middle", []string{"END"}, "END in the middle"},
+		{"answer", nil, "answer"},
+		{"ENDEND", []string{"END"}, "END"},
+	}
+	for _, tt := range tests {
+		if got := trimStop(tt.in, tt.stop); got != tt.want {
+			t.Errorf("trimStop(%q, %q) = %q, want %q", tt.in, tt.stop, got, tt.want)
+		}
+	}
+}
+
+// stopRouter returns a router whose provider replies with content, optionally
+// stripping stop sequences
+func stopRouter(content string, deltas []string, strip bool) *Router {
+	stub := &stubProvider{
+		models: []string{"m"},
+		complete: func(ctx context.Context, req *Request) (*Response, error) {
+			return textResponse("stub", content), nil
+		},
+		stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+			var events []Event
+			for _, d := range deltas {
+				events = append(events, Event{Type: EventContentDelta, Content: d})
+			}
+			events = append(events, Event{Type: EventDone, Response: textResponse("stub", content)})
+			return eventStream(events...), nil
+		},
+	}
+	opts := []Option{WithProvider("stub", stub)}
+	if strip {
+		opts = append(opts, WithStripStopSequences())
+	}
+	return New(opts...)
+}
+
+// stopRequest builds a request for the stub's model with stop sequences
+func stopRequest(stop ...string) *Request {
+	req := userRequest("hi")
+	req.Model = "m"
+	req.Stop = stop
+	return req
+}
+
+func TestStripStopSequencesComplete(t *testing.T) {
+	tests := []struct {
+		name  string
+		strip bool
+		stop  []string
+		want  string
+	}{
+		{"stripped", true, []string{"STOP", "END"}, "The answer is 42."},
+		{"option off", false, []string{"END"}, "The answer is 42.END"},
+		{"no stop in request", true, nil, "The answer is 42.END"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := stopRouter("The answer is 42.END", nil, tt.strip)
+			resp, err := r.Complete(context.Background(), stopRequest(tt.stop...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.Text(); got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripStopSequencesStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		deltas []string
+		stop   []string
+		want   string
+	}{
+		{"stop in its own delta", []string{"The answer", " is 42.", "END"}, []string{"END"}, "The answer is 42."},
+		{"stop across deltas", []string{"The answer is 42.E", "N", "D"}, []string{"END"}, "The answer is 42."},
+		{"stop inside a delta", []string{"The answer is 42.EN", "D"}, []string{"STOP", "END"}, "The answer is 42."},
+		{"partial match kept", []string{"The answer is 42.EN"}, []string{"END"}, "The answer is 42.EN"},
+		{"multibyte content", []string{"Réponse: 42 ✓", "END"}, []string{"END"}, "Réponse: 42 ✓"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var content string
+			for _, d := range tt.deltas {
+				content += d
+			}
+			r := stopRouter(content, tt.deltas, true)
+			ch, err := r.Stream(context.Background(), stopRequest(tt.stop...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var streamed string
+			var final *Response
+			for _, e := range collect(ch) {
+				switch e.Type {
+				case EventContentDelta:
+					streamed += e.Content
+				case EventDone:
+					final = e.Response
+				}
+			}
+			if streamed != tt.want {
+				t.Errorf("streamed content = %q, want %q", streamed, tt.want)
+			}
+			if final == nil || final.Text() != tt.want {
+				t.Errorf("final response = %+v, want content %q", final, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripStopSequencesStreamPerChoice(t *testing.T) {
+	stub := &stubProvider{models: []string{"m"}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return eventStream(
+			Event{Type: EventContentDelta, Index: 0, Content: "firstE"},
+			Event{Type: EventContentDelta, Index: 1, Content: "secondEN"},
+			Event{Type: EventContentDelta, Index: 0, Content: "ND"},
+			Event{Type: EventContentDelta, Index: 1, Content: "D"},
+			Event{Type: EventDone, Response: &Response{}},
+		), nil
+	}}
+	r := New(WithProvider("stub", stub), WithStripStopSequences())
+
+	ch, err := r.Stream(context.Background(), stopRequest("END"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := make(map[int]string)
+	for _, e := range collect(ch) {
+		if e.Type == EventContentDelta {
+			content[e.Index] += e.Content
+		}
+	}
+	if content[0] != "first" || content[1] != "second" {
+		t.Errorf("content = %q, want each choice stripped", content)
+	}
+}
+
+func TestStripStopSequencesStreamCanceled(t *testing.T) {
+	stub := &stubProvider{stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return eventStream(
+			Event{Type: EventContentDelta, Content: "The answer is long enough"},
+			Event{Type: EventContentDelta, Content: " to be sent in parts"},
+			Event{Type: EventDone, Response: &Response{}},
+		), nil
+	}}
+	p := &stopStripProvider{Provider: stub}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.Stream(ctx, stopRequest("END"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+	cancel()
+
+	// Left unread, the channel closes instead of blocking on the next event
+	time.Sleep(20 * time.Millisecond)
+	if e, ok := <-ch; ok {
+		t.Errorf("received %+v after cancel, want the channel closed", e)
+	}
+}
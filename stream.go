@@ -0,0 +1,336 @@
+package llmrouter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Stream wraps a streaming event channel, accumulating content and tracking
+// the terminal error or response as events are consumed.
+type Stream struct {
+	ch       <-chan Event
+	cancel   context.CancelFunc
+	content  map[int]*strings.Builder // by choice index
+	response *Response
+	err      error
+	done     bool
+}
+
+// NewStream wraps an event channel. The cancel func, if non-nil, is called by Close.
+func NewStream(ch <-chan Event, cancel context.CancelFunc) *Stream {
+	return &Stream{
+		ch:      ch,
+		cancel:  cancel,
+		content: make(map[int]*strings.Builder),
+	}
+}
+
+// Next returns the next event. It returns false once the stream is exhausted.
+func (s *Stream) Next() (Event, bool) {
+	if s.done {
+		return Event{}, false
+	}
+
+	event, ok := <-s.ch
+	if !ok {
+		s.done = true
+		return Event{}, false
+	}
+
+	switch event.Type {
+	case EventContentDelta:
+		b := s.content[event.Index]
+		if b == nil {
+			b = &strings.Builder{}
+			s.content[event.Index] = b
+		}
+		b.WriteString(event.Content)
+	case EventDone:
+		s.response = event.Response
+	case EventError:
+		s.err = event.Error
+	}
+
+	return event, true
+}
+
+// Content returns the content of the first choice accumulated so far
+func (s *Stream) Content() string {
+	return s.ChoiceContent(0)
+}
+
+// ChoiceContent returns the content of the choice with the given index
+// accumulated so far, for requests with N > 1
+func (s *Stream) ChoiceContent(index int) string {
+	if b := s.content[index]; b != nil {
+		return b.String()
+	}
+	return ""
+}
+
+// Response returns the final response, or nil if the stream has not completed
+func (s *Stream) Response() *Response {
+	return s.response
+}
+
+// Err returns the error event received on the stream, if any
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// Close stops the stream and drains any remaining events
+func (s *Stream) Close() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.done {
+		return
+	}
+	s.done = true
+	go func() {
+		for range s.ch {
+		}
+	}()
+}
+
+// StreamTyped performs a streaming completion and returns it wrapped in a Stream
+func (r *Router) StreamTyped(ctx context.Context, req *Request) (*Stream, error) {
+	ch, cancel, err := r.StreamWithCancel(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return NewStream(ch, cancel), nil
+}
+
+// StreamWithCancel performs a streaming completion and returns a CancelFunc
+// that stops the underlying provider stream. After cancel is called the
+// returned channel is closed promptly, without a trailing error event. If ctx
+// is canceled or times out first, the stream ends with an EventError
+// wrapping ErrContextCanceled.
+func (r *Router) StreamWithCancel(parent context.Context, req *Request) (<-chan Event, context.CancelFunc, error) {
+	ctx, cancelCtx := context.WithCancel(parent)
+
+	ch, err := r.Stream(ctx, req)
+	if err != nil {
+		cancelCtx()
+		return nil, nil, err
+	}
+
+	// stopped is closed when the caller cancels, so their cancellation can be
+	// told apart from the parent context's
+	stopped := make(chan struct{})
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() { close(stopped) })
+		cancelCtx()
+	}
+
+	outCh := make(chan Event)
+	go func() {
+		defer close(outCh)
+		// Drain whatever the provider still sends so its goroutine can exit
+		defer func() {
+			go func() {
+				for range ch {
+				}
+			}()
+		}()
+
+		// finish ends the stream once ctx is done, reporting the parent's
+		// cancellation unless the caller canceled or an error was already sent
+		var failed bool
+		finish := func() {
+			select {
+			case <-stopped:
+				return
+			default:
+			}
+			if failed {
+				return
+			}
+			select {
+			case outCh <- Event{Type: EventError, Error: fmt.Errorf("%w: %w", ErrContextCanceled, parent.Err())}:
+			case <-stopped:
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				finish()
+				return
+			case event, ok := <-ch:
+				if !ok {
+					cancelCtx()
+					return
+				}
+				select {
+				case outCh <- event:
+					failed = failed || event.Type == EventError
+				case <-ctx.Done():
+					finish()
+					return
+				}
+			}
+		}
+	}()
+
+	return outCh, cancel, nil
+}
+
+// StreamMessages streams req as progressively more complete assistant
+// messages: each one carries the content and tool calls assembled so far,
+// and the last is the final message, sent when the stream completes. Only
+// the first choice is followed. On a stream error the channel is closed
+// without the final message; use Stream or StreamTyped to inspect the error.
+func (r *Router) StreamMessages(ctx context.Context, req *Request) (<-chan Message, error) {
+	ch, err := r.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	outCh := make(chan Message)
+	go func() {
+		defer close(outCh)
+
+		var content, reasoning strings.Builder
+		acc := newToolCallAccumulator()
+		snapshot := func() Message {
+			msg := Message{
+				Role:             RoleAssistant,
+				Content:          content.String(),
+				ReasoningContent: reasoning.String(),
+			}
+			if calls := acc.calls(); len(calls) > 0 {
+				msg.ToolCalls = calls
+			}
+			return msg
+		}
+
+		for event := range ch {
+			if event.Index != 0 {
+				continue
+			}
+			switch event.Type {
+			case EventContentDelta:
+				content.WriteString(event.Content)
+			case EventReasoningDelta:
+				if event.Delta == nil {
+					continue
+				}
+				reasoning.WriteString(event.Delta.ReasoningContent)
+			case EventToolCallDelta:
+				if event.Delta == nil {
+					continue
+				}
+				for _, tc := range event.Delta.ToolCalls {
+					acc.add(event.Index, tc)
+				}
+			case EventDone:
+				// Sends the final message
+			default:
+				continue
+			}
+			select {
+			case outCh <- snapshot():
+			case <-ctx.Done():
+				go func() {
+					for range ch {
+					}
+				}()
+				return
+			}
+		}
+	}()
+
+	return outCh, nil
+}
+
+// ChunkOption controls how StreamFromResponse splits content into deltas
+type ChunkOption func(content string) []string
+
+// ChunkBy splits content into deltas of at most n runes
+func ChunkBy(n int) ChunkOption {
+	return func(content string) []string {
+		if n < 1 {
+			return []string{content}
+		}
+		runes := []rune(content)
+		chunks := make([]string, 0, (len(runes)+n-1)/n)
+		for start := 0; start < len(runes); start += n {
+			chunks = append(chunks, string(runes[start:min(start+n, len(runes))]))
+		}
+		return chunks
+	}
+}
+
+// ChunkByWord splits content into one delta per word, each carrying the
+// whitespace that precedes it
+func ChunkByWord() ChunkOption {
+	return func(content string) []string {
+		var chunks []string
+		start := 0
+		inWord := false
+		for i, r := range content {
+			space := unicode.IsSpace(r)
+			if space && inWord {
+				chunks = append(chunks, content[start:i])
+				start = i
+			}
+			inWord = !space
+		}
+		switch rest := content[start:]; {
+		case rest == "":
+		case strings.TrimSpace(rest) == "" && len(chunks) > 0:
+			// Trailing whitespace stays with the last word
+			chunks[len(chunks)-1] += rest
+		default:
+			chunks = append(chunks, rest)
+		}
+		return chunks
+	}
+}
+
+// StreamFromResponse emulates a stream from a complete response: the content
+// (as one delta, or split deterministically by the last ChunkOption), one tool call
+// event if the response has tool calls, then done
+func StreamFromResponse(resp *Response, opts ...ChunkOption) <-chan Event {
+	var chunks []string
+	if len(resp.Choices) > 0 && resp.Choices[0].Message != nil && resp.Choices[0].Message.Content != "" {
+		content := resp.Choices[0].Message.Content
+		chunks = []string{content}
+		if len(opts) > 0 {
+			chunks = opts[len(opts)-1](content)
+		}
+	}
+
+	ch := make(chan Event, len(chunks)+2)
+
+	if len(resp.Choices) > 0 && resp.Choices[0].Message != nil {
+		msg := resp.Choices[0].Message
+		for _, chunk := range chunks {
+			ch <- Event{
+				Type:    EventContentDelta,
+				Content: chunk,
+			}
+		}
+		if len(msg.ToolCalls) > 0 {
+			ch <- Event{
+				Type: EventToolCallDelta,
+				Delta: &Delta{
+					ToolCalls: msg.ToolCalls,
+				},
+			}
+		}
+	}
+
+	ch <- Event{
+		Type:     EventDone,
+		Response: resp,
+	}
+	close(ch)
+	return ch
+}
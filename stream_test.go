@@ -0,0 +1,352 @@
+package llmrouter
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestStreamAccumulates(t *testing.T) {
+	final := textResponse("stub", "hello world")
+	s := NewStream(eventStream(
+		Event{Type: EventContentDelta, Content: "hello"},
+		Event{Type: EventHeartbeat},
+		Event{Type: EventContentDelta, Content: " world"},
+		Event{Type: EventDone, Response: final},
+	), nil)
+
+	var types []EventType
+	for {
+		event, ok := s.Next()
+		if !ok {
+			break
+		}
+		types = append(types, event.Type)
+	}
+
+	if len(types) != 4 {
+		t.Errorf("got %d events, want 4", len(types))
+	}
+	if s.Content() != "hello world" {
+		t.Errorf("Content = %q", s.Content())
+	}
+	if s.Response() != final {
+		t.Error("Response is not the done event's response")
+	}
+	if s.Err() != nil {
+		t.Errorf("Err = %v", s.Err())
+	}
+	if _, ok := s.Next(); ok {
+		t.Error("Next returned an event after the stream ended")
+	}
+}
+
+func TestStreamRecordsError(t *testing.T) {
+	s := NewStream(eventStream(
+		Event{Type: EventContentDelta, Content: "partial"},
+		Event{Type: EventError, Error: ErrRateLimited},
+	), nil)
+	for {
+		if _, ok := s.Next(); !ok {
+			break
+		}
+	}
+
+	if !errors.Is(s.Err(), ErrRateLimited) {
+		t.Errorf("Err = %v, want ErrRateLimited", s.Err())
+	}
+	if s.Response() != nil {
+		t.Error("Response set on a failed stream")
+	}
+	if s.Content() != "partial" {
+		t.Errorf("Content = %q", s.Content())
+	}
+}
+
+func TestStreamCloseCancelsAndDrains(t *testing.T) {
+	ch := make(chan Event)
+	canceled := false
+	s := NewStream(ch, func() { canceled = true })
+
+	s.Close()
+	if !canceled {
+		t.Error("Close did not call cancel")
+	}
+	// The drain goroutine consumes what the producer still sends
+	ch <- Event{Type: EventContentDelta, Content: "late"}
+	close(ch)
+	if _, ok := s.Next(); ok {
+		t.Error("Next returned an event after Close")
+	}
+}
+
+// blockingStream starts a stream that sends one delta, then blocks until ctx
+// is done
+func blockingStream(ctx context.Context, req *Request) (<-chan Event, error) {
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		select {
+		case ch <- Event{Type: EventContentDelta, Content: "first"}:
+		case <-ctx.Done():
+			return
+		}
+		<-ctx.Done()
+	}()
+	return ch, nil
+}
+
+func TestStreamWithCancelCallerCancel(t *testing.T) {
+	r := New(WithProvider("stub", &stubProvider{models: []string{"m"}, stream: blockingStream}))
+
+	ch, cancel, err := r.StreamWithCancel(context.Background(), &Request{Model: "m", Messages: userRequest("hi").Messages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event := <-ch; event.Content != "first" {
+		t.Fatalf("first event = %+v", event)
+	}
+
+	cancel()
+	select {
+	case event, ok := <-ch:
+		if ok {
+			t.Errorf("got %+v after cancel, want the channel closed", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestStreamWithCancelParentCanceled(t *testing.T) {
+	r := New(WithProvider("stub", &stubProvider{models: []string{"m"}, stream: blockingStream}))
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	ch, cancel, err := r.StreamWithCancel(parent, &Request{Model: "m", Messages: userRequest("hi").Messages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	<-ch
+
+	cancelParent()
+	events := collect(ch)
+	if len(events) != 1 || events[0].Type != EventError {
+		t.Fatalf("events = %+v, want one error", events)
+	}
+	if !errors.Is(events[0].Error, ErrContextCanceled) || !errors.Is(events[0].Error, context.Canceled) {
+		t.Errorf("error = %v, want ErrContextCanceled wrapping context.Canceled", events[0].Error)
+	}
+}
+
+func TestStreamChoiceContent(t *testing.T) {
+	s := NewStream(eventStream(
+		Event{Type: EventContentDelta, Index: 0, Content: "a"},
+		Event{Type: EventContentDelta, Index: 1, Content: "x"},
+		Event{Type: EventContentDelta, Index: 0, Content: "b"},
+		Event{Type: EventContentDelta, Index: 1, Content: "y"},
+	), nil)
+	for {
+		if _, ok := s.Next(); !ok {
+			break
+		}
+	}
+
+	if s.Content() != "ab" || s.ChoiceContent(0) != "ab" {
+		t.Errorf("choice 0 = %q, want ab", s.Content())
+	}
+	if s.ChoiceContent(1) != "xy" {
+		t.Errorf("choice 1 = %q, want xy", s.ChoiceContent(1))
+	}
+	if s.ChoiceContent(2) != "" {
+		t.Errorf("choice 2 = %q, want empty", s.ChoiceContent(2))
+	}
+}
+
+func TestChunkBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		content string
+		want    []string
+	}{
+		{"even", 3, "abcdef", []string{"abc", "def"}},
+		{"remainder", 4, "abcdefghij", []string{"abcd", "efgh", "ij"}},
+		{"counts runes", 2, "héllo→", []string{"hé", "ll", "o→"}},
+		{"empty", 3, "", []string{}},
+		{"non-positive size keeps content whole", 0, "abc", []string{"abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChunkBy(tt.n)(tt.content); !slices.Equal(got, tt.want) {
+				t.Errorf("ChunkBy(%d)(%q) = %q, want %q", tt.n, tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkByWord(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"hello brave new world", []string{"hello", " brave", " new", " world"}},
+		{"  leading space", []string{"  leading", " space"}},
+		{"trailing  \n", []string{"trailing  \n"}},
+		{"a\nb\tc ", []string{"a", "\nb", "\tc "}},
+		{"   ", []string{"   "}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := ChunkByWord()(tt.content); !slices.Equal(got, tt.want) {
+			t.Errorf("ChunkByWord()(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestStreamFromResponseChunks(t *testing.T) {
+	resp := textResponse("mock", "one two three")
+
+	var deltas []string
+	for event := range StreamFromResponse(resp, ChunkByWord()) {
+		if event.Type == EventContentDelta {
+			deltas = append(deltas, event.Content)
+		}
+	}
+	if want := []string{"one", " two", " three"}; !slices.Equal(deltas, want) {
+		t.Errorf("deltas = %q, want %q", deltas, want)
+	}
+
+	// Chunking is deterministic and lossless
+	for i := 0; i < 3; i++ {
+		stream := NewStream(StreamFromResponse(resp, ChunkBy(4)), nil)
+		for _, ok := stream.Next(); ok; _, ok = stream.Next() {
+		}
+		if stream.Content() != "one two three" || stream.Response() != resp {
+			t.Fatalf("run %d: content %q, want the full content and response", i, stream.Content())
+		}
+	}
+
+	var whole []string
+	for event := range StreamFromResponse(resp) {
+		if event.Type == EventContentDelta {
+			whole = append(whole, event.Content)
+		}
+	}
+	if !slices.Equal(whole, []string{"one two three"}) {
+		t.Errorf("without options deltas = %q, want the content in one delta", whole)
+	}
+}
+
+// messagesRouter returns a router whose only provider streams events
+func messagesRouter(events ...Event) *Router {
+	stub := &stubProvider{models: []string{"m"}, stream: func(ctx context.Context, req *Request) (<-chan Event, error) {
+		return eventStream(events...), nil
+	}}
+	return New(WithProvider("stub", stub))
+}
+
+func TestStreamMessages(t *testing.T) {
+	idx := 0
+	r := messagesRouter(
+		Event{Type: EventReasoningDelta, Delta: &Delta{ReasoningContent: "think"}},
+		Event{Type: EventContentDelta, Content: "Hel"},
+		Event{Type: EventContentDelta, Index: 1, Content: "other choice"},
+		Event{Type: EventHeartbeat},
+		Event{Type: EventContentDelta, Content: "lo"},
+		Event{Type: EventToolCallDelta, Delta: &Delta{ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Index: &idx, Function: FuncCall{Name: "weather"}}}}},
+		Event{Type: EventToolCallDelta, Delta: &Delta{ToolCalls: []ToolCall{{Index: &idx, Function: FuncCall{Arguments: `{"city":`}}}}},
+		Event{Type: EventToolCallDelta, Delta: &Delta{ToolCalls: []ToolCall{{Index: &idx, Function: FuncCall{Arguments: `"Paris"}`}}}}},
+		Event{Type: EventDone, Response: textResponse("stub", "Hello")},
+	)
+
+	req := userRequest("hi")
+	req.Model = "m"
+	ch, err := r.StreamMessages(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []Message
+	for msg := range ch {
+		msgs = append(msgs, msg)
+	}
+
+	if len(msgs) != 7 {
+		t.Fatalf("got %d messages, want 7", len(msgs))
+	}
+	var contents []string
+	for _, m := range msgs {
+		contents = append(contents, m.Content)
+	}
+	if want := []string{"", "Hel", "Hello", "Hello", "Hello", "Hello", "Hello"}; !slices.Equal(contents, want) {
+		t.Errorf("contents = %q, want %q", contents, want)
+	}
+
+	last := msgs[len(msgs)-1]
+	want := Message{
+		Role:             RoleAssistant,
+		Content:          "Hello",
+		ReasoningContent: "think",
+		ToolCalls:        []ToolCall{{ID: "call_1", Type: "function", Index: &idx, Function: FuncCall{Name: "weather", Arguments: `{"city":"Paris"}`}}},
+	}
+	if !reflect.DeepEqual(last, want) {
+		t.Errorf("last message = %+v, want %+v", last, want)
+	}
+}
+
+func TestStreamMessagesError(t *testing.T) {
+	r := messagesRouter(
+		Event{Type: EventContentDelta, Content: "partial"},
+		Event{Type: EventError, Error: ErrProviderError},
+	)
+
+	req := userRequest("hi")
+	req.Model = "m"
+	ch, err := r.StreamMessages(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgs []Message
+	for msg := range ch {
+		msgs = append(msgs, msg)
+	}
+	if len(msgs) != 1 || msgs[0].Content != "partial" {
+		t.Errorf("messages = %+v, want only the partial snapshot", msgs)
+	}
+}
+
+func TestStreamMessagesStopsOnCancel(t *testing.T) {
+	r := messagesRouter(
+		Event{Type: EventContentDelta, Content: "a"},
+		Event{Type: EventContentDelta, Content: "b"},
+		Event{Type: EventDone, Response: textResponse("stub", "ab")},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := userRequest("hi")
+	req.Model = "m"
+	ch, err := r.StreamMessages(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+	cancel()
+
+	// Left unread, the channel closes instead of blocking on the next message
+	time.Sleep(20 * time.Millisecond)
+	if msg, ok := <-ch; ok {
+		t.Errorf("received %+v after cancel, want the channel closed", msg)
+	}
+}
+
+func TestStreamMessagesRoutingError(t *testing.T) {
+	r := messagesRouter()
+	req := userRequest("hi")
+	req.Model = "unknown"
+	if _, err := r.StreamMessages(context.Background(), req); !errors.Is(err, ErrUnknownModel) {
+		t.Errorf("err = %v, want ErrUnknownModel", err)
+	}
+}
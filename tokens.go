@@ -0,0 +1,80 @@
+package llmrouter
+
+import "unicode/utf8"
+
+// TokenCounter estimates the number of tokens in a piece of text
+type TokenCounter func(text string) int
+
+// EstimateTokens approximates the token count of text using the common
+// heuristic of roughly four characters per token. It is intentionally
+// provider-agnostic and should only be used where an estimate is acceptable.
+func EstimateTokens(text string) int {
+	n := utf8.RuneCountInString(text)
+	if n == 0 {
+		return 0
+	}
+	return (n + 3) / 4
+}
+
+// EstimateRequestTokens approximates the prompt token count of a request
+func EstimateRequestTokens(req *Request, count TokenCounter) int {
+	if count == nil {
+		count = EstimateTokens
+	}
+
+	total := 0
+	for _, msg := range req.Messages {
+		total += count(msg.Content)
+		for _, p := range msg.ContentParts {
+			total += count(p.Text)
+		}
+		for _, tc := range msg.ToolCalls {
+			total += count(tc.Function.Name) + count(tc.Function.Arguments)
+		}
+	}
+	return total
+}
+
+// streamTally counts a stream's output so its usage can be estimated when
+// the provider reports none, as some do for streams
+type streamTally struct {
+	prompt     int
+	completion int
+	update     *Usage // the latest running estimate, if any
+}
+
+func newStreamTally(req *Request) *streamTally {
+	return &streamTally{prompt: EstimateRequestTokens(req, nil)}
+}
+
+func (t *streamTally) add(event Event) {
+	switch event.Type {
+	case EventContentDelta:
+		t.completion += EstimateTokens(event.Content)
+	case EventReasoningDelta, EventToolCallDelta:
+		if event.Delta == nil {
+			return
+		}
+		t.completion += EstimateTokens(event.Delta.ReasoningContent)
+		for _, tc := range event.Delta.ToolCalls {
+			t.completion += EstimateTokens(tc.Function.Name) + EstimateTokens(tc.Function.Arguments)
+		}
+	case EventUsageUpdate:
+		t.update = event.Usage
+	}
+}
+
+// usage returns the usage resp reports, or else an estimate
+func (t *streamTally) usage(resp *Response) *Usage {
+	if resp != nil && resp.Usage != nil {
+		return resp.Usage
+	}
+	if t.update != nil {
+		return t.update
+	}
+	return &Usage{
+		PromptTokens:     t.prompt,
+		CompletionTokens: t.completion,
+		TotalTokens:      t.prompt + t.completion,
+	}
+}
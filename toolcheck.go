@@ -0,0 +1,24 @@
+package llmrouter
+
+import "fmt"
+
+// CheckToolMessages verifies that every tool message answers a tool call
+// made by an earlier assistant message. Providers such as OpenAI reject
+// orphaned tool results with an unhelpful error, so this reports the
+// offending message up front as ErrInvalidRequest.
+func CheckToolMessages(msgs []Message) error {
+	calls := make(map[string]bool)
+	for i, msg := range msgs {
+		switch msg.Role {
+		case RoleAssistant:
+			for _, tc := range msg.ToolCalls {
+				calls[tc.ID] = true
+			}
+		case RoleTool:
+			if !calls[msg.ToolCallID] {
+				return fmt.Errorf("%w: tool message %d (tool_call_id %q) has no preceding assistant tool call", ErrInvalidRequest, i, msg.ToolCallID)
+			}
+		}
+	}
+	return nil
+}
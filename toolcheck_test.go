@@ -0,0 +1,53 @@
+package llmrouter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckToolMessages(t *testing.T) {
+	call := Message{Role: RoleAssistant, ToolCalls: []ToolCall{
+		{ID: "call_1", Type: "function", Function: FuncCall{Name: "weather"}},
+		{ID: "call_2", Type: "function", Function: FuncCall{Name: "time"}},
+	}}
+	tests := []struct {
+		name     string
+		msgs     []Message
+		contains string
+	}{
+		{"no tools", []Message{{Role: RoleUser, Content: "hi"}}, ""},
+		{"answered calls", []Message{
+			{Role: RoleUser, Content: "hi"},
+			call,
+			{Role: RoleTool, ToolCallID: "call_1", Content: "sunny"},
+			{Role: RoleTool, ToolCallID: "call_2", Content: "noon"},
+		}, ""},
+		{"orphaned", []Message{
+			{Role: RoleUser, Content: "hi"},
+			{Role: RoleTool, ToolCallID: "call_9", Content: "sunny"},
+		}, `tool message 1 (tool_call_id "call_9")`},
+		{"unknown id", []Message{
+			call,
+			{Role: RoleTool, ToolCallID: "call_3", Content: "sunny"},
+		}, `"call_3"`},
+		{"result before its call", []Message{
+			{Role: RoleTool, ToolCallID: "call_1", Content: "sunny"},
+			call,
+		}, "tool message 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckToolMessages(tt.msgs)
+			if tt.contains == "" {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidRequest) || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("err = %v, want ErrInvalidRequest mentioning %s", err, tt.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package llmrouter
+
+import (
+	"crypto/tls"
+	"log"
+	"net/http"
+)
+
+// HTTPClient returns an HTTP client applying the config's TLS settings, or
+// nil if the config uses the default TLS verification
+func (c ProviderConfig) HTTPClient() *http.Client {
+	if !c.InsecureSkipVerify && c.RootCAs == nil {
+		return nil
+	}
+	if c.InsecureSkipVerify {
+		log.Printf("llmrouter: WARNING: TLS certificate verification is disabled for provider %q (%s); connections are open to interception", c.Name, c.BaseURL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            c.RootCAs,
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec // explicit opt-in for on-prem endpoints
+	}
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,45 @@
+package llmrouter
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"testing"
+)
+
+// tlsConfig returns the TLS config of the client's transport
+func tlsConfig(t *testing.T, client *http.Client) *tls.Config {
+	t.Helper()
+	if client == nil {
+		t.Fatal("no client built")
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil {
+		t.Fatalf("transport = %T without a TLS config", client.Transport)
+	}
+	return transport.TLSClientConfig
+}
+
+func TestHTTPClientDefaultTLS(t *testing.T) {
+	if client := (ProviderConfig{Name: "vllm"}).HTTPClient(); client != nil {
+		t.Error("client built without TLS settings, want nil for the SDK default")
+	}
+}
+
+func TestHTTPClientRootCAs(t *testing.T) {
+	pool := x509.NewCertPool()
+	cfg := tlsConfig(t, ProviderConfig{Name: "vllm", RootCAs: pool}.HTTPClient())
+	if cfg.RootCAs != pool || cfg.InsecureSkipVerify {
+		t.Errorf("TLS config = %+v, want the custom pool with verification on", cfg)
+	}
+	if cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x, want TLS 1.2", cfg.MinVersion)
+	}
+}
+
+func TestHTTPClientInsecureSkipVerify(t *testing.T) {
+	cfg := tlsConfig(t, ProviderConfig{Name: "vllm", InsecureSkipVerify: true}.HTTPClient())
+	if !cfg.InsecureSkipVerify || cfg.RootCAs != nil {
+		t.Errorf("TLS config = %+v, want verification skipped", cfg)
+	}
+}
@@ -1,7 +1,10 @@
 package llmrouter
 
 import (
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -14,11 +17,61 @@ type Request struct {
 	Temperature *float64       `json:"temperature,omitempty"`
 	MaxTokens   *int           `json:"max_tokens,omitempty"`
 	TopP        *float64       `json:"top_p,omitempty"`
+	N           *int           `json:"n,omitempty"`
 	Stop        []string       `json:"stop,omitempty"`
+	ServiceTier string         `json:"service_tier,omitempty"` // OpenAI only, e.g. "flex", "priority"
+	Prefill     string         `json:"prefill,omitempty"`      // text the assistant reply must start with
+	Grounding   bool           `json:"grounding,omitempty"`    // search the web for the answer; ErrNotSupported where unavailable
 	Metadata    map[string]any `json:"metadata,omitempty"`
+	Stream      bool           `json:"stream,omitempty"`   // used by Router.Do to pick Complete or Stream
+	Logprobs    bool           `json:"logprobs,omitempty"` // OpenAI only, reported on streamed content deltas
+
+	// IdempotencyKey lets the provider deduplicate retried requests. Sent as
+	// the Idempotency-Key header by the OpenAI-compatible provider.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+
+	// BuiltinTools enables tools hosted by the provider, by name. Supported:
+	//   - BuiltinWebSearch: Anthropic, and OpenAI in Responses API mode
+	//   - BuiltinCodeInterpreter: OpenAI in Responses API mode
+	// Providers without the requested tool return ErrNotSupported. Sources
+	// the tools used are surfaced on Response.Citations, and hosted tool runs
+	// on Response.BuiltinToolCalls where the provider reports them.
+	BuiltinTools []string `json:"builtin_tools,omitempty"`
+}
+
+// Built-in provider tools, for Request.BuiltinTools
+const (
+	BuiltinWebSearch       = "web_search"
+	BuiltinCodeInterpreter = "code_interpreter"
+)
+
+// ResponseFormat constrains the shape of the reply. Anthropic has no native
+// JSON mode, so it is emulated with a forced tool whose input schema is the
+// requested schema; the tool input becomes the reply content.
+type ResponseFormat struct {
+	Type       string      `json:"type"` // "text", "json_object" or "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
 }
 
-// Message represents a chat message
+// JSONSchema describes the schema for a "json_schema" response format
+type JSONSchema struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+	Strict      bool            `json:"strict,omitempty"`
+}
+
+// Message represents a chat message.
+//
+// CacheHint marks the end of a reusable prompt prefix. It is best-effort:
+// Anthropic emits a cache_control breakpoint, Gemini serves the prefix from
+// cached content, and OpenAI ignores it since its prefix caching is automatic.
+//
+// Tool results may carry ContentParts (text and images) for tools that return
+// images. Anthropic sends them inside the tool_result, Gemini alongside the
+// function response, and OpenAI as a follow-up user message.
 type Message struct {
 	Role         Role          `json:"role"`
 	Content      string        `json:"content"`
@@ -26,14 +79,41 @@ type Message struct {
 	Name         string        `json:"name,omitempty"`
 	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
 	ToolCallID   string        `json:"tool_call_id,omitempty"`
+	CacheHint    bool          `json:"cache_hint,omitempty"`
+
+	// ReasoningContent is the model's reasoning, for providers that return
+	// it separately from the answer (e.g. DeepSeek's reasoner). Output only.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+
+	// ContentBlocks holds the reply's text and tool calls in the order the
+	// model produced them, for providers that interleave them (Anthropic).
+	// Content and ToolCalls carry the same data, and rewrites of Content by
+	// the router or middleware keep it in step through SetContent. Output only.
+	ContentBlocks []ContentPart `json:"content_blocks,omitempty"`
+}
+
+// Text returns the message content followed by any text parts
+func (m Message) Text() string {
+	text := m.Content
+	for _, p := range m.ContentParts {
+		if p.Type != "text" || p.Text == "" {
+			continue
+		}
+		if text != "" {
+			text += "\n"
+		}
+		text += p.Text
+	}
+	return text
 }
 
 // ContentPart represents a part of a multimodal message
 type ContentPart struct {
-	Type     string    `json:"type"`                // "text", "image_url", or "document"
+	Type     string    `json:"type"`                // "text", "image_url", "document", or "tool_call" (ContentBlocks only)
 	Text     string    `json:"text,omitempty"`
 	ImageURL *ImageURL `json:"image_url,omitempty"`
 	Document *Document `json:"document,omitempty"`
+	ToolCall *ToolCall `json:"tool_call,omitempty"`
 }
 
 // ImageURL represents an image reference with both URL and base64 forms
@@ -44,9 +124,12 @@ type ImageURL struct {
 	MediaType string `json:"media_type,omitempty"`
 }
 
-// Document represents a document (PDF, etc.) for providers that support it natively
+// Document represents a document (PDF, etc.) for providers that support it
+// natively, either inline as Base64 or by URI: an https URL for Anthropic, or
+// a File API or Cloud Storage URI for Gemini
 type Document struct {
-	Base64    string `json:"base64"`
+	Base64    string `json:"base64,omitempty"`
+	URI       string `json:"uri,omitempty"`
 	MediaType string `json:"media_type"` // e.g. "application/pdf"
 }
 
@@ -62,28 +145,98 @@ const (
 
 // Response represents a unified LLM response (OpenAI-compatible)
 type Response struct {
-	ID       string   `json:"id"`
-	Object   string   `json:"object"`
-	Created  int64    `json:"created"`
-	Model    string   `json:"model"`
-	Choices  []Choice `json:"choices"`
-	Usage    *Usage   `json:"usage,omitempty"`
-	Provider string   `json:"provider"`
+	ID          string         `json:"id"`
+	Object      string         `json:"object"`
+	Created     int64          `json:"created"`
+	Model       string         `json:"model"`
+	Choices     []Choice       `json:"choices"`
+	Usage       *Usage         `json:"usage,omitempty"`
+	Provider    string         `json:"provider"`
+	ServiceTier string         `json:"service_tier,omitempty"`
+	Citations   []Citation     `json:"citations,omitempty"`
+	Metadata    map[string]any `json:"metadata,omitempty"` // set by middleware, e.g. "json_repaired"
+
+	BuiltinToolCalls []BuiltinToolCall `json:"builtin_tool_calls,omitempty"`
+}
+
+// BuiltinToolCall records a run of a provider-hosted tool requested through
+// Request.BuiltinTools. The provider has already executed it.
+type BuiltinToolCall struct {
+	Type   string `json:"type"` // e.g. "web_search", "code_interpreter"
+	ID     string `json:"id,omitempty"`
+	Input  string `json:"input,omitempty"`  // e.g. the search query or the code run
+	Output string `json:"output,omitempty"` // e.g. the interpreter logs
+}
+
+// Text returns the first choice's content, or "" if there is none
+func (r *Response) Text() string {
+	if msg := r.firstMessage(); msg != nil {
+		return msg.Content
+	}
+	return ""
+}
+
+// ToolCalls returns the first choice's tool calls, or nil if there are none
+func (r *Response) ToolCalls() []ToolCall {
+	if msg := r.firstMessage(); msg != nil {
+		return msg.ToolCalls
+	}
+	return nil
+}
+
+func (r *Response) firstMessage() *Message {
+	if r == nil || len(r.Choices) == 0 {
+		return nil
+	}
+	return r.Choices[0].Message
+}
+
+// MarshalJSONIndent returns the response as indented JSON, e.g. for audit logs
+func (r *Response) MarshalJSONIndent() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Citation is a source the provider used to ground its response
+type Citation struct {
+	URL     string `json:"url"`
+	Title   string `json:"title,omitempty"`
+	Snippet string `json:"snippet,omitempty"`
 }
 
 // Choice represents a completion choice
 type Choice struct {
-	Index        int      `json:"index"`
-	Message      *Message `json:"message,omitempty"`
-	Delta        *Delta   `json:"delta,omitempty"`
-	FinishReason string   `json:"finish_reason,omitempty"`
+	Index         int            `json:"index"`
+	Message       *Message       `json:"message,omitempty"`
+	Delta         *Delta         `json:"delta,omitempty"`
+	FinishReason  string         `json:"finish_reason,omitempty"`
+	FinishDetails *FinishDetails `json:"finish_details,omitempty"`
+}
+
+// FinishDetails describes why a choice finished
+type FinishDetails struct {
+	Reason          string `json:"reason"`               // normalized, same as Choice.FinishReason
+	RawReason       string `json:"raw_reason,omitempty"` // provider's own stop reason
+	ToolCalled      bool   `json:"tool_called,omitempty"`
+	ContentFiltered bool   `json:"content_filtered,omitempty"`
+}
+
+// NewFinishDetails builds finish details from a normalized and a raw provider
+// reason. ContentFiltered is derived from the normalized reason.
+func NewFinishDetails(reason, raw string, toolCalled bool) *FinishDetails {
+	return &FinishDetails{
+		Reason:          reason,
+		RawReason:       raw,
+		ToolCalled:      toolCalled || reason == "tool_calls",
+		ContentFiltered: reason == "content_filter",
+	}
 }
 
 // Delta represents streaming content delta
 type Delta struct {
-	Role      Role       `json:"role,omitempty"`
-	Content   string     `json:"content,omitempty"`
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Role             Role       `json:"role,omitempty"`
+	Content          string     `json:"content,omitempty"`
+	ReasoningContent string     `json:"reasoning_content,omitempty"`
+	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
 }
 
 // Usage represents token usage
@@ -93,25 +246,104 @@ type Usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
-// Event represents a streaming event
+// Event represents a streaming event. In JSON the type is its String form
+// and the error its message.
 type Event struct {
-	Type     EventType
-	Content  string
-	Delta    *Delta
-	Response *Response
-	Error    error
+	Type     EventType `json:"type"`
+	Index    int       `json:"index,omitempty"` // choice index when streaming multiple choices (N > 1)
+	Content  string    `json:"content,omitempty"`
+	Logprob  *float64  `json:"logprob,omitempty"` // log probability of Content, when Request.Logprobs is set (OpenAI only)
+	Delta    *Delta    `json:"delta,omitempty"`
+	Response *Response `json:"response,omitempty"`
+	Usage    *Usage    `json:"usage,omitempty"`
+	Error    error     `json:"-"`
+}
+
+// MarshalJSON encodes the event with its error as a string
+func (e Event) MarshalJSON() ([]byte, error) {
+	type plain Event // drops the methods to avoid recursion
+	v := struct {
+		plain
+		Error string `json:"error,omitempty"`
+	}{plain: plain(e)}
+	if e.Error != nil {
+		v.Error = e.Error.Error()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes an event, restoring the error from its message
+func (e *Event) UnmarshalJSON(data []byte) error {
+	type plain Event
+	var v struct {
+		plain
+		Error string `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*e = Event(v.plain)
+	if v.Error != "" {
+		e.Error = errors.New(v.Error)
+	}
+	return nil
 }
 
 // EventType represents the type of streaming event
 type EventType int
 
 const (
-	EventContentDelta  EventType = iota // Text content chunk
-	EventToolCallDelta                  // Tool call chunk
-	EventDone                           // Stream completed
-	EventError                          // Error occurred
+	EventContentDelta   EventType = iota // Text content chunk
+	EventToolCallDelta                   // Tool call chunk
+	EventDone                            // Stream completed
+	EventError                           // Error occurred
+	EventUsageUpdate                     // Running usage estimate
+	EventReasoningDelta                  // Reasoning chunk, in Delta.ReasoningContent
+	EventHeartbeat                       // Keep-alive while waiting; carries no data
 )
 
+// String returns the snake_case name of the event type
+func (t EventType) String() string {
+	switch t {
+	case EventContentDelta:
+		return "content_delta"
+	case EventToolCallDelta:
+		return "tool_call_delta"
+	case EventDone:
+		return "done"
+	case EventError:
+		return "error"
+	case EventUsageUpdate:
+		return "usage_update"
+	case EventReasoningDelta:
+		return "reasoning_delta"
+	case EventHeartbeat:
+		return "heartbeat"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// MarshalJSON encodes the event type as its String form
+func (t EventType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON decodes an event type from its String form
+func (t *EventType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	for et := EventContentDelta; et <= EventHeartbeat; et++ {
+		if et.String() == name {
+			*t = et
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown event type %q", name)
+}
+
 // Tool represents a function/tool definition
 type Tool struct {
 	Type     string   `json:"type"`
@@ -150,6 +382,80 @@ type FuncRef struct {
 	Name string `json:"name"`
 }
 
+// ImageRequest represents an image generation request
+type ImageRequest struct {
+	Model          string `json:"model,omitempty"`
+	Prompt         string `json:"prompt"`
+	Size           string `json:"size,omitempty"` // e.g. "1024x1024"
+	N              int    `json:"n,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"` // "url" or "b64_json"
+}
+
+// ImageResponse represents generated images
+type ImageResponse struct {
+	Created  int64   `json:"created"`
+	Model    string  `json:"model"`
+	Images   []Image `json:"images"`
+	Provider string  `json:"provider"`
+}
+
+// Image represents a single generated image
+type Image struct {
+	URL           string `json:"url,omitempty"`
+	Base64        string `json:"b64_json,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// TranscribeRequest represents a speech-to-text request
+type TranscribeRequest struct {
+	Model       string   `json:"model,omitempty"`
+	Audio       []byte   `json:"-"`
+	Filename    string   `json:"filename,omitempty"`   // e.g. "speech.mp3", used to infer the audio format
+	MediaType   string   `json:"media_type,omitempty"` // e.g. "audio/mpeg"
+	Language    string   `json:"language,omitempty"`
+	Prompt      string   `json:"prompt,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// TranscribeResponse represents a transcription result
+type TranscribeResponse struct {
+	Text     string `json:"text"`
+	Model    string `json:"model"`
+	Provider string `json:"provider"`
+}
+
+// SpeechRequest represents a text-to-speech request
+type SpeechRequest struct {
+	Model  string   `json:"model,omitempty"`
+	Input  string   `json:"input"`
+	Voice  string   `json:"voice,omitempty"`  // e.g. "alloy"
+	Format string   `json:"format,omitempty"` // e.g. "mp3", "wav", "opus"
+	Speed  *float64 `json:"speed,omitempty"`
+}
+
+// SpeechResponse represents synthesized audio
+type SpeechResponse struct {
+	Audio     []byte `json:"-"`
+	MediaType string `json:"media_type"`
+	Model     string `json:"model"`
+	Provider  string `json:"provider"`
+}
+
+// EmbeddingRequest represents a text embedding request
+type EmbeddingRequest struct {
+	Model      string   `json:"model,omitempty"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"` // 0 uses the model default
+}
+
+// EmbeddingResponse holds one vector per input, in input order
+type EmbeddingResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float64 `json:"embeddings"`
+	Usage      *Usage      `json:"usage,omitempty"`
+	Provider   string      `json:"provider"`
+}
+
 // ProviderConfig holds common configuration for providers
 type ProviderConfig struct {
 	Name       string
@@ -159,4 +465,10 @@ type ProviderConfig struct {
 	Models     []string
 	MaxRetries int
 	Timeout    time.Duration
+	UserAgent  string // appended to the SDK's User-Agent header
+
+	// TLS settings for self-hosted endpoints behind internal CAs. Honored by
+	// the OpenAI-compatible and Anthropic providers.
+	InsecureSkipVerify bool
+	RootCAs            *x509.CertPool
 }
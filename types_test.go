@@ -0,0 +1,154 @@
+package llmrouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEventTypeString(t *testing.T) {
+	tests := []struct {
+		t    EventType
+		want string
+	}{
+		{EventContentDelta, "content_delta"},
+		{EventToolCallDelta, "tool_call_delta"},
+		{EventDone, "done"},
+		{EventError, "error"},
+		{EventUsageUpdate, "usage_update"},
+		{EventReasoningDelta, "reasoning_delta"},
+		{EventHeartbeat, "heartbeat"},
+		{EventType(99), "EventType(99)"},
+	}
+	for _, tt := range tests {
+		if got := tt.t.String(); got != tt.want {
+			t.Errorf("EventType(%d).String() = %q, want %q", int(tt.t), got, tt.want)
+		}
+		if got := fmt.Sprintf("%v", tt.t); got != tt.want {
+			t.Errorf("%%v of EventType(%d) = %q, want %q", int(tt.t), got, tt.want)
+		}
+	}
+}
+
+func TestResponseText(t *testing.T) {
+	calls := []ToolCall{{ID: "call_1", Type: "function", Function: FuncCall{Name: "weather"}}}
+	tests := []struct {
+		name  string
+		resp  *Response
+		text  string
+		calls []ToolCall
+	}{
+		{"nil response", nil, "", nil},
+		{"no choices", &Response{}, "", nil},
+		{"nil message", &Response{Choices: []Choice{{}}}, "", nil},
+		{"text", textResponse("stub", "hello"), "hello", nil},
+		{"tool calls", &Response{Choices: []Choice{{Message: &Message{Content: "checking", ToolCalls: calls}}}}, "checking", calls},
+		{"first choice only", &Response{Choices: []Choice{
+			{Message: &Message{Content: "first"}},
+			{Message: &Message{Content: "second", ToolCalls: calls}},
+		}}, "first", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resp.Text(); got != tt.text {
+				t.Errorf("Text() = %q, want %q", got, tt.text)
+			}
+			if got := tt.resp.ToolCalls(); !reflect.DeepEqual(got, tt.calls) {
+				t.Errorf("ToolCalls() = %+v, want %+v", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestEventJSONRoundTrip(t *testing.T) {
+	idx := 0
+	events := []Event{
+		{Type: EventContentDelta, Index: 1, Content: "Hello", Logprob: floatPtr(-0.5)},
+		{Type: EventToolCallDelta, Delta: &Delta{ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Index: &idx, Function: FuncCall{Name: "weather", Arguments: `{"city":`}}}}},
+		{Type: EventReasoningDelta, Delta: &Delta{ReasoningContent: "thinking"}},
+		{Type: EventUsageUpdate, Usage: &Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}},
+		{Type: EventDone, Response: &Response{
+			ID:       "resp_1",
+			Model:    "gpt-4o",
+			Provider: "openai",
+			Choices:  []Choice{{Message: &Message{Role: RoleAssistant, Content: "hi"}, FinishReason: "stop"}},
+			Usage:    &Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		}},
+		{Type: EventHeartbeat},
+	}
+	for _, want := range events {
+		t.Run(want.Type.String(), func(t *testing.T) {
+			data, err := json.Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got Event
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip of %s = %+v, want %+v", data, got, want)
+			}
+		})
+	}
+}
+
+func TestEventJSONError(t *testing.T) {
+	data, err := json.Marshal(Event{Type: EventError, Error: fmt.Errorf("%w: boom", ErrProviderError)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"error","error":"provider error: boom"}`
+	if string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+
+	var got Event
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != EventError || got.Error == nil || got.Error.Error() != "provider error: boom" {
+		t.Errorf("decoded = %+v, want the error message restored", got)
+	}
+}
+
+func TestEventTypeJSON(t *testing.T) {
+	data, err := json.Marshal(Event{Type: EventContentDelta, Content: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"type":"content_delta","content":"hi"}`; string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+
+	var e Event
+	if err := json.Unmarshal([]byte(`{"type":"bogus"}`), &e); err == nil {
+		t.Error("unknown event type decoded without error")
+	}
+	if err := json.Unmarshal([]byte(`{"type":2}`), &e); err == nil {
+		t.Error("numeric event type decoded without error")
+	}
+}
+
+func TestResponseMarshalJSONIndent(t *testing.T) {
+	resp := textResponse("openai", "hi")
+	resp.Usage = &Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}
+
+	data, err := resp.MarshalJSONIndent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\n  \"provider\": \"openai\"") || !strings.Contains(string(data), "\"total_tokens\": 5") {
+		t.Errorf("JSON = %s, want indented provider and usage", data)
+	}
+
+	var got Response
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, resp) {
+		t.Errorf("round trip = %+v, want %+v", got, resp)
+	}
+}